@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"go_short/conf"
+	adminapp "go_short/internal/application/admin"
+)
+
+const adminUsage = `usage: go_short [flags] admin <command>
+
+commands:
+  create-user -username NAME -email EMAIL [-role user|admin] [-verified]
+                             create a user; the password is read from standard input
+  activate-user USER         activate a user (ID, username or email)
+  deactivate-user USER       deactivate a user (ID, username or email)
+`
+
+// runAdmin 執行 admin 子命令，以與管理後台相同的用例管理使用者
+func runAdmin(settings *conf.Watcher, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, adminUsage)
+		return 2
+	}
+	switch args[0] {
+	case "create-user":
+		return runCreateUser(settings, args[1:])
+	case "activate-user", "deactivate-user":
+		return runSetUserActive(settings, args[0], args[1:])
+	default:
+		fmt.Fprint(os.Stderr, adminUsage)
+		return 2
+	}
+}
+
+// runCreateUser 建立使用者，密碼從標準輸入讀取，避免出現在行程列表與 shell 歷史中
+func runCreateUser(settings *conf.Watcher, args []string) int {
+	flags := newFlagSet("admin create-user", "admin create-user -username NAME -email EMAIL [-role user|admin] [-verified] < password")
+	username := flags.String("username", "", "username (at least 3 characters)")
+	email := flags.String("email", "", "email address")
+	role := flags.String("role", "user", "role: user or admin")
+	verified := flags.Bool("verified", false, "mark the email as verified")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	if *username == "" || *email == "" {
+		flags.Usage()
+		return 2
+	}
+
+	if isTerminal(os.Stdin) {
+		fmt.Fprint(os.Stderr, "Password: ")
+	}
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && password == "" {
+		return fail("admin create-user", errors.New("no password on standard input"))
+	}
+	password = strings.TrimRight(password, "\r\n")
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+
+	user, err := deps.AdminApp.CreateUser(ctx, adminapp.CreateUserInput{
+		Username:      *username,
+		Email:         *email,
+		Password:      password,
+		Role:          *role,
+		EmailVerified: *verified,
+	})
+	if err != nil {
+		return fail("admin create-user", err)
+	}
+	fmt.Printf("created user %d (%s, role %s)\n", user.ID, user.Username, user.Role)
+	return 0
+}
+
+// runSetUserActive 啟用或停用使用者
+func runSetUserActive(settings *conf.Watcher, command string, args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "usage: go_short [flags] admin %s USER\n", command)
+		return 2
+	}
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+
+	user, err := deps.AdminApp.FindUser(ctx, args[0])
+	if err != nil {
+		return fail("admin "+command, err)
+	}
+	if command == "activate-user" {
+		err = deps.AdminApp.ActivateUser(ctx, user.ID)
+	} else {
+		// 命令列沒有登入的管理員，actorID 0 不會與任何使用者相同
+		err = deps.AdminApp.DeactivateUser(ctx, 0, user.ID)
+	}
+	if err != nil {
+		return fail("admin "+command, err)
+	}
+	fmt.Printf("%sd user %d (%s)\n", strings.TrimSuffix(command, "-user"), user.ID, user.Username)
+	return 0
+}
+
+// isTerminal 檢查檔案是否為互動式終端機
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
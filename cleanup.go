@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"go_short/conf"
+)
+
+// runCleanup 執行 cleanup 子命令：run-once 清理一次過期連結後結束，可交給 cron 排程
+func runCleanup(settings *conf.Watcher, args []string) int {
+	if len(args) == 0 || args[0] != "run-once" {
+		fmt.Fprint(os.Stderr, "usage: go_short [flags] cleanup run-once\n")
+		return 2
+	}
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+
+	deleted, err := deps.URLApp.RunCleanup(ctx, deps.JobMonitor())
+	if err != nil {
+		return fail("cleanup", err)
+	}
+	fmt.Printf("deleted %d expired link(s)\n", deleted)
+	return 0
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"go_short/conf"
+	"go_short/internal/bootstrap"
+)
+
+// newFlagSet 建立子命令的參數解析器，-h 時輸出 usage 與各參數的說明
+func newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "usage: go_short [flags] %s\n", usage)
+		flags.PrintDefaults()
+	}
+	return flags
+}
+
+// parseFlags 解析子命令的參數，無法繼續執行時 ok 為 false，code 是應返回的結束碼 (-h 時為 0)
+func parseFlags(flags *flag.FlagSet, args []string) (ok bool, code int) {
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return false, 0
+	}
+	if err != nil {
+		return false, 2
+	}
+	return true, 0
+}
+
+// initCommand 為命令列工具初始化與伺服器相同的依賴項 (日誌寫到標準錯誤)，
+// 並返回在收到 SIGINT / SIGTERM 時取消的 context
+func initCommand(settings *conf.Watcher) (*bootstrap.Dependencies, context.Context, func(), error) {
+	deps, err := bootstrap.InitDependencies(settings, os.Stderr)
+	if err != nil {
+		slog.Error("Failed to initialize dependencies", "error", err)
+		return nil, nil, nil, err
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	return deps, ctx, func() {
+		stop()
+		deps.Close()
+	}, nil
+}
+
+// fail 輸出命令的錯誤並返回結束碼 1
+func fail(command string, err error) int {
+	fmt.Fprintf(os.Stderr, "%s: %v\n", command, err)
+	return 1
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// captureStderr 執行 fn 並返回其寫到標準錯誤的內容
+func captureStderr(t *testing.T, fn func()) string {
+	t.Helper()
+	file, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	stderr := os.Stderr
+	os.Stderr = file
+	defer func() { os.Stderr = stderr }()
+
+	fn()
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	out, err := io.ReadAll(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// 以下命令都在初始化依賴項之前結束，不需要資料庫與 Redis
+func TestRunDispatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantOutput string
+	}{
+		{name: "help", args: []string{"-h"}, wantCode: 0, wantOutput: "commands:"},
+		{name: "unknown config flag", args: []string{"-no-such-flag"}, wantCode: 2},
+		{name: "invalid config value", args: []string{"-server.shutdown_timeout", "soon", "links"}, wantCode: 2},
+		{name: "unknown command", args: []string{"deploy"}, wantCode: 2, wantOutput: `unknown command "deploy"`},
+		{name: "migrate without command", args: []string{"migrate"}, wantCode: 2, wantOutput: "usage: go_short [flags] migrate"},
+		{name: "worker unknown job", args: []string{"worker", "-jobs", "clicks,emails"}, wantCode: 2, wantOutput: `unknown job "emails"`},
+		{name: "worker help", args: []string{"worker", "-h"}, wantCode: 0, wantOutput: "-jobs"},
+		{name: "worker unknown flag", args: []string{"worker", "-verbose"}, wantCode: 2},
+		{name: "admin without command", args: []string{"admin"}, wantCode: 2, wantOutput: "usage: go_short [flags] admin"},
+		{name: "admin unknown command", args: []string{"admin", "delete-user"}, wantCode: 2, wantOutput: "usage: go_short [flags] admin"},
+		{name: "create-user without email", args: []string{"admin", "create-user", "-username", "root"}, wantCode: 2, wantOutput: "-email"},
+		{name: "activate-user without user", args: []string{"admin", "activate-user"}, wantCode: 2, wantOutput: "admin activate-user USER"},
+		{name: "deactivate-user with two users", args: []string{"admin", "deactivate-user", "a", "b"}, wantCode: 2, wantOutput: "admin deactivate-user USER"},
+		{name: "links without command", args: []string{"links"}, wantCode: 2, wantOutput: "usage: go_short [flags] links"},
+		{name: "links unknown command", args: []string{"links", "sync"}, wantCode: 2, wantOutput: "usage: go_short [flags] links"},
+		{name: "links import help", args: []string{"links", "import", "-h"}, wantCode: 0, wantOutput: "-algorithm"},
+		{name: "links import two files", args: []string{"links", "import", "a.csv", "b.csv"}, wantCode: 2, wantOutput: "links import"},
+		{name: "links import missing file", args: []string{"links", "import", "/nonexistent/links.csv"}, wantCode: 1, wantOutput: "links import:"},
+		{name: "links export unknown flag", args: []string{"links", "export", "-format", "json"}, wantCode: 2},
+		{name: "cleanup without run-once", args: []string{"cleanup"}, wantCode: 2, wantOutput: "cleanup run-once"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var code int
+			output := captureStderr(t, func() { code = run(tt.args) })
+			if code != tt.wantCode {
+				t.Errorf("exit code %d, want %d (stderr: %s)", code, tt.wantCode, output)
+			}
+			if !strings.Contains(output, tt.wantOutput) {
+				t.Errorf("stderr %q, want it to contain %q", output, tt.wantOutput)
+			}
+		})
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantOK   bool
+		wantCode int
+		wantUser string
+		wantArgs []string
+	}{
+		{name: "flags and arguments", args: []string{"-user", "alice", "links.csv"}, wantOK: true, wantUser: "alice", wantArgs: []string{"links.csv"}},
+		{name: "flag after argument is an argument", args: []string{"links.csv", "-user", "alice"}, wantOK: true, wantArgs: []string{"links.csv", "-user", "alice"}},
+		{name: "help", args: []string{"-h"}, wantCode: 0},
+		{name: "unknown flag", args: []string{"-owner", "alice"}, wantCode: 2},
+		{name: "missing value", args: []string{"-user"}, wantCode: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			flags := newFlagSet("links import", "links import [-user USER] [file]")
+			flags.SetOutput(io.Discard)
+			user := flags.String("user", "", "owner")
+			ok, code := parseFlags(flags, tt.args)
+			if ok != tt.wantOK || code != tt.wantCode {
+				t.Fatalf("parseFlags = %t, %d; want %t, %d", ok, code, tt.wantOK, tt.wantCode)
+			}
+			if !ok {
+				return
+			}
+			if *user != tt.wantUser || strings.Join(flags.Args(), " ") != strings.Join(tt.wantArgs, " ") {
+				t.Errorf("user %q, args %v; want %q, %v", *user, flags.Args(), tt.wantUser, tt.wantArgs)
+			}
+		})
+	}
+}
+
+func TestNewFlagSetUsage(t *testing.T) {
+	flags := newFlagSet("worker", "worker [-jobs list]")
+	var out strings.Builder
+	flags.SetOutput(&out)
+	flags.String("jobs", "clicks", "jobs to run")
+	if err := flags.Parse([]string{"-h"}); err != flag.ErrHelp {
+		t.Fatalf("err = %v, want flag.ErrHelp", err)
+	}
+	if !strings.HasPrefix(out.String(), "usage: go_short [flags] worker [-jobs list]\n") || !strings.Contains(out.String(), "jobs to run") {
+		t.Errorf("usage = %q", out.String())
+	}
+}
@@ -1,84 +1,430 @@
 package conf
 
 import (
-	"log"
-	"os"
+	"errors"
+	"fmt"
+	"net/netip"
+	"net/url"
 	"strconv"
-	"sync"
+	"strings"
+	"time"
+)
 
-	"github.com/joho/godotenv"
+// 執行環境
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
 )
 
+// 欄位標籤說明：
+//   - key:     設定檔與命令列參數中的名稱，巢狀結構以 "." 串接，例如 server.port
+//   - env:     對應的環境變數
+//   - default: 預設值，格式與環境變數相同
+//   - unit:    時間欄位只給整數時的單位 (s、m 或 h)，沿用舊的 *_SECONDS 等環境變數
+//   - help:    命令列 -h 顯示的說明
+//   - reload:  "true" 表示可在執行中重新載入 (見 Watcher)，標在結構上時套用到其下所有欄位
+
+// Config 是應用程式的完整設定，依序由預設值、設定檔、環境變數與命令列參數載入，見 Load
+type Config struct {
+	Env           string         `key:"env" env:"APP_ENV" default:"development" help:"development or production; production requires a strong JWT secret"`
+	Server        Server         `key:"server"`
+	Database      Database       `key:"database"`
+	Redis         Redis          `key:"redis"`
+	Shortener     Shortener      `key:"shortener"`
+	Auth          Auth           `key:"auth"`
+	Login         Login          `key:"login"`
+	OIDCProviders []OIDCProvider `key:"oidc_providers"` // 由設定檔的清單或 OIDC_PROVIDERS 環境變數載入，不提供命令列參數
+	RateLimit     RateLimiting   `key:"rate_limit" reload:"true"`
+	Blocklist     Blocklist      `key:"blocklist" reload:"true"`
+	Plans         Plans          `key:"plans"`
+	Webhooks      Webhooks       `key:"webhooks"`
+	Events        Events         `key:"events"`
+	Clicks        Clicks         `key:"clicks"`
+	Metrics       Metrics        `key:"metrics"`
+	Health        Health         `key:"health"`
+	Tracing       Tracing        `key:"tracing"`
+	Log           Log            `key:"log"`
+	Mailer        Mailer         `key:"mailer"`
+
+	// File 是載入的設定檔路徑，未使用設定檔時為空
+	File string `key:"-"`
+}
+
+// Server 是 HTTP 伺服器的設定
+type Server struct {
+	Port            int           `key:"port" env:"SERVER_PORT" default:"8080" help:"HTTP listen port"`
+	PublicBaseURL   string        `key:"public_base_url" env:"PUBLIC_BASE_URL" default:"http://localhost:8080" help:"public URL used in links sent by email"`
+	TrustedProxies  []string      `key:"trusted_proxies" env:"TRUSTED_PROXIES" help:"comma-separated IPs or CIDRs whose X-Forwarded-For is trusted"`
+	ShutdownDrain   time.Duration `key:"shutdown_drain" env:"SHUTDOWN_DRAIN_SECONDS" unit:"s" default:"5s" help:"how long /readyz answers 503 before the server stops"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT_SECONDS" unit:"s" default:"10s" help:"how long in-flight requests may run after shutdown starts"`
+}
+
+// Addr 返回 HTTP 伺服器的監聽位址
+func (s Server) Addr() string {
+	return ":" + strconv.Itoa(s.Port)
+}
+
+// Database 是 PostgreSQL 連線的設定
 type Database struct {
-	Host     string
-	Port     string
-	User     string
-	Password string
-	DBName   string
+	Host     string `key:"host" env:"DB_HOST" default:"localhost" help:"PostgreSQL host"`
+	Port     int    `key:"port" env:"DB_PORT" default:"5432" help:"PostgreSQL port"`
+	User     string `key:"user" env:"DB_USER" default:"postgres" help:"PostgreSQL user"`
+	Password string `key:"password" env:"DB_PASSWORD" help:"PostgreSQL password"`
+	DBName   string `key:"name" env:"DB_NAME" default:"go_short" help:"PostgreSQL database"`
+	SSLMode  string `key:"sslmode" env:"DB_SSLMODE" default:"disable" help:"PostgreSQL sslmode"`
+	TimeZone string `key:"timezone" env:"DB_TIMEZONE" default:"Asia/Taipei" help:"session time zone of database connections"`
+
+	AutoMigrate    bool          `key:"auto_migrate" env:"DB_AUTO_MIGRATE" default:"false" help:"apply pending migrations at startup, serialized across replicas by an advisory lock"`
+	MigrateTimeout time.Duration `key:"migrate_timeout" env:"DB_MIGRATE_TIMEOUT_SECONDS" unit:"s" default:"5m" help:"how long startup waits for the migration lock and pending migrations"`
 }
 
+// Redis 是 Redis 連線的設定
 type Redis struct {
-	Host     string
-	Port     string
-	Password string
-	DB       int
+	Host     string `key:"host" env:"REDIS_HOST" default:"localhost" help:"Redis host"`
+	Port     int    `key:"port" env:"REDIS_PORT" default:"6379" help:"Redis port"`
+	Password string `key:"password" env:"REDIS_PASSWORD" help:"Redis password"`
+	DB       int    `key:"db" env:"REDIS_DB" default:"0" help:"Redis database number"`
 }
 
-type Config struct {
-	// Database
-	DBHost     string
-	DBPort     string
-	DBUser     string
-	DBPassword string
-	DBName     string
-	// Redis
-	RedisHost     string
-	RedisPort     string
-	RedisPassword string
-	RedisDB       int
-	// URL Shortener
-	ShortenerAlgorithm string
-	// Server
-	ServerPort string
-}
-
-var config *Config
-var loadConfigOnce sync.Once
-
-func loadConfig() {
-	err := godotenv.Load()
+// Addr 返回 Redis 的連線位址
+func (r Redis) Addr() string {
+	return r.Host + ":" + strconv.Itoa(r.Port)
+}
+
+// Shortener 是短連結的設定
+type Shortener struct {
+	Algorithm       string        `key:"algorithm" env:"SHORTENER_ALGORITHM" default:"base62" reload:"true" help:"default short code algorithm: base62, base64, md5 or random"`
+	CacheTTL        time.Duration `key:"cache_ttl" env:"CACHE_TTL" default:"24h" help:"how long resolved links stay in the Redis cache"`
+	CleanupInterval time.Duration `key:"cleanup_interval" env:"CLEANUP_INTERVAL" default:"1h" help:"interval of the expired link cleanup"`
+}
+
+// Auth 是登入權杖與帳號的設定
+type Auth struct {
+	JWTSecret                     string        `key:"jwt_secret" env:"JWT_SECRET" help:"HMAC key of access tokens; at least 32 characters in production"`
+	AccessTokenTTL                time.Duration `key:"access_token_ttl" env:"ACCESS_TOKEN_TTL_MINUTES" unit:"m" default:"15m" help:"access token lifetime"`
+	RefreshTokenTTL               time.Duration `key:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL_HOURS" unit:"h" default:"720h" help:"refresh token lifetime"`
+	RequireEmailVerification      bool          `key:"require_email_verification" env:"REQUIRE_EMAIL_VERIFICATION" default:"false" help:"reject logins of unverified email addresses"`
+	AccountDeletionLinkPolicy     string        `key:"account_deletion_link_policy" env:"ACCOUNT_DELETION_LINK_POLICY" default:"disable" help:"links of deleted accounts: disable or transfer"`
+	AccountDeletionTransferUserID uint          `key:"account_deletion_transfer_user_id" env:"ACCOUNT_DELETION_TRANSFER_USER_ID" default:"0" help:"user receiving the links of deleted accounts with the transfer policy"`
+}
+
+// 刪除帳號時個人連結的處理方式
+const (
+	LinkPolicyDisable  = "disable"
+	LinkPolicyTransfer = "transfer"
+)
+
+// insecureJWTSecret 是開發環境未設定 JWT_SECRET 時使用的金鑰
+const insecureJWTSecret = "a_very_insecure_default_secret_key_change_me"
+
+// weakJWTSecrets 是文件與範例中出現過、不可用於正式環境的金鑰
+var weakJWTSecrets = []string{
+	insecureJWTSecret,
+	"your_strong_secret_key_here_at_least_32_chars",
+}
+
+// minJWTSecretLength 是正式環境 JWT 金鑰的最短長度
+const minJWTSecretLength = 32
+
+// Login 是登入失敗鎖定的設定
+type Login struct {
+	MaxFailures   int           `key:"max_failures" env:"LOGIN_MAX_FAILURES" default:"5" help:"failed logins per username before a lockout"`
+	MaxIPFailures int           `key:"max_ip_failures" env:"LOGIN_MAX_IP_FAILURES" default:"20" help:"failed logins per IP before a lockout"`
+	LockoutBase   time.Duration `key:"lockout" env:"LOGIN_LOCKOUT_SECONDS" unit:"s" default:"30s" help:"first lockout; doubles with every further failure"`
+}
+
+// OIDCProvider 是單一 OpenID Connect 身分提供者的設定
+type OIDCProvider struct {
+	Name          string   `key:"name"`
+	IssuerURL     string   `key:"issuer"`
+	ClientID      string   `key:"client_id"`
+	ClientSecret  string   `key:"client_secret"`
+	RedirectURL   string   `key:"redirect_url"`
+	Scopes        []string `key:"scopes"`
+	AutoProvision bool     `key:"auto_provision"`
+}
+
+// RateLimit 是一組路由的限流設定：每個 Window 最多 Limit 次請求，Limit 為 0 表示不限流
+type RateLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+// UnmarshalText 解析 "次數/時間" (例如 "100/1m")，"off" 表示不限流
+func (r *RateLimit) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if value == "off" {
+		*r = RateLimit{}
+		return nil
+	}
+	parsed, err := parseRateLimit(value)
 	if err != nil {
-		log.Println("Warning: Error loading .env file, using environment variables")
+		return fmt.Errorf("expected <requests>/<window> or off, got %q", value)
 	}
+	*r = parsed
+	return nil
+}
 
-	redisDB, err := strconv.Atoi(os.Getenv("REDIS_DB"))
-	if err != nil {
-		redisDB = 0 // Default Redis DB
+// 限流的路由群組名稱
+const (
+	RateLimitRedirect = "redirect" // 短連結重定向 (依 IP)
+	RateLimitCreate   = "create"   // 建立短連結 (依使用者、API 金鑰或 IP)
+	RateLimitAuth     = "auth"     // 登入、註冊等未登入的帳號操作 (依 IP)
+	RateLimitAPI      = "api"      // 需登入的 API (依使用者或 API 金鑰)
+	RateLimitAdmin    = "admin"    // 管理後台
+)
+
+// RateLimiting 是各路由群組的限流設定
+type RateLimiting struct {
+	Enabled  bool      `key:"enabled" env:"RATE_LIMIT_ENABLED" default:"true" help:"enable rate limiting"`
+	Redirect RateLimit `key:"redirect" env:"RATE_LIMIT_REDIRECT" default:"600/1m" help:"redirects per IP (<requests>/<window> or off)"`
+	Create   RateLimit `key:"create" env:"RATE_LIMIT_CREATE" default:"30/1m" help:"link creation per user, API key or IP"`
+	Auth     RateLimit `key:"auth" env:"RATE_LIMIT_AUTH" default:"30/1m" help:"login, signup and other account requests per IP"`
+	API      RateLimit `key:"api" env:"RATE_LIMIT_API" default:"300/1m" help:"authenticated API requests without a plan limit"`
+	Admin    RateLimit `key:"admin" env:"RATE_LIMIT_ADMIN" default:"120/1m" help:"admin API requests"`
+}
+
+// Rule 返回路由群組的限流設定，未知的群組或設為 off 時返回 false
+func (r RateLimiting) Rule(group string) (RateLimit, bool) {
+	var limit RateLimit
+	switch group {
+	case RateLimitRedirect:
+		limit = r.Redirect
+	case RateLimitCreate:
+		limit = r.Create
+	case RateLimitAuth:
+		limit = r.Auth
+	case RateLimitAPI:
+		limit = r.API
+	case RateLimitAdmin:
+		limit = r.Admin
 	}
+	return limit, limit.Limit > 0
+}
+
+// Blocklist 是封鎖清單的設定
+type Blocklist struct {
+	IPs     []string `key:"ips" env:"BLOCKLIST_IPS" help:"comma-separated client IPs or CIDRs that are refused with 403"`
+	Domains []string `key:"domains" env:"BLOCKLIST_DOMAINS" help:"comma-separated destination domains (and their subdomains) that links cannot point to"`
+}
+
+// Plans 是方案與配額的設定
+type Plans struct {
+	UpgradeURL string `key:"upgrade_url" env:"UPGRADE_URL" help:"upgrade page included in quota errors"`
+}
+
+// Webhooks 是 webhook 投遞的設定
+type Webhooks struct {
+	Timeout              time.Duration `key:"timeout" env:"WEBHOOK_TIMEOUT_SECONDS" unit:"s" default:"10s" help:"timeout of each delivery"`
+	MaxAttempts          int           `key:"max_attempts" env:"WEBHOOK_MAX_ATTEMPTS" default:"8" help:"attempts before a delivery is dead-lettered"`
+	AllowPrivateNetworks bool          `key:"allow_private_networks" env:"WEBHOOK_ALLOW_PRIVATE_NETWORKS" default:"false" help:"allow deliveries to private and loopback addresses (local testing only)"`
+}
+
+// Events 是領域事件的設定
+type Events struct {
+	Sinks        []string `key:"sinks" env:"EVENT_SINKS" help:"extra destinations of outbox events: redis"`
+	Stream       string   `key:"stream" env:"EVENT_STREAM" default:"goshort:events" help:"Redis Stream of the redis sink"`
+	StreamMaxLen int64    `key:"stream_maxlen" env:"EVENT_STREAM_MAXLEN" default:"100000" help:"approximate maximum length of the event stream; 0 disables trimming"`
+}
+
+// Clicks 是點擊處理的設定
+type Clicks struct {
+	Stream         string `key:"stream" env:"CLICK_STREAM" default:"goshort:clicks" help:"Redis Stream that redirects append clicks to"`
+	StreamMaxLen   int64  `key:"stream_maxlen" env:"CLICK_STREAM_MAXLEN" default:"1000000" help:"approximate maximum length of the click stream; 0 disables trimming"`
+	ConsumerGroup  string `key:"consumer_group" env:"CLICK_CONSUMER_GROUP" default:"click-writers" help:"consumer group of the click workers"`
+	ConsumerName   string `key:"consumer_name" env:"CLICK_CONSUMER_NAME" help:"consumer name of this instance (default <hostname>-<pid>)"`
+	WorkerInServer bool   `key:"worker_in_server" env:"CLICK_WORKER_IN_SERVER" default:"true" help:"run a click worker inside the API server"`
+}
+
+// Metrics 是 Prometheus 指標的設定
+type Metrics struct {
+	Enabled bool   `key:"enabled" env:"METRICS_ENABLED" default:"true" help:"expose /metrics and record metrics"`
+	Token   string `key:"token" env:"METRICS_TOKEN" help:"bearer token required to scrape /metrics"`
+}
 
-	config = &Config{
-		// Database
-		DBHost:     os.Getenv("DB_HOST"),
-		DBPort:     os.Getenv("DB_PORT"),
-		DBUser:     os.Getenv("DB_USER"),
-		DBPassword: os.Getenv("DB_PASSWORD"),
-		DBName:     os.Getenv("DB_NAME"),
-		// Redis
-		RedisHost:     os.Getenv("REDIS_HOST"),
-		RedisPort:     os.Getenv("REDIS_PORT"),
-		RedisPassword: os.Getenv("REDIS_PASSWORD"),
-		RedisDB:       redisDB,
-		// URL Shortener
-		ShortenerAlgorithm: os.Getenv("SHORTENER_ALGORITHM"),
+// Health 是就緒檢查的設定
+type Health struct {
+	CheckTimeout time.Duration `key:"check_timeout" env:"HEALTH_CHECK_TIMEOUT_SECONDS" unit:"s" default:"2s" help:"timeout of each /readyz check"`
+}
+
+// Tracing 是 OpenTelemetry 追蹤的設定
+type Tracing struct {
+	Exporter    string  `key:"exporter" env:"TRACING_EXPORTER" default:"none" help:"none, stdout, file or otlp"`
+	File        string  `key:"file" env:"TRACING_FILE" default:"tmp/traces.jsonl" help:"output file of the file exporter"`
+	SampleRatio float64 `key:"sample_ratio" env:"TRACING_SAMPLE_RATIO" default:"1" help:"fraction of new traces that are sampled (0-1)"`
+	ServiceName string  `key:"service_name" env:"TRACING_SERVICE_NAME" default:"go_short" help:"service.name resource attribute"`
+}
+
+// Log 是日誌的設定
+type Log struct {
+	Level  string `key:"level" env:"LOG_LEVEL" default:"info" reload:"true" help:"debug, info, warn or error"`
+	Format string `key:"format" env:"LOG_FORMAT" default:"json" help:"json or text"`
+}
+
+// Mailer 是郵件寄送的設定
+type Mailer struct {
+	Driver       string `key:"driver" env:"MAILER_DRIVER" default:"log" help:"smtp, file or log"`
+	From         string `key:"from" env:"MAIL_FROM" default:"no-reply@localhost" help:"sender address"`
+	FileDir      string `key:"file_dir" env:"MAIL_FILE_DIR" default:"tmp/mail" help:"output directory of the file driver"`
+	SMTPHost     string `key:"smtp_host" env:"SMTP_HOST" help:"SMTP server"`
+	SMTPPort     int    `key:"smtp_port" env:"SMTP_PORT" default:"587" help:"SMTP port"`
+	SMTPUsername string `key:"smtp_username" env:"SMTP_USERNAME" help:"SMTP user"`
+	SMTPPassword string `key:"smtp_password" env:"SMTP_PASSWORD" help:"SMTP password"`
+}
+
+// IsProduction 表示以正式環境執行
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// Validate 檢查設定是否有效，返回所有問題
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
 	}
+	oneOf := func(key, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		errs = append(errs, fmt.Errorf("%s must be one of %s, got %q", key, strings.Join(allowed, ", "), value))
+	}
+
+	oneOf("env", c.Env, EnvDevelopment, EnvProduction)
 
-	// Set default algorithm if not specified
-	if config.ShortenerAlgorithm == "" {
-		config.ShortenerAlgorithm = "base62"
+	check(validPort(c.Server.Port), "server.port must be between 1 and 65535")
+	if u, err := url.Parse(c.Server.PublicBaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		errs = append(errs, fmt.Errorf("server.public_base_url must be an absolute URL, got %q", c.Server.PublicBaseURL))
+	}
+	check(c.Server.ShutdownDrain >= 0, "server.shutdown_drain must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.Database.Host != "", "database.host is required")
+	check(validPort(c.Database.Port), "database.port must be between 1 and 65535")
+	check(c.Database.User != "", "database.user is required")
+	check(c.Database.DBName != "", "database.name is required")
+	if _, err := time.LoadLocation(c.Database.TimeZone); err != nil {
+		errs = append(errs, fmt.Errorf("database.timezone: %w", err))
+	}
+	check(c.Database.MigrateTimeout > 0, "database.migrate_timeout must be positive")
+
+	check(c.Redis.Host != "", "redis.host is required")
+	check(validPort(c.Redis.Port), "redis.port must be between 1 and 65535")
+	check(c.Redis.DB >= 0, "redis.db must not be negative")
+
+	oneOf("shortener.algorithm", c.Shortener.Algorithm, "base62", "base64", "md5", "random")
+	check(c.Shortener.CacheTTL > 0, "shortener.cache_ttl must be positive")
+	check(c.Shortener.CleanupInterval > 0, "shortener.cleanup_interval must be positive")
+
+	if err := c.validateJWTSecret(); err != nil {
+		errs = append(errs, err)
+	}
+	check(c.Auth.AccessTokenTTL > 0, "auth.access_token_ttl must be positive")
+	check(c.Auth.RefreshTokenTTL > 0, "auth.refresh_token_ttl must be positive")
+	oneOf("auth.account_deletion_link_policy", c.Auth.AccountDeletionLinkPolicy, LinkPolicyDisable, LinkPolicyTransfer)
+	check(c.Auth.AccountDeletionLinkPolicy != LinkPolicyTransfer || c.Auth.AccountDeletionTransferUserID != 0,
+		"auth.account_deletion_transfer_user_id is required with the transfer policy")
+
+	check(c.Login.MaxFailures > 0, "login.max_failures must be positive")
+	check(c.Login.MaxIPFailures > 0, "login.max_ip_failures must be positive")
+	check(c.Login.LockoutBase > 0, "login.lockout must be positive")
+
+	for i, p := range c.OIDCProviders {
+		check(p.Name != "", "oidc_providers[%d].name is required", i)
+		check(p.IssuerURL != "" && p.ClientID != "", "oidc provider %q requires issuer and client_id", p.Name)
+	}
+
+	check(c.Webhooks.Timeout > 0, "webhooks.timeout must be positive")
+	check(c.Webhooks.MaxAttempts > 0, "webhooks.max_attempts must be positive")
+
+	for _, entry := range c.Blocklist.IPs {
+		check(validIPOrCIDR(entry), "blocklist.ips: invalid IP or CIDR %q", entry)
+	}
+	for _, domain := range c.Blocklist.Domains {
+		check(!strings.ContainsAny(domain, "/: "), "blocklist.domains: invalid domain %q", domain)
 	}
+
+	for _, sink := range c.Events.Sinks {
+		oneOf("events.sinks", sink, "redis")
+	}
+	check(c.Events.Stream != "", "events.stream is required")
+	check(c.Events.StreamMaxLen >= 0, "events.stream_maxlen must not be negative")
+
+	check(c.Clicks.Stream != "", "clicks.stream is required")
+	check(c.Clicks.StreamMaxLen >= 0, "clicks.stream_maxlen must not be negative")
+	check(c.Clicks.ConsumerGroup != "", "clicks.consumer_group is required")
+
+	check(c.Health.CheckTimeout > 0, "health.check_timeout must be positive")
+
+	oneOf("tracing.exporter", c.Tracing.Exporter, "none", "stdout", "file", "otlp")
+	check(c.Tracing.SampleRatio >= 0 && c.Tracing.SampleRatio <= 1, "tracing.sample_ratio must be between 0 and 1")
+
+	oneOf("log.level", strings.ToLower(c.Log.Level), "debug", "info", "warn", "error")
+	oneOf("log.format", c.Log.Format, "json", "text")
+
+	oneOf("mailer.driver", c.Mailer.Driver, "smtp", "file", "log")
+	check(c.Mailer.Driver != "smtp" || c.Mailer.SMTPHost != "", "mailer.smtp_host is required with the smtp driver")
+	check(validPort(c.Mailer.SMTPPort), "mailer.smtp_port must be between 1 and 65535")
+
+	return errors.Join(errs...)
+}
+
+// validateJWTSecret 在正式環境拒絕空白、過短或範例中的 JWT 金鑰
+func (c *Config) validateJWTSecret() error {
+	if !c.IsProduction() {
+		return nil
+	}
+	secret := c.Auth.JWTSecret
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("auth.jwt_secret must be at least %d characters in production", minJWTSecretLength)
+	}
+	for _, weak := range weakJWTSecrets {
+		if secret == weak {
+			return errors.New("auth.jwt_secret is a published example value and must be replaced in production")
+		}
+	}
+	return nil
+}
+
+func validIPOrCIDR(value string) bool {
+	if _, err := netip.ParsePrefix(value); err == nil {
+		return true
+	}
+	_, err := netip.ParseAddr(value)
+	return err == nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
 }
 
-func Conf() *Config {
-	loadConfigOnce.Do(loadConfig)
-	return config
+func parseRateLimit(value string) (RateLimit, error) {
+	parts := strings.SplitN(value, "/", 2)
+	if len(parts) != 2 {
+		return RateLimit{}, strconv.ErrSyntax
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || limit <= 0 {
+		return RateLimit{}, strconv.ErrSyntax
+	}
+	window, err := time.ParseDuration(strings.TrimSpace(parts[1]))
+	if err != nil || window <= 0 {
+		return RateLimit{}, strconv.ErrSyntax
+	}
+	return RateLimit{Limit: limit, Window: window}, nil
+}
+
+// splitList 將逗號分隔的字串拆為去除空白的清單
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
 }
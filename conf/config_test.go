@@ -0,0 +1,169 @@
+package conf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig 返回只含預設值的有效設定
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	clearEnv(t)
+	config, _, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return config
+}
+
+func TestValidateRules(t *testing.T) {
+	strongSecret := strings.Repeat("k", minJWTSecretLength)
+	tests := []struct {
+		name   string
+		mutate func(*Config)
+		want   string // 空字串表示設定有效
+	}{
+		{name: "defaults", mutate: func(c *Config) {}},
+		{name: "env", mutate: func(c *Config) { c.Env = "staging" }, want: "env must be one of development, production"},
+
+		{name: "server port", mutate: func(c *Config) { c.Server.Port = 70000 }, want: "server.port must be between 1 and 65535"},
+		{name: "public base url", mutate: func(c *Config) { c.Server.PublicBaseURL = "localhost:8080" }, want: "server.public_base_url must be an absolute URL"},
+		{name: "shutdown drain", mutate: func(c *Config) { c.Server.ShutdownDrain = -time.Second }, want: "server.shutdown_drain must not be negative"},
+		{name: "shutdown timeout", mutate: func(c *Config) { c.Server.ShutdownTimeout = 0 }, want: "server.shutdown_timeout must be positive"},
+
+		{name: "database host", mutate: func(c *Config) { c.Database.Host = "" }, want: "database.host is required"},
+		{name: "database port", mutate: func(c *Config) { c.Database.Port = 0 }, want: "database.port must be between 1 and 65535"},
+		{name: "database user", mutate: func(c *Config) { c.Database.User = "" }, want: "database.user is required"},
+		{name: "database name", mutate: func(c *Config) { c.Database.DBName = "" }, want: "database.name is required"},
+		{name: "database timezone", mutate: func(c *Config) { c.Database.TimeZone = "Mars/Olympus" }, want: "database.timezone"},
+		{name: "migrate timeout", mutate: func(c *Config) { c.Database.MigrateTimeout = 0 }, want: "database.migrate_timeout must be positive"},
+
+		{name: "redis host", mutate: func(c *Config) { c.Redis.Host = "" }, want: "redis.host is required"},
+		{name: "redis port", mutate: func(c *Config) { c.Redis.Port = -1 }, want: "redis.port must be between 1 and 65535"},
+		{name: "redis db", mutate: func(c *Config) { c.Redis.DB = -1 }, want: "redis.db must not be negative"},
+
+		{name: "algorithm", mutate: func(c *Config) { c.Shortener.Algorithm = "sha1" }, want: "shortener.algorithm must be one of base62, base64, md5, random"},
+		{name: "cache ttl", mutate: func(c *Config) { c.Shortener.CacheTTL = 0 }, want: "shortener.cache_ttl must be positive"},
+		{name: "cleanup interval", mutate: func(c *Config) { c.Shortener.CleanupInterval = 0 }, want: "shortener.cleanup_interval must be positive"},
+
+		{name: "development accepts any jwt secret", mutate: func(c *Config) { c.Auth.JWTSecret = "short" }},
+		{name: "production strong jwt secret", mutate: func(c *Config) { c.Env = EnvProduction; c.Auth.JWTSecret = strongSecret }},
+		{name: "production empty jwt secret", mutate: func(c *Config) { c.Env = EnvProduction; c.Auth.JWTSecret = "" }, want: "auth.jwt_secret must be at least 32 characters in production"},
+		{name: "production short jwt secret", mutate: func(c *Config) { c.Env = EnvProduction; c.Auth.JWTSecret = strongSecret[1:] }, want: "auth.jwt_secret must be at least 32 characters in production"},
+		{name: "production insecure default jwt secret", mutate: func(c *Config) { c.Env = EnvProduction; c.Auth.JWTSecret = insecureJWTSecret }, want: "auth.jwt_secret is a published example value"},
+		{name: "production example jwt secret", mutate: func(c *Config) {
+			c.Env = EnvProduction
+			c.Auth.JWTSecret = "your_strong_secret_key_here_at_least_32_chars"
+		}, want: "auth.jwt_secret is a published example value"},
+		{name: "access token ttl", mutate: func(c *Config) { c.Auth.AccessTokenTTL = 0 }, want: "auth.access_token_ttl must be positive"},
+		{name: "refresh token ttl", mutate: func(c *Config) { c.Auth.RefreshTokenTTL = -time.Hour }, want: "auth.refresh_token_ttl must be positive"},
+		{name: "link policy", mutate: func(c *Config) { c.Auth.AccountDeletionLinkPolicy = "delete" }, want: "auth.account_deletion_link_policy must be one of disable, transfer"},
+		{name: "transfer policy without user", mutate: func(c *Config) { c.Auth.AccountDeletionLinkPolicy = LinkPolicyTransfer }, want: "auth.account_deletion_transfer_user_id is required"},
+		{name: "transfer policy with user", mutate: func(c *Config) {
+			c.Auth.AccountDeletionLinkPolicy = LinkPolicyTransfer
+			c.Auth.AccountDeletionTransferUserID = 1
+		}},
+
+		{name: "login max failures", mutate: func(c *Config) { c.Login.MaxFailures = 0 }, want: "login.max_failures must be positive"},
+		{name: "login max ip failures", mutate: func(c *Config) { c.Login.MaxIPFailures = 0 }, want: "login.max_ip_failures must be positive"},
+		{name: "login lockout", mutate: func(c *Config) { c.Login.LockoutBase = 0 }, want: "login.lockout must be positive"},
+
+		{name: "oidc provider name", mutate: func(c *Config) {
+			c.OIDCProviders = []OIDCProvider{{IssuerURL: "https://id.example.com", ClientID: "app"}}
+		}, want: "oidc_providers[0].name is required"},
+		{name: "oidc provider issuer", mutate: func(c *Config) {
+			c.OIDCProviders = []OIDCProvider{{Name: "corp", ClientID: "app"}}
+		}, want: `oidc provider "corp" requires issuer and client_id`},
+
+		{name: "webhook timeout", mutate: func(c *Config) { c.Webhooks.Timeout = 0 }, want: "webhooks.timeout must be positive"},
+		{name: "webhook max attempts", mutate: func(c *Config) { c.Webhooks.MaxAttempts = 0 }, want: "webhooks.max_attempts must be positive"},
+
+		{name: "blocklist ip", mutate: func(c *Config) { c.Blocklist.IPs = []string{"10.0.0.0/8", "10.0.0.300"} }, want: `blocklist.ips: invalid IP or CIDR "10.0.0.300"`},
+		{name: "blocklist domain", mutate: func(c *Config) { c.Blocklist.Domains = []string{"https://evil.example"} }, want: `blocklist.domains: invalid domain "https://evil.example"`},
+
+		{name: "event sink", mutate: func(c *Config) { c.Events.Sinks = []string{"kafka"} }, want: "events.sinks must be one of redis"},
+		{name: "event stream", mutate: func(c *Config) { c.Events.Stream = "" }, want: "events.stream is required"},
+		{name: "event stream maxlen", mutate: func(c *Config) { c.Events.StreamMaxLen = -1 }, want: "events.stream_maxlen must not be negative"},
+
+		{name: "click stream", mutate: func(c *Config) { c.Clicks.Stream = "" }, want: "clicks.stream is required"},
+		{name: "click stream maxlen", mutate: func(c *Config) { c.Clicks.StreamMaxLen = -1 }, want: "clicks.stream_maxlen must not be negative"},
+		{name: "click consumer group", mutate: func(c *Config) { c.Clicks.ConsumerGroup = "" }, want: "clicks.consumer_group is required"},
+
+		{name: "health check timeout", mutate: func(c *Config) { c.Health.CheckTimeout = 0 }, want: "health.check_timeout must be positive"},
+
+		{name: "tracing exporter", mutate: func(c *Config) { c.Tracing.Exporter = "jaeger" }, want: "tracing.exporter must be one of none, stdout, file, otlp"},
+		{name: "tracing sample ratio", mutate: func(c *Config) { c.Tracing.SampleRatio = 1.5 }, want: "tracing.sample_ratio must be between 0 and 1"},
+
+		{name: "log level is case insensitive", mutate: func(c *Config) { c.Log.Level = "DEBUG" }},
+		{name: "log level", mutate: func(c *Config) { c.Log.Level = "trace" }, want: "log.level must be one of debug, info, warn, error"},
+		{name: "log format", mutate: func(c *Config) { c.Log.Format = "xml" }, want: "log.format must be one of json, text"},
+
+		{name: "mailer driver", mutate: func(c *Config) { c.Mailer.Driver = "sendgrid" }, want: "mailer.driver must be one of smtp, file, log"},
+		{name: "smtp host", mutate: func(c *Config) { c.Mailer.Driver = "smtp" }, want: "mailer.smtp_host is required with the smtp driver"},
+		{name: "smtp port", mutate: func(c *Config) { c.Mailer.SMTPPort = 0 }, want: "mailer.smtp_port must be between 1 and 65535"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := validConfig(t)
+			tt.mutate(config)
+			err := config.Validate()
+			if tt.want == "" {
+				if err != nil {
+					t.Fatalf("Validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateReportsAllErrors(t *testing.T) {
+	config := validConfig(t)
+	config.Server.Port = 0
+	config.Redis.Host = ""
+	config.Log.Format = "xml"
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted an invalid configuration")
+	}
+	for _, want := range []string{"server.port", "redis.host", "log.format"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v, want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestLoadRefusesWeakJWTSecretInProduction(t *testing.T) {
+	clearEnv(t)
+	t.Setenv("APP_ENV", EnvProduction)
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "auth.jwt_secret") {
+		t.Fatalf("production without JWT_SECRET: err = %v, want a jwt_secret error", err)
+	}
+
+	t.Setenv("JWT_SECRET", "your_strong_secret_key_here_at_least_32_chars")
+	if _, _, err := Load(nil); err == nil || !strings.Contains(err.Error(), "published example value") {
+		t.Fatalf("production with the example JWT_SECRET: err = %v, want a published example error", err)
+	}
+
+	t.Setenv("JWT_SECRET", strings.Repeat("s", minJWTSecretLength))
+	if _, _, err := Load(nil); err != nil {
+		t.Fatalf("production with a strong JWT_SECRET: %v", err)
+	}
+}
+
+func TestRateLimitingRule(t *testing.T) {
+	limits := RateLimiting{Redirect: RateLimit{Limit: 10, Window: time.Second}}
+	if rule, ok := limits.Rule(RateLimitRedirect); !ok || rule.Limit != 10 {
+		t.Errorf("Rule(redirect) = %+v, %v", rule, ok)
+	}
+	if _, ok := limits.Rule(RateLimitCreate); ok {
+		t.Error("a group set to off must not be limited")
+	}
+	if _, ok := limits.Rule("unknown"); ok {
+		t.Error("an unknown group must not be limited")
+	}
+}
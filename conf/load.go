@@ -0,0 +1,377 @@
+package conf
+
+import (
+	"encoding"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// oidcProvidersKey 是設定檔中 OIDC 提供者清單的名稱
+const oidcProvidersKey = "oidc_providers"
+
+// field 是設定中的一個值，以標籤描述其名稱、環境變數與預設值
+type field struct {
+	key  string
+	env  string
+	def  string
+	unit string
+	help string
+	// reload 表示可在執行中重新載入
+	reload bool
+	value  reflect.Value
+}
+
+// Load 依序套用預設值、設定檔、環境變數 (含 .env) 與命令列參數，並檢查設定是否有效
+// 設定檔以 -config 參數或 CONFIG_FILE 環境變數指定，依副檔名讀取 YAML 或 TOML；
+// 返回命令列中參數之後的其餘引數 (例如子命令)。帶 -h 時返回 flag.ErrHelp
+func Load(args []string) (*Config, []string, error) {
+	if err := godotenv.Load(); err != nil {
+		slog.Debug("No .env file loaded, using environment variables")
+	}
+
+	config := &Config{}
+	fields := collectFields(reflect.ValueOf(config).Elem(), "", false)
+	for _, f := range fields {
+		if f.def == "" {
+			continue
+		}
+		if err := setValue(f.value, f.unit, f.def); err != nil {
+			return nil, nil, fmt.Errorf("default of %s: %w", f.key, err)
+		}
+	}
+
+	// 命令列參數最後套用，先記錄下來
+	flags := flag.NewFlagSet(filepath.Base(os.Args[0]), flag.ContinueOnError)
+	configFile := flags.String("config", os.Getenv("CONFIG_FILE"), "path of a YAML or TOML config file (env CONFIG_FILE)")
+	overrides := make(map[string]string)
+	for _, f := range fields {
+		key := f.key
+		flags.Func(key, usage(f), func(value string) error {
+			overrides[key] = value
+			return nil
+		})
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, nil, err
+	}
+
+	if *configFile != "" {
+		if err := loadFile(config, fields, *configFile); err != nil {
+			return nil, nil, err
+		}
+		config.File = *configFile
+	}
+
+	// 已設定但為空的環境變數也會覆寫，讓設定檔中的值可以用環境變數清除
+	for _, f := range fields {
+		if f.env == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(f.env)
+		if !ok {
+			continue
+		}
+		if err := setValue(f.value, f.unit, value); err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", f.env, err)
+		}
+	}
+	if names, ok := os.LookupEnv("OIDC_PROVIDERS"); ok {
+		config.OIDCProviders = loadOIDCProviders(names)
+	}
+
+	for _, f := range fields {
+		if value, ok := overrides[f.key]; ok {
+			if err := setValue(f.value, f.unit, value); err != nil {
+				return nil, nil, fmt.Errorf("-%s: %w", f.key, err)
+			}
+		}
+	}
+
+	applyDerivedDefaults(config)
+	if err := config.Validate(); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration: %w", err)
+	}
+	return config, flags.Args(), nil
+}
+
+// applyDerivedDefaults 填入無法以固定值表示的預設值
+func applyDerivedDefaults(config *Config) {
+	if config.Clicks.ConsumerName == "" {
+		hostname, _ := os.Hostname()
+		config.Clicks.ConsumerName = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if config.Auth.JWTSecret == "" && !config.IsProduction() {
+		slog.Warn("JWT_SECRET not set, using an insecure default key (refused in production)")
+		config.Auth.JWTSecret = insecureJWTSecret
+	}
+}
+
+// usage 組出命令列參數的說明，包含環境變數與預設值
+func usage(f field) string {
+	var b strings.Builder
+	b.WriteString(f.help)
+	if f.env != "" {
+		fmt.Fprintf(&b, " (env %s", f.env)
+		if f.def != "" {
+			fmt.Fprintf(&b, ", default %s", f.def)
+		}
+		b.WriteString(")")
+	} else if f.def != "" {
+		fmt.Fprintf(&b, " (default %s)", f.def)
+	}
+	return b.String()
+}
+
+// collectFields 依 key 標籤列出結構中可設定的值，巢狀結構的名稱以 "." 串接，reload 標籤由外層結構繼承
+// 結構清單 (OIDC 提供者) 不在其中，另外由 loadFile 與 loadOIDCProviders 處理
+func collectFields(v reflect.Value, prefix string, reload bool) []field {
+	var fields []field
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		key := sf.Tag.Get("key")
+		if key == "" || key == "-" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		fv := v.Field(i)
+		fieldReload := reload || sf.Tag.Get("reload") == "true"
+		switch {
+		case sf.Type.Kind() == reflect.Struct && !isScalar(fv):
+			fields = append(fields, collectFields(fv, key, fieldReload)...)
+		case sf.Type.Kind() == reflect.Slice && sf.Type.Elem().Kind() == reflect.Struct:
+		default:
+			fields = append(fields, field{
+				key:    key,
+				env:    sf.Tag.Get("env"),
+				def:    sf.Tag.Get("default"),
+				unit:   sf.Tag.Get("unit"),
+				help:   sf.Tag.Get("help"),
+				reload: fieldReload,
+				value:  fv,
+			})
+		}
+	}
+	return fields
+}
+
+// isScalar 表示結構型別的值以單一字串設定 (實作 encoding.TextUnmarshaler，例如 RateLimit)
+func isScalar(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits 是時間欄位只給整數時可用的單位
+var durationUnits = map[string]time.Duration{
+	"s": time.Second,
+	"m": time.Minute,
+	"h": time.Hour,
+}
+
+// setValue 將字串解析為欄位的型別並寫入
+func setValue(v reflect.Value, unit string, raw string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	raw = strings.TrimSpace(raw)
+	if v.Type() == durationType {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil && unit != "" {
+			v.SetInt(n * int64(durationUnits[unit]))
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q", raw)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("invalid boolean %q", raw)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid unsigned integer %q", raw)
+		}
+		v.SetUint(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(splitList(raw)))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
+
+// loadFile 讀取 YAML 或 TOML 設定檔並套用到 config，未知的名稱視為錯誤以免拼錯的設定被忽略
+func loadFile(config *Config, fields []field, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	tree := make(map[string]interface{})
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return fmt.Errorf("config file %s: unsupported format %q (use .yaml, .yml or .toml)", path, ext)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+
+	if providers, ok := tree[oidcProvidersKey]; ok {
+		delete(tree, oidcProvidersKey)
+		config.OIDCProviders, err = decodeOIDCProviders(providers)
+		if err != nil {
+			return fmt.Errorf("config file %s: %w", path, err)
+		}
+	}
+
+	values := make(map[string]string)
+	if err := flatten(tree, "", values); err != nil {
+		return fmt.Errorf("config file %s: %w", path, err)
+	}
+	byKey := make(map[string]field, len(fields))
+	for _, f := range fields {
+		byKey[f.key] = f
+	}
+	var unknown []string
+	for key, value := range values {
+		f, ok := byKey[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		if err := setValue(f.value, f.unit, value); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("config file %s: unknown keys: %s", path, strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// flatten 將巢狀的設定轉為以 "." 串接名稱的字串值，清單以逗號串接
+func flatten(tree map[string]interface{}, prefix string, out map[string]string) error {
+	for key, value := range tree {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if err := flatten(v, key, out); err != nil {
+				return err
+			}
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				if _, ok := item.(map[string]interface{}); ok {
+					return fmt.Errorf("%s: expected a list of values", key)
+				}
+				items = append(items, fmt.Sprint(item))
+			}
+			out[key] = strings.Join(items, ",")
+		case nil:
+			out[key] = ""
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
+
+// decodeOIDCProviders 將設定檔中的提供者清單轉為 OIDCProvider
+func decodeOIDCProviders(value interface{}) ([]OIDCProvider, error) {
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("%s: expected a list", oidcProvidersKey)
+	}
+	providers := make([]OIDCProvider, 0, len(list))
+	for i, item := range list {
+		entry, ok := item.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("%s[%d]: expected a table", oidcProvidersKey, i)
+		}
+		values := make(map[string]string)
+		if err := flatten(entry, "", values); err != nil {
+			return nil, fmt.Errorf("%s[%d]: %w", oidcProvidersKey, i, err)
+		}
+		var provider OIDCProvider
+		fields := collectFields(reflect.ValueOf(&provider).Elem(), "", false)
+		for _, f := range fields {
+			value, ok := values[f.key]
+			if !ok {
+				continue
+			}
+			delete(values, f.key)
+			if err := setValue(f.value, f.unit, value); err != nil {
+				return nil, fmt.Errorf("%s[%d].%s: %w", oidcProvidersKey, i, f.key, err)
+			}
+		}
+		for key := range values {
+			return nil, fmt.Errorf("%s[%d]: unknown key %s", oidcProvidersKey, i, key)
+		}
+		provider.Name = strings.ToLower(provider.Name)
+		providers = append(providers, provider)
+	}
+	return providers, nil
+}
+
+// loadOIDCProviders 讀取 OIDC_PROVIDERS 列出的每個提供者，各自的設定位於 OIDC_<NAME>_* 環境變數
+func loadOIDCProviders(names string) []OIDCProvider {
+	var providers []OIDCProvider
+	for _, name := range splitList(names) {
+		name = strings.ToLower(name)
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		provider := OIDCProvider{
+			Name:         name,
+			IssuerURL:    os.Getenv(prefix + "ISSUER"),
+			ClientID:     os.Getenv(prefix + "CLIENT_ID"),
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			RedirectURL:  os.Getenv(prefix + "REDIRECT_URL"),
+			Scopes:       splitList(os.Getenv(prefix + "SCOPES")),
+		}
+		provider.AutoProvision, _ = strconv.ParseBool(os.Getenv(prefix + "AUTO_PROVISION"))
+		providers = append(providers, provider)
+	}
+	return providers
+}
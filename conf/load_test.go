@@ -0,0 +1,236 @@
+package conf
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clearEnv 移除所有設定用的環境變數，測試結束後還原
+func clearEnv(t *testing.T) {
+	t.Helper()
+	names := []string{"CONFIG_FILE", "OIDC_PROVIDERS"}
+	for _, f := range collectFields(reflect.ValueOf(&Config{}).Elem(), "", false) {
+		if f.env != "" {
+			names = append(names, f.env)
+		}
+	}
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+// writeFile 在暫存目錄寫入設定檔並返回路徑
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadDefaults(t *testing.T) {
+	clearEnv(t)
+	config, rest, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("rest = %v, want none", rest)
+	}
+	if config.Server.Port != 8080 || config.Auth.AccessTokenTTL != 15*time.Minute || config.Shortener.Algorithm != "base62" {
+		t.Errorf("defaults not applied: port %d, access ttl %s, algorithm %q", config.Server.Port, config.Auth.AccessTokenTTL, config.Shortener.Algorithm)
+	}
+	if config.RateLimit.Redirect != (RateLimit{Limit: 600, Window: time.Minute}) {
+		t.Errorf("rate_limit.redirect = %+v", config.RateLimit.Redirect)
+	}
+	if config.Auth.JWTSecret != insecureJWTSecret {
+		t.Errorf("development must fall back to the insecure JWT secret, got %q", config.Auth.JWTSecret)
+	}
+	if config.Clicks.ConsumerName == "" {
+		t.Error("clicks.consumer_name must default to <hostname>-<pid>")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := "server:\n  port: 9000\nlog:\n  level: debug\nmetrics:\n  token: from-file\n"
+	tests := []struct {
+		name      string
+		file      bool
+		env       map[string]string
+		args      []string
+		wantPort  int
+		wantLevel string
+		wantToken string
+	}{
+		{name: "default", wantPort: 8080, wantLevel: "info"},
+		{name: "file over default", file: true, wantPort: 9000, wantLevel: "debug", wantToken: "from-file"},
+		{name: "env over file", file: true, env: map[string]string{"SERVER_PORT": "9100"}, wantPort: 9100, wantLevel: "debug", wantToken: "from-file"},
+		{name: "flag over env", file: true, env: map[string]string{"SERVER_PORT": "9100", "LOG_LEVEL": "warn"}, args: []string{"-server.port=9200"}, wantPort: 9200, wantLevel: "warn", wantToken: "from-file"},
+		{name: "empty env clears file value", file: true, env: map[string]string{"METRICS_TOKEN": ""}, wantPort: 9000, wantLevel: "debug", wantToken: ""},
+		{name: "flag over empty env", file: true, env: map[string]string{"METRICS_TOKEN": ""}, args: []string{"-metrics.token=from-flag"}, wantPort: 9000, wantLevel: "debug", wantToken: "from-flag"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			if tt.file {
+				t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", file))
+			}
+			for name, value := range tt.env {
+				t.Setenv(name, value)
+			}
+			config, _, err := Load(tt.args)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if config.Server.Port != tt.wantPort || config.Log.Level != tt.wantLevel || config.Metrics.Token != tt.wantToken {
+				t.Errorf("got port %d, level %q, token %q; want %d, %q, %q",
+					config.Server.Port, config.Log.Level, config.Metrics.Token, tt.wantPort, tt.wantLevel, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestLoadConfigFlagAndRemainingArgs(t *testing.T) {
+	clearEnv(t)
+	path := writeFile(t, "config.toml", "[shortener]\nalgorithm = \"md5\"\n")
+	config, rest, err := Load([]string{"-config", path, "migrate", "up"})
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if config.Shortener.Algorithm != "md5" || config.File != path {
+		t.Errorf("algorithm %q, file %q; want md5 from %s", config.Shortener.Algorithm, config.File, path)
+	}
+	if !reflect.DeepEqual(rest, []string{"migrate", "up"}) {
+		t.Errorf("rest = %v, want [migrate up]", rest)
+	}
+}
+
+func TestLoadRejectsUnknownKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    string
+	}{
+		{name: "yaml", file: "config.yaml", content: "server:\n  prot: 9000\nlog:\n  levl: debug\n", want: "unknown keys: log.levl, server.prot"},
+		{name: "toml", file: "config.toml", content: "[redis]\nhots = \"cache\"\n", want: "unknown keys: redis.hots"},
+		{name: "oidc provider", file: "config.yaml", content: "oidc_providers:\n  - name: corp\n    issuer: https://id.example.com\n    client_id: app\n    secret: x\n", want: "unknown key secret"},
+		{name: "unsupported format", file: "config.json", content: "{}", want: "unsupported format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearEnv(t)
+			_, _, err := Load([]string{"-config", writeFile(t, tt.file, tt.content)})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadUnits(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+		got   func(*Config) interface{}
+		want  interface{}
+	}{
+		{env: "ACCESS_TOKEN_TTL_MINUTES", value: "30", got: func(c *Config) interface{} { return c.Auth.AccessTokenTTL }, want: 30 * time.Minute},
+		{env: "ACCESS_TOKEN_TTL_MINUTES", value: "90s", got: func(c *Config) interface{} { return c.Auth.AccessTokenTTL }, want: 90 * time.Second},
+		{env: "REFRESH_TOKEN_TTL_HOURS", value: "48", got: func(c *Config) interface{} { return c.Auth.RefreshTokenTTL }, want: 48 * time.Hour},
+		{env: "LOGIN_LOCKOUT_SECONDS", value: "45", got: func(c *Config) interface{} { return c.Login.LockoutBase }, want: 45 * time.Second},
+		{env: "CACHE_TTL", value: "2h30m", got: func(c *Config) interface{} { return c.Shortener.CacheTTL }, want: 150 * time.Minute},
+		{env: "RATE_LIMIT_API", value: "100/30s", got: func(c *Config) interface{} { return c.RateLimit.API }, want: RateLimit{Limit: 100, Window: 30 * time.Second}},
+		{env: "RATE_LIMIT_API", value: "off", got: func(c *Config) interface{} { return c.RateLimit.API }, want: RateLimit{}},
+		{env: "TRUSTED_PROXIES", value: " 10.0.0.1, 192.168.0.0/16 ,", got: func(c *Config) interface{} { return c.Server.TrustedProxies }, want: []string{"10.0.0.1", "192.168.0.0/16"}},
+		{env: "DB_AUTO_MIGRATE", value: "true", got: func(c *Config) interface{} { return c.Database.AutoMigrate }, want: true},
+		{env: "TRACING_SAMPLE_RATIO", value: "0.25", got: func(c *Config) interface{} { return c.Tracing.SampleRatio }, want: 0.25},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(tt.env, tt.value)
+			config, _, err := Load(nil)
+			if err != nil {
+				t.Fatalf("Load: %v", err)
+			}
+			if got := tt.got(config); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	tests := []struct {
+		env   string
+		value string
+		want  string
+	}{
+		{env: "SERVER_PORT", value: "http", want: `SERVER_PORT: invalid integer "http"`},
+		{env: "SERVER_PORT", value: "", want: `SERVER_PORT: invalid integer ""`},
+		{env: "CACHE_TTL", value: "soon", want: `CACHE_TTL: invalid duration "soon"`},
+		{env: "METRICS_ENABLED", value: "maybe", want: `METRICS_ENABLED: invalid boolean "maybe"`},
+		{env: "RATE_LIMIT_AUTH", value: "10 per minute", want: "RATE_LIMIT_AUTH: expected <requests>/<window> or off"},
+		{env: "RATE_LIMIT_AUTH", value: "0/1m", want: "RATE_LIMIT_AUTH: expected <requests>/<window> or off"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env+"="+tt.value, func(t *testing.T) {
+			clearEnv(t)
+			t.Setenv(tt.env, tt.value)
+			_, _, err := Load(nil)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadOIDCProviders(t *testing.T) {
+	file := "oidc_providers:\n  - name: Corp\n    issuer: https://id.example.com\n    client_id: app\n    scopes: [openid, email]\n"
+
+	t.Run("file", func(t *testing.T) {
+		clearEnv(t)
+		config, _, err := Load([]string{"-config", writeFile(t, "config.yaml", file)})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		want := []OIDCProvider{{Name: "corp", IssuerURL: "https://id.example.com", ClientID: "app", Scopes: []string{"openid", "email"}}}
+		if !reflect.DeepEqual(config.OIDCProviders, want) {
+			t.Errorf("providers = %+v, want %+v", config.OIDCProviders, want)
+		}
+	})
+
+	t.Run("env replaces file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("OIDC_PROVIDERS", "google")
+		t.Setenv("OIDC_GOOGLE_ISSUER", "https://accounts.google.com")
+		t.Setenv("OIDC_GOOGLE_CLIENT_ID", "client")
+		t.Setenv("OIDC_GOOGLE_AUTO_PROVISION", "true")
+		config, _, err := Load([]string{"-config", writeFile(t, "config.yaml", file)})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(config.OIDCProviders) != 1 || config.OIDCProviders[0].Name != "google" || !config.OIDCProviders[0].AutoProvision {
+			t.Errorf("providers = %+v, want only google with auto provision", config.OIDCProviders)
+		}
+	})
+
+	t.Run("empty env clears file", func(t *testing.T) {
+		clearEnv(t)
+		t.Setenv("OIDC_PROVIDERS", "")
+		config, _, err := Load([]string{"-config", writeFile(t, "config.yaml", file)})
+		if err != nil {
+			t.Fatalf("Load: %v", err)
+		}
+		if len(config.OIDCProviders) != 0 {
+			t.Errorf("providers = %+v, want none", config.OIDCProviders)
+		}
+	})
+}
@@ -0,0 +1,156 @@
+package conf
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// fileCheckInterval 是檢查設定檔是否變更的間隔
+const fileCheckInterval = 5 * time.Second
+
+// Watcher 保存目前的設定，在設定檔變更或收到 SIGHUP 時重新載入：
+// 標有 reload 的設定會一次替換並通知訂閱者，其餘設定的變更只記錄為需要重新啟動
+type Watcher struct {
+	args    []string
+	current atomic.Pointer[Config]
+
+	mu          sync.Mutex // 序列化重新載入與訂閱
+	subscribers []func(*Config)
+}
+
+// NewWatcher 以已載入的設定建立 Watcher，args 是載入時使用的命令列參數，重新載入時沿用
+func NewWatcher(config *Config, args []string) *Watcher {
+	w := &Watcher{args: args}
+	w.current.Store(config)
+	return w
+}
+
+// Current 返回目前的設定，返回的設定不可修改
+func (w *Watcher) Current() *Config {
+	return w.current.Load()
+}
+
+// Subscribe 以目前的設定呼叫 fn，之後每次可熱更新的設定改變時再以新的設定呼叫
+func (w *Watcher) Subscribe(fn func(*Config)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.subscribers = append(w.subscribers, fn)
+	fn(w.current.Load())
+}
+
+// Watch 監看 SIGHUP 與設定檔的內容直到 ctx 結束，兩者皆會觸發 Reload
+func (w *Watcher) Watch(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	ticker := time.NewTicker(fileCheckInterval)
+	defer ticker.Stop()
+	file := w.Current().File
+	lastSum := fileSum(file)
+
+	for {
+		select {
+		case <-hup:
+			slog.InfoContext(ctx, "Received SIGHUP, reloading configuration")
+			sum := fileSum(file)
+			if w.Reload() == nil {
+				lastSum = sum
+			}
+		case <-ticker.C:
+			lastSum = w.reloadIfChanged(ctx, file, lastSum)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// reloadIfChanged 在設定檔內容與 lastSum 不同時重新載入，返回之後比對用的雜湊；
+// 重新載入失敗時保留 lastSum，下次檢查會再試一次，修正後的檔案不會因雜湊已記錄而被略過
+func (w *Watcher) reloadIfChanged(ctx context.Context, file string, lastSum []byte) []byte {
+	if file == "" {
+		return lastSum
+	}
+	// 以內容比對，Kubernetes ConfigMap 以替換符號連結的方式更新時修改時間不一定改變
+	sum := fileSum(file)
+	if sum == nil || bytes.Equal(sum, lastSum) {
+		return lastSum
+	}
+	slog.InfoContext(ctx, "Configuration file changed, reloading", "file", file)
+	if err := w.Reload(); err != nil {
+		return lastSum
+	}
+	return sum
+}
+
+// Reload 重新載入設定並套用可熱更新的變更，設定無效時保留目前的設定並返回錯誤
+func (w *Watcher) Reload() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	loaded, _, err := Load(w.args)
+	if err != nil {
+		slog.Error("Configuration reload failed, keeping the current configuration", "error", err)
+		return err
+	}
+
+	current := w.current.Load()
+	next := *current
+	currentFields := collectFields(reflect.ValueOf(current).Elem(), "", false)
+	loadedFields := collectFields(reflect.ValueOf(loaded).Elem(), "", false)
+	nextFields := collectFields(reflect.ValueOf(&next).Elem(), "", false)
+
+	var changed, restart []string
+	for i, f := range currentFields {
+		if reflect.DeepEqual(f.value.Interface(), loadedFields[i].value.Interface()) {
+			continue
+		}
+		if !f.reload {
+			restart = append(restart, f.key)
+			continue
+		}
+		nextFields[i].value.Set(loadedFields[i].value)
+		changed = append(changed, f.key)
+	}
+	if !reflect.DeepEqual(current.OIDCProviders, loaded.OIDCProviders) {
+		restart = append(restart, oidcProvidersKey)
+	}
+
+	// 只記錄名稱，避免密鑰等設定值寫入日誌
+	if len(restart) > 0 {
+		slog.Warn("Configuration changes require a restart and were not applied", "keys", restart)
+	}
+	if len(changed) == 0 {
+		slog.Info("Configuration reloaded, no runtime settings changed")
+		return nil
+	}
+
+	w.current.Store(&next)
+	for _, fn := range w.subscribers {
+		fn(&next)
+	}
+	slog.Info("Configuration reloaded", "changed", changed)
+	return nil
+}
+
+// fileSum 返回檔案內容的雜湊，無法讀取時返回 nil
+func fileSum(path string) []byte {
+	if path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,94 @@
+package conf
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// newTestWatcher 以設定檔載入設定並建立 Watcher，返回設定檔路徑
+func newTestWatcher(t *testing.T, content string) (*Watcher, string) {
+	t.Helper()
+	clearEnv(t)
+	path := writeFile(t, "config.yaml", content)
+	args := []string{"-config", path}
+	config, _, err := Load(args)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return NewWatcher(config, args), path
+}
+
+func rewrite(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloadIfChangedRetriesAfterFailedReload(t *testing.T) {
+	ctx := context.Background()
+	w, path := newTestWatcher(t, "log:\n  level: info\n")
+	var notified []string
+	w.Subscribe(func(c *Config) { notified = append(notified, c.Log.Level) })
+	lastSum := fileSum(path)
+
+	rewrite(t, path, "log:\n  level: verbose\n")
+	badSum := fileSum(path)
+	lastSum = w.reloadIfChanged(ctx, path, lastSum)
+	if string(lastSum) == string(badSum) {
+		t.Fatal("a failed reload must not record the checksum of the bad file")
+	}
+	if w.Current().Log.Level != "info" {
+		t.Fatalf("log.level = %q after a failed reload, want info", w.Current().Log.Level)
+	}
+
+	// 檔案內容不變但導致失敗的原因已排除 (這裡以環境變數修正)，下次檢查必須再試
+	t.Setenv("LOG_LEVEL", "debug")
+	lastSum = w.reloadIfChanged(ctx, path, lastSum)
+	if string(lastSum) != string(badSum) {
+		t.Fatal("a successful reload must record the checksum of the file")
+	}
+	if w.Current().Log.Level != "debug" {
+		t.Fatalf("log.level = %q, want debug after the retry", w.Current().Log.Level)
+	}
+
+	// 已成功載入的內容不會重複載入
+	t.Setenv("LOG_LEVEL", "warn")
+	if sum := w.reloadIfChanged(ctx, path, lastSum); string(sum) != string(lastSum) || w.Current().Log.Level != "debug" {
+		t.Fatalf("an unchanged file must not reload, log.level = %q", w.Current().Log.Level)
+	}
+
+	if len(notified) != 2 || notified[0] != "info" || notified[1] != "debug" {
+		t.Errorf("subscriber saw %v, want [info debug]", notified)
+	}
+}
+
+func TestReloadAppliesOnlyRuntimeSettings(t *testing.T) {
+	w, path := newTestWatcher(t, "server:\n  port: 9000\nrate_limit:\n  api: 100/1m\n")
+
+	rewrite(t, path, "server:\n  port: 9100\nrate_limit:\n  api: 50/1m\n")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	current := w.Current()
+	if current.RateLimit.API.Limit != 50 {
+		t.Errorf("rate_limit.api = %+v, want the reloaded 50/1m", current.RateLimit.API)
+	}
+	if current.Server.Port != 9000 {
+		t.Errorf("server.port = %d, want 9000 until restart", current.Server.Port)
+	}
+}
+
+func TestReloadKeepsConfigurationOnError(t *testing.T) {
+	w, path := newTestWatcher(t, "rate_limit:\n  api: 100/1m\n")
+	before := w.Current()
+
+	rewrite(t, path, "rate_limit:\n  api: fast\n")
+	if err := w.Reload(); err == nil {
+		t.Fatal("Reload accepted an invalid file")
+	}
+	if w.Current() != before {
+		t.Error("a failed reload must keep the current configuration")
+	}
+}
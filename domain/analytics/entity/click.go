@@ -0,0 +1,49 @@
+package entity
+
+import (
+	"time"
+	"unicode/utf8"
+)
+
+// 欄位長度上限，與 link_clicks 資料表一致
+const (
+	maxReferrerLength  = 1024
+	maxUserAgentLength = 512
+)
+
+// Click 是一次重定向的點擊記錄
+type Click struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StreamID  string    `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // 來源訊息的 ID，重複處理同一訊息時不會重複寫入
+	LinkID    uint      `json:"link_id" gorm:"not null;index:idx_link_clicks_link_id_clicked_at,priority:1"`
+	ClickedAt time.Time `json:"clicked_at" gorm:"not null;index:idx_link_clicks_link_id_clicked_at,priority:2"`
+	Referrer  string    `json:"referrer,omitempty" gorm:"type:varchar(1024);not null;default:''"`
+	UserAgent string    `json:"user_agent,omitempty" gorm:"type:varchar(512);not null;default:''"`
+}
+
+// TableName 指定點擊記錄的資料表名稱
+func (Click) TableName() string {
+	return "link_clicks"
+}
+
+// NewClick 建立點擊記錄，過長的來源與 User-Agent 會被截斷
+func NewClick(linkID uint, clickedAt time.Time, referrer, userAgent string) *Click {
+	return &Click{
+		LinkID:    linkID,
+		ClickedAt: clickedAt.UTC(),
+		Referrer:  truncate(referrer, maxReferrerLength),
+		UserAgent: truncate(userAgent, maxUserAgentLength),
+	}
+}
+
+// truncate 將字串截斷至 max 個位元組以內，不切斷 UTF-8 字元
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	value = value[:max]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
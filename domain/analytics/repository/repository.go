@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/analytics/entity"
+)
+
+// ClickRepository 定義了點擊記錄的儲存庫介面
+type ClickRepository interface {
+	// InsertBatch 寫入一批點擊記錄並累加對應連結的訪問次數；
+	// StreamID 已存在的記錄會被略過，也不會重複累加
+	InsertBatch(ctx context.Context, clicks []*entity.Click) error
+
+	// FindByUserID 返回使用者個人連結的點擊記錄 (依時間排序)
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.Click, error)
+
+	// DeleteExpired 依連結建立者所屬方案的 analytics_retention_days 刪除過期的點擊記錄
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+// StreamMessage 是從點擊串流讀取的訊息
+type StreamMessage struct {
+	ID    string
+	Click *entity.Click // 無法解析的訊息為 nil，確認後丟棄
+}
+
+// StreamStats 是點擊串流與 consumer group 的狀態
+type StreamStats struct {
+	Length    int64 `json:"length"`    // 串流中的訊息數
+	Pending   int64 `json:"pending"`   // 已讀取但尚未確認的訊息數
+	Lag       int64 `json:"lag"`       // 尚未被 consumer group 讀取的訊息數
+	Consumers int64 `json:"consumers"` // consumer group 中的消費者數
+}
+
+// ClickStream 定義了點擊佇列的介面 (Redis Streams consumer group)
+type ClickStream interface {
+	// Add 將點擊加入串流
+	Add(ctx context.Context, click *entity.Click) error
+
+	// EnsureGroup 建立 consumer group (已存在時不做任何事)
+	EnsureGroup(ctx context.Context) error
+
+	// Read 以指定的消費者名稱讀取新訊息，最多等待 block
+	Read(ctx context.Context, consumer string, count int64, block time.Duration) ([]StreamMessage, error)
+
+	// Reclaim 將閒置超過 minIdle 的未確認訊息 (例如消費者崩潰) 轉給指定的消費者
+	Reclaim(ctx context.Context, consumer string, minIdle time.Duration, count int64) ([]StreamMessage, error)
+
+	// Ack 確認訊息已處理完成
+	Ack(ctx context.Context, ids ...string) error
+
+	// Stats 返回串流長度、未確認數與延遲
+	Stats(ctx context.Context) (*StreamStats, error)
+}
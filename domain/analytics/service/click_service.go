@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_short/domain/analytics/entity"
+	"go_short/domain/analytics/repository"
+)
+
+// 自訂領域錯誤
+var (
+	ErrStreamError   = errors.New("click stream error")
+	ErrDatabaseError = errors.New("database error")
+)
+
+// ClickService 負責點擊的收集與批次寫入
+// 重定向只將點擊加入串流，由消費者批次寫入資料庫；串流無法使用時退回直接寫入
+type ClickService struct {
+	clickRepo repository.ClickRepository
+	stream    repository.ClickStream
+}
+
+// NewClickService 創建點擊服務
+func NewClickService(clickRepo repository.ClickRepository, stream repository.ClickStream) *ClickService {
+	return &ClickService{
+		clickRepo: clickRepo,
+		stream:    stream,
+	}
+}
+
+// Record 記錄一次點擊；串流寫入失敗時直接寫入資料庫，避免遺失點擊
+func (s *ClickService) Record(ctx context.Context, click *entity.Click) error {
+	err := s.stream.Add(ctx, click)
+	if err == nil {
+		return nil
+	}
+	slog.ErrorContext(ctx, "Failed to add click for link to stream, writing directly", "link_id", click.LinkID, "error", err)
+
+	click.StreamID = "direct-" + newDirectID()
+	if err := s.clickRepo.InsertBatch(ctx, []*entity.Click{click}); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// EnsureGroup 建立消費者使用的 consumer group
+func (s *ClickService) EnsureGroup(ctx context.Context) error {
+	if err := s.stream.EnsureGroup(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to create click consumer group", "error", err)
+		return ErrStreamError
+	}
+	return nil
+}
+
+// ProcessNew 讀取新的點擊 (最多等待 block) 並批次寫入，返回處理的訊息數
+func (s *ClickService) ProcessNew(ctx context.Context, consumer string, batchSize int64, block time.Duration) (int, error) {
+	messages, err := s.stream.Read(ctx, consumer, batchSize, block)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read click stream", "error", err)
+		return 0, ErrStreamError
+	}
+	return s.process(ctx, messages)
+}
+
+// ReclaimPending 接手閒置超過 minIdle 的未確認點擊 (例如其他消費者在寫入前崩潰) 並批次寫入
+func (s *ClickService) ReclaimPending(ctx context.Context, consumer string, minIdle time.Duration, batchSize int64) (int, error) {
+	messages, err := s.stream.Reclaim(ctx, consumer, minIdle, batchSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to reclaim pending clicks", "error", err)
+		return 0, ErrStreamError
+	}
+	return s.process(ctx, messages)
+}
+
+// Stats 返回點擊串流的長度、未確認數與延遲
+func (s *ClickService) Stats(ctx context.Context) (*repository.StreamStats, error) {
+	stats, err := s.stream.Stats(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to read click stream stats", "error", err)
+		return nil, ErrStreamError
+	}
+	return stats, nil
+}
+
+// ListUserClicks 返回使用者個人連結的點擊記錄
+func (s *ClickService) ListUserClicks(ctx context.Context, userID uint) ([]*entity.Click, error) {
+	clicks, err := s.clickRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return clicks, nil
+}
+
+// PruneExpired 依方案的分析資料保留天數刪除過期的點擊記錄
+func (s *ClickService) PruneExpired(ctx context.Context) (int64, error) {
+	deleted, err := s.clickRepo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, ErrDatabaseError
+	}
+	return deleted, nil
+}
+
+// process 將訊息寫入資料庫後確認；寫入失敗時不確認，訊息留在 pending 中稍後由 ReclaimPending 重試
+// 無法解析的訊息直接確認丟棄，避免一再重試
+func (s *ClickService) process(ctx context.Context, messages []repository.StreamMessage) (int, error) {
+	if len(messages) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	clicks := make([]*entity.Click, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		if message.Click == nil {
+			slog.WarnContext(ctx, "Dropping malformed click message", "message_id", message.ID)
+			continue
+		}
+		message.Click.StreamID = message.ID
+		clicks = append(clicks, message.Click)
+	}
+
+	if err := s.clickRepo.InsertBatch(ctx, clicks); err != nil {
+		slog.ErrorContext(ctx, "Failed to insert clicks", "count", len(clicks), "error", err)
+		return 0, ErrDatabaseError
+	}
+	if err := s.stream.Ack(ctx, ids...); err != nil {
+		// 已寫入的訊息之後會被重新處理，StreamID 唯一索引保證不會重複寫入
+		slog.ErrorContext(ctx, "Failed to acknowledge click messages", "count", len(ids), "error", err)
+		return len(messages), ErrStreamError
+	}
+	return len(messages), nil
+}
+
+// newDirectID 產生直接寫入時使用的唯一 StreamID
+func newDirectID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
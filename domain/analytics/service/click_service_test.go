@@ -0,0 +1,235 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"go_short/domain/analytics/entity"
+	"go_short/domain/analytics/repository"
+)
+
+// fakeClickRepo 與資料庫實作相同，以 StreamID 去除重複的點擊
+type fakeClickRepo struct {
+	repository.ClickRepository
+	clicks    map[string]*entity.Click
+	insertErr error
+	inserts   int
+}
+
+func (r *fakeClickRepo) InsertBatch(ctx context.Context, clicks []*entity.Click) error {
+	r.inserts++
+	if r.insertErr != nil {
+		return r.insertErr
+	}
+	for _, click := range clicks {
+		if _, ok := r.clicks[click.StreamID]; !ok {
+			r.clicks[click.StreamID] = click
+		}
+	}
+	return nil
+}
+
+// fakeClickStream 模擬 consumer group：讀取後的訊息在確認前都留在 pending 中
+type fakeClickStream struct {
+	repository.ClickStream
+	unread   []repository.StreamMessage
+	pending  map[string]repository.StreamMessage
+	acked    []string
+	addErr   error
+	readErr  error
+	ackErr   error
+	reclaims []time.Duration
+}
+
+func newFakeClickStream(messages ...repository.StreamMessage) *fakeClickStream {
+	return &fakeClickStream{unread: messages, pending: make(map[string]repository.StreamMessage)}
+}
+
+func (s *fakeClickStream) Add(ctx context.Context, click *entity.Click) error {
+	return s.addErr
+}
+
+func (s *fakeClickStream) Read(ctx context.Context, consumer string, count int64, block time.Duration) ([]repository.StreamMessage, error) {
+	if s.readErr != nil {
+		return nil, s.readErr
+	}
+	n := int(count)
+	if n > len(s.unread) {
+		n = len(s.unread)
+	}
+	messages := s.unread[:n]
+	s.unread = s.unread[n:]
+	for _, message := range messages {
+		s.pending[message.ID] = message
+	}
+	return messages, nil
+}
+
+func (s *fakeClickStream) Reclaim(ctx context.Context, consumer string, minIdle time.Duration, count int64) ([]repository.StreamMessage, error) {
+	s.reclaims = append(s.reclaims, minIdle)
+	var messages []repository.StreamMessage
+	for _, message := range s.pending {
+		// 重新投遞時重新解析，與 Redis 實作相同不沿用上次的物件
+		if message.Click != nil {
+			click := *message.Click
+			click.StreamID = ""
+			message.Click = &click
+		}
+		messages = append(messages, message)
+	}
+	return messages, nil
+}
+
+func (s *fakeClickStream) Ack(ctx context.Context, ids ...string) error {
+	if s.ackErr != nil {
+		return s.ackErr
+	}
+	for _, id := range ids {
+		delete(s.pending, id)
+		s.acked = append(s.acked, id)
+	}
+	return nil
+}
+
+func clickMessage(id string, linkID uint) repository.StreamMessage {
+	return repository.StreamMessage{ID: id, Click: &entity.Click{LinkID: linkID}}
+}
+
+func newTestClickService(stream *fakeClickStream) (*ClickService, *fakeClickRepo) {
+	repo := &fakeClickRepo{clicks: make(map[string]*entity.Click)}
+	return NewClickService(repo, stream), repo
+}
+
+func TestProcessNewWritesThenAcks(t *testing.T) {
+	stream := newFakeClickStream(clickMessage("1-0", 7), clickMessage("2-0", 8))
+	s, repo := newTestClickService(stream)
+
+	n, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second)
+	if err != nil || n != 2 {
+		t.Fatalf("ProcessNew = %d, %v; want 2", n, err)
+	}
+	if len(repo.clicks) != 2 || repo.clicks["1-0"].LinkID != 7 || repo.clicks["2-0"].LinkID != 8 {
+		t.Errorf("stored clicks %v, want both keyed by stream ID", repo.clicks)
+	}
+	if len(stream.pending) != 0 || strings.Join(stream.acked, ",") != "1-0,2-0" {
+		t.Errorf("pending %v, acked %v; want both acknowledged", stream.pending, stream.acked)
+	}
+}
+
+func TestProcessLeavesMessagesPendingWhenInsertFails(t *testing.T) {
+	stream := newFakeClickStream(clickMessage("1-0", 7), clickMessage("2-0", 7))
+	s, repo := newTestClickService(stream)
+	repo.insertErr = errors.New("connection reset")
+
+	n, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second)
+	if !errors.Is(err, ErrDatabaseError) || n != 0 {
+		t.Fatalf("ProcessNew = %d, %v; want ErrDatabaseError", n, err)
+	}
+	if len(stream.acked) != 0 || len(stream.pending) != 2 {
+		t.Fatalf("acked %v, pending %d; a failed insert must not acknowledge", stream.acked, len(stream.pending))
+	}
+
+	// 資料庫恢復後由 ReclaimPending 接手
+	repo.insertErr = nil
+	n, err = s.ReclaimPending(context.Background(), "worker-2", time.Minute, 10)
+	if err != nil || n != 2 {
+		t.Fatalf("ReclaimPending = %d, %v; want 2", n, err)
+	}
+	if len(repo.clicks) != 2 || len(stream.pending) != 0 {
+		t.Errorf("stored %d clicks, %d still pending; want everything written and acknowledged", len(repo.clicks), len(stream.pending))
+	}
+	if len(stream.reclaims) != 1 || stream.reclaims[0] != time.Minute {
+		t.Errorf("reclaimed with %v, want the minimum idle time passed through", stream.reclaims)
+	}
+}
+
+func TestProcessDropsMalformedMessages(t *testing.T) {
+	stream := newFakeClickStream(clickMessage("1-0", 7), repository.StreamMessage{ID: "2-0"}, clickMessage("3-0", 9))
+	s, repo := newTestClickService(stream)
+
+	n, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second)
+	if err != nil || n != 3 {
+		t.Fatalf("ProcessNew = %d, %v; want 3 messages handled", n, err)
+	}
+	if len(repo.clicks) != 2 {
+		t.Errorf("stored %d clicks, want the 2 valid ones", len(repo.clicks))
+	}
+	if len(stream.pending) != 0 {
+		t.Errorf("pending %v; malformed messages must be acknowledged so they are not retried", stream.pending)
+	}
+}
+
+func TestProcessAckFailureIsRetriedWithoutDuplicates(t *testing.T) {
+	stream := newFakeClickStream(clickMessage("1-0", 7))
+	s, repo := newTestClickService(stream)
+	stream.ackErr = errors.New("redis timeout")
+
+	n, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second)
+	if !errors.Is(err, ErrStreamError) || n != 1 {
+		t.Fatalf("ProcessNew = %d, %v; want 1 with ErrStreamError", n, err)
+	}
+	if len(stream.pending) != 1 {
+		t.Fatal("an unacknowledged message must stay pending")
+	}
+
+	stream.ackErr = nil
+	if _, err := s.ReclaimPending(context.Background(), "worker-1", time.Minute, 10); err != nil {
+		t.Fatalf("ReclaimPending: %v", err)
+	}
+	if repo.inserts != 2 || len(repo.clicks) != 1 || len(stream.pending) != 0 {
+		t.Errorf("inserts %d, stored %d, pending %d; want the retry written once and acknowledged", repo.inserts, len(repo.clicks), len(stream.pending))
+	}
+}
+
+func TestProcessNewReadError(t *testing.T) {
+	stream := newFakeClickStream()
+	stream.readErr = errors.New("NOGROUP")
+	s, repo := newTestClickService(stream)
+
+	if _, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second); !errors.Is(err, ErrStreamError) {
+		t.Fatalf("err = %v, want ErrStreamError", err)
+	}
+	if repo.inserts != 0 {
+		t.Error("nothing must be written when the stream cannot be read")
+	}
+}
+
+func TestProcessNewWithoutMessages(t *testing.T) {
+	s, repo := newTestClickService(newFakeClickStream())
+	if n, err := s.ProcessNew(context.Background(), "worker-1", 10, time.Second); err != nil || n != 0 {
+		t.Fatalf("ProcessNew = %d, %v", n, err)
+	}
+	if repo.inserts != 0 {
+		t.Error("an empty read must not touch the database")
+	}
+}
+
+func TestRecordFallsBackToDirectWrite(t *testing.T) {
+	stream := newFakeClickStream()
+	s, repo := newTestClickService(stream)
+
+	if err := s.Record(context.Background(), &entity.Click{LinkID: 1}); err != nil || repo.inserts != 0 {
+		t.Fatalf("Record = %v with %d inserts; want the click only added to the stream", err, repo.inserts)
+	}
+
+	stream.addErr = errors.New("redis down")
+	if err := s.Record(context.Background(), &entity.Click{LinkID: 1}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if len(repo.clicks) != 1 {
+		t.Fatalf("stored %d clicks, want the direct write", len(repo.clicks))
+	}
+	for id := range repo.clicks {
+		if !strings.HasPrefix(id, "direct-") {
+			t.Errorf("stream ID %q, want a direct- ID that cannot collide with stream IDs", id)
+		}
+	}
+
+	repo.insertErr = errors.New("database down")
+	if err := s.Record(context.Background(), &entity.Click{LinkID: 1}); !errors.Is(err, ErrDatabaseError) {
+		t.Errorf("err = %v, want ErrDatabaseError when both paths fail", err)
+	}
+}
@@ -0,0 +1,48 @@
+package entity
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// 稽核記錄的目標類型
+const (
+	TargetUser     = "user"
+	TargetUsername = "username" // 登入失敗且帳號不存在時，以嘗試的使用者名稱作為目標
+	TargetLink     = "link"
+	TargetAPIKey   = "api_key"
+	TargetWebhook  = "webhook"
+	TargetProvider = "identity_provider" // 外部登入在確認身分前失敗時，以身分提供者名稱作為目標
+)
+
+// AuditEntry 是一筆只能新增的稽核記錄
+type AuditEntry struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt  time.Time `json:"created_at" gorm:"not null;index"`
+	ActorID    *uint     `json:"actor_id,omitempty" gorm:"index"`                                                     // 執行動作的使用者，匿名請求為 nil
+	Action     string    `json:"action" gorm:"type:varchar(64);not null;index"`                                       // 例如 link.update、user.login
+	TargetType string    `json:"target_type" gorm:"type:varchar(32);not null;index:idx_audit_logs_target,priority:1"` // 目標類型，見 Target* 常數
+	TargetID   string    `json:"target_id" gorm:"type:varchar(255);not null;index:idx_audit_logs_target,priority:2"`  // 目標的 ID
+	Changes    *string   `json:"-" gorm:"type:text"`                                                                  // 欄位差異 (JSON)，格式為 {"欄位": {"from": 舊值, "to": 新值}}
+	IP         *string   `json:"ip,omitempty" gorm:"column:ip;type:varchar(64)"`                                      // 請求來源 IP
+	UserAgent  *string   `json:"user_agent,omitempty" gorm:"type:varchar(255)"`                                       // 請求的 User-Agent
+	RequestID  *string   `json:"request_id,omitempty" gorm:"type:varchar(64);index"`                                  // 對應回應標頭 X-Request-ID
+}
+
+// TableName 指定資料表名稱
+func (AuditEntry) TableName() string {
+	return "audit_logs"
+}
+
+// MarshalJSON 將 Changes 以 JSON 物件而非字串輸出
+func (e AuditEntry) MarshalJSON() ([]byte, error) {
+	type alias AuditEntry
+	var changes json.RawMessage
+	if e.Changes != nil {
+		changes = json.RawMessage(*e.Changes)
+	}
+	return json.Marshal(struct {
+		alias
+		Changes json.RawMessage `json:"changes,omitempty"`
+	}{alias(e), changes})
+}
@@ -0,0 +1,28 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/audit/entity"
+)
+
+// AuditFilter 是查詢稽核記錄的條件，零值欄位表示不限制
+type AuditFilter struct {
+	ActorID    *uint
+	Action     string // 完全相符，或以 "." 結尾時比對前綴 (例如 "link.")
+	TargetType string
+	TargetID   string
+	RequestID  string
+	Since      *time.Time
+	Until      *time.Time
+}
+
+// AuditRepository 定義了稽核記錄的儲存庫介面
+type AuditRepository interface {
+	// Append 新增一筆稽核記錄
+	Append(ctx context.Context, entry *entity.AuditEntry) error
+
+	// Search 依條件由新到舊查詢稽核記錄並返回總筆數
+	Search(ctx context.Context, filter AuditFilter, offset, limit int) ([]*entity.AuditEntry, int64, error)
+}
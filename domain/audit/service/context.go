@@ -0,0 +1,31 @@
+package service
+
+import "context"
+
+// RequestMetadata 是由 API 層放入 context 的請求資訊，寫入稽核記錄時使用
+type RequestMetadata struct {
+	ActorID   *uint
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type metadataKey struct{}
+
+// WithRequestMetadata 將請求資訊放入 context
+func WithRequestMetadata(ctx context.Context, meta RequestMetadata) context.Context {
+	return context.WithValue(ctx, metadataKey{}, meta)
+}
+
+// WithActor 在 context 的請求資訊中設定已認證的使用者
+func WithActor(ctx context.Context, actorID uint) context.Context {
+	meta := MetadataFrom(ctx)
+	meta.ActorID = &actorID
+	return WithRequestMetadata(ctx, meta)
+}
+
+// MetadataFrom 取出 context 中的請求資訊，不存在時返回零值
+func MetadataFrom(ctx context.Context) RequestMetadata {
+	meta, _ := ctx.Value(metadataKey{}).(RequestMetadata)
+	return meta
+}
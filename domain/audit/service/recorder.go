@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"go_short/domain/audit/entity"
+	"go_short/domain/audit/repository"
+)
+
+// ignoredFields 是計算差異時忽略的欄位 (每次更新都會變動，沒有稽核價值)
+var ignoredFields = map[string]bool{
+	"updated_at": true,
+}
+
+// Event 描述一個要稽核的動作
+// Before 與 After 為變更前後的實體，建立時 Before 為 nil，刪除時 After 為 nil
+type Event struct {
+	ActorID    *uint // 為 nil 時使用 context 中已認證的使用者
+	Action     string
+	TargetType string
+	TargetID   string
+	Before     interface{}
+	After      interface{}
+}
+
+// Recorder 負責將動作寫入稽核記錄
+// 寫入失敗只記錄日誌，不影響原本的操作；nil 的 Recorder 不做任何事
+type Recorder struct {
+	repo repository.AuditRepository
+}
+
+// NewRecorder 創建稽核記錄器
+func NewRecorder(repo repository.AuditRepository) *Recorder {
+	return &Recorder{repo: repo}
+}
+
+// Record 計算變更差異並寫入稽核記錄
+func (r *Recorder) Record(ctx context.Context, event Event) {
+	if r == nil || r.repo == nil {
+		return
+	}
+
+	meta := MetadataFrom(ctx)
+	entry := &entity.AuditEntry{
+		CreatedAt:  time.Now(),
+		ActorID:    event.ActorID,
+		Action:     event.Action,
+		TargetType: event.TargetType,
+		TargetID:   event.TargetID,
+		IP:         optional(meta.IP),
+		UserAgent:  optional(truncate(meta.UserAgent, 255)),
+		RequestID:  optional(meta.RequestID),
+	}
+	if entry.ActorID == nil {
+		entry.ActorID = meta.ActorID
+	}
+
+	changes, err := Diff(event.Before, event.After)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error computing audit diff", "action", event.Action, "target_type", event.TargetType, "target_id", event.TargetID, "error", err)
+	} else if len(changes) > 0 {
+		encoded, err := json.Marshal(changes)
+		if err == nil {
+			value := string(encoded)
+			entry.Changes = &value
+		}
+	}
+
+	if err := r.repo.Append(ctx, entry); err != nil {
+		slog.ErrorContext(ctx, "Error writing audit entry", "action", event.Action, "target_type", event.TargetType, "target_id", event.TargetID, "error", err)
+	}
+}
+
+// Change 是單一欄位的變更
+type Change struct {
+	From interface{} `json:"from,omitempty"`
+	To   interface{} `json:"to,omitempty"`
+}
+
+// Diff 以 JSON 表示比較兩個實體，返回有變動的欄位
+// 實體中以 json:"-" 隱藏的欄位 (例如密碼雜湊) 不會出現在差異中
+func Diff(before, after interface{}) (map[string]Change, error) {
+	beforeFields, err := toFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := toFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]Change)
+	for key, from := range beforeFields {
+		if ignoredFields[key] {
+			continue
+		}
+		to, ok := afterFields[key]
+		if !ok || !reflect.DeepEqual(from, to) {
+			changes[key] = Change{From: from, To: to}
+		}
+	}
+	for key, to := range afterFields {
+		if ignoredFields[key] {
+			continue
+		}
+		if _, ok := beforeFields[key]; !ok {
+			changes[key] = Change{To: to}
+		}
+	}
+	return changes, nil
+}
+
+func toFields(value interface{}) (map[string]interface{}, error) {
+	if value == nil || (reflect.ValueOf(value).Kind() == reflect.Ptr && reflect.ValueOf(value).IsNil()) {
+		return nil, nil
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(encoded, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func optional(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
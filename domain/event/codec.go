@@ -0,0 +1,44 @@
+package event
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrUnknownEventType 表示無法還原的事件類型
+var ErrUnknownEventType = errors.New("event: unknown event type")
+
+// Aggregate 類型，用於 outbox 記錄所屬的實體
+const (
+	AggregateLink = "link"
+	AggregateUser = "user"
+)
+
+// Aggregated 由屬於特定實體的事件實作，outbox 據此記錄實體類型與 ID
+type Aggregated interface {
+	Aggregate() (aggregateType string, aggregateID string)
+}
+
+// decoders 依事件類型建立空的事件結構，供 Decode 還原
+var decoders = map[string]func() Event{
+	TypeLinkCreated:     func() Event { return &LinkEvent{} },
+	TypeLinkUpdated:     func() Event { return &LinkEvent{} },
+	TypeLinkDeleted:     func() Event { return &LinkEvent{} },
+	TypeLinkClicked:     func() Event { return &LinkEvent{} },
+	TypeLinkExpired:     func() Event { return &LinkEvent{} },
+	TypeUserActivated:   func() Event { return &UserEvent{} },
+	TypeUserDeactivated: func() Event { return &UserEvent{} },
+}
+
+// Decode 將 JSON 序列化的事件還原為對應的事件結構
+func Decode(eventType string, payload []byte) (Event, error) {
+	newEvent, ok := decoders[eventType]
+	if !ok {
+		return nil, ErrUnknownEventType
+	}
+	e := newEvent()
+	if err := json.Unmarshal(payload, e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
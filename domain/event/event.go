@@ -0,0 +1,115 @@
+package event
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event 是領域中已發生的事實，例如連結被建立或被點擊
+type Event interface {
+	// EventID 是事件的唯一識別碼，訂閱者可據此去除重複
+	EventID() string
+	// EventType 是事件類型，例如 link.created
+	EventType() string
+	// OccurredAt 是事件發生的時間
+	OccurredAt() time.Time
+}
+
+// Base 實作 Event 的共用欄位，嵌入於各事件結構中
+type Base struct {
+	ID   string    `json:"id"`
+	Type string    `json:"type"`
+	At   time.Time `json:"occurred_at"`
+}
+
+// NewBase 以新的隨機 ID 與目前時間建立事件的共用欄位
+func NewBase(eventType string) Base {
+	return Base{ID: newEventID(), Type: eventType, At: time.Now().UTC()}
+}
+
+// EventID 返回事件 ID
+func (b Base) EventID() string { return b.ID }
+
+// EventType 返回事件類型
+func (b Base) EventType() string { return b.Type }
+
+// OccurredAt 返回事件發生時間
+func (b Base) OccurredAt() time.Time { return b.At }
+
+// Publisher 發布領域事件；發布失敗不影響已完成的狀態變更
+type Publisher interface {
+	Publish(ctx context.Context, events ...Event)
+}
+
+// Handler 處理訂閱的事件；返回錯誤時由 Relay 稍後重新發送
+type Handler func(ctx context.Context, e Event) error
+
+// Bus 是同步的行程內事件匯流排，依事件類型將事件交給訂閱者
+// 訂閱者應盡快返回，耗時的工作 (例如對外發送) 須自行轉為非同步
+type Bus struct {
+	mu       sync.RWMutex
+	handlers map[string][]Handler
+}
+
+// NewBus 創建事件匯流排
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[string][]Handler)}
+}
+
+// Subscribe 訂閱指定類型的事件，eventType 為 "*" 時訂閱所有事件
+func (b *Bus) Subscribe(eventType string, handler Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[eventType] = append(b.handlers[eventType], handler)
+}
+
+// Publish 依序將事件交給訂閱者；訂閱者的錯誤與 panic 只記錄日誌，不影響其他訂閱者與發布者
+// nil 的 Bus 不做任何事
+func (b *Bus) Publish(ctx context.Context, events ...Event) {
+	for _, e := range events {
+		if err := b.Dispatch(ctx, e); err != nil {
+			slog.ErrorContext(ctx, "Event handler failed", "event_type", e.EventType(), "event_id", e.EventID(), "error", err)
+		}
+	}
+}
+
+// Dispatch 將單一事件交給所有訂閱者，返回第一個失敗訂閱者的錯誤
+// 即使有訂閱者失敗，其餘訂閱者仍會收到事件
+func (b *Bus) Dispatch(ctx context.Context, e Event) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.RLock()
+	handlers := append(append([]Handler(nil), b.handlers[e.EventType()]...), b.handlers["*"]...)
+	b.mu.RUnlock()
+
+	var firstErr error
+	for _, handler := range handlers {
+		if err := dispatch(ctx, handler, e); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+func dispatch(ctx context.Context, handler Handler, e Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("handler panicked: %v", r)
+		}
+	}()
+	return handler(ctx, e)
+}
+
+func newEventID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return time.Now().UTC().Format("20060102150405.000000000")
+	}
+	return hex.EncodeToString(buf)
+}
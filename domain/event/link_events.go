@@ -0,0 +1,62 @@
+package event
+
+import (
+	"strconv"
+	"time"
+)
+
+// 連結相關的事件類型
+const (
+	TypeLinkCreated = "link.created"
+	TypeLinkUpdated = "link.updated"
+	TypeLinkDeleted = "link.deleted"
+	TypeLinkClicked = "link.clicked"
+	TypeLinkExpired = "link.expired"
+)
+
+// LinkTypes 是所有連結事件類型
+var LinkTypes = []string{TypeLinkCreated, TypeLinkUpdated, TypeLinkDeleted, TypeLinkClicked, TypeLinkExpired}
+
+// LinkLifecycleTypes 是經由 outbox 與狀態變更一起提交的連結事件類型
+// 點擊事件量大且不改變連結狀態，不寫入 outbox
+var LinkLifecycleTypes = []string{TypeLinkCreated, TypeLinkUpdated, TypeLinkDeleted, TypeLinkExpired}
+
+// Link 是事件中攜帶的連結資訊
+type Link struct {
+	ID          uint       `json:"id"`
+	ShortURL    string     `json:"short_url"`
+	OriginalURL string     `json:"original_url"`
+	DomainID    *uint      `json:"domain_id,omitempty"`
+	UserID      *uint      `json:"user_id,omitempty"`
+	WorkspaceID *uint      `json:"workspace_id,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`
+}
+
+// Visit 是點擊事件中攜帶的訪客資訊
+type Visit struct {
+	Referrer  string `json:"referrer,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
+// LinkEvent 是連結生命週期與點擊事件
+type LinkEvent struct {
+	Base
+	Link  Link   `json:"link"`
+	Visit *Visit `json:"visit,omitempty"` // 只有點擊事件才有值
+}
+
+// NewLinkEvent 建立指定類型的連結事件
+func NewLinkEvent(eventType string, link Link) *LinkEvent {
+	return &LinkEvent{Base: NewBase(eventType), Link: link}
+}
+
+// NewClickEvent 建立連結點擊事件
+func NewClickEvent(link Link, visit Visit) *LinkEvent {
+	return &LinkEvent{Base: NewBase(TypeLinkClicked), Link: link, Visit: &visit}
+}
+
+// Aggregate 返回事件所屬的連結
+func (e *LinkEvent) Aggregate() (string, string) {
+	return AggregateLink, strconv.FormatUint(uint64(e.Link.ID), 10)
+}
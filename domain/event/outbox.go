@@ -0,0 +1,54 @@
+package event
+
+import (
+	"context"
+	"time"
+)
+
+// Outbox 將事件與觸發它的狀態變更寫入同一個資料庫交易，交易提交後才由 Relay 發布
+// 因此已提交的狀態變更一定有對應的事件，行程崩潰後也不會遺失
+type Outbox interface {
+	// Transaction 在資料庫交易中執行 fn；fn 內以同一個 ctx 呼叫的儲存庫操作與 Append 一起提交或回滾
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	// Append 記錄事件；ctx 不在交易中時單獨寫入
+	Append(ctx context.Context, events ...Event) error
+}
+
+// Record 是 outbox 中的一筆事件
+type Record struct {
+	ID            uint `gorm:"primaryKey"`
+	CreatedAt     time.Time
+	EventID       string     `gorm:"type:varchar(64);not null;uniqueIndex"`
+	EventType     string     `gorm:"type:varchar(64);not null"`
+	AggregateType string     `gorm:"type:varchar(32);not null"` // 事件所屬的實體類型，例如 link、user
+	AggregateID   string     `gorm:"type:varchar(64);not null"` // 事件所屬的實體 ID
+	Payload       string     `gorm:"type:jsonb;not null"`       // 事件的 JSON 內容
+	OccurredAt    time.Time  `gorm:"not null"`                  // 事件發生時間
+	PublishedAt   *time.Time // 所有目的地都收到後的時間，nil 表示尚未發布
+	Attempts      int        `gorm:"not null;default:0"` // 發布失敗的次數
+	NextAttemptAt time.Time  `gorm:"not null"`           // 下次嘗試發布的時間
+	LastError     *string    `gorm:"type:varchar(1024)"` // 最後一次失敗的原因
+}
+
+// TableName 指定 outbox 的資料表名稱
+func (Record) TableName() string {
+	return "outbox_events"
+}
+
+// OutboxRepository 是 Relay 讀取與更新 outbox 記錄的儲存庫
+type OutboxRepository interface {
+	// ClaimDue 取得到期且尚未發布的記錄 (依寫入順序) 並將下次嘗試時間延後 lease，多個實例不會取得同一筆
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Record, error)
+	Update(ctx context.Context, record *Record) error
+	// DeletePublishedBefore 刪除在指定時間之前已發布的記錄
+	DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Sink 是行程外的事件目的地，例如 Redis Streams
+// 同一事件可能因重試而送達多次，接收端應以事件 ID 去除重複
+type Sink interface {
+	// Name 是目的地名稱，用於日誌與錯誤訊息
+	Name() string
+	// Send 發送一筆事件記錄
+	Send(ctx context.Context, record *Record) error
+}
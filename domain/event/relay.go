@@ -0,0 +1,104 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Relay 設定
+const (
+	relayLease      = time.Minute     // 取得的記錄在此時間內不會被其他實例重複處理
+	relayBaseDelay  = time.Second     // 第一次失敗後的重試間隔，之後每次加倍
+	relayMaxDelay   = 5 * time.Minute // 重試間隔上限
+	maxErrorMessage = 1024            // 與 last_error 欄位長度一致
+)
+
+// Relay 將已提交的 outbox 記錄發布到所有目的地與行程內訂閱者
+// 目的地或訂閱者失敗時整筆記錄稍後重試 (至少一次)，不會因重試次數而放棄
+type Relay struct {
+	repo  OutboxRepository
+	bus   *Bus
+	sinks []Sink
+	now   func() time.Time
+}
+
+// NewRelay 創建 Relay，bus 為 nil 時只發送到 sinks
+func NewRelay(repo OutboxRepository, bus *Bus, sinks ...Sink) *Relay {
+	return &Relay{
+		repo:  repo,
+		bus:   bus,
+		sinks: sinks,
+		now:   func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// RelayDue 發布已到期的記錄 (最多 limit 筆)，返回取得的筆數
+// 某筆記錄更新失敗時仍繼續處理其餘記錄，所有錯誤合併後返回
+func (r *Relay) RelayDue(ctx context.Context, limit int) (int, error) {
+	records, err := r.repo.ClaimDue(ctx, r.now(), relayLease, limit)
+	if err != nil {
+		return 0, err
+	}
+	var errs []error
+	for _, record := range records {
+		if err := r.publish(ctx, record); err != nil {
+			record.Attempts++
+			record.NextAttemptAt = r.now().Add(relayDelay(record.Attempts))
+			message := err.Error()
+			if len(message) > maxErrorMessage {
+				message = message[:maxErrorMessage]
+			}
+			record.LastError = &message
+			slog.ErrorContext(ctx, "Failed to relay event", "event_type", record.EventType, "event_id", record.EventID, "attempts", record.Attempts, "error", err)
+		} else {
+			publishedAt := r.now()
+			record.PublishedAt = &publishedAt
+			record.LastError = nil
+		}
+		if err := r.repo.Update(ctx, record); err != nil {
+			// 未更新的記錄在 lease 到期後會再發布一次，接收端以事件 ID 去除重複
+			errs = append(errs, fmt.Errorf("update outbox record %d: %w", record.ID, err))
+		}
+	}
+	return len(records), errors.Join(errs...)
+}
+
+// Prune 刪除超過保留時間的已發布記錄
+func (r *Relay) Prune(ctx context.Context, retention time.Duration) (int64, error) {
+	return r.repo.DeletePublishedBefore(ctx, r.now().Add(-retention))
+}
+
+// publish 先發送到所有 sinks，全部成功後才交給行程內訂閱者，避免訂閱者因 sink 重試而重複收到事件
+func (r *Relay) publish(ctx context.Context, record *Record) error {
+	for _, sink := range r.sinks {
+		if err := sink.Send(ctx, record); err != nil {
+			return fmt.Errorf("%s: %w", sink.Name(), err)
+		}
+	}
+
+	e, err := Decode(record.EventType, []byte(record.Payload))
+	if err != nil {
+		// 未知的事件類型無法交給訂閱者，但已送達 sinks，不再重試
+		slog.WarnContext(ctx, "Skipping subscribers for unknown event type", "event_type", record.EventType, "event_id", record.EventID, "error", err)
+		return nil
+	}
+	if err := r.bus.Dispatch(ctx, e); err != nil {
+		return fmt.Errorf("subscriber: %w", err)
+	}
+	return nil
+}
+
+// relayDelay 返回第 attempts 次失敗後的重試間隔
+func relayDelay(attempts int) time.Duration {
+	delay := relayBaseDelay
+	for i := 1; i < attempts && delay < relayMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > relayMaxDelay {
+		delay = relayMaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,270 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeOutboxRepo 以記憶體保存 outbox 記錄，ClaimDue 依 ID 順序返回到期的記錄
+type fakeOutboxRepo struct {
+	records   []*Record
+	updateErr error
+	updated   []uint
+	prunedAt  time.Time
+}
+
+func (r *fakeOutboxRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Record, error) {
+	var due []*Record
+	for _, record := range r.records {
+		if record.PublishedAt != nil || record.NextAttemptAt.After(now) {
+			continue
+		}
+		if len(due) == limit {
+			break
+		}
+		record.NextAttemptAt = now.Add(lease)
+		due = append(due, record)
+	}
+	return due, nil
+}
+
+func (r *fakeOutboxRepo) Update(ctx context.Context, record *Record) error {
+	r.updated = append(r.updated, record.ID)
+	return r.updateErr
+}
+
+func (r *fakeOutboxRepo) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	r.prunedAt = before
+	return 0, nil
+}
+
+// recordingSink 記錄收到的事件 ID，failing 中的事件 ID 發送失敗
+type recordingSink struct {
+	name    string
+	log     *[]string
+	failing map[string]bool
+}
+
+func (s *recordingSink) Name() string {
+	return s.name
+}
+
+func (s *recordingSink) Send(ctx context.Context, record *Record) error {
+	*s.log = append(*s.log, s.name+":"+record.EventID)
+	if s.failing[record.EventID] {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+// newRecord 建立一筆連結建立事件的 outbox 記錄
+func newRecord(t *testing.T, id uint, eventID string, at time.Time) *Record {
+	t.Helper()
+	e := NewLinkEvent(TypeLinkCreated, Link{ID: id})
+	e.ID = eventID
+	payload, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Record{ID: id, EventID: eventID, EventType: TypeLinkCreated, Payload: string(payload), NextAttemptAt: at}
+}
+
+type relayFixture struct {
+	relay *Relay
+	repo  *fakeOutboxRepo
+	sink  *recordingSink
+	log   *[]string
+	now   time.Time
+}
+
+func newRelayFixture(t *testing.T, eventIDs ...string) *relayFixture {
+	t.Helper()
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	repo := &fakeOutboxRepo{}
+	for i, id := range eventIDs {
+		repo.records = append(repo.records, newRecord(t, uint(i+1), id, now.Add(-time.Minute)))
+	}
+	log := &[]string{}
+	sink := &recordingSink{name: "stream", log: log, failing: map[string]bool{}}
+	bus := NewBus()
+	bus.Subscribe("*", func(ctx context.Context, e Event) error {
+		*log = append(*log, "bus:"+e.EventID())
+		return nil
+	})
+	f := &relayFixture{relay: NewRelay(repo, bus, sink), repo: repo, sink: sink, log: log, now: now}
+	f.relay.now = func() time.Time { return f.now }
+	return f
+}
+
+func TestRelayPublishesInOrderSinksBeforeSubscribers(t *testing.T) {
+	f := newRelayFixture(t, "e1", "e2", "e3")
+
+	n, err := f.relay.RelayDue(context.Background(), 10)
+	if err != nil || n != 3 {
+		t.Fatalf("RelayDue = %d, %v; want 3 records", n, err)
+	}
+	want := []string{"stream:e1", "bus:e1", "stream:e2", "bus:e2", "stream:e3", "bus:e3"}
+	if strings.Join(*f.log, " ") != strings.Join(want, " ") {
+		t.Errorf("publish order = %v, want %v", *f.log, want)
+	}
+	for _, record := range f.repo.records {
+		if record.PublishedAt == nil || !record.PublishedAt.Equal(f.now) || record.Attempts != 0 {
+			t.Errorf("record %s: published %v, attempts %d; want published now", record.EventID, record.PublishedAt, record.Attempts)
+		}
+	}
+}
+
+func TestRelayRespectsLimit(t *testing.T) {
+	f := newRelayFixture(t, "e1", "e2", "e3")
+	if n, _ := f.relay.RelayDue(context.Background(), 2); n != 2 {
+		t.Fatalf("RelayDue claimed %d records, want 2", n)
+	}
+	if f.repo.records[2].PublishedAt != nil {
+		t.Error("the record beyond the limit must wait for the next run")
+	}
+}
+
+func TestRelayRetriesFailedRecordWithBackoff(t *testing.T) {
+	f := newRelayFixture(t, "e1", "e2")
+	f.sink.failing["e1"] = true
+	ctx := context.Background()
+
+	if _, err := f.relay.RelayDue(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	failed, ok := f.repo.records[0], f.repo.records[1]
+	if failed.PublishedAt != nil || failed.Attempts != 1 || !failed.NextAttemptAt.Equal(f.now.Add(time.Second)) {
+		t.Fatalf("failed record: published %v, attempts %d, next %s; want a retry in 1s", failed.PublishedAt, failed.Attempts, failed.NextAttemptAt)
+	}
+	if failed.LastError == nil || *failed.LastError != "stream: connection refused" {
+		t.Errorf("last error = %v", failed.LastError)
+	}
+	if ok.PublishedAt == nil {
+		t.Error("a failed record must not block the records after it")
+	}
+	for _, entry := range *f.log {
+		if entry == "bus:e1" {
+			t.Error("subscribers must not see an event until every sink has it")
+		}
+	}
+
+	// 還沒到重試時間不會再發送
+	if n, _ := f.relay.RelayDue(ctx, 10); n != 0 {
+		t.Fatalf("claimed %d records before the retry time", n)
+	}
+
+	// 第二次失敗後間隔加倍
+	f.now = f.now.Add(time.Second)
+	if _, err := f.relay.RelayDue(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if failed.Attempts != 2 || !failed.NextAttemptAt.Equal(f.now.Add(2*time.Second)) {
+		t.Fatalf("after the second failure: attempts %d, next %s; want a retry in 2s", failed.Attempts, failed.NextAttemptAt)
+	}
+
+	// 恢復後發布並清除錯誤
+	delete(f.sink.failing, "e1")
+	f.now = f.now.Add(2 * time.Second)
+	if _, err := f.relay.RelayDue(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if failed.PublishedAt == nil || failed.LastError != nil {
+		t.Fatalf("after recovery: published %v, last error %v", failed.PublishedAt, failed.LastError)
+	}
+}
+
+func TestRelayRetriesWhenSubscriberFails(t *testing.T) {
+	f := newRelayFixture(t, "e1")
+	f.relay.bus.Subscribe(TypeLinkCreated, func(ctx context.Context, e Event) error {
+		return errors.New("webhook queue full")
+	})
+
+	if _, err := f.relay.RelayDue(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	record := f.repo.records[0]
+	if record.PublishedAt != nil || record.Attempts != 1 || record.LastError == nil || !strings.HasPrefix(*record.LastError, "subscriber: ") {
+		t.Fatalf("record: published %v, attempts %d, last error %v; want a subscriber retry", record.PublishedAt, record.Attempts, record.LastError)
+	}
+}
+
+func TestRelayTruncatesLongErrors(t *testing.T) {
+	f := newRelayFixture(t, "e1")
+	f.relay.sinks = []Sink{failingSink{message: strings.Repeat("x", 2*maxErrorMessage)}}
+
+	f.relay.RelayDue(context.Background(), 10)
+	if got := len(*f.repo.records[0].LastError); got != maxErrorMessage {
+		t.Errorf("last error has %d bytes, want %d", got, maxErrorMessage)
+	}
+}
+
+func TestRelaySkipsSubscribersForUnknownEventType(t *testing.T) {
+	f := newRelayFixture(t, "e1")
+	f.repo.records[0].EventType = "link.archived"
+
+	if _, err := f.relay.RelayDue(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	if f.repo.records[0].PublishedAt == nil {
+		t.Error("an event type without decoder is delivered to sinks and not retried")
+	}
+	if strings.Join(*f.log, " ") != "stream:e1" {
+		t.Errorf("log = %v, want only the sink", *f.log)
+	}
+}
+
+func TestRelayReturnsUpdateError(t *testing.T) {
+	f := newRelayFixture(t, "e1", "e2")
+	f.repo.updateErr = errors.New("connection reset")
+
+	n, err := f.relay.RelayDue(context.Background(), 10)
+	if err == nil || n != 2 || !errors.Is(err, f.repo.updateErr) {
+		t.Fatalf("RelayDue = %d, %v; want the update error", n, err)
+	}
+	if len(f.repo.updated) != 2 {
+		t.Errorf("updated %v, want every record of the batch updated", f.repo.updated)
+	}
+}
+
+func TestRelayDelay(t *testing.T) {
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{9, 256 * time.Second},
+		{10, relayMaxDelay},
+		{100, relayMaxDelay},
+	}
+	for _, tt := range tests {
+		if got := relayDelay(tt.attempts); got != tt.want {
+			t.Errorf("relayDelay(%d) = %s, want %s", tt.attempts, got, tt.want)
+		}
+	}
+}
+
+func TestRelayPrune(t *testing.T) {
+	f := newRelayFixture(t)
+	f.relay.Prune(context.Background(), 24*time.Hour)
+	if want := f.now.Add(-24 * time.Hour); !f.repo.prunedAt.Equal(want) {
+		t.Errorf("pruned before %s, want %s", f.repo.prunedAt, want)
+	}
+}
+
+type failingSink struct {
+	message string
+}
+
+func (s failingSink) Name() string {
+	return "broken"
+}
+
+func (s failingSink) Send(ctx context.Context, record *Record) error {
+	return errors.New(s.message)
+}
@@ -0,0 +1,34 @@
+package event
+
+import "strconv"
+
+// 使用者相關的事件類型
+const (
+	TypeUserActivated   = "user.activated"
+	TypeUserDeactivated = "user.deactivated"
+)
+
+// UserTypes 是所有使用者事件類型
+var UserTypes = []string{TypeUserActivated, TypeUserDeactivated}
+
+// User 是事件中攜帶的使用者資訊
+type User struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+// UserEvent 是使用者帳號狀態變更事件
+type UserEvent struct {
+	Base
+	User User `json:"user"`
+}
+
+// NewUserEvent 建立指定類型的使用者事件
+func NewUserEvent(eventType string, user User) *UserEvent {
+	return &UserEvent{Base: NewBase(eventType), User: user}
+}
+
+// Aggregate 返回事件所屬的使用者
+func (e *UserEvent) Aggregate() (string, string) {
+	return AggregateUser, strconv.FormatUint(uint64(e.User.ID), 10)
+}
@@ -0,0 +1,75 @@
+package entity
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// APIKeyPrefix 是所有 API 金鑰的固定前綴，用來與 JWT 區分
+const APIKeyPrefix = "gs_"
+
+// API 金鑰可授予的權限範圍
+const (
+	ScopeLinksRead  = "links:read"  // 讀取連結
+	ScopeLinksWrite = "links:write" // 建立、修改、刪除連結
+	ScopeStatsRead  = "stats:read"  // 讀取連結統計
+)
+
+// AllScopes 列出所有可用的權限範圍
+var AllScopes = []string{ScopeLinksRead, ScopeLinksWrite, ScopeStatsRead}
+
+// APIKey 代表供程式化客戶端使用的長期金鑰，明文只在建立時顯示一次
+type APIKey struct {
+	gorm.Model
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	Name       string     `json:"name" gorm:"type:varchar(100);not null"`
+	Prefix     string     `json:"prefix" gorm:"type:varchar(20);not null"`        // 金鑰開頭的部分字元，方便辨識
+	KeyHash    string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // 金鑰的 SHA-256 雜湊
+	Scopes     string     `json:"-" gorm:"type:varchar(255);not null"`            // 以逗號分隔的權限範圍
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// TableName 指定 APIKey 實體的資料表名稱
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList 返回金鑰的權限範圍列表
+func (k *APIKey) ScopeList() []string {
+	if k.Scopes == "" {
+		return nil
+	}
+	return strings.Split(k.Scopes, ",")
+}
+
+// HasScope 檢查金鑰是否擁有指定權限範圍
+func (k *APIKey) HasScope(scope string) bool {
+	for _, s := range k.ScopeList() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable 檢查金鑰是否未撤銷且未過期
+func (k *APIKey) IsUsable() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
+
+// IsValidScope 檢查權限範圍名稱是否有效
+func IsValidScope(scope string) bool {
+	for _, s := range AllScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
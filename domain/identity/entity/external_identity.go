@@ -0,0 +1,26 @@
+package entity
+
+import (
+	"gorm.io/gorm"
+)
+
+// ExternalIdentity 代表使用者在外部 OpenID Connect 身分提供者的帳號
+type ExternalIdentity struct {
+	gorm.Model
+	UserID   uint    `json:"user_id" gorm:"not null;index"`
+	Provider string  `json:"provider" gorm:"type:varchar(50);not null;uniqueIndex:idx_external_identities_provider_subject,priority:1,where:deleted_at IS NULL"` // 設定中的提供者名稱
+	Subject  string  `json:"subject" gorm:"type:varchar(255);not null;uniqueIndex:idx_external_identities_provider_subject,priority:2,where:deleted_at IS NULL"` // ID Token 的 sub
+	Email    *string `json:"email,omitempty" gorm:"type:varchar(255)"`                                                                                           // 連結時提供者回報的電子郵件
+}
+
+// TableName 指定 ExternalIdentity 實體的資料表名稱
+func (ExternalIdentity) TableName() string {
+	return "external_identities"
+}
+
+// OIDCState 是授權請求發出後、回呼前暫存的登入狀態
+type OIDCState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"` // PKCE code verifier
+	Nonce        string `json:"nonce"`         // 需與 ID Token 中的 nonce 相符
+}
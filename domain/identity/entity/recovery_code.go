@@ -0,0 +1,20 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecoveryCode 代表一組兩步驟驗證的一次性復原碼，在無法使用驗證器 App 時代替 TOTP 驗證碼
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `gorm:"not null;index"`
+	CodeHash string     `gorm:"type:varchar(64);not null"` // 復原碼的 SHA-256 雜湊
+	UsedAt   *time.Time // 使用時間，使用後即失效
+}
+
+// TableName 指定 RecoveryCode 實體的資料表名稱
+func (RecoveryCode) TableName() string {
+	return "recovery_codes"
+}
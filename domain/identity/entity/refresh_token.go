@@ -0,0 +1,29 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RefreshToken 代表一個可換取新存取權杖的 refresh token，每次使用後即輪替
+// 同一次登入產生的所有 token 屬於同一個 family
+type RefreshToken struct {
+	gorm.Model
+	UserID    uint       `gorm:"not null;index"`
+	FamilyID  string     `gorm:"type:varchar(64);not null;index"`       // 登入工作階段 ID，也寫入存取權杖的 sid
+	TokenHash string     `gorm:"type:varchar(64);not null;uniqueIndex"` // token 的 SHA-256 雜湊
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time // 已被換成新 token 的時間
+	RevokedAt *time.Time // 登出或偵測到重複使用時撤銷
+}
+
+// TableName 指定 RefreshToken 實體的資料表名稱
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// IsExpired 檢查 token 是否已過期
+func (t *RefreshToken) IsExpired() bool {
+	return time.Now().After(t.ExpiresAt)
+}
@@ -7,14 +7,25 @@ import (
 	"gorm.io/gorm"
 )
 
+// 使用者角色
+const (
+	RoleUser  = "user"  // 一般使用者
+	RoleAdmin = "admin" // 管理員，可存取 /admin API
+)
+
 // User 代表系統中的使用者
 type User struct {
-	gorm.Model              // 包含 ID, CreatedAt, UpdatedAt, DeletedAt
-	Username     string     `gorm:"type:varchar(100);uniqueIndex;not null"` // 使用者名稱，唯一且不為空
-	Email        string     `gorm:"type:varchar(255);uniqueIndex;not null"` // 電子郵件，唯一且不為空
-	PasswordHash string     `gorm:"type:varchar(255);not null"`             // 存儲雜湊後的密碼
-	IsActive     bool       `gorm:"default:true"`                           // 帳號是否啟用
-	LastLogin    *time.Time // 最後登入時間
+	gorm.Model                 // 包含 ID, CreatedAt, UpdatedAt, DeletedAt
+	Username        string     `json:"username" gorm:"type:varchar(100);uniqueIndex:idx_users_username,where:deleted_at IS NULL;not null"` // 使用者名稱，在未刪除的使用者中唯一且不為空
+	Email           string     `json:"email" gorm:"type:varchar(255);uniqueIndex:idx_users_email,where:deleted_at IS NULL;not null"`       // 電子郵件，在未刪除的使用者中唯一且不為空
+	PasswordHash    string     `json:"-" gorm:"type:varchar(255);not null"`                                                                // 存儲雜湊後的密碼
+	Role            string     `json:"role" gorm:"type:varchar(20);not null;default:'user'"`                                               // 使用者角色
+	Plan            string     `json:"plan" gorm:"type:varchar(32);not null;default:'free'"`                                               // 所屬方案，決定配額上限
+	IsActive        bool       `json:"is_active" gorm:"default:true"`                                                                      // 帳號是否啟用
+	LastLogin       *time.Time `json:"last_login,omitempty"`                                                                               // 最後登入時間
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`                                                                        // 電子郵件驗證時間
+	TOTPSecret      *string    `json:"-" gorm:"column:totp_secret;type:varchar(64)"`                                                       // TOTP 共享密鑰 (註冊中或已啟用)
+	TOTPEnabledAt   *time.Time `json:"totp_enabled_at,omitempty" gorm:"column:totp_enabled_at"`                                            // 兩步驟驗證啟用時間
 }
 
 // TableName 指定 User 實體的資料表名稱
@@ -35,10 +46,31 @@ func NewUser(username, email, password string) (*User, error) {
 		Email:    email,
 		// PasswordHash: string(hashedPassword), // 實際應存儲雜湊值
 		PasswordHash: "placeholder_hash", // 暫時使用佔位符
+		Role:         RoleUser,
 		IsActive:     true,
 	}, nil
 }
 
+// IsAdmin 檢查使用者是否為管理員
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsEmailVerified 檢查使用者是否已驗證電子郵件
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// IsTOTPEnabled 檢查使用者是否已啟用兩步驟驗證
+func (u *User) IsTOTPEnabled() bool {
+	return u.TOTPEnabledAt != nil && u.TOTPSecret != nil
+}
+
+// IsValidRole 檢查角色名稱是否為已定義的使用者角色
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleAdmin
+}
+
 // SetPassword 雜湊並設定使用者密碼 (可以在領域服務中實現此邏輯)
 func (u *User) SetPassword(password string) error {
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"go_short/domain/identity/entity" // 引入 User 實體
 )
@@ -23,8 +24,110 @@ type UserRepository interface {
 	// Update 更新使用者資訊 (例如 LastLogin, IsActive)
 	Update(ctx context.Context, user *entity.User) error
 
-	// Delete 標記刪除使用者 (如果使用軟刪除) 或永久刪除
-	// Delete(ctx context.Context, id uint) error
+	// Search 依使用者名稱或電子郵件模糊搜尋使用者 (query 為空時返回全部)，並返回符合條件的總數
+	Search(ctx context.Context, query string, offset, limit int) ([]*entity.User, int64, error)
+
+	// CountUsers 返回使用者總數與啟用中的使用者數
+	CountUsers(ctx context.Context) (total int64, active int64, err error)
+
+	// Delete 軟刪除使用者
+	Delete(ctx context.Context, id uint) error
+}
+
+// APIKeyRepository 定義了 API 金鑰的存取操作介面
+type APIKeyRepository interface {
+	// Create 創建一把新金鑰
+	Create(ctx context.Context, key *entity.APIKey) error
+
+	// FindByID 根據 ID 查找金鑰
+	FindByID(ctx context.Context, id uint) (*entity.APIKey, error)
+
+	// FindByHash 根據金鑰雜湊查找金鑰
+	FindByHash(ctx context.Context, keyHash string) (*entity.APIKey, error)
+
+	// FindByUserID 獲取使用者的所有金鑰
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.APIKey, error)
+
+	// Update 更新金鑰 (例如 LastUsedAt, RevokedAt)
+	Update(ctx context.Context, key *entity.APIKey) error
+}
+
+// RefreshTokenRepository 定義了 refresh token 的存取操作介面
+type RefreshTokenRepository interface {
+	// Create 儲存一個新的 refresh token
+	Create(ctx context.Context, token *entity.RefreshToken) error
+
+	// FindByHash 根據 token 雜湊查找 refresh token
+	FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error)
+
+	// MarkUsed 在 token 尚未被使用時標記為已使用，返回是否成功標記 (並行請求只有一個會成功)
+	MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error)
+
+	// RevokeFamily 撤銷同一 family 中所有尚未撤銷的 token
+	RevokeFamily(ctx context.Context, familyID string, at time.Time) error
+
+	// RevokeAllForUser 撤銷使用者所有尚未撤銷的 token，exceptFamilyID 不為空時保留該 family
+	RevokeAllForUser(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error
+}
+
+// RecoveryCodeRepository 定義了兩步驟驗證復原碼的存取操作介面
+type RecoveryCodeRepository interface {
+	// ReplaceForUser 刪除使用者現有的復原碼並儲存新的一組
+	ReplaceForUser(ctx context.Context, userID uint, codes []*entity.RecoveryCode) error
+
+	// Consume 將符合雜湊且尚未使用的復原碼標記為已使用，返回是否成功
+	Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error)
+
+	// CountUnused 返回使用者尚未使用的復原碼數量
+	CountUnused(ctx context.Context, userID uint) (int64, error)
+
+	// DeleteForUser 刪除使用者所有的復原碼
+	DeleteForUser(ctx context.Context, userID uint) error
+}
+
+// ExternalIdentityRepository 定義了外部身分連結的存取操作介面
+type ExternalIdentityRepository interface {
+	// Create 建立一筆外部身分連結
+	Create(ctx context.Context, identity *entity.ExternalIdentity) error
+
+	// FindByProviderSubject 根據提供者與 subject 查找連結
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalIdentity, error)
+
+	// FindByUserID 獲取使用者所有的外部身分連結
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.ExternalIdentity, error)
+}
+
+// OIDCStateStore 暫存 OIDC 授權流程的狀態，每個 state 只能取出一次
+type OIDCStateStore interface {
+	// Save 以 state 為鍵儲存登入狀態
+	Save(ctx context.Context, state string, data *entity.OIDCState, ttl time.Duration) error
+
+	// Take 取出並刪除登入狀態，不存在或已過期時返回 nil
+	Take(ctx context.Context, state string) (*entity.OIDCState, error)
+}
+
+// TokenDenylist 記錄已撤銷但尚未過期的存取權杖 (以 jti 識別)
+type TokenDenylist interface {
+	// Add 將 jti 加入黑名單，ttl 到期後自動移除
+	Add(ctx context.Context, jti string, ttl time.Duration) error
+
+	// Contains 檢查 jti 是否在黑名單中
+	Contains(ctx context.Context, jti string) (bool, error)
+}
+
+// LoginAttemptStore 記錄登入失敗次數與暫時鎖定狀態，key 由呼叫端決定 (例如使用者名稱或 IP)
+type LoginAttemptStore interface {
+	// IncrementFailures 將失敗次數加一並返回累計次數，window 內沒有新的失敗時計數歸零
+	IncrementFailures(ctx context.Context, key string, window time.Duration) (int64, error)
+
+	// Lock 鎖定 key 一段時間
+	Lock(ctx context.Context, key string, ttl time.Duration) error
+
+	// LockRemaining 返回 key 剩餘的鎖定時間，未鎖定時為 0
+	LockRemaining(ctx context.Context, key string) (time.Duration, error)
+
+	// Reset 清除 key 的失敗次數與鎖定
+	Reset(ctx context.Context, key string) error
 }
 
 // 可以在此文件中添加其他 Identity 相關的 Repository 介面，
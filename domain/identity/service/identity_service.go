@@ -3,15 +3,17 @@ package service
 import (
 	"context"
 	"errors"
-	"log" // 引入 log
+	"log/slog"
 
+	"go_short/domain/event"
+	"go_short/domain/identity/entity"
 	"go_short/domain/identity/repository" // 依賴 Repository 介面
-	// 可能需要引入 entity
 )
 
 // 自訂領域錯誤
 var ErrUserNotFound = errors.New("service: user not found")
 var ErrServiceInternal = errors.New("service: internal error") // 通用內部錯誤
+var ErrInvalidPassword = errors.New("service: current password is incorrect")
 
 // IdentityService 定義了用戶領域的核心業務邏輯接口 (可選，但良好實踐)
 type IdentityService interface {
@@ -20,21 +22,22 @@ type IdentityService interface {
 	// DeactivateUser 停用指定 ID 的使用者帳號
 	DeactivateUser(ctx context.Context, userID uint) error
 
-	// 可以在這裡定義更複雜的業務操作，例如：
-	// ChangeUserPassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
-	// DeactivateUser(ctx context.Context, userID uint) error
+	// ChangeUserPassword 驗證舊密碼後設定新密碼
+	ChangeUserPassword(ctx context.Context, userID uint, oldPassword, newPassword string) error
 }
 
 // identityService 是 IdentityService 的具體實現
 type identityService struct {
 	userRepo repository.UserRepository
-	// 可能注入其他依賴，如外部服務、策略物件等
+	outbox   event.Outbox
 }
 
 // NewIdentityService 創建 identityService 實例
-func NewIdentityService(userRepo repository.UserRepository) IdentityService {
+// 帳號啟用與停用事件與狀態變更一起寫入 outbox，outbox 為 nil 時不發布領域事件
+func NewIdentityService(userRepo repository.UserRepository, outbox event.Outbox) IdentityService {
 	return &identityService{
 		userRepo: userRepo,
+		outbox:   outbox,
 	}
 }
 
@@ -44,7 +47,7 @@ func NewIdentityService(userRepo repository.UserRepository) IdentityService {
 func (s *identityService) ActivateUser(ctx context.Context, userID uint) error {
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		log.Printf("Error finding user %d for activation: %v", userID, err)
+		slog.ErrorContext(ctx, "Error finding user for activation", "user_id", userID, "error", err)
 		return ErrServiceInternal // 不暴露內部錯誤細節
 	}
 	if user == nil {
@@ -53,21 +56,18 @@ func (s *identityService) ActivateUser(ctx context.Context, userID uint) error {
 
 	// 如果已經是啟用狀態，可以直接返回 nil，或返回特定提示（取決於業務需求）
 	if user.IsActive {
-		log.Printf("User %d is already active.", userID)
+		slog.InfoContext(ctx, "User is already active", "user_id", userID)
 		return nil // 或者 return errors.New("service: user already active")
 	}
 
 	// 更新狀態
 	user.IsActive = true
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		log.Printf("Error updating user %d status for activation: %v", userID, err)
+	if err := s.updateWithEvent(ctx, user, event.TypeUserActivated); err != nil {
+		slog.ErrorContext(ctx, "Error updating user status for activation", "user_id", userID, "error", err)
 		return ErrServiceInternal
 	}
 
-	log.Printf("User %d activated successfully.", userID)
-	// (可選) 發布領域事件 UserActivatedEvent
-	// ...
-
+	slog.InfoContext(ctx, "User activated successfully", "user_id", userID)
 	return nil
 }
 
@@ -75,7 +75,7 @@ func (s *identityService) ActivateUser(ctx context.Context, userID uint) error {
 func (s *identityService) DeactivateUser(ctx context.Context, userID uint) error {
 	user, err := s.userRepo.FindByID(ctx, userID)
 	if err != nil {
-		log.Printf("Error finding user %d for deactivation: %v", userID, err)
+		slog.ErrorContext(ctx, "Error finding user for deactivation", "user_id", userID, "error", err)
 		return ErrServiceInternal
 	}
 	if user == nil {
@@ -84,7 +84,7 @@ func (s *identityService) DeactivateUser(ctx context.Context, userID uint) error
 
 	// 如果已經是停用狀態，可以直接返回
 	if !user.IsActive {
-		log.Printf("User %d is already inactive.", userID)
+		slog.InfoContext(ctx, "User is already inactive", "user_id", userID)
 		return nil
 	}
 
@@ -93,16 +93,54 @@ func (s *identityService) DeactivateUser(ctx context.Context, userID uint) error
 
 	// 更新狀態
 	user.IsActive = false
-	if err := s.userRepo.Update(ctx, user); err != nil {
-		log.Printf("Error updating user %d status for deactivation: %v", userID, err)
+	if err := s.updateWithEvent(ctx, user, event.TypeUserDeactivated); err != nil {
+		slog.ErrorContext(ctx, "Error updating user status for deactivation", "user_id", userID, "error", err)
 		return ErrServiceInternal
 	}
 
-	log.Printf("User %d deactivated successfully.", userID)
-	// (可選) 發布領域事件 UserDeactivatedEvent
-	// ...
+	slog.InfoContext(ctx, "User deactivated successfully", "user_id", userID)
+	return nil
+}
+
+// ChangeUserPassword 驗證舊密碼後設定新密碼
+func (s *identityService) ChangeUserPassword(ctx context.Context, userID uint, oldPassword, newPassword string) error {
+	user, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for password change", "user_id", userID, "error", err)
+		return ErrServiceInternal
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if !user.CheckPassword(oldPassword) {
+		return ErrInvalidPassword
+	}
 
+	if err := user.SetPassword(newPassword); err != nil {
+		slog.ErrorContext(ctx, "Error hashing new password for user", "user_id", userID, "error", err)
+		return ErrServiceInternal
+	}
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating password for user", "user_id", userID, "error", err)
+		return ErrServiceInternal
+	}
+
+	slog.InfoContext(ctx, "User changed password", "user_id", userID)
 	return nil
 }
 
+// updateWithEvent 保存使用者並在同一個交易中記錄帳號狀態事件
+func (s *identityService) updateWithEvent(ctx context.Context, user *entity.User, eventType string) error {
+	if s.outbox == nil {
+		return s.userRepo.Update(ctx, user)
+	}
+	return s.outbox.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return err
+		}
+		return s.outbox.Append(ctx, event.NewUserEvent(eventType, event.User{ID: user.ID, Username: user.Username}))
+	})
+}
+
 // --- 在這裡實現 IdentityService 介面中定義的其他方法 (未來可能實現) ---
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go_short/domain/identity/repository"
+)
+
+// ErrLoginLocked 表示登入因失敗次數過多而暫時被鎖定
+var ErrLoginLocked = errors.New("service: too many failed login attempts")
+
+// LockedError 帶有鎖定剩餘時間的 ErrLoginLocked
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", ErrLoginLocked, e.RetryAfter.Round(time.Second))
+}
+
+// Unwrap 讓 errors.Is(err, ErrLoginLocked) 成立
+func (e *LockedError) Unwrap() error {
+	return ErrLoginLocked
+}
+
+// LockoutPolicy 定義登入失敗的鎖定規則
+type LockoutPolicy struct {
+	MaxUserFailures int           // 同一使用者名稱連續失敗幾次後鎖定
+	MaxIPFailures   int           // 同一 IP 連續失敗幾次後鎖定
+	BaseLockout     time.Duration // 第一次鎖定的時間，之後每次失敗加倍
+	MaxLockout      time.Duration // 鎖定時間上限
+	FailureWindow   time.Duration // 失敗次數的記憶時間
+}
+
+// DefaultLockoutPolicy 返回預設的鎖定規則
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		MaxUserFailures: 5,
+		MaxIPFailures:   20,
+		BaseLockout:     30 * time.Second,
+		MaxLockout:      time.Hour,
+		FailureWindow:   24 * time.Hour,
+	}
+}
+
+// LoginGuard 依使用者名稱與來源 IP 追蹤登入失敗，並以指數退避暫時鎖定
+// 不論使用者名稱是否存在都以相同方式計數，避免透過鎖定行為探測帳號
+type LoginGuard struct {
+	store  repository.LoginAttemptStore
+	policy LockoutPolicy
+}
+
+// NewLoginGuard 創建 LoginGuard 實例
+func NewLoginGuard(store repository.LoginAttemptStore, policy LockoutPolicy) *LoginGuard {
+	return &LoginGuard{
+		store:  store,
+		policy: policy,
+	}
+}
+
+// Check 在驗證密碼前呼叫，使用者名稱或 IP 被鎖定時返回 *LockedError
+func (g *LoginGuard) Check(ctx context.Context, username, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range g.keys(username, ip) {
+		remaining, err := g.store.LockRemaining(ctx, key)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error checking login lock", "key", key, "error", err)
+			continue
+		}
+		if remaining > retryAfter {
+			retryAfter = remaining
+		}
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordFailure 記錄一次失敗，超過門檻時鎖定並返回 *LockedError
+func (g *LoginGuard) RecordFailure(ctx context.Context, username, ip string) error {
+	var retryAfter time.Duration
+	for _, key := range g.keys(username, ip) {
+		failures, err := g.store.IncrementFailures(ctx, key, g.policy.FailureWindow)
+		if err != nil {
+			continue
+		}
+
+		limit := g.policy.MaxUserFailures
+		if strings.HasPrefix(key, "ip:") {
+			limit = g.policy.MaxIPFailures
+		}
+		if failures < int64(limit) {
+			continue
+		}
+
+		lockout := g.lockoutFor(failures - int64(limit))
+		if err := g.store.Lock(ctx, key, lockout); err != nil {
+			continue
+		}
+		slog.WarnContext(ctx, "Login locked", "key", key, "failures", failures, "lockout", lockout)
+		if lockout > retryAfter {
+			retryAfter = lockout
+		}
+	}
+	if retryAfter > 0 {
+		return &LockedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RecordSuccess 在登入成功後清除該使用者名稱的失敗次數與鎖定
+// IP 的計數不清除，避免攻擊者以自己的帳號登入來重置計數
+func (g *LoginGuard) RecordSuccess(ctx context.Context, username string) {
+	if err := g.store.Reset(ctx, userKey(username)); err != nil {
+		slog.ErrorContext(ctx, "Error resetting login failures", "username", username, "error", err)
+	}
+}
+
+// Unlock 由管理員手動解除使用者名稱的鎖定
+func (g *LoginGuard) Unlock(ctx context.Context, username string) error {
+	if err := g.store.Reset(ctx, userKey(username)); err != nil {
+		return ErrServiceInternal
+	}
+	slog.InfoContext(ctx, "Login unlocked", "key", userKey(username))
+	return nil
+}
+
+// lockoutFor 計算第 n 次超過門檻 (從 0 開始) 的鎖定時間
+func (g *LoginGuard) lockoutFor(n int64) time.Duration {
+	lockout := g.policy.BaseLockout
+	for i := int64(0); i < n && lockout < g.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > g.policy.MaxLockout {
+		lockout = g.policy.MaxLockout
+	}
+	return lockout
+}
+
+// keys 返回要計數的鍵；username 為空時 (例如外部登入尚未確認身分) 只以來源 IP 計數
+func (g *LoginGuard) keys(username, ip string) []string {
+	var keys []string
+	if username != "" {
+		keys = append(keys, userKey(username))
+	}
+	if ip != "" {
+		keys = append(keys, "ip:"+ip)
+	}
+	return keys
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(strings.TrimSpace(username))
+}
@@ -0,0 +1,16 @@
+package notification
+
+import "context"
+
+// Message 是一封待寄出的純文字電子郵件
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer 定義寄送電子郵件的介面，具體實現位於 infra/mailer
+type Mailer interface {
+	// Send 寄出一封郵件
+	Send(ctx context.Context, msg Message) error
+}
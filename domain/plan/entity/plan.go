@@ -0,0 +1,85 @@
+package entity
+
+import "time"
+
+// 預設建立的方案名稱
+const (
+	PlanFree     = "free"
+	PlanPro      = "pro"
+	PlanBusiness = "business"
+)
+
+// 配額名稱，出現在超過配額的錯誤回應中
+const (
+	QuotaLinks         = "links"          // 目前擁有的連結數
+	QuotaMonthlyLinks  = "monthly_links"  // 本月建立的連結數
+	QuotaCustomAliases = "custom_aliases" // 自訂短碼數
+	QuotaCustomDomains = "custom_domains" // 自訂網域數
+)
+
+// Plan 是一個方案及其配額，上限為 nil 表示不限制
+type Plan struct {
+	Name                   string `json:"name" gorm:"primaryKey;type:varchar(32)"`
+	DisplayName            string `json:"display_name" gorm:"type:varchar(100);not null"`
+	MaxLinks               *int   `json:"max_links"`
+	MonthlyLinks           *int   `json:"monthly_links"`
+	MaxCustomAliases       *int   `json:"max_custom_aliases"`
+	MaxCustomDomains       *int   `json:"max_custom_domains"`
+	APIRateLimit           int    `json:"api_rate_limit"`           // 每分鐘 API 請求數，0 表示使用全域設定
+	AnalyticsRetentionDays *int   `json:"analytics_retention_days"` // 點擊分析資料的保留天數
+	SortOrder              int    `json:"-"`
+}
+
+// TableName 指定資料表名稱
+func (Plan) TableName() string {
+	return "plans"
+}
+
+// Limit 返回指定配額的上限，不限制時返回 nil
+func (p *Plan) Limit(quota string) *int {
+	switch quota {
+	case QuotaLinks:
+		return p.MaxLinks
+	case QuotaMonthlyLinks:
+		return p.MonthlyLinks
+	case QuotaCustomAliases:
+		return p.MaxCustomAliases
+	case QuotaCustomDomains:
+		return p.MaxCustomDomains
+	default:
+		return nil
+	}
+}
+
+// Usage 是使用者目前的用量
+type Usage struct {
+	Links         int64     `json:"links"`
+	MonthlyLinks  int64     `json:"monthly_links"`
+	CustomAliases int64     `json:"custom_aliases"`
+	CustomDomains int64     `json:"custom_domains"`
+	PeriodStart   time.Time `json:"period_start"` // 本月用量的起算時間 (UTC 每月一日)
+	PeriodEnd     time.Time `json:"period_end"`
+}
+
+// Used 返回指定配額的用量
+func (u *Usage) Used(quota string) int64 {
+	switch quota {
+	case QuotaLinks:
+		return u.Links
+	case QuotaMonthlyLinks:
+		return u.MonthlyLinks
+	case QuotaCustomAliases:
+		return u.CustomAliases
+	case QuotaCustomDomains:
+		return u.CustomDomains
+	default:
+		return 0
+	}
+}
+
+// BillingPeriod 返回 t 所在月份 (UTC) 的起訖時間
+func BillingPeriod(t time.Time) (time.Time, time.Time) {
+	t = t.UTC()
+	start := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
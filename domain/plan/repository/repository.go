@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/plan/entity"
+)
+
+// PlanRepository 定義了方案的儲存庫介面
+type PlanRepository interface {
+	// List 依排序返回所有方案
+	List(ctx context.Context) ([]*entity.Plan, error)
+
+	// FindByName 根據名稱查找方案，不存在時返回 nil
+	FindByName(ctx context.Context, name string) (*entity.Plan, error)
+
+	// FindByUserID 返回使用者所屬的方案，使用者不存在時返回 nil
+	FindByUserID(ctx context.Context, userID uint) (*entity.Plan, error)
+}
+
+// UsageRepository 定義了用量統計的儲存庫介面
+type UsageRepository interface {
+	// UserUsage 統計使用者建立的連結、自訂短碼與網域 (含工作區中的資源)
+	// 本月建立數包含已刪除的連結，避免以刪除再建立的方式繞過每月配額
+	UserUsage(ctx context.Context, userID uint, periodStart time.Time) (*entity.Usage, error)
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_short/domain/plan/entity"
+	"go_short/domain/plan/repository"
+)
+
+// 方案與配額相關錯誤
+var (
+	ErrPlanNotFound  = errors.New("plan not found")
+	ErrUserNotFound  = errors.New("user not found")
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	ErrDatabaseError = errors.New("database operation failed")
+)
+
+// QuotaExceededError 描述超過的配額，讓用戶端可以顯示升級提示
+type QuotaExceededError struct {
+	Quota      string // 配額名稱，見 entity.Quota* 常數
+	Plan       string // 使用者目前的方案
+	Limit      int64
+	Used       int64
+	UpgradeURL string // 升級頁面，未設定時為空字串
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s quota exceeded for plan %s (%d/%d)", e.Quota, e.Plan, e.Used, e.Limit)
+}
+
+// Is 讓 errors.Is(err, ErrQuotaExceeded) 成立
+func (e *QuotaExceededError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
+
+// UsageReport 是使用者的方案與用量
+type UsageReport struct {
+	Plan  *entity.Plan  `json:"plan"`
+	Usage *entity.Usage `json:"usage"`
+}
+
+// QuotaService 負責查詢方案並檢查配額
+// 檢查與建立並非同一交易，併發建立時可能略為超出上限
+type QuotaService struct {
+	planRepo   repository.PlanRepository
+	usageRepo  repository.UsageRepository
+	upgradeURL string
+	now        func() time.Time
+}
+
+// NewQuotaService 創建配額服務，upgradeURL 會附在超過配額的錯誤中
+func NewQuotaService(planRepo repository.PlanRepository, usageRepo repository.UsageRepository, upgradeURL string) *QuotaService {
+	return &QuotaService{
+		planRepo:   planRepo,
+		usageRepo:  usageRepo,
+		upgradeURL: upgradeURL,
+		now:        time.Now,
+	}
+}
+
+// ListPlans 返回所有方案
+func (s *QuotaService) ListPlans(ctx context.Context) ([]*entity.Plan, error) {
+	plans, err := s.planRepo.List(ctx)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return plans, nil
+}
+
+// FindPlan 根據名稱查找方案
+func (s *QuotaService) FindPlan(ctx context.Context, name string) (*entity.Plan, error) {
+	plan, err := s.planRepo.FindByName(ctx, name)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if plan == nil {
+		return nil, ErrPlanNotFound
+	}
+	return plan, nil
+}
+
+// UserPlan 返回使用者所屬的方案
+func (s *QuotaService) UserPlan(ctx context.Context, userID uint) (*entity.Plan, error) {
+	plan, err := s.planRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if plan == nil {
+		return nil, ErrUserNotFound
+	}
+	return plan, nil
+}
+
+// Usage 返回使用者的方案與本月用量
+func (s *QuotaService) Usage(ctx context.Context, userID uint) (*UsageReport, error) {
+	plan, err := s.UserPlan(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.usage(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &UsageReport{Plan: plan, Usage: usage}, nil
+}
+
+// CheckLinkCreation 檢查使用者是否還能建立連結；customAlias 為 true 時一併檢查自訂短碼配額
+func (s *QuotaService) CheckLinkCreation(ctx context.Context, userID uint, customAlias bool) error {
+	quotas := []string{entity.QuotaLinks, entity.QuotaMonthlyLinks}
+	if customAlias {
+		quotas = append(quotas, entity.QuotaCustomAliases)
+	}
+	return s.check(ctx, userID, quotas...)
+}
+
+// CheckDomainCreation 檢查使用者是否還能註冊自訂網域
+func (s *QuotaService) CheckDomainCreation(ctx context.Context, userID uint) error {
+	return s.check(ctx, userID, entity.QuotaCustomDomains)
+}
+
+// check 依序檢查配額，返回第一個已用盡的配額
+func (s *QuotaService) check(ctx context.Context, userID uint, quotas ...string) error {
+	plan, err := s.UserPlan(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	limited := false
+	for _, quota := range quotas {
+		if plan.Limit(quota) != nil {
+			limited = true
+			break
+		}
+	}
+	if !limited {
+		return nil
+	}
+
+	usage, err := s.usage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, quota := range quotas {
+		limit := plan.Limit(quota)
+		if limit == nil {
+			continue
+		}
+		if used := usage.Used(quota); used >= int64(*limit) {
+			return &QuotaExceededError{
+				Quota:      quota,
+				Plan:       plan.Name,
+				Limit:      int64(*limit),
+				Used:       used,
+				UpgradeURL: s.upgradeURL,
+			}
+		}
+	}
+	return nil
+}
+
+func (s *QuotaService) usage(ctx context.Context, userID uint) (*entity.Usage, error) {
+	start, end := entity.BillingPeriod(s.now())
+	usage, err := s.usageRepo.UserUsage(ctx, userID, start)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	usage.PeriodStart = start
+	usage.PeriodEnd = end
+	return usage, nil
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_short/domain/plan/entity"
+	"go_short/domain/plan/repository"
+)
+
+type fakePlanRepo struct {
+	repository.PlanRepository
+	plans map[uint]*entity.Plan
+	err   error
+}
+
+func (r *fakePlanRepo) FindByUserID(ctx context.Context, userID uint) (*entity.Plan, error) {
+	return r.plans[userID], r.err
+}
+
+// fakeUsageRepo 返回固定用量並記錄查詢的起算時間
+type fakeUsageRepo struct {
+	usage       entity.Usage
+	err         error
+	calls       int
+	periodStart time.Time
+}
+
+func (r *fakeUsageRepo) UserUsage(ctx context.Context, userID uint, periodStart time.Time) (*entity.Usage, error) {
+	r.calls++
+	r.periodStart = periodStart
+	if r.err != nil {
+		return nil, r.err
+	}
+	usage := r.usage
+	return &usage, nil
+}
+
+func limit(n int) *int {
+	return &n
+}
+
+func newTestQuotaService(plan *entity.Plan, usage entity.Usage) (*QuotaService, *fakeUsageRepo) {
+	usageRepo := &fakeUsageRepo{usage: usage}
+	s := NewQuotaService(&fakePlanRepo{plans: map[uint]*entity.Plan{1: plan}}, usageRepo, "https://example.com/upgrade")
+	s.now = func() time.Time { return time.Date(2024, 3, 15, 23, 0, 0, 0, time.FixedZone("UTC+8", 8*3600)) }
+	return s, usageRepo
+}
+
+func TestCheckLinkCreation(t *testing.T) {
+	free := &entity.Plan{Name: entity.PlanFree, MaxLinks: limit(10), MonthlyLinks: limit(5), MaxCustomAliases: limit(1)}
+	tests := []struct {
+		name        string
+		usage       entity.Usage
+		customAlias bool
+		wantQuota   string // 空字串表示允許
+	}{
+		{name: "under every limit", usage: entity.Usage{Links: 9, MonthlyLinks: 4}},
+		{name: "links reached", usage: entity.Usage{Links: 10, MonthlyLinks: 0}, wantQuota: entity.QuotaLinks},
+		{name: "monthly links reached", usage: entity.Usage{Links: 3, MonthlyLinks: 5}, wantQuota: entity.QuotaMonthlyLinks},
+		{name: "aliases ignored without alias", usage: entity.Usage{CustomAliases: 1}},
+		{name: "aliases reached", usage: entity.Usage{CustomAliases: 1}, customAlias: true, wantQuota: entity.QuotaCustomAliases},
+		{name: "first exhausted quota wins", usage: entity.Usage{Links: 10, MonthlyLinks: 5, CustomAliases: 1}, customAlias: true, wantQuota: entity.QuotaLinks},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestQuotaService(free, tt.usage)
+			err := s.CheckLinkCreation(context.Background(), 1, tt.customAlias)
+			if tt.wantQuota == "" {
+				if err != nil {
+					t.Fatalf("CheckLinkCreation: %v", err)
+				}
+				return
+			}
+			var exceeded *QuotaExceededError
+			if !errors.As(err, &exceeded) || !errors.Is(err, ErrQuotaExceeded) {
+				t.Fatalf("err = %v, want a QuotaExceededError", err)
+			}
+			if exceeded.Quota != tt.wantQuota || exceeded.Plan != entity.PlanFree || exceeded.UpgradeURL != "https://example.com/upgrade" {
+				t.Errorf("got %+v, want quota %s of plan free with the upgrade URL", exceeded, tt.wantQuota)
+			}
+			if exceeded.Used < exceeded.Limit {
+				t.Errorf("used %d is below limit %d", exceeded.Used, exceeded.Limit)
+			}
+		})
+	}
+}
+
+func TestCheckLinkCreationCountsFromStartOfUTCMonth(t *testing.T) {
+	s, usageRepo := newTestQuotaService(&entity.Plan{Name: entity.PlanFree, MonthlyLinks: limit(5)}, entity.Usage{})
+	if err := s.CheckLinkCreation(context.Background(), 1, false); err != nil {
+		t.Fatal(err)
+	}
+	// 台北時間 3/15 23:00 是 UTC 3/15 15:00
+	if want := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC); !usageRepo.periodStart.Equal(want) {
+		t.Errorf("period start = %s, want %s", usageRepo.periodStart, want)
+	}
+}
+
+func TestUnlimitedPlanSkipsUsage(t *testing.T) {
+	s, usageRepo := newTestQuotaService(&entity.Plan{Name: entity.PlanBusiness}, entity.Usage{Links: 1 << 40})
+	if err := s.CheckLinkCreation(context.Background(), 1, true); err != nil {
+		t.Fatalf("CheckLinkCreation: %v", err)
+	}
+	if err := s.CheckDomainCreation(context.Background(), 1); err != nil {
+		t.Fatalf("CheckDomainCreation: %v", err)
+	}
+	if usageRepo.calls != 0 {
+		t.Errorf("usage queried %d times, want none for an unlimited plan", usageRepo.calls)
+	}
+}
+
+func TestCheckDomainCreation(t *testing.T) {
+	pro := &entity.Plan{Name: entity.PlanPro, MaxLinks: limit(1), MaxCustomDomains: limit(2)}
+
+	s, _ := newTestQuotaService(pro, entity.Usage{Links: 5, CustomDomains: 1})
+	if err := s.CheckDomainCreation(context.Background(), 1); err != nil {
+		t.Fatalf("domains are not limited by the link quota: %v", err)
+	}
+
+	s, _ = newTestQuotaService(pro, entity.Usage{CustomDomains: 2})
+	var exceeded *QuotaExceededError
+	if err := s.CheckDomainCreation(context.Background(), 1); !errors.As(err, &exceeded) || exceeded.Quota != entity.QuotaCustomDomains {
+		t.Fatalf("err = %v, want the custom_domains quota", err)
+	}
+	if exceeded.Error() != "custom_domains quota exceeded for plan pro (2/2)" {
+		t.Errorf("message = %q", exceeded.Error())
+	}
+}
+
+func TestCheckQuotaErrors(t *testing.T) {
+	s := NewQuotaService(&fakePlanRepo{}, &fakeUsageRepo{}, "")
+	if err := s.CheckLinkCreation(context.Background(), 2, false); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("unknown user: err = %v, want ErrUserNotFound", err)
+	}
+
+	s = NewQuotaService(&fakePlanRepo{err: errors.New("connection reset")}, &fakeUsageRepo{}, "")
+	if err := s.CheckLinkCreation(context.Background(), 1, false); !errors.Is(err, ErrDatabaseError) {
+		t.Errorf("plan lookup failure: err = %v, want ErrDatabaseError", err)
+	}
+
+	plans := &fakePlanRepo{plans: map[uint]*entity.Plan{1: {Name: entity.PlanFree, MaxLinks: limit(1)}}}
+	s = NewQuotaService(plans, &fakeUsageRepo{err: errors.New("timeout")}, "")
+	if err := s.CheckLinkCreation(context.Background(), 1, false); !errors.Is(err, ErrDatabaseError) {
+		t.Errorf("usage failure: err = %v, want ErrDatabaseError", err)
+	}
+}
@@ -0,0 +1,64 @@
+package entity
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErasureRecord 是個人資料清除的稽核記錄
+// 記錄只能新增不能修改，每筆記錄的雜湊值包含前一筆的雜湊值，形成可檢查竄改的雜湊鏈
+type ErasureRecord struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	CreatedAt   time.Time `json:"created_at" gorm:"not null"`
+	SubjectID   uint      `json:"subject_id" gorm:"not null;index"`                  // 被清除資料的使用者 ID
+	RequestedBy uint      `json:"requested_by" gorm:"not null"`                      // 執行清除的管理員 ID
+	Reason      string    `json:"reason" gorm:"type:varchar(500);not null"`          // 清除原因或請求編號
+	Summary     string    `json:"summary" gorm:"type:text;not null"`                 // 各資料表受影響筆數 (JSON)
+	PrevHash    string    `json:"prev_hash" gorm:"type:varchar(64);not null"`        // 前一筆記錄的雜湊值，第一筆為空字串
+	Hash        string    `json:"hash" gorm:"type:varchar(64);not null;uniqueIndex"` // 本筆記錄的 SHA-256 雜湊值
+}
+
+// TableName 指定資料表名稱
+func (ErasureRecord) TableName() string {
+	return "erasure_records"
+}
+
+// Seal 以前一筆記錄的雜湊值計算本筆記錄的雜湊值
+// 時間截斷至微秒，與資料庫儲存的精度一致，確保讀回後可重新計算出相同結果
+func (r *ErasureRecord) Seal(prevHash string) {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.CreatedAt = r.CreatedAt.UTC().Truncate(time.Microsecond)
+	r.PrevHash = prevHash
+	r.Hash = r.ComputeHash()
+}
+
+// ComputeHash 依記錄內容計算雜湊值
+func (r *ErasureRecord) ComputeHash() string {
+	fields := []string{
+		r.PrevHash,
+		strconv.FormatUint(uint64(r.SubjectID), 10),
+		strconv.FormatUint(uint64(r.RequestedBy), 10),
+		r.Reason,
+		r.Summary,
+		r.CreatedAt.UTC().Format(time.RFC3339Nano),
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyChain 依序檢查記錄的雜湊鏈，返回第一筆不一致的記錄 ID，全部一致時返回 0
+func VerifyChain(records []*ErasureRecord) uint {
+	prev := ""
+	for _, record := range records {
+		if record.PrevHash != prev || record.ComputeHash() != record.Hash {
+			return record.ID
+		}
+		prev = record.Hash
+	}
+	return 0
+}
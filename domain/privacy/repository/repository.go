@@ -0,0 +1,21 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"go_short/domain/privacy/entity"
+)
+
+// ErrSubjectNotFound 表示要清除的使用者不存在 (包含已軟刪除的帳號仍視為存在)
+var ErrSubjectNotFound = errors.New("erasure subject not found")
+
+// ErasureRepository 定義了個人資料清除與其稽核記錄的儲存庫介面
+type ErasureRepository interface {
+	// EraseUser 在同一交易中清除或匿名化使用者的個人資料，並將稽核記錄接在雜湊鏈末端
+	// 實作需填入 record.Summary 並以 Seal 計算雜湊值
+	EraseUser(ctx context.Context, userID uint, record *entity.ErasureRecord) error
+
+	// List 依建立順序返回所有稽核記錄
+	List(ctx context.Context) ([]*entity.ErasureRecord, error)
+}
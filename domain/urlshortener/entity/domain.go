@@ -0,0 +1,61 @@
+package entity
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// DomainVerificationPrefix 是 DNS TXT 驗證記錄的名稱前綴
+const DomainVerificationPrefix = "_go-short-challenge."
+
+// DomainVerificationValuePrefix 是 DNS TXT 驗證記錄內容的前綴
+const DomainVerificationValuePrefix = "go-short-verification="
+
+// Domain 代表一個自訂短網域，每個網域擁有獨立的短碼命名空間
+// 同一主機可被多個租戶申請，但只有一筆能通過驗證
+type Domain struct {
+	gorm.Model
+	Host              string     `json:"host" gorm:"type:varchar(255);not null;index:idx_domains_host;uniqueIndex:idx_domains_verified_host,where:deleted_at IS NULL AND verified_at IS NOT NULL"`
+	UserID            *uint      `json:"user_id,omitempty" gorm:"index"`                      // 擁有此網域的使用者
+	WorkspaceID       *uint      `json:"workspace_id,omitempty" gorm:"index"`                 // 擁有此網域的工作區
+	FallbackURL       *string    `json:"fallback_url,omitempty" gorm:"type:varchar(2048)"`    // 短碼不存在時的導向網址
+	VerificationToken string     `json:"verification_token" gorm:"type:varchar(64);not null"` // DNS TXT 驗證用 token
+	VerifiedAt        *time.Time `json:"verified_at,omitempty"`
+}
+
+// TableName 指定資料表名稱
+func (Domain) TableName() string {
+	return "domains"
+}
+
+// IsVerified 檢查網域是否已通過 DNS 驗證
+func (d *Domain) IsVerified() bool {
+	return d.VerifiedAt != nil
+}
+
+// IsOwnedBy 檢查網域是否為指定使用者的個人網域 (不屬於任何工作區)
+func (d *Domain) IsOwnedBy(userID uint) bool {
+	return d.WorkspaceID == nil && d.UserID != nil && *d.UserID == userID
+}
+
+// VerificationRecordName 返回需要設定 TXT 記錄的 DNS 名稱
+func (d *Domain) VerificationRecordName() string {
+	return DomainVerificationPrefix + d.Host
+}
+
+// VerificationRecordValue 返回 TXT 記錄應包含的內容
+func (d *Domain) VerificationRecordValue() string {
+	return DomainVerificationValuePrefix + d.VerificationToken
+}
+
+// NormalizeHost 將 Host 標頭或使用者輸入轉為統一格式 (小寫、去除埠號與結尾的點)
+func NormalizeHost(host string) string {
+	host = strings.TrimSpace(strings.ToLower(host))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.TrimSuffix(host, ".")
+}
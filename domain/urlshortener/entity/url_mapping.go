@@ -9,12 +9,16 @@ import (
 // URLMapping 是 URL 縮短服務的核心實體
 type URLMapping struct {
 	gorm.Model
-	ShortURL    *string    `json:"short_url" gorm:"column:short_url;type:varchar(255);unique"`
-	OriginalURL string     `json:"original_url" gorm:"column:original_url;type:varchar(255);not null"` // 修改 unique 為 not null
+	ShortURL    *string    `json:"short_url" gorm:"column:short_url;type:varchar(255);index;uniqueIndex:idx_url_mappings_default_short_url,where:domain_id IS NULL;uniqueIndex:idx_url_mappings_domain_short_url,priority:2,where:domain_id IS NOT NULL"` // 在同一網域內唯一
+	OriginalURL string     `json:"original_url" gorm:"column:original_url;type:varchar(255);not null;index"`                                                                                                                                              // 修改 unique 為 not null
 	Algorithm   string     `json:"algorithm" gorm:"type:varchar(50);default:'base62'"`
 	Visits      int        `json:"visits" gorm:"default:0"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty" gorm:"index"`
 	UserID      *uint      `json:"user_id,omitempty" gorm:"index"`
+	DomainID    *uint      `json:"domain_id,omitempty" gorm:"index;uniqueIndex:idx_url_mappings_domain_short_url,priority:1,where:domain_id IS NOT NULL"` // 為 nil 時屬於預設網域
+	WorkspaceID *uint      `json:"workspace_id,omitempty" gorm:"index"`                                                                                   // 為 nil 時屬於個人
+	DisabledAt  *time.Time `json:"disabled_at,omitempty"`                                                                                                 // 被管理員停用的時間
+	CustomAlias bool       `json:"custom_alias" gorm:"default:false"`                                                                                     // 短碼是否由使用者自訂
 }
 
 // TableName 指定資料表名稱
@@ -30,6 +34,16 @@ func (u *URLMapping) IsExpired() bool {
 	return time.Now().After(*u.ExpiresAt)
 }
 
+// IsDisabled 檢查連結是否已被管理員停用
+func (u *URLMapping) IsDisabled() bool {
+	return u.DisabledAt != nil
+}
+
+// IsOwnedBy 檢查連結是否為指定使用者的個人連結 (不屬於任何工作區)
+func (u *URLMapping) IsOwnedBy(userID uint) bool {
+	return u.WorkspaceID == nil && u.UserID != nil && *u.UserID == userID
+}
+
 // IncrementVisits 增加訪問計數
 func (u *URLMapping) IncrementVisits() {
 	u.Visits++
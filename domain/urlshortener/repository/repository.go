@@ -7,35 +7,93 @@ import (
 	"go_short/domain/urlshortener/entity"
 )
 
+// LinkScope 描述連結所屬的網域與擁有者，nil 欄位表示「未設定」而非「任意」
+type LinkScope struct {
+	DomainID    *uint
+	UserID      *uint
+	WorkspaceID *uint
+}
+
+// LinkStats 是全系統連結的統計數據
+type LinkStats struct {
+	TotalLinks    int64 `json:"total_links"`
+	TotalVisits   int64 `json:"total_visits"`
+	ExpiredLinks  int64 `json:"expired_links"`
+	DisabledLinks int64 `json:"disabled_links"`
+}
+
 // URLRepository 定義了 URL 映射的儲存庫介面
 type URLRepository interface {
-	// FindByShortURL 根據短 URL 查找映射
-	FindByShortURL(ctx context.Context, shortURL string) (*entity.URLMapping, error)
-	
-	// FindByOriginalURL 根據原始 URL 查找映射
-	FindByOriginalURL(ctx context.Context, originalURL string) (*entity.URLMapping, error)
-	
+	// FindByID 根據 ID 查找映射
+	FindByID(ctx context.Context, id uint) (*entity.URLMapping, error)
+
+	// FindByShortURL 在指定網域內根據短 URL 查找映射 (domainID 為 nil 表示預設網域)
+	FindByShortURL(ctx context.Context, domainID *uint, shortURL string) (*entity.URLMapping, error)
+
+	// ShortURLExists 檢查短碼在指定網域內是否已被使用 (包含已刪除的連結，唯一索引不排除軟刪除)
+	ShortURLExists(ctx context.Context, domainID *uint, shortURL string) (bool, error)
+
+	// FindByOriginalURL 在相同網域與擁有者範圍內根據原始 URL 查找未停用且未過期的映射
+	FindByOriginalURL(ctx context.Context, scope LinkScope, originalURL string) (*entity.URLMapping, error)
+
+	// FindByUserID 獲取使用者的個人連結 (不含工作區連結)
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.URLMapping, error)
+
+	// FindByWorkspaceID 獲取工作區的所有連結
+	FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.URLMapping, error)
+
 	// Save 保存 URL 映射
 	Save(ctx context.Context, mapping *entity.URLMapping) error
-	
+
 	// Update 更新 URL 映射
 	Update(ctx context.Context, mapping *entity.URLMapping) error
-	
-	// FindAll 獲取所有 URL 映射
-	FindAll(ctx context.Context) ([]*entity.URLMapping, error)
-	
-	// DeleteExpired 刪除所有過期的 URL 映射
-	DeleteExpired(ctx context.Context) error
+
+	// Delete 刪除 URL 映射
+	Delete(ctx context.Context, id uint) error
+
+	// FindPage 依 ID 順序獲取一頁 URL 映射，並返回總數
+	FindPage(ctx context.Context, offset, limit int) ([]*entity.URLMapping, int64, error)
+
+	// FindExpired 獲取在 now 之前已過期的 URL 映射
+	FindExpired(ctx context.Context, now time.Time) ([]*entity.URLMapping, error)
+
+	// Stats 返回全系統連結的統計數據
+	Stats(ctx context.Context) (*LinkStats, error)
+}
+
+// DomainRepository 定義了自訂網域的儲存庫介面
+type DomainRepository interface {
+	// Create 創建一個新網域
+	Create(ctx context.Context, domain *entity.Domain) error
+
+	// FindByID 根據 ID 查找網域
+	FindByID(ctx context.Context, id uint) (*entity.Domain, error)
+
+	// FindByHost 根據主機名稱查找網域；同一主機可有多筆未驗證的申請，
+	// 有已驗證的網域時返回該網域，否則返回最早的申請
+	FindByHost(ctx context.Context, host string) (*entity.Domain, error)
+
+	// FindByUserID 獲取使用者的個人網域 (不含工作區網域)
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.Domain, error)
+
+	// FindByWorkspaceID 獲取工作區的所有網域
+	FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.Domain, error)
+
+	// Update 更新網域
+	Update(ctx context.Context, domain *entity.Domain) error
+
+	// Delete 刪除網域
+	Delete(ctx context.Context, id uint) error
 }
 
 // CacheRepository 定義了 URL 映射的緩存儲存庫介面
 type CacheRepository interface {
 	// Get 從緩存中獲取 URL 映射
 	Get(ctx context.Context, shortURL string) (string, bool)
-	
+
 	// Set 將 URL 映射保存到緩存
 	Set(ctx context.Context, shortURL string, originalURL string, expiration time.Duration) error
-	
+
 	// Delete 從緩存中刪除 URL 映射
 	Delete(ctx context.Context, shortURL string) error
 }
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"go_short/domain/urlshortener/entity"
+	"go_short/domain/urlshortener/repository"
+)
+
+// Domain 相關錯誤定義
+var (
+	ErrInvalidDomain            = errors.New("invalid domain name")
+	ErrDomainNotFound           = errors.New("domain not found")
+	ErrDomainAlreadyExists      = errors.New("domain already registered")
+	ErrDomainNotVerified        = errors.New("domain has not been verified")
+	ErrDomainVerificationFailed = errors.New("domain verification TXT record not found")
+)
+
+var hostPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z][a-z0-9-]{0,62}$`)
+
+// TXTResolver 抽象 DNS TXT 記錄查詢，方便在測試中替換為假的實作
+type TXTResolver interface {
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// DomainService 負責自訂網域的註冊、驗證與管理
+type DomainService struct {
+	domainRepo repository.DomainRepository
+	cacheRepo  repository.CacheRepository
+	resolver   TXTResolver
+}
+
+// NewDomainService 創建一個新的網域服務
+func NewDomainService(domainRepo repository.DomainRepository, cacheRepo repository.CacheRepository, resolver TXTResolver) *DomainService {
+	return &DomainService{
+		domainRepo: domainRepo,
+		cacheRepo:  cacheRepo,
+		resolver:   resolver,
+	}
+}
+
+// RegisterDomain 註冊一個新的網域，需完成 DNS 驗證後才能使用
+// workspaceID 不為 nil 時網域屬於該工作區，否則屬於 userID 個人；
+// 只有已驗證的主機不能再申請，未驗證的申請不會阻擋真正的擁有者
+func (s *DomainService) RegisterDomain(ctx context.Context, userID uint, workspaceID *uint, host string, fallbackURL *string) (*entity.Domain, error) {
+	host = entity.NormalizeHost(host)
+	if !hostPattern.MatchString(host) {
+		return nil, ErrInvalidDomain
+	}
+
+	existing, err := s.domainRepo.FindByHost(ctx, host)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if existing != nil && existing.IsVerified() {
+		return nil, ErrDomainAlreadyExists
+	}
+
+	token, err := newVerificationToken()
+	if err != nil {
+		return nil, err
+	}
+
+	domain := &entity.Domain{
+		Host:              host,
+		UserID:            &userID,
+		WorkspaceID:       workspaceID,
+		FallbackURL:       fallbackURL,
+		VerificationToken: token,
+	}
+	if err := s.domainRepo.Create(ctx, domain); err != nil {
+		return nil, ErrDatabaseError
+	}
+	return domain, nil
+}
+
+// FindByID 根據 ID 獲取網域
+func (s *DomainService) FindByID(ctx context.Context, domainID uint) (*entity.Domain, error) {
+	domain, err := s.domainRepo.FindByID(ctx, domainID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+// FindByHost 根據主機名稱獲取網域，有已驗證的網域時優先返回
+func (s *DomainService) FindByHost(ctx context.Context, host string) (*entity.Domain, error) {
+	domain, err := s.domainRepo.FindByHost(ctx, entity.NormalizeHost(host))
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if domain == nil {
+		return nil, ErrDomainNotFound
+	}
+	return domain, nil
+}
+
+// ListUserDomains 獲取使用者的個人網域
+func (s *DomainService) ListUserDomains(ctx context.Context, userID uint) ([]*entity.Domain, error) {
+	domains, err := s.domainRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return domains, nil
+}
+
+// ListWorkspaceDomains 獲取工作區的網域
+func (s *DomainService) ListWorkspaceDomains(ctx context.Context, workspaceID uint) ([]*entity.Domain, error) {
+	domains, err := s.domainRepo.FindByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return domains, nil
+}
+
+// VerifyDomain 透過 DNS TXT 記錄驗證網域所有權，主機已由其他申請驗證時返回 ErrDomainAlreadyExists
+func (s *DomainService) VerifyDomain(ctx context.Context, domain *entity.Domain) (*entity.Domain, error) {
+	if domain.IsVerified() {
+		return domain, nil
+	}
+
+	existing, err := s.domainRepo.FindByHost(ctx, domain.Host)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if existing != nil && existing.IsVerified() && existing.ID != domain.ID {
+		return nil, ErrDomainAlreadyExists
+	}
+
+	records, err := s.resolver.LookupTXT(ctx, domain.VerificationRecordName())
+	if err != nil {
+		slog.WarnContext(ctx, "TXT lookup failed", "record_name", domain.VerificationRecordName(), "error", err)
+		return nil, ErrDomainVerificationFailed
+	}
+
+	expected := domain.VerificationRecordValue()
+	for _, record := range records {
+		if strings.TrimSpace(record) == expected {
+			now := time.Now()
+			domain.VerifiedAt = &now
+			if err := s.domainRepo.Update(ctx, domain); err != nil {
+				domain.VerifiedAt = nil
+				// 檢查後到寫入前被其他申請搶先驗證時，由唯一索引擋下
+				if existing, checkErr := s.domainRepo.FindByHost(ctx, domain.Host); checkErr == nil && existing != nil && existing.IsVerified() {
+					return nil, ErrDomainAlreadyExists
+				}
+				return nil, ErrDatabaseError
+			}
+			s.invalidateHost(ctx, domain.Host)
+			return domain, nil
+		}
+	}
+	return nil, ErrDomainVerificationFailed
+}
+
+// UpdateFallbackURL 更新網域在短碼不存在時的導向網址 (nil 表示移除)
+func (s *DomainService) UpdateFallbackURL(ctx context.Context, domain *entity.Domain, fallbackURL *string) (*entity.Domain, error) {
+	domain.FallbackURL = fallbackURL
+	if err := s.domainRepo.Update(ctx, domain); err != nil {
+		return nil, ErrDatabaseError
+	}
+	return domain, nil
+}
+
+// DeleteDomain 刪除網域
+func (s *DomainService) DeleteDomain(ctx context.Context, domain *entity.Domain) error {
+	if err := s.domainRepo.Delete(ctx, domain.ID); err != nil {
+		return ErrDatabaseError
+	}
+	s.invalidateHost(ctx, domain.Host)
+	return nil
+}
+
+// invalidateHost 清除重定向流程中快取的網域解析結果
+func (s *DomainService) invalidateHost(ctx context.Context, host string) {
+	s.cacheRepo.Delete(ctx, domainCacheKey(host))
+}
+
+func newVerificationToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_short/domain/urlshortener/entity"
+	"go_short/domain/urlshortener/repository"
+)
+
+// fakeDomainRepo 以記憶體保存網域，FindByHost 與 GORM 實作相同：已驗證的網域優先，其次是最早的申請
+type fakeDomainRepo struct {
+	repository.DomainRepository
+	domains []*entity.Domain
+}
+
+func (r *fakeDomainRepo) Create(_ context.Context, domain *entity.Domain) error {
+	domain.ID = uint(len(r.domains) + 1)
+	r.domains = append(r.domains, domain)
+	return nil
+}
+
+func (r *fakeDomainRepo) FindByHost(_ context.Context, host string) (*entity.Domain, error) {
+	var found *entity.Domain
+	for _, domain := range r.domains {
+		if domain.Host != host {
+			continue
+		}
+		if domain.IsVerified() {
+			return domain, nil
+		}
+		if found == nil {
+			found = domain
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeDomainRepo) Update(context.Context, *entity.Domain) error {
+	return nil
+}
+
+type fakeCacheRepo struct {
+	repository.CacheRepository
+}
+
+func (fakeCacheRepo) Delete(context.Context, string) error {
+	return nil
+}
+
+// fakeResolver 返回預先設定的 TXT 記錄
+type fakeResolver map[string][]string
+
+func (r fakeResolver) LookupTXT(_ context.Context, name string) ([]string, error) {
+	return r[name], nil
+}
+
+func TestUnverifiedClaimDoesNotBlockOtherTenant(t *testing.T) {
+	ctx := context.Background()
+	repo := &fakeDomainRepo{}
+	resolver := fakeResolver{}
+	svc := NewDomainService(repo, fakeCacheRepo{}, resolver)
+
+	claimA, err := svc.RegisterDomain(ctx, 1, nil, "go.example.com", nil)
+	if err != nil {
+		t.Fatalf("tenant A register: %v", err)
+	}
+	workspaceID := uint(7)
+	claimB, err := svc.RegisterDomain(ctx, 2, &workspaceID, "GO.example.com.", nil)
+	if err != nil {
+		t.Fatalf("tenant B register while A's claim is unverified: %v", err)
+	}
+
+	resolver[claimB.VerificationRecordName()] = []string{claimB.VerificationRecordValue()}
+	if _, err := svc.VerifyDomain(ctx, claimB); err != nil {
+		t.Fatalf("tenant B verify: %v", err)
+	}
+	if found, err := svc.FindByHost(ctx, "go.example.com"); err != nil || found.ID != claimB.ID {
+		t.Fatalf("FindByHost = %v, %v; want tenant B's domain", found, err)
+	}
+
+	// A 之後才設定 TXT 記錄也不能取得已被驗證的主機
+	resolver[claimA.VerificationRecordName()] = append(resolver[claimA.VerificationRecordName()], claimA.VerificationRecordValue())
+	if _, err := svc.VerifyDomain(ctx, claimA); !errors.Is(err, ErrDomainAlreadyExists) {
+		t.Fatalf("tenant A verify after B: err = %v, want ErrDomainAlreadyExists", err)
+	}
+	if claimA.IsVerified() {
+		t.Fatal("tenant A's claim must stay unverified")
+	}
+	if _, err := svc.RegisterDomain(ctx, 3, nil, "go.example.com", nil); !errors.Is(err, ErrDomainAlreadyExists) {
+		t.Fatalf("register verified host: err = %v, want ErrDomainAlreadyExists", err)
+	}
+}
+
+func TestVerifyDomainKeepsVerifiedDomain(t *testing.T) {
+	now := time.Now()
+	domain := &entity.Domain{Host: "go.example.com", VerifiedAt: &now}
+	svc := NewDomainService(&fakeDomainRepo{}, fakeCacheRepo{}, fakeResolver{})
+
+	verified, err := svc.VerifyDomain(context.Background(), domain)
+	if err != nil || verified != domain {
+		t.Fatalf("VerifyDomain = %v, %v; want the same verified domain", verified, err)
+	}
+}
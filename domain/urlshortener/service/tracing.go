@@ -0,0 +1,23 @@
+package service
+
+import (
+	"errors"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer 建立 URLService 的 span；未設定 TracerProvider 時不做任何事
+var tracer = otel.Tracer("go_short/domain/urlshortener/service")
+
+// endSpan 記錄錯誤並結束 span；連結不存在、已過期等預期內的結果不標記為失敗
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if errors.Is(err, ErrDatabaseError) || errors.Is(err, ErrCacheError) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
@@ -2,87 +2,235 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
+	"go_short/domain/event"
 	"go_short/domain/urlshortener/entity"
 	"go_short/domain/urlshortener/repository"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // URLService 錯誤定義
 var (
-	ErrURLNotFound     = errors.New("URL not found")
-	ErrURLExpired      = errors.New("URL has expired")
-	ErrInvalidURL      = errors.New("invalid URL format")
-	ErrDatabaseError   = errors.New("database operation failed")
-	ErrCacheError      = errors.New("cache operation failed")
+	ErrURLNotFound   = errors.New("URL not found")
+	ErrURLExpired    = errors.New("URL has expired")
+	ErrURLDisabled   = errors.New("URL has been disabled")
+	ErrInvalidURL    = errors.New("invalid URL format")
+	ErrDatabaseError = errors.New("database operation failed")
+	ErrCacheError    = errors.New("cache operation failed")
+	ErrInvalidAlias  = errors.New("alias must be 3-64 characters of letters, digits, '-' or '_' and cannot be a reserved path")
+	ErrAliasTaken    = errors.New("alias is already in use")
 )
 
+// aliasPattern 是自訂短碼允許的格式
+var aliasPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{2,63}$`)
+
+// reservedAliases 是與 API 路徑衝突、不可作為自訂短碼的名稱 (不分大小寫)
+// gin 會先比對固定路徑再比對 /:shortURL，router 新增頂層路徑時必須一併加入
+var reservedAliases = map[string]bool{
+	"admin":       true,
+	"api-keys":    true,
+	"auth":        true,
+	"domains":     true,
+	"healthz":     true,
+	"invitations": true,
+	"me":          true,
+	"metrics":     true,
+	"ping":        true,
+	"plans":       true,
+	"readyz":      true,
+	"url_mapping": true,
+	"webhooks":    true,
+	"workspaces":  true,
+}
+
+// maxGenerateAttempts 是產生的短碼與既有短碼 (例如自訂短碼) 衝突時的重試次數
+const maxGenerateAttempts = 3
+
+// domainCacheTTL 是主機名稱解析結果的快取時間
+const domainCacheTTL = 5 * time.Minute
+
+// CreateOptions 是創建短 URL 時的可選參數
+type CreateOptions struct {
+	ExpiresIn   *time.Duration // 過期時間
+	UserID      *uint          // 建立者，匿名建立時為 nil
+	WorkspaceID *uint          // 所屬工作區，個人連結時為 nil
+	DomainID    *uint          // 已驗證的自訂網域，nil 表示預設網域
+	Alias       string         // 自訂短碼，空字串表示依算法產生
+}
+
 // URLShortenerService 定義了 URL 縮短服務的介面
 type URLShortenerService interface {
 	// CreateShortURL 創建一個新的短 URL
-	CreateShortURL(ctx context.Context, originalURL string, algorithm string, expiresIn *time.Duration) (*entity.URLMapping, error)
-	
-	// GetOriginalURL 根據短 URL 獲取原始 URL
-	GetOriginalURL(ctx context.Context, shortURL string) (string, error)
-	
-	// GetAllURLMappings 獲取所有 URL 映射
-	GetAllURLMappings(ctx context.Context) ([]*entity.URLMapping, error)
-	
-	// CleanupExpiredURLs 清理過期的 URL 映射
-	CleanupExpiredURLs(ctx context.Context) error
+	CreateShortURL(ctx context.Context, originalURL string, algorithm string, opts CreateOptions) (*entity.URLMapping, error)
+
+	// GetOriginalURL 根據請求的主機名稱與短 URL 獲取原始 URL，並發布點擊事件
+	GetOriginalURL(ctx context.Context, host string, shortURL string, visit event.Visit) (string, error)
+
+	// GetURLMapping 根據 ID 獲取 URL 映射
+	GetURLMapping(ctx context.Context, id uint) (*entity.URLMapping, error)
+
+	// ListUserURLMappings 獲取使用者的個人 URL 映射
+	ListUserURLMappings(ctx context.Context, userID uint) ([]*entity.URLMapping, error)
+
+	// ListWorkspaceURLMappings 獲取工作區的 URL 映射
+	ListWorkspaceURLMappings(ctx context.Context, workspaceID uint) ([]*entity.URLMapping, error)
+
+	// UpdateURLMapping 保存對 URL 映射的修改並更新緩存
+	UpdateURLMapping(ctx context.Context, mapping *entity.URLMapping) error
+
+	// DeleteURLMapping 刪除 URL 映射並清除緩存
+	DeleteURLMapping(ctx context.Context, mapping *entity.URLMapping) error
+
+	// SetURLMappingDisabled 停用或重新啟用 URL 映射
+	SetURLMappingDisabled(ctx context.Context, mapping *entity.URLMapping, disabled bool) error
+
+	// ListURLMappings 依 ID 順序獲取一頁 URL 映射，並返回總數
+	ListURLMappings(ctx context.Context, offset, limit int) ([]*entity.URLMapping, int64, error)
+
+	// GetLinkStats 獲取全系統連結的統計數據
+	GetLinkStats(ctx context.Context) (*repository.LinkStats, error)
+
+	// CleanupExpiredURLs 清理過期的 URL 映射，返回已刪除的連結數
+	CleanupExpiredURLs(ctx context.Context) (int64, error)
 }
 
 // URLService 是 URLShortenerService 的實現
 type URLService struct {
 	urlRepo       repository.URLRepository
+	domainRepo    repository.DomainRepository
 	cacheRepo     repository.CacheRepository
 	cacheDuration time.Duration
+	outbox        event.Outbox
+	clicks        event.Publisher
 }
 
 // NewURLService 創建一個新的 URL 服務
-func NewURLService(urlRepo repository.URLRepository, cacheRepo repository.CacheRepository, cacheDuration time.Duration) *URLService {
+// 連結的建立、修改、刪除與過期事件與狀態變更一起寫入 outbox；點擊事件直接交給 clicks
+// outbox 或 clicks 為 nil 時不發布對應的領域事件
+func NewURLService(urlRepo repository.URLRepository, domainRepo repository.DomainRepository, cacheRepo repository.CacheRepository, cacheDuration time.Duration, outbox event.Outbox, clicks event.Publisher) *URLService {
 	return &URLService{
 		urlRepo:       urlRepo,
+		domainRepo:    domainRepo,
 		cacheRepo:     cacheRepo,
 		cacheDuration: cacheDuration,
+		outbox:        outbox,
+		clicks:        clicks,
 	}
 }
 
 // CreateShortURL 創建一個新的短 URL
-func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, algorithm string, expiresIn *time.Duration) (*entity.URLMapping, error) {
-	// 檢查 URL 是否已存在
-	existingMapping, err := s.urlRepo.FindByOriginalURL(ctx, originalURL)
+// 指定自訂短碼時一律建立新連結，不與既有的相同網址合併
+func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, algorithm string, opts CreateOptions) (*entity.URLMapping, error) {
+	ctx, span := tracer.Start(ctx, "URLService.CreateShortURL", trace.WithAttributes(
+		attribute.String("link.algorithm", algorithm),
+		attribute.Bool("link.custom_alias", opts.Alias != ""),
+	))
+	urlMapping, err := s.createShortURL(ctx, originalURL, algorithm, opts)
+	endSpan(span, err)
+	return urlMapping, err
+}
+
+func (s *URLService) createShortURL(ctx context.Context, originalURL string, algorithm string, opts CreateOptions) (*entity.URLMapping, error) {
+	if opts.Alias != "" {
+		return s.createWithAlias(ctx, originalURL, algorithm, opts)
+	}
+
+	// 檢查同一網域與擁有者範圍內 URL 是否已存在
+	scope := repository.LinkScope{
+		DomainID:    opts.DomainID,
+		UserID:      opts.UserID,
+		WorkspaceID: opts.WorkspaceID,
+	}
+	existingMapping, err := s.urlRepo.FindByOriginalURL(ctx, scope, originalURL)
 	if err != nil {
 		return nil, ErrDatabaseError
 	}
-	
-	// 如果 URL 已存在，直接返回
-	if existingMapping != nil {
+
+	// 如果 URL 已存在且仍可使用，直接返回；已停用或過期的連結不能交給新的建立者
+	if existingMapping != nil && !existingMapping.IsDisabled() && !existingMapping.IsExpired() {
 		return existingMapping, nil
 	}
-	
-	// 創建新的 URL 映射
-	urlMapping := &entity.URLMapping{
-		OriginalURL: originalURL,
-		Algorithm:   algorithm,
+
+	// 創建新的 URL 映射並保存到數據庫以獲取 ID，產生短碼後更新，與建立事件在同一個交易中提交
+	urlMapping := newURLMapping(originalURL, algorithm, opts)
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.urlRepo.Save(ctx, urlMapping); err != nil {
+			return ErrDatabaseError
+		}
+
+		// 根據算法生成短 URL
+		shortURL, err := s.generateShortURL(ctx, originalURL, algorithm, int(urlMapping.ID), opts.DomainID)
+		if err != nil {
+			return err
+		}
+		urlMapping.ShortURL = shortURL
+
+		// 更新數據庫
+		if err := s.urlRepo.Update(ctx, urlMapping); err != nil {
+			return ErrDatabaseError
+		}
+		return s.record(ctx, event.TypeLinkCreated, urlMapping)
+	})
+	if err != nil {
+		return nil, err
 	}
-	
-	// 設置過期時間（如果有）
-	if expiresIn != nil {
-		expiresAt := time.Now().Add(*expiresIn)
-		urlMapping.ExpiresAt = &expiresAt
+
+	// 緩存 URL 映射
+	if urlMapping.ShortURL != nil {
+		s.cacheMapping(ctx, urlMapping)
+	}
+	return urlMapping, nil
+}
+
+// createWithAlias 以使用者指定的短碼建立連結
+func (s *URLService) createWithAlias(ctx context.Context, originalURL string, algorithm string, opts CreateOptions) (*entity.URLMapping, error) {
+	alias := opts.Alias
+	if !aliasPattern.MatchString(alias) || reservedAliases[strings.ToLower(alias)] {
+		return nil, ErrInvalidAlias
 	}
-	
-	// 保存到數據庫以獲取 ID
-	if err := s.urlRepo.Save(ctx, urlMapping); err != nil {
+
+	taken, err := s.urlRepo.ShortURLExists(ctx, opts.DomainID, alias)
+	if err != nil {
 		return nil, ErrDatabaseError
 	}
-	
-	// 根據算法生成短 URL
-	id := int(urlMapping.ID)
+	if taken {
+		return nil, ErrAliasTaken
+	}
+
+	urlMapping := newURLMapping(originalURL, algorithm, opts)
+	urlMapping.ShortURL = &alias
+	urlMapping.CustomAlias = true
+	err = s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.urlRepo.Save(ctx, urlMapping); err != nil {
+			return err
+		}
+		return s.record(ctx, event.TypeLinkCreated, urlMapping)
+	})
+	if err != nil {
+		// 檢查後到寫入前被其他請求搶先使用時，由唯一索引擋下 (交易回滾後才能再次查詢)
+		if taken, checkErr := s.urlRepo.ShortURLExists(ctx, opts.DomainID, alias); checkErr == nil && taken {
+			return nil, ErrAliasTaken
+		}
+		return nil, ErrDatabaseError
+	}
+
+	s.cacheMapping(ctx, urlMapping)
+	return urlMapping, nil
+}
+
+// generateShortURL 依算法產生短碼；與既有短碼 (例如自訂短碼) 衝突時改用隨機短碼重試
+func (s *URLService) generateShortURL(ctx context.Context, originalURL string, algorithm string, id int, domainID *uint) (*string, error) {
 	var shortener ShortenerStrategy
-	
+
 	switch algorithm {
 	case "base64":
 		shortener = &Base64Strategy{}
@@ -93,62 +241,266 @@ func (s *URLService) CreateShortURL(ctx context.Context, originalURL string, alg
 	default: // base62 是默認值
 		shortener = &Base62Strategy{}
 	}
-	
-	// 生成短 URL
-	urlMapping.ShortURL = shortener.Generate(originalURL, id)
-	
-	// 更新數據庫
-	if err := s.urlRepo.Update(ctx, urlMapping); err != nil {
-		return nil, ErrDatabaseError
-	}
-	
-	// 緩存 URL 映射
-	if urlMapping.ShortURL != nil {
-		cacheExpiration := s.cacheDuration
-		if urlMapping.ExpiresAt != nil {
-			// 如果 URL 有過期時間，使用較短的緩存時間
-			timeUntilExpiry := time.Until(*urlMapping.ExpiresAt)
-			if timeUntilExpiry < cacheExpiration {
-				cacheExpiration = timeUntilExpiry
-			}
+
+	for attempt := 0; ; attempt++ {
+		shortURL := shortener.Generate(originalURL, id)
+		taken, err := s.urlRepo.ShortURLExists(ctx, domainID, *shortURL)
+		if err != nil {
+			return nil, ErrDatabaseError
+		}
+		if !taken || attempt >= maxGenerateAttempts {
+			return shortURL, nil
 		}
-		
-		s.cacheRepo.Set(ctx, *urlMapping.ShortURL, urlMapping.OriginalURL, cacheExpiration)
+		shortener = &RandomStrategy{}
 	}
-	
-	return urlMapping, nil
 }
 
-// GetOriginalURL 根據短 URL 獲取原始 URL
-func (s *URLService) GetOriginalURL(ctx context.Context, shortURL string) (string, error) {
-	// 先從緩存中查找
-	if originalURL, found := s.cacheRepo.Get(ctx, shortURL); found {
-		return originalURL, nil
+// newURLMapping 依建立參數組成尚未保存的 URL 映射
+func newURLMapping(originalURL string, algorithm string, opts CreateOptions) *entity.URLMapping {
+	urlMapping := &entity.URLMapping{
+		OriginalURL: originalURL,
+		Algorithm:   algorithm,
+		UserID:      opts.UserID,
+		WorkspaceID: opts.WorkspaceID,
+		DomainID:    opts.DomainID,
+	}
+
+	// 設置過期時間（如果有）
+	if opts.ExpiresIn != nil {
+		expiresAt := time.Now().Add(*opts.ExpiresIn)
+		urlMapping.ExpiresAt = &expiresAt
+	}
+	return urlMapping
+}
+
+// GetOriginalURL 根據請求的主機名稱與短 URL 獲取原始 URL
+// 若主機為已驗證的自訂網域，則在該網域的命名空間內查找；
+// 找不到短碼時若網域設定了 fallback URL，則返回 fallback URL
+// 訪問次數不在此處更新，而是由點擊事件的訂閱者 (點擊處理任務) 批次累加
+func (s *URLService) GetOriginalURL(ctx context.Context, host string, shortURL string, visit event.Visit) (string, error) {
+	ctx, span := tracer.Start(ctx, "URLService.GetOriginalURL", trace.WithAttributes(
+		attribute.String("link.host", host),
+		attribute.String("link.short_url", shortURL),
+	))
+	originalURL, err := s.getOriginalURL(ctx, host, shortURL, visit)
+	endSpan(span, err)
+	return originalURL, err
+}
+
+func (s *URLService) getOriginalURL(ctx context.Context, host string, shortURL string, visit event.Visit) (string, error) {
+	span := trace.SpanFromContext(ctx)
+	domainID, err := s.resolveDomainID(ctx, host)
+	if err != nil {
+		return "", err
+	}
+
+	// 先從緩存中查找 (舊格式的緩存值無法還原連結資訊，視為未命中)
+	if cached, found := s.cacheRepo.Get(ctx, mappingCacheKey(domainID, shortURL)); found {
+		if link, ok := decodeCachedLink(cached); ok {
+			span.SetAttributes(attribute.Bool("cache.hit", true))
+			s.publishClick(ctx, link, visit)
+			return link.OriginalURL, nil
+		}
 	}
-	
+	span.SetAttributes(attribute.Bool("cache.hit", false))
+
 	// 如果緩存中沒有，從數據庫查找
-	urlMapping, err := s.urlRepo.FindByShortURL(ctx, shortURL)
+	urlMapping, err := s.urlRepo.FindByShortURL(ctx, domainID, shortURL)
 	if err != nil {
 		return "", ErrDatabaseError
 	}
-	
+
 	if urlMapping == nil {
+		if domainID != nil {
+			return s.domainFallback(ctx, *domainID)
+		}
 		return "", ErrURLNotFound
 	}
-	
-	// 檢查 URL 是否過期
+
+	// 檢查 URL 是否被停用或過期
+	if urlMapping.IsDisabled() {
+		return "", ErrURLDisabled
+	}
 	if urlMapping.IsExpired() {
 		return "", ErrURLExpired
 	}
-	
-	// 增加訪問計數
-	urlMapping.IncrementVisits()
-	if err := s.urlRepo.Update(ctx, urlMapping); err != nil {
-		// 這裡我們只記錄錯誤，不阻止用戶訪問
-		// 因為增加訪問計數不是關鍵操作
-	}
-	
+
 	// 緩存結果
+	s.cacheMapping(ctx, urlMapping)
+
+	s.publishClick(ctx, linkSnapshot(urlMapping), visit)
+	return urlMapping.OriginalURL, nil
+}
+
+// GetURLMapping 根據 ID 獲取 URL 映射
+func (s *URLService) GetURLMapping(ctx context.Context, id uint) (*entity.URLMapping, error) {
+	urlMapping, err := s.urlRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if urlMapping == nil {
+		return nil, ErrURLNotFound
+	}
+	return urlMapping, nil
+}
+
+// ListUserURLMappings 獲取使用者的個人 URL 映射
+func (s *URLService) ListUserURLMappings(ctx context.Context, userID uint) ([]*entity.URLMapping, error) {
+	mappings, err := s.urlRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return mappings, nil
+}
+
+// ListWorkspaceURLMappings 獲取工作區的 URL 映射
+func (s *URLService) ListWorkspaceURLMappings(ctx context.Context, workspaceID uint) ([]*entity.URLMapping, error) {
+	mappings, err := s.urlRepo.FindByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return mappings, nil
+}
+
+// UpdateURLMapping 保存對 URL 映射的修改並更新緩存
+func (s *URLService) UpdateURLMapping(ctx context.Context, urlMapping *entity.URLMapping) error {
+	err := s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.urlRepo.Update(ctx, urlMapping); err != nil {
+			return err
+		}
+		return s.record(ctx, event.TypeLinkUpdated, urlMapping)
+	})
+	if err != nil {
+		return ErrDatabaseError
+	}
+	if urlMapping.ShortURL != nil {
+		if urlMapping.IsExpired() || urlMapping.IsDisabled() {
+			s.cacheRepo.Delete(ctx, mappingCacheKey(urlMapping.DomainID, *urlMapping.ShortURL))
+		} else {
+			s.cacheMapping(ctx, urlMapping)
+		}
+	}
+	return nil
+}
+
+// SetURLMappingDisabled 停用或重新啟用 URL 映射，停用後重定向將不再生效
+func (s *URLService) SetURLMappingDisabled(ctx context.Context, urlMapping *entity.URLMapping, disabled bool) error {
+	if disabled == urlMapping.IsDisabled() {
+		return nil
+	}
+	if disabled {
+		now := time.Now()
+		urlMapping.DisabledAt = &now
+	} else {
+		urlMapping.DisabledAt = nil
+	}
+	return s.UpdateURLMapping(ctx, urlMapping)
+}
+
+// DeleteURLMapping 刪除 URL 映射並清除緩存
+func (s *URLService) DeleteURLMapping(ctx context.Context, urlMapping *entity.URLMapping) error {
+	if err := s.deleteWithEvent(ctx, urlMapping, event.TypeLinkDeleted); err != nil {
+		return ErrDatabaseError
+	}
+	if urlMapping.ShortURL != nil {
+		s.cacheRepo.Delete(ctx, mappingCacheKey(urlMapping.DomainID, *urlMapping.ShortURL))
+	}
+	return nil
+}
+
+// ListURLMappings 依 ID 順序獲取一頁 URL 映射，並返回總數
+func (s *URLService) ListURLMappings(ctx context.Context, offset, limit int) ([]*entity.URLMapping, int64, error) {
+	mappings, total, err := s.urlRepo.FindPage(ctx, offset, limit)
+	if err != nil {
+		return nil, 0, ErrDatabaseError
+	}
+	return mappings, total, nil
+}
+
+// GetLinkStats 獲取全系統連結的統計數據
+func (s *URLService) GetLinkStats(ctx context.Context) (*repository.LinkStats, error) {
+	stats, err := s.urlRepo.Stats(ctx)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return stats, nil
+}
+
+// CleanupExpiredURLs 清理過期的 URL 映射，並為每個連結發布 link.expired 事件
+// 返回已刪除的連結數；中途失敗時返回失敗前已刪除的數量
+func (s *URLService) CleanupExpiredURLs(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "URLService.CleanupExpiredURLs")
+	deleted, err := s.cleanupExpiredURLs(ctx)
+	span.SetAttributes(attribute.Int64("cleanup.deleted", deleted))
+	endSpan(span, err)
+	return deleted, err
+}
+
+func (s *URLService) cleanupExpiredURLs(ctx context.Context) (int64, error) {
+	expired, err := s.urlRepo.FindExpired(ctx, time.Now())
+	if err != nil {
+		return 0, ErrDatabaseError
+	}
+	var deleted int64
+	for _, urlMapping := range expired {
+		if err := s.deleteWithEvent(ctx, urlMapping, event.TypeLinkExpired); err != nil {
+			return deleted, ErrDatabaseError
+		}
+		deleted++
+		if urlMapping.ShortURL != nil {
+			s.cacheRepo.Delete(ctx, mappingCacheKey(urlMapping.DomainID, *urlMapping.ShortURL))
+		}
+	}
+	return deleted, nil
+}
+
+// resolveDomainID 將請求的主機名稱解析為已驗證網域的 ID，
+// 非自訂網域 (或尚未驗證) 時返回 nil，表示使用預設網域
+func (s *URLService) resolveDomainID(ctx context.Context, host string) (*uint, error) {
+	host = entity.NormalizeHost(host)
+	if host == "" {
+		return nil, nil
+	}
+
+	key := domainCacheKey(host)
+	if cached, found := s.cacheRepo.Get(ctx, key); found {
+		id, err := strconv.ParseUint(cached, 10, 64)
+		if err == nil {
+			if id == 0 {
+				return nil, nil
+			}
+			domainID := uint(id)
+			return &domainID, nil
+		}
+	}
+
+	domain, err := s.domainRepo.FindByHost(ctx, host)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if domain == nil || !domain.IsVerified() {
+		s.cacheRepo.Set(ctx, key, "0", domainCacheTTL)
+		return nil, nil
+	}
+
+	s.cacheRepo.Set(ctx, key, strconv.FormatUint(uint64(domain.ID), 10), domainCacheTTL)
+	return &domain.ID, nil
+}
+
+// domainFallback 返回網域設定的 fallback URL
+func (s *URLService) domainFallback(ctx context.Context, domainID uint) (string, error) {
+	domain, err := s.domainRepo.FindByID(ctx, domainID)
+	if err != nil {
+		return "", ErrDatabaseError
+	}
+	if domain == nil || domain.FallbackURL == nil || *domain.FallbackURL == "" {
+		return "", ErrURLNotFound
+	}
+	return *domain.FallbackURL, nil
+}
+
+// cacheMapping 將 URL 映射寫入緩存，過期時間不超過映射本身的過期時間
+func (s *URLService) cacheMapping(ctx context.Context, urlMapping *entity.URLMapping) {
 	cacheExpiration := s.cacheDuration
 	if urlMapping.ExpiresAt != nil {
 		// 如果 URL 有過期時間，使用較短的緩存時間
@@ -157,18 +509,91 @@ func (s *URLService) GetOriginalURL(ctx context.Context, shortURL string) (strin
 			cacheExpiration = timeUntilExpiry
 		}
 	}
-	
-	s.cacheRepo.Set(ctx, shortURL, urlMapping.OriginalURL, cacheExpiration)
-	
-	return urlMapping.OriginalURL, nil
+
+	s.cacheRepo.Set(ctx, mappingCacheKey(urlMapping.DomainID, *urlMapping.ShortURL), encodeCachedLink(linkSnapshot(urlMapping)), cacheExpiration)
+}
+
+// deleteWithEvent 刪除 URL 映射並在同一個交易中記錄刪除或過期事件
+func (s *URLService) deleteWithEvent(ctx context.Context, urlMapping *entity.URLMapping, eventType string) error {
+	return s.transaction(ctx, func(ctx context.Context) error {
+		if err := s.urlRepo.Delete(ctx, urlMapping.ID); err != nil {
+			return err
+		}
+		return s.record(ctx, eventType, urlMapping)
+	})
+}
+
+// transaction 在 outbox 的交易中執行 fn；未設定 outbox 時直接執行
+func (s *URLService) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if s.outbox == nil {
+		return fn(ctx)
+	}
+	return s.outbox.Transaction(ctx, fn)
+}
+
+// record 將連結事件寫入 outbox，必須在 transaction 中呼叫
+func (s *URLService) record(ctx context.Context, eventType string, urlMapping *entity.URLMapping) error {
+	if s.outbox == nil {
+		return nil
+	}
+	if err := s.outbox.Append(ctx, event.NewLinkEvent(eventType, linkSnapshot(urlMapping))); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// publishClick 發布點擊事件；點擊不改變連結狀態，不經過 outbox
+func (s *URLService) publishClick(ctx context.Context, link event.Link, visit event.Visit) {
+	if s.clicks == nil {
+		return
+	}
+	s.clicks.Publish(ctx, event.NewClickEvent(link, visit))
+}
+
+// linkSnapshot 返回事件與緩存中使用的連結資訊
+func linkSnapshot(urlMapping *entity.URLMapping) event.Link {
+	link := event.Link{
+		ID:          urlMapping.ID,
+		OriginalURL: urlMapping.OriginalURL,
+		DomainID:    urlMapping.DomainID,
+		UserID:      urlMapping.UserID,
+		WorkspaceID: urlMapping.WorkspaceID,
+		ExpiresAt:   urlMapping.ExpiresAt,
+		DisabledAt:  urlMapping.DisabledAt,
+	}
+	if urlMapping.ShortURL != nil {
+		link.ShortURL = *urlMapping.ShortURL
+	}
+	return link
+}
+
+// encodeCachedLink 將連結資訊編碼為緩存值，讓緩存命中時仍能發布帶有擁有者的點擊事件
+func encodeCachedLink(link event.Link) string {
+	encoded, err := json.Marshal(link)
+	if err != nil {
+		return link.OriginalURL
+	}
+	return string(encoded)
+}
+
+// decodeCachedLink 解析緩存值；舊格式 (只有原始網址) 返回 false
+func decodeCachedLink(value string) (event.Link, bool) {
+	var link event.Link
+	if !strings.HasPrefix(value, "{") || json.Unmarshal([]byte(value), &link) != nil || link.ID == 0 {
+		return event.Link{}, false
+	}
+	return link, true
 }
 
-// GetAllURLMappings 獲取所有 URL 映射
-func (s *URLService) GetAllURLMappings(ctx context.Context) ([]*entity.URLMapping, error) {
-	return s.urlRepo.FindAll(ctx)
+// mappingCacheKey 返回短 URL 的緩存鍵，預設網域沿用短碼本身作為鍵
+func mappingCacheKey(domainID *uint, shortURL string) string {
+	if domainID == nil {
+		return shortURL
+	}
+	return fmt.Sprintf("d:%d:%s", *domainID, shortURL)
 }
 
-// CleanupExpiredURLs 清理過期的 URL 映射
-func (s *URLService) CleanupExpiredURLs(ctx context.Context) error {
-	return s.urlRepo.DeleteExpired(ctx)
+// domainCacheKey 返回主機名稱解析結果的緩存鍵
+func domainCacheKey(host string) string {
+	return "host:" + host
 }
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_short/domain/urlshortener/entity"
+	"go_short/domain/urlshortener/repository"
+)
+
+// staleURLRepo 的 FindByOriginalURL 返回固定的映射，模擬儲存庫遺漏條件的情況
+type staleURLRepo struct {
+	repository.URLRepository
+	existing *entity.URLMapping
+	saved    []*entity.URLMapping
+}
+
+func (r *staleURLRepo) FindByOriginalURL(ctx context.Context, scope repository.LinkScope, originalURL string) (*entity.URLMapping, error) {
+	return r.existing, nil
+}
+
+func (r *staleURLRepo) Save(ctx context.Context, mapping *entity.URLMapping) error {
+	r.saved = append(r.saved, mapping)
+	mapping.ID = uint(100 + len(r.saved))
+	return nil
+}
+
+func (r *staleURLRepo) Update(ctx context.Context, mapping *entity.URLMapping) error {
+	return nil
+}
+
+func (r *staleURLRepo) ShortURLExists(ctx context.Context, domainID *uint, shortURL string) (bool, error) {
+	return false, nil
+}
+
+// nopCache 是永遠未命中的緩存
+type nopCache struct{}
+
+func (nopCache) Get(ctx context.Context, key string) (string, bool) {
+	return "", false
+}
+
+func (nopCache) Set(ctx context.Context, key string, value string, expiration time.Duration) error {
+	return nil
+}
+
+func (nopCache) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+// 與頂層路徑同名的短碼永遠無法轉址，建立時必須被拒絕
+func TestCreateShortURLRejectsReservedAliases(t *testing.T) {
+	s := NewURLService(nil, nil, nil, 0, nil, nil)
+	for _, alias := range []string{"admin", "webhooks", "metrics", "healthz", "readyz", "Webhooks", "READYZ"} {
+		_, err := s.CreateShortURL(context.Background(), "https://example.com", "base62", CreateOptions{Alias: alias})
+		if !errors.Is(err, ErrInvalidAlias) {
+			t.Errorf("alias %q: got error %v, want ErrInvalidAlias", alias, err)
+		}
+	}
+}
+
+// 相同網址的既有連結只有在仍可使用時才會被重用
+func TestCreateShortURLReusesOnlyLiveLinks(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	future := time.Now().Add(time.Hour)
+	tests := []struct {
+		name      string
+		existing  entity.URLMapping
+		wantReuse bool
+	}{
+		{name: "live link", existing: entity.URLMapping{}, wantReuse: true},
+		{name: "link that expires later", existing: entity.URLMapping{ExpiresAt: &future}, wantReuse: true},
+		{name: "expired link", existing: entity.URLMapping{ExpiresAt: &past}},
+		{name: "disabled link", existing: entity.URLMapping{DisabledAt: &past}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := tt.existing
+			existing.ID = 1
+			existing.OriginalURL = "https://example.com"
+			repo := &staleURLRepo{existing: &existing}
+			s := NewURLService(repo, nil, nopCache{}, time.Hour, nil, nil)
+
+			mapping, err := s.CreateShortURL(context.Background(), "https://example.com", "base62", CreateOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reused := mapping.ID == 1; reused != tt.wantReuse {
+				t.Errorf("reused = %t, want %t", reused, tt.wantReuse)
+			}
+			if !tt.wantReuse && (mapping.IsDisabled() || mapping.IsExpired() || len(repo.saved) != 1) {
+				t.Errorf("mapping = %+v, want a new live link", mapping)
+			}
+		})
+	}
+}
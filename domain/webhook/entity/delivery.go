@@ -0,0 +1,108 @@
+package entity
+
+import "time"
+
+// 投遞狀態
+const (
+	DeliveryPending   = "pending"   // 等待 (重新) 發送
+	DeliverySucceeded = "succeeded" // 端點回應 2xx
+	DeliveryDead      = "dead"      // 重試次數用盡或 webhook 已移除，保留作為 dead letter
+)
+
+// Delivery 是一個事件對一個 webhook 的投遞，同時作為投遞記錄與 dead letter
+type Delivery struct {
+	ID             uint       `json:"id" gorm:"primaryKey"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	WebhookID      uint       `json:"webhook_id" gorm:"not null;index"`
+	EventID        string     `json:"event_id" gorm:"type:varchar(64);not null"`
+	EventType      string     `json:"event_type" gorm:"type:varchar(64);not null"`
+	Payload        string     `json:"-" gorm:"type:text;not null"`                     // 發送的 JSON 內容，重試時不變
+	Status         string     `json:"status" gorm:"type:varchar(16);not null;index"`   // 見 Delivery* 常數
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`              // 已嘗試次數
+	NextAttemptAt  *time.Time `json:"next_attempt_at,omitempty" gorm:"index"`          // 下次嘗試時間，pending 時才有值
+	LastAttemptAt  *time.Time `json:"last_attempt_at,omitempty"`                       // 最後一次嘗試的時間
+	ResponseStatus *int       `json:"response_status,omitempty"`                       // 最後一次的 HTTP 狀態碼
+	LastError      *string    `json:"last_error,omitempty" gorm:"type:varchar(1024)"`  // 最後一次失敗的原因
+	DurationMS     *int64     `json:"duration_ms,omitempty" gorm:"column:duration_ms"` // 最後一次請求耗時
+}
+
+// TableName 指定資料表名稱
+func (Delivery) TableName() string {
+	return "webhook_deliveries"
+}
+
+// BackoffPolicy 決定失敗後的重試間隔：第 n 次失敗後等待 Base * 2^(n-1)，最多 Max
+type BackoffPolicy struct {
+	MaxAttempts int
+	Base        time.Duration
+	Max         time.Duration
+}
+
+// DefaultBackoffPolicy 返回預設的重試策略 (最多 8 次，約 2 小時內)
+func DefaultBackoffPolicy() BackoffPolicy {
+	return BackoffPolicy{MaxAttempts: 8, Base: 30 * time.Second, Max: time.Hour}
+}
+
+// Delay 返回第 attempts 次失敗後的等待時間
+func (p BackoffPolicy) Delay(attempts int) time.Duration {
+	delay := p.Base
+	for i := 1; i < attempts && delay < p.Max; i++ {
+		delay *= 2
+	}
+	if delay > p.Max {
+		delay = p.Max
+	}
+	return delay
+}
+
+// RecordAttempt 記錄一次發送結果並決定下一個狀態
+func (d *Delivery) RecordAttempt(now time.Time, status int, duration time.Duration, err error, policy BackoffPolicy) {
+	d.Attempts++
+	d.LastAttemptAt = &now
+	ms := duration.Milliseconds()
+	d.DurationMS = &ms
+	d.ResponseStatus = nil
+	if status != 0 {
+		d.ResponseStatus = &status
+	}
+
+	if err == nil && status >= 200 && status < 300 {
+		d.Status = DeliverySucceeded
+		d.NextAttemptAt = nil
+		d.LastError = nil
+		return
+	}
+
+	message := "unexpected response status"
+	if err != nil {
+		message = err.Error()
+	}
+	if len(message) > 1024 {
+		message = message[:1024]
+	}
+	d.LastError = &message
+
+	if d.Attempts >= policy.MaxAttempts {
+		d.Status = DeliveryDead
+		d.NextAttemptAt = nil
+		return
+	}
+	next := now.Add(policy.Delay(d.Attempts))
+	d.Status = DeliveryPending
+	d.NextAttemptAt = &next
+}
+
+// MarkDead 直接將投遞移入 dead letter，例如 webhook 已被刪除或停用
+func (d *Delivery) MarkDead(reason string) {
+	d.Status = DeliveryDead
+	d.NextAttemptAt = nil
+	d.LastError = &reason
+}
+
+// Requeue 將 dead letter 重新排入佇列並重設嘗試次數
+func (d *Delivery) Requeue(now time.Time) {
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = &now
+}
@@ -0,0 +1,55 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/webhook/entity"
+)
+
+// WebhookRepository 定義了 webhook 的儲存庫介面
+type WebhookRepository interface {
+	// Create 創建 webhook
+	Create(ctx context.Context, webhook *entity.Webhook) error
+
+	// FindByID 根據 ID 查找 webhook，不存在時返回 nil
+	FindByID(ctx context.Context, id uint) (*entity.Webhook, error)
+
+	// FindByUserID 獲取使用者的個人 webhook (不含工作區 webhook)
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.Webhook, error)
+
+	// FindByWorkspaceID 獲取工作區的 webhook
+	FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.Webhook, error)
+
+	// FindActiveForOwner 獲取會收到某資源事件的啟用中 webhook：
+	// workspaceID 不為 nil 時為該工作區的 webhook，否則為 userID 的個人 webhook
+	FindActiveForOwner(ctx context.Context, userID *uint, workspaceID *uint) ([]*entity.Webhook, error)
+
+	// Update 更新 webhook
+	Update(ctx context.Context, webhook *entity.Webhook) error
+
+	// Delete 刪除 webhook
+	Delete(ctx context.Context, id uint) error
+}
+
+// DeliveryRepository 定義了 webhook 投遞記錄的儲存庫介面
+type DeliveryRepository interface {
+	// Create 新增投遞記錄
+	Create(ctx context.Context, deliveries []*entity.Delivery) error
+
+	// FindByID 根據 ID 查找投遞記錄，不存在時返回 nil
+	FindByID(ctx context.Context, id uint) (*entity.Delivery, error)
+
+	// FindByWebhookID 由新到舊列出 webhook 的投遞記錄，status 為空字串時不限狀態
+	FindByWebhookID(ctx context.Context, webhookID uint, status string, offset, limit int) ([]*entity.Delivery, int64, error)
+
+	// ClaimDue 取得最多 limit 筆已到期的待發送投遞，並將其下次嘗試時間延後 lease，
+	// 避免多個實例同時發送同一筆投遞
+	ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.Delivery, error)
+
+	// Update 更新投遞記錄
+	Update(ctx context.Context, delivery *entity.Delivery) error
+
+	// DeleteSucceededBefore 刪除在 before 之前已成功的投遞記錄，返回刪除筆數
+	DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error)
+}
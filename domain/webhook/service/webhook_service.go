@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go_short/domain/event"
+	"go_short/domain/webhook/entity"
+	"go_short/domain/webhook/repository"
+)
+
+// Webhook 相關錯誤
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrInvalidURL       = errors.New("webhook URL must be an absolute http or https URL")
+	ErrInvalidEvents    = errors.New("unknown or empty event types")
+	ErrNotDead          = errors.New("only dead deliveries can be redelivered")
+	ErrDatabaseError    = errors.New("database operation failed")
+)
+
+// deliveryLease 是取得投遞後的保留時間，發送逾時或實例當機時，投遞會在此時間後被重新取得
+const deliveryLease = 2 * time.Minute
+
+// Sender 負責將簽章後的 payload 以 HTTP POST 發送到端點，返回回應狀態碼
+type Sender interface {
+	Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error)
+}
+
+// WebhookService 負責管理 webhook、依領域事件建立投遞並依重試策略發送
+type WebhookService struct {
+	webhookRepo  repository.WebhookRepository
+	deliveryRepo repository.DeliveryRepository
+	sender       Sender
+	policy       entity.BackoffPolicy
+	now          func() time.Time
+}
+
+// NewWebhookService 創建 webhook 服務
+func NewWebhookService(webhookRepo repository.WebhookRepository, deliveryRepo repository.DeliveryRepository, sender Sender, policy entity.BackoffPolicy) *WebhookService {
+	return &WebhookService{
+		webhookRepo:  webhookRepo,
+		deliveryRepo: deliveryRepo,
+		sender:       sender,
+		policy:       policy,
+		now:          time.Now,
+	}
+}
+
+// SupportedEvents 返回可以訂閱的事件類型
+func SupportedEvents() []string {
+	return append([]string(nil), event.LinkTypes...)
+}
+
+// Register 註冊新的 webhook 並產生簽章密鑰
+// workspaceID 不為 nil 時 webhook 屬於該工作區，否則屬於 userID 個人
+func (s *WebhookService) Register(ctx context.Context, userID uint, workspaceID *uint, rawURL string, events []string, description string) (*entity.Webhook, error) {
+	if err := validateURL(rawURL); err != nil {
+		return nil, err
+	}
+	normalized, err := normalizeEvents(events)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	webhook := &entity.Webhook{
+		UserID:      &userID,
+		WorkspaceID: workspaceID,
+		URL:         rawURL,
+		Description: description,
+		Events:      normalized,
+		Secret:      secret,
+		Active:      true,
+	}
+	if err := s.webhookRepo.Create(ctx, webhook); err != nil {
+		return nil, ErrDatabaseError
+	}
+	return webhook, nil
+}
+
+// FindByID 根據 ID 獲取 webhook
+func (s *WebhookService) FindByID(ctx context.Context, id uint) (*entity.Webhook, error) {
+	webhook, err := s.webhookRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if webhook == nil {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// ListUserWebhooks 獲取使用者的個人 webhook
+func (s *WebhookService) ListUserWebhooks(ctx context.Context, userID uint) ([]*entity.Webhook, error) {
+	webhooks, err := s.webhookRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return webhooks, nil
+}
+
+// ListWorkspaceWebhooks 獲取工作區的 webhook
+func (s *WebhookService) ListWorkspaceWebhooks(ctx context.Context, workspaceID uint) ([]*entity.Webhook, error) {
+	webhooks, err := s.webhookRepo.FindByWorkspaceID(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	return webhooks, nil
+}
+
+// Update 修改 webhook 的端點、訂閱的事件、說明或啟用狀態，nil 參數表示不修改
+func (s *WebhookService) Update(ctx context.Context, webhook *entity.Webhook, rawURL *string, events []string, description *string, active *bool) error {
+	if rawURL != nil {
+		if err := validateURL(*rawURL); err != nil {
+			return err
+		}
+		webhook.URL = *rawURL
+	}
+	if events != nil {
+		normalized, err := normalizeEvents(events)
+		if err != nil {
+			return err
+		}
+		webhook.Events = normalized
+	}
+	if description != nil {
+		webhook.Description = *description
+	}
+	if active != nil {
+		webhook.Active = *active
+	}
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// RotateSecret 產生新的簽章密鑰，舊密鑰立即失效
+func (s *WebhookService) RotateSecret(ctx context.Context, webhook *entity.Webhook) error {
+	secret, err := newSecret()
+	if err != nil {
+		return err
+	}
+	webhook.Secret = secret
+	if err := s.webhookRepo.Update(ctx, webhook); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// Delete 刪除 webhook，尚未送出的投遞會在發送時移入 dead letter
+func (s *WebhookService) Delete(ctx context.Context, webhook *entity.Webhook) error {
+	if err := s.webhookRepo.Delete(ctx, webhook.ID); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// Enqueue 為訂閱此事件的 webhook 建立投遞，目前只處理連結事件
+func (s *WebhookService) Enqueue(ctx context.Context, e event.Event) error {
+	linkEvent, ok := e.(*event.LinkEvent)
+	if !ok {
+		return nil
+	}
+	if linkEvent.Link.WorkspaceID == nil && linkEvent.Link.UserID == nil {
+		return nil // 匿名建立的連結沒有擁有者可通知
+	}
+
+	webhooks, err := s.webhookRepo.FindActiveForOwner(ctx, linkEvent.Link.UserID, linkEvent.Link.WorkspaceID)
+	if err != nil {
+		return ErrDatabaseError
+	}
+	var targets []*entity.Webhook
+	for _, webhook := range webhooks {
+		if webhook.Subscribes(e.EventType()) {
+			targets = append(targets, webhook)
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return s.createDeliveries(ctx, targets, e.EventID(), e.EventType(), payload)
+}
+
+// EnqueuePing 對 webhook 發送測試事件，用於確認端點與簽章驗證是否正確
+func (s *WebhookService) EnqueuePing(ctx context.Context, webhook *entity.Webhook) (*entity.Delivery, error) {
+	base := event.NewBase(entity.TypePing)
+	payload, err := json.Marshal(struct {
+		event.Base
+		WebhookID uint `json:"webhook_id"`
+	}{base, webhook.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := s.newDelivery(webhook.ID, base.ID, base.Type, payload)
+	if err := s.deliveryRepo.Create(ctx, []*entity.Delivery{delivery}); err != nil {
+		return nil, ErrDatabaseError
+	}
+	return delivery, nil
+}
+
+// DeliverDue 同時發送已到期的投遞 (最多 limit 筆)，返回處理的筆數
+// 每筆投遞的結果都會嘗試寫回，寫回失敗的錯誤在全部處理後一併返回
+// limit 與發送逾時的乘積不影響保留時間，因為同一批投遞是並行發送的
+func (s *WebhookService) DeliverDue(ctx context.Context, limit int) (int, error) {
+	deliveries, err := s.deliveryRepo.ClaimDue(ctx, s.now(), deliveryLease, limit)
+	if err != nil {
+		return 0, ErrDatabaseError
+	}
+
+	webhooks := make(map[uint]*entity.Webhook)
+	for _, delivery := range deliveries {
+		if _, ok := webhooks[delivery.WebhookID]; ok {
+			continue
+		}
+		webhook, err := s.webhookRepo.FindByID(ctx, delivery.WebhookID)
+		if err != nil {
+			return 0, ErrDatabaseError
+		}
+		webhooks[delivery.WebhookID] = webhook
+	}
+
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		wg.Add(1)
+		go func(delivery *entity.Delivery) {
+			defer wg.Done()
+			s.deliver(ctx, webhooks[delivery.WebhookID], delivery)
+		}(delivery)
+	}
+	wg.Wait()
+
+	// 一筆更新失敗不影響其餘投遞，否則已發送的結果會遺失並在保留時間後重複發送
+	var errs []error
+	for _, delivery := range deliveries {
+		if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("update delivery %d: %w", delivery.ID, ErrDatabaseError))
+		}
+	}
+	return len(deliveries), errors.Join(errs...)
+}
+
+// ListDeliveries 分頁列出 webhook 的投遞記錄
+func (s *WebhookService) ListDeliveries(ctx context.Context, webhookID uint, status string, offset, limit int) ([]*entity.Delivery, int64, error) {
+	deliveries, total, err := s.deliveryRepo.FindByWebhookID(ctx, webhookID, status, offset, limit)
+	if err != nil {
+		return nil, 0, ErrDatabaseError
+	}
+	return deliveries, total, nil
+}
+
+// FindDelivery 根據 ID 獲取投遞記錄
+func (s *WebhookService) FindDelivery(ctx context.Context, id uint) (*entity.Delivery, error) {
+	delivery, err := s.deliveryRepo.FindByID(ctx, id)
+	if err != nil {
+		return nil, ErrDatabaseError
+	}
+	if delivery == nil {
+		return nil, ErrDeliveryNotFound
+	}
+	return delivery, nil
+}
+
+// Redeliver 將 dead letter 重新排入佇列，payload 與投遞 ID 不變
+func (s *WebhookService) Redeliver(ctx context.Context, delivery *entity.Delivery) error {
+	if delivery.Status != entity.DeliveryDead {
+		return ErrNotDead
+	}
+	delivery.Requeue(s.now())
+	if err := s.deliveryRepo.Update(ctx, delivery); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+// PruneDeliveries 刪除超過保留期限的成功投遞記錄 (dead letter 保留至手動處理或 webhook 刪除)
+func (s *WebhookService) PruneDeliveries(ctx context.Context, retention time.Duration) (int64, error) {
+	deleted, err := s.deliveryRepo.DeleteSucceededBefore(ctx, s.now().Add(-retention))
+	if err != nil {
+		return 0, ErrDatabaseError
+	}
+	return deleted, nil
+}
+
+// deliver 簽章並發送一筆投遞，依結果更新投遞狀態
+func (s *WebhookService) deliver(ctx context.Context, webhook *entity.Webhook, delivery *entity.Delivery) {
+	if webhook == nil || !webhook.Active {
+		delivery.MarkDead("webhook was deleted or disabled")
+		return
+	}
+
+	now := s.now()
+	body := []byte(delivery.Payload)
+	headers := map[string]string{
+		"Content-Type":         "application/json",
+		entity.HeaderEvent:     delivery.EventType,
+		entity.HeaderDelivery:  deliveryIDHeader(delivery),
+		entity.HeaderSignature: entity.Sign(webhook.Secret, now.Unix(), body),
+	}
+	status, err := s.sender.Send(ctx, webhook.URL, headers, body)
+	delivery.RecordAttempt(now, status, s.now().Sub(now), err, s.policy)
+}
+
+func (s *WebhookService) createDeliveries(ctx context.Context, webhooks []*entity.Webhook, eventID, eventType string, payload []byte) error {
+	deliveries := make([]*entity.Delivery, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, s.newDelivery(webhook.ID, eventID, eventType, payload))
+	}
+	if err := s.deliveryRepo.Create(ctx, deliveries); err != nil {
+		return ErrDatabaseError
+	}
+	return nil
+}
+
+func (s *WebhookService) newDelivery(webhookID uint, eventID, eventType string, payload []byte) *entity.Delivery {
+	now := s.now()
+	return &entity.Delivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		EventType:     eventType,
+		Payload:       string(payload),
+		Status:        entity.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// deliveryIDHeader 返回投遞 ID 標頭值 (事件 ID 與 webhook ID)，重試時不變，接收端可用來去除重複
+func deliveryIDHeader(delivery *entity.Delivery) string {
+	return delivery.EventID + "-" + strconv.FormatUint(uint64(delivery.WebhookID), 10)
+}
+
+// validateURL 檢查端點是否為絕對的 http(s) 網址
+func validateURL(rawURL string) error {
+	if len(rawURL) > 2048 {
+		return ErrInvalidURL
+	}
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" || parsed.User != nil {
+		return ErrInvalidURL
+	}
+	return nil
+}
+
+// normalizeEvents 檢查並去除重複的事件類型，返回以逗號分隔的字串
+func normalizeEvents(events []string) (string, error) {
+	supported := make(map[string]bool, len(event.LinkTypes)+1)
+	supported["*"] = true
+	for _, t := range event.LinkTypes {
+		supported[t] = true
+	}
+
+	seen := make(map[string]bool, len(events))
+	normalized := make([]string, 0, len(events))
+	for _, e := range events {
+		e = strings.TrimSpace(e)
+		if !supported[e] {
+			return "", ErrInvalidEvents
+		}
+		if !seen[e] {
+			seen[e] = true
+			normalized = append(normalized, e)
+		}
+	}
+	if len(normalized) == 0 {
+		return "", ErrInvalidEvents
+	}
+	if seen["*"] {
+		return "*", nil
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ","), nil
+}
+
+// newSecret 產生簽章用的隨機密鑰
+func newSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(buf), nil
+}
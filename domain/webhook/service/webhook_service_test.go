@@ -0,0 +1,307 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go_short/domain/event"
+	"go_short/domain/webhook/entity"
+	"go_short/domain/webhook/repository"
+)
+
+type fakeWebhookRepo struct {
+	repository.WebhookRepository
+	webhooks map[uint]*entity.Webhook
+}
+
+func (r *fakeWebhookRepo) FindByID(ctx context.Context, id uint) (*entity.Webhook, error) {
+	return r.webhooks[id], nil
+}
+
+func (r *fakeWebhookRepo) FindActiveForOwner(ctx context.Context, userID *uint, workspaceID *uint) ([]*entity.Webhook, error) {
+	var found []*entity.Webhook
+	for _, webhook := range r.webhooks {
+		if webhook.Active && webhook.WorkspaceID == nil && userID != nil && webhook.UserID != nil && *webhook.UserID == *userID {
+			found = append(found, webhook)
+		}
+	}
+	return found, nil
+}
+
+// fakeDeliveryRepo 以記憶體保存投遞，ClaimDue 與 GORM 實作相同只取得到期的 pending 投遞並延後保留時間
+type fakeDeliveryRepo struct {
+	repository.DeliveryRepository
+	deliveries []*entity.Delivery
+	updateErr  map[uint]error
+	updated    []uint
+}
+
+func (r *fakeDeliveryRepo) Create(ctx context.Context, deliveries []*entity.Delivery) error {
+	for _, delivery := range deliveries {
+		delivery.ID = uint(len(r.deliveries) + 1)
+		r.deliveries = append(r.deliveries, delivery)
+	}
+	return nil
+}
+
+func (r *fakeDeliveryRepo) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.Delivery, error) {
+	var due []*entity.Delivery
+	for _, delivery := range r.deliveries {
+		if len(due) == limit {
+			break
+		}
+		if delivery.Status != entity.DeliveryPending || delivery.NextAttemptAt == nil || delivery.NextAttemptAt.After(now) {
+			continue
+		}
+		next := now.Add(lease)
+		delivery.NextAttemptAt = &next
+		due = append(due, delivery)
+	}
+	return due, nil
+}
+
+func (r *fakeDeliveryRepo) Update(ctx context.Context, delivery *entity.Delivery) error {
+	r.updated = append(r.updated, delivery.ID)
+	return r.updateErr[delivery.ID]
+}
+
+// postSender 與 infra/webhook 的發送器相同，以 HTTP POST 發送並將非 2xx 回應視為失敗
+type postSender struct{}
+
+func (postSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// receivedRequest 是端點收到的一次請求
+type receivedRequest struct {
+	header http.Header
+	body   []byte
+}
+
+// endpoint 是接收 webhook 的測試伺服器，依序以 statuses 回應，用完後回應最後一個
+type endpoint struct {
+	*httptest.Server
+	mu       sync.Mutex
+	statuses []int
+	received []receivedRequest
+}
+
+func newEndpoint(t *testing.T, statuses ...int) *endpoint {
+	t.Helper()
+	e := &endpoint{statuses: statuses}
+	e.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		e.mu.Lock()
+		defer e.mu.Unlock()
+		e.received = append(e.received, receivedRequest{header: r.Header.Clone(), body: body})
+		status := e.statuses[0]
+		if len(e.statuses) > 1 {
+			e.statuses = e.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(e.Close)
+	return e
+}
+
+type webhookFixture struct {
+	service    *WebhookService
+	webhooks   *fakeWebhookRepo
+	deliveries *fakeDeliveryRepo
+	now        time.Time
+}
+
+const testSecret = "whsec_test"
+
+func newWebhookFixture(t *testing.T, url string, policy entity.BackoffPolicy) *webhookFixture {
+	t.Helper()
+	userID := uint(5)
+	webhook := &entity.Webhook{UserID: &userID, URL: url, Events: "*", Secret: testSecret, Active: true}
+	webhook.ID = 1
+	f := &webhookFixture{
+		webhooks:   &fakeWebhookRepo{webhooks: map[uint]*entity.Webhook{1: webhook}},
+		deliveries: &fakeDeliveryRepo{},
+		now:        time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewWebhookService(f.webhooks, f.deliveries, postSender{}, policy)
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+// enqueue 為使用者 5 的連結建立事件並返回投遞
+func (f *webhookFixture) enqueue(t *testing.T) *entity.Delivery {
+	t.Helper()
+	userID := uint(5)
+	if err := f.service.Enqueue(context.Background(), event.NewLinkEvent(event.TypeLinkCreated, event.Link{ID: 9, UserID: &userID})); err != nil {
+		t.Fatal(err)
+	}
+	if len(f.deliveries.deliveries) != 1 {
+		t.Fatalf("created %d deliveries, want 1", len(f.deliveries.deliveries))
+	}
+	return f.deliveries.deliveries[0]
+}
+
+// verifySignature 以接收端的方式驗證簽章標頭
+func verifySignature(t *testing.T, header string, body []byte, now time.Time) {
+	t.Helper()
+	timestamp, signature, ok := strings.Cut(header, ",v1=")
+	if !ok || !strings.HasPrefix(timestamp, "t=") {
+		t.Fatalf("malformed signature header %q", header)
+	}
+	if timestamp != "t="+strconv.FormatInt(now.Unix(), 10) {
+		t.Errorf("signature timestamp %s, want the send time %d", timestamp, now.Unix())
+	}
+	mac := hmac.New(sha256.New, []byte(testSecret))
+	mac.Write([]byte(strings.TrimPrefix(timestamp, "t=") + "."))
+	mac.Write(body)
+	if !hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil)))) {
+		t.Errorf("signature %s does not match the body", signature)
+	}
+}
+
+func TestDeliverDueRetriesWithBackoffUntilSuccess(t *testing.T) {
+	server := newEndpoint(t, http.StatusInternalServerError, http.StatusServiceUnavailable, http.StatusNoContent)
+	policy := entity.BackoffPolicy{MaxAttempts: 5, Base: 30 * time.Second, Max: time.Hour}
+	f := newWebhookFixture(t, server.URL, policy)
+	delivery := f.enqueue(t)
+	ctx := context.Background()
+
+	for attempt, wantDelay := range []time.Duration{30 * time.Second, time.Minute} {
+		if n, err := f.service.DeliverDue(ctx, 10); err != nil || n != 1 {
+			t.Fatalf("attempt %d: DeliverDue = %d, %v", attempt+1, n, err)
+		}
+		if delivery.Status != entity.DeliveryPending || delivery.Attempts != attempt+1 || !delivery.NextAttemptAt.Equal(f.now.Add(wantDelay)) {
+			t.Fatalf("attempt %d: status %s, attempts %d, next %v; want a retry in %s", attempt+1, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, wantDelay)
+		}
+		if delivery.ResponseStatus == nil || delivery.LastError == nil {
+			t.Errorf("attempt %d: the failed response must be recorded", attempt+1)
+		}
+		// 重試時間之前不會再發送
+		if n, _ := f.service.DeliverDue(ctx, 10); n != 0 {
+			t.Fatalf("attempt %d: sent again before the retry time", attempt+1)
+		}
+		f.now = f.now.Add(wantDelay)
+	}
+
+	if _, err := f.service.DeliverDue(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != entity.DeliverySucceeded || delivery.Attempts != 3 || delivery.NextAttemptAt != nil || delivery.LastError != nil {
+		t.Fatalf("after success: status %s, attempts %d, next %v, error %v", delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastError)
+	}
+
+	if len(server.received) != 3 {
+		t.Fatalf("endpoint received %d requests, want 3", len(server.received))
+	}
+	wantID := delivery.EventID + "-1"
+	sendTimes := []time.Time{f.now.Add(-90 * time.Second), f.now.Add(-time.Minute), f.now}
+	for i, req := range server.received {
+		if req.header.Get(entity.HeaderDelivery) != wantID {
+			t.Errorf("request %d: delivery ID %q, want %q on every retry", i+1, req.header.Get(entity.HeaderDelivery), wantID)
+		}
+		if req.header.Get(entity.HeaderEvent) != event.TypeLinkCreated || string(req.body) != delivery.Payload {
+			t.Errorf("request %d: event %q, body %s", i+1, req.header.Get(entity.HeaderEvent), req.body)
+		}
+		verifySignature(t, req.header.Get(entity.HeaderSignature), req.body, sendTimes[i])
+	}
+}
+
+func TestDeliverDueDeadLettersAfterMaxAttempts(t *testing.T) {
+	server := newEndpoint(t, http.StatusInternalServerError)
+	f := newWebhookFixture(t, server.URL, entity.BackoffPolicy{MaxAttempts: 2, Base: time.Second, Max: time.Minute})
+	delivery := f.enqueue(t)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := f.service.DeliverDue(ctx, 10); err != nil {
+			t.Fatal(err)
+		}
+		f.now = f.now.Add(time.Minute)
+	}
+	if delivery.Status != entity.DeliveryDead || delivery.Attempts != 2 || delivery.NextAttemptAt != nil {
+		t.Fatalf("status %s, attempts %d, next %v; want a dead letter", delivery.Status, delivery.Attempts, delivery.NextAttemptAt)
+	}
+	if n, _ := f.service.DeliverDue(ctx, 10); n != 0 || len(server.received) != 2 {
+		t.Fatalf("a dead letter must not be sent again (claimed %d, received %d)", n, len(server.received))
+	}
+
+	// 手動重新投遞後從頭計算嘗試次數
+	if err := f.service.Redeliver(ctx, delivery); err != nil {
+		t.Fatal(err)
+	}
+	server.statuses = []int{http.StatusOK}
+	if _, err := f.service.DeliverDue(ctx, 10); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != entity.DeliverySucceeded || delivery.Attempts != 1 {
+		t.Fatalf("after redelivery: status %s, attempts %d", delivery.Status, delivery.Attempts)
+	}
+}
+
+func TestDeliverDueDeadLettersDisabledWebhook(t *testing.T) {
+	server := newEndpoint(t, http.StatusOK)
+	f := newWebhookFixture(t, server.URL, entity.DefaultBackoffPolicy())
+	delivery := f.enqueue(t)
+	f.webhooks.webhooks[1].Active = false
+
+	if _, err := f.service.DeliverDue(context.Background(), 10); err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Status != entity.DeliveryDead || len(server.received) != 0 {
+		t.Fatalf("status %s, received %d; want a dead letter without sending", delivery.Status, len(server.received))
+	}
+}
+
+func TestDeliverDueUpdatesEveryDelivery(t *testing.T) {
+	server := newEndpoint(t, http.StatusOK)
+	f := newWebhookFixture(t, server.URL, entity.DefaultBackoffPolicy())
+	for id := uint(2); id <= 3; id++ {
+		webhook := *f.webhooks.webhooks[1]
+		webhook.ID = id
+		f.webhooks.webhooks[id] = &webhook
+	}
+	userID := uint(5)
+	if err := f.service.Enqueue(context.Background(), event.NewLinkEvent(event.TypeLinkCreated, event.Link{ID: 9, UserID: &userID})); err != nil {
+		t.Fatal(err)
+	}
+	f.deliveries.updateErr = map[uint]error{1: errors.New("connection reset"), 3: errors.New("connection reset")}
+
+	n, err := f.service.DeliverDue(context.Background(), 10)
+	if n != 3 || !errors.Is(err, ErrDatabaseError) {
+		t.Fatalf("DeliverDue = %d, %v; want 3 with ErrDatabaseError", n, err)
+	}
+	if !strings.Contains(err.Error(), "update delivery 1") || !strings.Contains(err.Error(), "update delivery 3") {
+		t.Errorf("err = %v, want both failed updates", err)
+	}
+	if len(f.deliveries.updated) != 3 {
+		t.Errorf("updated %v, want every delivery written back", f.deliveries.updated)
+	}
+}
@@ -0,0 +1,81 @@
+package entity
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role 代表成員在工作區中的角色
+type Role string
+
+const (
+	RoleOwner  Role = "owner"  // 可管理成員、網域與所有連結
+	RoleEditor Role = "editor" // 可建立、修改與刪除連結
+	RoleViewer Role = "viewer" // 只能查看連結與統計
+)
+
+// roleRank 用於比較角色權限高低
+var roleRank = map[Role]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// IsValid 檢查角色是否為已定義的角色
+func (r Role) IsValid() bool {
+	_, ok := roleRank[r]
+	return ok
+}
+
+// AtLeast 檢查角色權限是否不低於指定角色
+func (r Role) AtLeast(min Role) bool {
+	return roleRank[r] >= roleRank[min]
+}
+
+// Workspace 代表一個共享連結、網域與統計的團隊工作區
+type Workspace struct {
+	gorm.Model
+	Name    string `json:"name" gorm:"type:varchar(100);not null"`
+	OwnerID uint   `json:"owner_id" gorm:"not null;index"` // 建立者
+}
+
+// TableName 指定資料表名稱
+func (Workspace) TableName() string {
+	return "workspaces"
+}
+
+// Membership 代表使用者在工作區中的成員資格
+type Membership struct {
+	gorm.Model
+	WorkspaceID uint `json:"workspace_id" gorm:"not null;uniqueIndex:idx_workspace_members_workspace_user,where:deleted_at IS NULL"`
+	UserID      uint `json:"user_id" gorm:"not null;index;uniqueIndex:idx_workspace_members_workspace_user,where:deleted_at IS NULL"`
+	Role        Role `json:"role" gorm:"type:varchar(20);not null"`
+}
+
+// TableName 指定資料表名稱
+func (Membership) TableName() string {
+	return "workspace_members"
+}
+
+// Invitation 代表邀請某個電子郵件加入工作區的邀請函
+type Invitation struct {
+	gorm.Model
+	WorkspaceID uint       `json:"workspace_id" gorm:"not null;index"`
+	Email       string     `json:"email" gorm:"type:varchar(255);not null"`
+	Role        Role       `json:"role" gorm:"type:varchar(20);not null"`
+	TokenHash   string     `json:"-" gorm:"type:varchar(64);not null;uniqueIndex"` // 只儲存 token 的雜湊值
+	InvitedBy   uint       `json:"invited_by" gorm:"not null"`
+	ExpiresAt   time.Time  `json:"expires_at" gorm:"not null"`
+	AcceptedAt  *time.Time `json:"accepted_at,omitempty"`
+}
+
+// TableName 指定資料表名稱
+func (Invitation) TableName() string {
+	return "workspace_invitations"
+}
+
+// IsPending 檢查邀請是否仍可接受
+func (i *Invitation) IsPending() bool {
+	return i.AcceptedAt == nil && time.Now().Before(i.ExpiresAt)
+}
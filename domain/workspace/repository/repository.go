@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"go_short/domain/workspace/entity"
+)
+
+// WorkspaceRepository 定義了工作區與成員資料的存取操作介面
+type WorkspaceRepository interface {
+	// CreateWithOwner 創建工作區並將建立者加入為 owner (同一交易內完成)
+	CreateWithOwner(ctx context.Context, workspace *entity.Workspace) error
+
+	// FindByID 根據 ID 查找工作區
+	FindByID(ctx context.Context, id uint) (*entity.Workspace, error)
+
+	// FindByUserID 獲取使用者所屬的所有工作區
+	FindByUserID(ctx context.Context, userID uint) ([]*entity.Workspace, error)
+
+	// FindMembership 查找使用者在工作區中的成員資格，不存在時返回 nil
+	FindMembership(ctx context.Context, workspaceID, userID uint) (*entity.Membership, error)
+
+	// ListMembers 獲取工作區的所有成員
+	ListMembers(ctx context.Context, workspaceID uint) ([]*entity.Membership, error)
+
+	// CountMembersWithRole 計算工作區中擁有指定角色的成員數
+	CountMembersWithRole(ctx context.Context, workspaceID uint, role entity.Role) (int64, error)
+
+	// SaveMembership 創建或更新成員資格
+	SaveMembership(ctx context.Context, membership *entity.Membership) error
+
+	// DeleteMembership 移除成員資格
+	DeleteMembership(ctx context.Context, membership *entity.Membership) error
+
+	// SharesWorkspace 檢查兩位使用者是否至少同屬一個工作區
+	SharesWorkspace(ctx context.Context, userID, otherUserID uint) (bool, error)
+}
+
+// InvitationRepository 定義了工作區邀請的存取操作介面
+type InvitationRepository interface {
+	// Create 創建一個新邀請
+	Create(ctx context.Context, invitation *entity.Invitation) error
+
+	// FindByTokenHash 根據 token 雜湊值查找邀請
+	FindByTokenHash(ctx context.Context, tokenHash string) (*entity.Invitation, error)
+
+	// ListPending 獲取工作區中尚未接受且未過期的邀請
+	ListPending(ctx context.Context, workspaceID uint) ([]*entity.Invitation, error)
+
+	// Update 更新邀請 (例如標記為已接受)
+	Update(ctx context.Context, invitation *entity.Invitation) error
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/repository"
+)
+
+// 工作區領域錯誤
+var (
+	ErrWorkspaceNotFound  = errors.New("workspace not found")
+	ErrForbidden          = errors.New("insufficient workspace permissions")
+	ErrInvalidRole        = errors.New("invalid workspace role")
+	ErrMemberNotFound     = errors.New("workspace member not found")
+	ErrAlreadyMember      = errors.New("user is already a workspace member")
+	ErrLastOwner          = errors.New("workspace must keep at least one owner")
+	ErrInvitationNotFound = errors.New("invitation not found or expired")
+	ErrInvitationMismatch = errors.New("invitation was issued for a different email")
+	ErrServiceInternal    = errors.New("service: internal error")
+)
+
+// invitationTTL 是工作區邀請的有效期限
+const invitationTTL = 7 * 24 * time.Hour
+
+// WorkspaceService 負責工作區、成員角色與邀請的業務邏輯
+type WorkspaceService struct {
+	workspaceRepo  repository.WorkspaceRepository
+	invitationRepo repository.InvitationRepository
+}
+
+// NewWorkspaceService 創建 WorkspaceService 實例
+func NewWorkspaceService(workspaceRepo repository.WorkspaceRepository, invitationRepo repository.InvitationRepository) *WorkspaceService {
+	return &WorkspaceService{
+		workspaceRepo:  workspaceRepo,
+		invitationRepo: invitationRepo,
+	}
+}
+
+// CreateWorkspace 創建工作區，建立者自動成為 owner
+func (s *WorkspaceService) CreateWorkspace(ctx context.Context, ownerID uint, name string) (*entity.Workspace, error) {
+	workspace := &entity.Workspace{
+		Name:    strings.TrimSpace(name),
+		OwnerID: ownerID,
+	}
+	if err := s.workspaceRepo.CreateWithOwner(ctx, workspace); err != nil {
+		slog.ErrorContext(ctx, "Error creating workspace for user", "owner_id", ownerID, "error", err)
+		return nil, ErrServiceInternal
+	}
+	return workspace, nil
+}
+
+// ListWorkspaces 獲取使用者所屬的工作區
+func (s *WorkspaceService) ListWorkspaces(ctx context.Context, userID uint) ([]*entity.Workspace, error) {
+	workspaces, err := s.workspaceRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing workspaces for user", "user_id", userID, "error", err)
+		return nil, ErrServiceInternal
+	}
+	return workspaces, nil
+}
+
+// Authorize 檢查使用者在工作區中的角色是否至少為 min，成功時返回其成員資格
+func (s *WorkspaceService) Authorize(ctx context.Context, userID, workspaceID uint, min entity.Role) (*entity.Membership, error) {
+	membership, err := s.workspaceRepo.FindMembership(ctx, workspaceID, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding membership of user in workspace", "user_id", userID, "workspace_id", workspaceID, "error", err)
+		return nil, ErrServiceInternal
+	}
+	if membership == nil {
+		// 非成員一律視為找不到，避免洩漏工作區是否存在
+		return nil, ErrWorkspaceNotFound
+	}
+	if !membership.Role.AtLeast(min) {
+		return nil, ErrForbidden
+	}
+	return membership, nil
+}
+
+// IsMember 檢查使用者是否為工作區成員
+func (s *WorkspaceService) IsMember(ctx context.Context, userID, workspaceID uint) (bool, error) {
+	membership, err := s.workspaceRepo.FindMembership(ctx, workspaceID, userID)
+	if err != nil {
+		return false, ErrServiceInternal
+	}
+	return membership != nil, nil
+}
+
+// SharesWorkspace 檢查兩位使用者是否同屬至少一個工作區
+func (s *WorkspaceService) SharesWorkspace(ctx context.Context, userID, otherUserID uint) (bool, error) {
+	shares, err := s.workspaceRepo.SharesWorkspace(ctx, userID, otherUserID)
+	if err != nil {
+		return false, ErrServiceInternal
+	}
+	return shares, nil
+}
+
+// GetWorkspace 獲取工作區 (需為成員)
+func (s *WorkspaceService) GetWorkspace(ctx context.Context, userID, workspaceID uint) (*entity.Workspace, error) {
+	if _, err := s.Authorize(ctx, userID, workspaceID, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+	workspace, err := s.workspaceRepo.FindByID(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	if workspace == nil {
+		return nil, ErrWorkspaceNotFound
+	}
+	return workspace, nil
+}
+
+// ListMembers 獲取工作區成員 (需為成員)
+func (s *WorkspaceService) ListMembers(ctx context.Context, userID, workspaceID uint) ([]*entity.Membership, error) {
+	if _, err := s.Authorize(ctx, userID, workspaceID, entity.RoleViewer); err != nil {
+		return nil, err
+	}
+	members, err := s.workspaceRepo.ListMembers(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	return members, nil
+}
+
+// UpdateMemberRole 變更成員角色 (僅 owner 可操作)
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, actorID, workspaceID, memberID uint, role entity.Role) (*entity.Membership, error) {
+	if !role.IsValid() {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.Authorize(ctx, actorID, workspaceID, entity.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	membership, err := s.workspaceRepo.FindMembership(ctx, workspaceID, memberID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	if membership == nil {
+		return nil, ErrMemberNotFound
+	}
+	if membership.Role == entity.RoleOwner && role != entity.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	membership.Role = role
+	if err := s.workspaceRepo.SaveMembership(ctx, membership); err != nil {
+		return nil, ErrServiceInternal
+	}
+	return membership, nil
+}
+
+// RemoveMember 移除成員；owner 可移除任何人，成員也可自行離開
+func (s *WorkspaceService) RemoveMember(ctx context.Context, actorID, workspaceID, memberID uint) error {
+	if actorID == memberID {
+		if _, err := s.Authorize(ctx, actorID, workspaceID, entity.RoleViewer); err != nil {
+			return err
+		}
+	} else if _, err := s.Authorize(ctx, actorID, workspaceID, entity.RoleOwner); err != nil {
+		return err
+	}
+
+	membership, err := s.workspaceRepo.FindMembership(ctx, workspaceID, memberID)
+	if err != nil {
+		return ErrServiceInternal
+	}
+	if membership == nil {
+		return ErrMemberNotFound
+	}
+	if membership.Role == entity.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID); err != nil {
+			return err
+		}
+	}
+
+	if err := s.workspaceRepo.DeleteMembership(ctx, membership); err != nil {
+		return ErrServiceInternal
+	}
+	return nil
+}
+
+// CreateInvitation 邀請指定電子郵件以某角色加入工作區 (僅 owner 可操作)
+// 返回的明文 token 只會出現這一次，資料庫僅保存其雜湊
+func (s *WorkspaceService) CreateInvitation(ctx context.Context, actorID, workspaceID uint, email string, role entity.Role) (*entity.Invitation, string, error) {
+	if !role.IsValid() {
+		return nil, "", ErrInvalidRole
+	}
+	if _, err := s.Authorize(ctx, actorID, workspaceID, entity.RoleOwner); err != nil {
+		return nil, "", err
+	}
+
+	token, err := newInvitationToken()
+	if err != nil {
+		return nil, "", ErrServiceInternal
+	}
+
+	invitation := &entity.Invitation{
+		WorkspaceID: workspaceID,
+		Email:       strings.ToLower(strings.TrimSpace(email)),
+		Role:        role,
+		TokenHash:   hashInvitationToken(token),
+		InvitedBy:   actorID,
+		ExpiresAt:   time.Now().Add(invitationTTL),
+	}
+	if err := s.invitationRepo.Create(ctx, invitation); err != nil {
+		slog.ErrorContext(ctx, "Error creating invitation for workspace", "workspace_id", workspaceID, "error", err)
+		return nil, "", ErrServiceInternal
+	}
+	return invitation, token, nil
+}
+
+// ListInvitations 獲取工作區中待處理的邀請 (僅 owner 可查看)
+func (s *WorkspaceService) ListInvitations(ctx context.Context, actorID, workspaceID uint) ([]*entity.Invitation, error) {
+	if _, err := s.Authorize(ctx, actorID, workspaceID, entity.RoleOwner); err != nil {
+		return nil, err
+	}
+	invitations, err := s.invitationRepo.ListPending(ctx, workspaceID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	return invitations, nil
+}
+
+// AcceptInvitation 以邀請 token 加入工作區，使用者的電子郵件必須與邀請相符
+func (s *WorkspaceService) AcceptInvitation(ctx context.Context, userID uint, userEmail string, token string) (*entity.Membership, error) {
+	invitation, err := s.invitationRepo.FindByTokenHash(ctx, hashInvitationToken(token))
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	if invitation == nil || !invitation.IsPending() {
+		return nil, ErrInvitationNotFound
+	}
+	if !strings.EqualFold(invitation.Email, strings.TrimSpace(userEmail)) {
+		return nil, ErrInvitationMismatch
+	}
+
+	existing, err := s.workspaceRepo.FindMembership(ctx, invitation.WorkspaceID, userID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+	if existing != nil {
+		return nil, ErrAlreadyMember
+	}
+
+	membership := &entity.Membership{
+		WorkspaceID: invitation.WorkspaceID,
+		UserID:      userID,
+		Role:        invitation.Role,
+	}
+	if err := s.workspaceRepo.SaveMembership(ctx, membership); err != nil {
+		return nil, ErrServiceInternal
+	}
+
+	now := time.Now()
+	invitation.AcceptedAt = &now
+	if err := s.invitationRepo.Update(ctx, invitation); err != nil {
+		slog.ErrorContext(ctx, "Error marking invitation as accepted", "invitation_id", invitation.ID, "error", err)
+	}
+	return membership, nil
+}
+
+// SoleOwnedWorkspaces 返回使用者是唯一 owner 且還有其他成員的工作區，使用者離開後這些工作區將無人管理
+func (s *WorkspaceService) SoleOwnedWorkspaces(ctx context.Context, userID uint) ([]*entity.Workspace, error) {
+	workspaces, err := s.workspaceRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		return nil, ErrServiceInternal
+	}
+
+	var owned []*entity.Workspace
+	for _, workspace := range workspaces {
+		membership, err := s.workspaceRepo.FindMembership(ctx, workspace.ID, userID)
+		if err != nil {
+			return nil, ErrServiceInternal
+		}
+		if membership == nil || membership.Role != entity.RoleOwner {
+			continue
+		}
+		owners, err := s.workspaceRepo.CountMembersWithRole(ctx, workspace.ID, entity.RoleOwner)
+		if err != nil {
+			return nil, ErrServiceInternal
+		}
+		if owners > 1 {
+			continue
+		}
+		members, err := s.workspaceRepo.ListMembers(ctx, workspace.ID)
+		if err != nil {
+			return nil, ErrServiceInternal
+		}
+		if len(members) > 1 {
+			owned = append(owned, workspace)
+		}
+	}
+	return owned, nil
+}
+
+// ensureAnotherOwner 確保移除或降級一位 owner 後工作區仍有 owner
+func (s *WorkspaceService) ensureAnotherOwner(ctx context.Context, workspaceID uint) error {
+	owners, err := s.workspaceRepo.CountMembersWithRole(ctx, workspaceID, entity.RoleOwner)
+	if err != nil {
+		return ErrServiceInternal
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+func newInvitationToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func hashInvitationToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/repository"
+)
+
+// fakeWorkspaceRepo 以 工作區 -> 使用者 -> 角色 記錄成員
+type fakeWorkspaceRepo struct {
+	repository.WorkspaceRepository
+	members map[uint]map[uint]entity.Role
+}
+
+func (r *fakeWorkspaceRepo) FindByUserID(ctx context.Context, userID uint) ([]*entity.Workspace, error) {
+	var workspaces []*entity.Workspace
+	for id := uint(1); id <= uint(len(r.members)); id++ {
+		if _, ok := r.members[id][userID]; ok {
+			workspace := &entity.Workspace{Name: "team"}
+			workspace.ID = id
+			workspaces = append(workspaces, workspace)
+		}
+	}
+	return workspaces, nil
+}
+
+func (r *fakeWorkspaceRepo) FindMembership(ctx context.Context, workspaceID, userID uint) (*entity.Membership, error) {
+	role, ok := r.members[workspaceID][userID]
+	if !ok {
+		return nil, nil
+	}
+	return &entity.Membership{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeWorkspaceRepo) CountMembersWithRole(ctx context.Context, workspaceID uint, role entity.Role) (int64, error) {
+	var n int64
+	for _, r := range r.members[workspaceID] {
+		if r == role {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeWorkspaceRepo) ListMembers(ctx context.Context, workspaceID uint) ([]*entity.Membership, error) {
+	var members []*entity.Membership
+	for userID, role := range r.members[workspaceID] {
+		members = append(members, &entity.Membership{WorkspaceID: workspaceID, UserID: userID, Role: role})
+	}
+	return members, nil
+}
+
+func TestSoleOwnedWorkspaces(t *testing.T) {
+	repo := &fakeWorkspaceRepo{members: map[uint]map[uint]entity.Role{
+		1: {1: entity.RoleOwner, 2: entity.RoleEditor}, // 唯一的 owner，還有其他成員
+		2: {1: entity.RoleOwner},                       // 只有自己
+		3: {1: entity.RoleOwner, 2: entity.RoleOwner},  // 還有另一位 owner
+		4: {1: entity.RoleViewer, 2: entity.RoleOwner}, // 不是 owner
+		5: {2: entity.RoleOwner, 3: entity.RoleEditor}, // 不是成員
+	}}
+	owned, err := NewWorkspaceService(repo, nil).SoleOwnedWorkspaces(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(owned) != 1 || owned[0].ID != 1 {
+		t.Errorf("owned = %v, want only workspace 1", owned)
+	}
+}
@@ -2,28 +2,33 @@ package database
 
 import (
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 
 	"go_short/conf"
+	"go_short/infra/logging"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
-// InitDB 初始化數據庫連接
-func InitDB(config *conf.Config) (*gorm.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Taipei",
-		config.DBHost, config.DBPort, config.DBUser, config.DBPassword, config.DBName)
+// slowQueryThreshold 超過此時間的查詢會記錄為慢查詢
+const slowQueryThreshold = 200 * time.Millisecond
 
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+// InitDB 初始化數據庫連接，SQL 日誌以 logger 輸出 (只在 debug 等級輸出每個查詢)
+func InitDB(config *conf.Config, logger *slog.Logger) (*gorm.DB, error) {
+	db := config.Database
+	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s TimeZone=%s",
+		db.Host, db.Port, db.User, db.Password, db.DBName, db.SSLMode, db.TimeZone)
+
+	conn, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logging.NewGormLogger(logger, slowQueryThreshold),
 	})
 	if err != nil {
-		log.Printf("Failed to connect to database: %v", err)
+		logger.Error("Failed to connect to database", "error", err)
 		return nil, err
 	}
 
-	log.Println("Database connection established")
-	return db, nil
+	logger.Info("Database connection established")
+	return conn, nil
 }
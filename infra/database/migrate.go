@@ -0,0 +1,360 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID 是遷移時使用的 PostgreSQL advisory lock ID ("go_short" 的 ASCII)，
+// 多個副本同時啟動時只有一個會執行遷移，其餘等待後看到已是最新版本
+const migrationLockID int64 = 0x676f5f73686f7274
+
+// ErrDirtySchema 表示上次的遷移未完成，需要手動修復後以 migrate force 標記版本
+var ErrDirtySchema = errors.New("database schema is dirty")
+
+// Migration 是一個版本的遷移檔
+type Migration struct {
+	Version uint
+	Name    string
+	up      string
+	down    string
+}
+
+// MigrationStatus 是一個遷移檔的套用狀態
+type MigrationStatus struct {
+	Migration
+	Applied bool
+}
+
+// Migrator 執行嵌入的 SQL 遷移檔，版本記錄在與 golang-migrate 相容的 schema_migrations 表
+type Migrator struct {
+	db         *sql.DB
+	source     fs.FS
+	migrations []Migration // 依版本排序
+}
+
+// NewMigrator 讀取 source 根目錄中的遷移檔，每個版本都必須有 up 與 down 檔
+func NewMigrator(db *gorm.DB, source fs.FS) (*Migrator, error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	return newMigrator(sqlDB, source)
+}
+
+// newMigrator 以 database/sql 連線建立 Migrator
+func newMigrator(sqlDB *sql.DB, source fs.FS) (*Migrator, error) {
+	entries, err := fs.ReadDir(source, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[uint]*Migration)
+	for _, entry := range entries {
+		name := entry.Name()
+		base, direction, ok := cutDirection(name)
+		if !ok {
+			continue
+		}
+		prefix, title, ok := strings.Cut(base, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if !ok || err != nil || version == 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		m := byVersion[uint(version)]
+		if m == nil {
+			m = &Migration{Version: uint(version), Name: title}
+			byVersion[uint(version)] = m
+		}
+		if m.Name != title {
+			return nil, fmt.Errorf("migration %d has different names: %q and %q", version, m.Name, title)
+		}
+		if direction == "up" {
+			m.up = name
+		} else {
+			m.down = name
+		}
+	}
+
+	migrator := &Migrator{db: sqlDB, source: source}
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both up and down files", m.Version, m.Name)
+		}
+		migrator.migrations = append(migrator.migrations, *m)
+	}
+	sort.Slice(migrator.migrations, func(i, j int) bool {
+		return migrator.migrations[i].Version < migrator.migrations[j].Version
+	})
+	return migrator, nil
+}
+
+// cutDirection 將 xxx.up.sql / xxx.down.sql 拆成 xxx 與方向
+func cutDirection(name string) (base, direction string, ok bool) {
+	if base, ok := strings.CutSuffix(name, ".up.sql"); ok {
+		return base, "up", true
+	}
+	if base, ok := strings.CutSuffix(name, ".down.sql"); ok {
+		return base, "down", true
+	}
+	return "", "", false
+}
+
+// Latest 返回最新的遷移版本，沒有遷移檔時返回 0
+func (m *Migrator) Latest() uint {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Version 返回資料庫目前的結構版本，尚未套用任何遷移時返回 0
+func (m *Migrator) Version(ctx context.Context) (version uint, dirty bool, err error) {
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+	return readVersion(ctx, m.db)
+}
+
+// Status 返回每個遷移檔的套用狀態與資料庫目前的版本
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, uint, bool, error) {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	statuses := make([]MigrationStatus, len(m.migrations))
+	for i, migration := range m.migrations {
+		statuses[i] = MigrationStatus{Migration: migration, Applied: migration.Version <= version}
+	}
+	return statuses, version, dirty, nil
+}
+
+// Check 確認資料庫的結構版本與執行檔內嵌的最新遷移一致且不是 dirty 狀態
+func (m *Migrator) Check(ctx context.Context) error {
+	version, dirty, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+	if dirty {
+		return fmt.Errorf("%w at version %d", ErrDirtySchema, version)
+	}
+	if version == 0 && m.Latest() > 0 {
+		return errors.New("no migrations applied")
+	}
+	if version != m.Latest() {
+		return fmt.Errorf("schema version %d, expected %d", version, m.Latest())
+	}
+	return nil
+}
+
+// Up 依序套用最多 n 個尚未套用的遷移 (n <= 0 表示全部)，返回套用的數量
+func (m *Migrator) Up(ctx context.Context, n int) (int, error) {
+	applied := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if version > m.Latest() {
+			return fmt.Errorf("schema version %d is newer than the latest migration %d", version, m.Latest())
+		}
+		for _, migration := range m.migrations {
+			if migration.Version <= version {
+				continue
+			}
+			if n > 0 && applied >= n {
+				break
+			}
+			if err := m.apply(ctx, conn, migration.up, migration.Version); err != nil {
+				return fmt.Errorf("migration %d_%s up: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Migration applied", "version", migration.Version, "name", migration.Name)
+			applied++
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down 依序回復最多 n 個已套用的遷移 (n <= 0 表示全部)，返回回復的數量
+func (m *Migrator) Down(ctx context.Context, n int) (int, error) {
+	reverted := 0
+	err := m.withLock(ctx, func(conn *sql.Conn) error {
+		version, err := m.cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.find(version); version > 0 && !ok {
+			return fmt.Errorf("schema version %d has no migration file", version)
+		}
+		for i := len(m.migrations) - 1; i >= 0 && version > 0; i-- {
+			migration := m.migrations[i]
+			if migration.Version != version {
+				continue
+			}
+			if n > 0 && reverted >= n {
+				break
+			}
+			var previous uint
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+			if err := m.apply(ctx, conn, migration.down, previous); err != nil {
+				return fmt.Errorf("migration %d_%s down: %w", migration.Version, migration.Name, err)
+			}
+			slog.InfoContext(ctx, "Migration reverted", "version", migration.Version, "name", migration.Name)
+			version = previous
+			reverted++
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Force 將資料庫記錄的版本設為 version 並清除 dirty 狀態，不執行任何遷移
+// 用於手動修復失敗的遷移之後；version 為 0 表示沒有套用任何遷移
+func (m *Migrator) Force(ctx context.Context, version uint) error {
+	if version != 0 {
+		if _, ok := m.find(version); !ok {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+	}
+	return m.withLock(ctx, func(conn *sql.Conn) error {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+		if err := setVersion(ctx, tx, version); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		slog.WarnContext(ctx, "Schema version forced", "version", version)
+		return nil
+	})
+}
+
+// find 返回指定版本的遷移
+func (m *Migrator) find(version uint) (Migration, bool) {
+	for _, migration := range m.migrations {
+		if migration.Version == version {
+			return migration, true
+		}
+	}
+	return Migration{}, false
+}
+
+// withLock 在持有 advisory lock 的連線上執行 fn，並確保 schema_migrations 表存在
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	// advisory lock 屬於連線，因此取得、使用與釋放都必須在同一條連線上
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", migrationLockID).Scan(&locked); err != nil {
+		return err
+	}
+	if !locked {
+		slog.InfoContext(ctx, "Waiting for another process to finish migrating")
+		if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+			return fmt.Errorf("acquire migration lock: %w", err)
+		}
+	}
+	defer func() {
+		// ctx 可能已結束，釋放鎖不受其影響；連線關閉時鎖也會釋放
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID); err != nil {
+			slog.Error("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	// 與 golang-migrate 相同的結構，既有資料庫可直接沿用
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)"); err != nil {
+		return err
+	}
+	return fn(conn)
+}
+
+// cleanVersion 返回目前的版本，dirty 時返回 ErrDirtySchema
+func (m *Migrator) cleanVersion(ctx context.Context, conn *sql.Conn) (uint, error) {
+	version, dirty, err := readVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w at version %d, fix the schema manually and run migrate force", ErrDirtySchema, version)
+	}
+	return version, nil
+}
+
+// apply 在一個交易中執行遷移檔並記錄新版本，失敗時整個遷移回復，版本不變
+func (m *Migrator) apply(ctx context.Context, conn *sql.Conn, file string, version uint) error {
+	body, err := fs.ReadFile(m.source, file)
+	if err != nil {
+		return err
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	// 不帶參數時以 simple protocol 執行，一個檔案可包含多個語句
+	if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+		return err
+	}
+	if err := setVersion(ctx, tx, version); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// queryer 是 *sql.DB、*sql.Conn 與 *sql.Tx 共有的查詢方法
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// readVersion 讀取 schema_migrations 的版本，沒有記錄時返回 0
+func readVersion(ctx context.Context, q queryer) (uint, bool, error) {
+	var version int64
+	var dirty bool
+	err := q.QueryRowContext(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if version < 0 {
+		return 0, dirty, nil
+	}
+	return uint(version), dirty, nil
+}
+
+// setVersion 以 golang-migrate 的方式記錄版本：表中只保留一列，版本 0 時不保留任何列
+func setVersion(ctx context.Context, tx *sql.Tx, version uint) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version == 0 {
+		return nil
+	}
+	_, err := tx.ExecContext(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", int64(version))
+	return err
+}
@@ -0,0 +1,378 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// fakePostgres 模擬 Migrator 用到的 PostgreSQL 行為：schema_migrations 表、advisory lock 與交易
+// 遷移檔內容只記錄在 executed 中，包含 FAIL 的語句執行失敗
+type fakePostgres struct {
+	tableExists bool
+	rows        []versionRow
+	executed    []string
+	locks       int
+	unlocks     int
+
+	// 交易開始時的快照，回復時還原
+	snapshot *fakeSnapshot
+}
+
+type versionRow struct {
+	version int64
+	dirty   bool
+}
+
+type fakeSnapshot struct {
+	tableExists bool
+	rows        []versionRow
+	executed    []string
+}
+
+func (db *fakePostgres) Connect(ctx context.Context) (driver.Conn, error) {
+	return &fakeConn{db: db}, nil
+}
+
+func (db *fakePostgres) Driver() driver.Driver {
+	return nil
+}
+
+type fakeConn struct {
+	db *fakePostgres
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not supported")
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	db := c.db
+	db.snapshot = &fakeSnapshot{
+		tableExists: db.tableExists,
+		rows:        append([]versionRow(nil), db.rows...),
+		executed:    append([]string(nil), db.executed...),
+	}
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.snapshot = nil
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	db := c.db
+	if db.snapshot != nil {
+		db.tableExists, db.rows, db.executed = db.snapshot.tableExists, db.snapshot.rows, db.snapshot.executed
+		db.snapshot = nil
+	}
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	db := c.db
+	switch {
+	case strings.HasPrefix(query, "SELECT pg_advisory_lock"):
+		db.locks++
+	case strings.HasPrefix(query, "SELECT pg_advisory_unlock"):
+		db.unlocks++
+	case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS schema_migrations"):
+		db.tableExists = true
+	case query == "DELETE FROM schema_migrations":
+		db.rows = nil
+	case strings.HasPrefix(query, "INSERT INTO schema_migrations"):
+		db.rows = append(db.rows, versionRow{version: args[0].Value.(int64)})
+	case strings.Contains(query, "FAIL"):
+		return nil, errors.New("syntax error")
+	default:
+		db.executed = append(db.executed, query)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	db := c.db
+	switch {
+	case strings.HasPrefix(query, "SELECT to_regclass"):
+		return &fakeRows{columns: []string{"exists"}, values: [][]driver.Value{{db.tableExists}}}, nil
+	case strings.HasPrefix(query, "SELECT pg_try_advisory_lock"):
+		db.locks++
+		return &fakeRows{columns: []string{"locked"}, values: [][]driver.Value{{true}}}, nil
+	case strings.HasPrefix(query, "SELECT version, dirty FROM schema_migrations"):
+		rows := &fakeRows{columns: []string{"version", "dirty"}}
+		if len(db.rows) > 0 {
+			rows.values = append(rows.values, []driver.Value{db.rows[0].version, db.rows[0].dirty})
+		}
+		return rows, nil
+	}
+	return nil, errors.New("unexpected query: " + query)
+}
+
+type fakeRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string {
+	return r.columns
+}
+
+func (r *fakeRows) Close() error {
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+// testMigrations 是三個版本的遷移檔
+func testMigrations() fstest.MapFS {
+	return fstest.MapFS{
+		"000001_users.up.sql":    {Data: []byte("CREATE TABLE users")},
+		"000001_users.down.sql":  {Data: []byte("DROP TABLE users")},
+		"000002_links.up.sql":    {Data: []byte("CREATE TABLE links")},
+		"000002_links.down.sql":  {Data: []byte("DROP TABLE links")},
+		"000003_clicks.up.sql":   {Data: []byte("CREATE TABLE clicks")},
+		"000003_clicks.down.sql": {Data: []byte("DROP TABLE clicks")},
+		"embed.go":               {Data: []byte("package migrations")},
+	}
+}
+
+func newTestMigrator(t *testing.T, source fstest.MapFS) (*Migrator, *fakePostgres) {
+	t.Helper()
+	db := &fakePostgres{}
+	sqlDB := sql.OpenDB(db)
+	// 與 PostgreSQL 相同，advisory lock 與交易都在同一條連線上
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+	migrator, err := newMigrator(sqlDB, source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return migrator, db
+}
+
+// assertVersion 確認資料庫記錄的版本
+func assertVersion(t *testing.T, migrator *Migrator, want uint) {
+	t.Helper()
+	version, dirty, err := migrator.Version(context.Background())
+	if err != nil || dirty || version != want {
+		t.Fatalf("Version = %d, dirty %t, %v; want %d", version, dirty, err, want)
+	}
+}
+
+func TestNewMigratorValidatesFiles(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"000001_users.up.sql": {}},
+			wantErr: "needs both up and down files",
+		},
+		{
+			name:    "missing version",
+			files:   fstest.MapFS{"users.up.sql": {}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "version zero",
+			files:   fstest.MapFS{"0_users.up.sql": {}, "0_users.down.sql": {}},
+			wantErr: "invalid migration file name",
+		},
+		{
+			name:    "different names",
+			files:   fstest.MapFS{"000001_users.up.sql": {}, "000001_accounts.down.sql": {}},
+			wantErr: "different names",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newMigrator(sql.OpenDB(&fakePostgres{}), tt.files)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("err = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestMigratorLatest(t *testing.T) {
+	migrator, _ := newTestMigrator(t, testMigrations())
+	if migrator.Latest() != 3 {
+		t.Errorf("Latest = %d, want 3", migrator.Latest())
+	}
+	empty, _ := newTestMigrator(t, fstest.MapFS{})
+	if empty.Latest() != 0 {
+		t.Errorf("Latest without migrations = %d, want 0", empty.Latest())
+	}
+}
+
+func TestMigratorUpAndDown(t *testing.T) {
+	migrator, db := newTestMigrator(t, testMigrations())
+	ctx := context.Background()
+	assertVersion(t, migrator, 0)
+
+	if n, err := migrator.Up(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("Up(1) = %d, %v", n, err)
+	}
+	assertVersion(t, migrator, 1)
+
+	if n, err := migrator.Up(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("Up(0) = %d, %v; want the remaining 2", n, err)
+	}
+	assertVersion(t, migrator, 3)
+	if n, err := migrator.Up(ctx, 0); err != nil || n != 0 {
+		t.Fatalf("Up at the latest version = %d, %v", n, err)
+	}
+	if err := migrator.Check(ctx); err != nil {
+		t.Fatalf("Check at the latest version: %v", err)
+	}
+
+	if n, err := migrator.Down(ctx, 1); err != nil || n != 1 {
+		t.Fatalf("Down(1) = %d, %v", n, err)
+	}
+	assertVersion(t, migrator, 2)
+	if n, err := migrator.Down(ctx, 0); err != nil || n != 2 {
+		t.Fatalf("Down(0) = %d, %v; want the remaining 2", n, err)
+	}
+	assertVersion(t, migrator, 0)
+	if len(db.rows) != 0 {
+		t.Errorf("rows %v, want none at version 0", db.rows)
+	}
+
+	want := "CREATE TABLE users,CREATE TABLE links,CREATE TABLE clicks,DROP TABLE clicks,DROP TABLE links,DROP TABLE users"
+	if got := strings.Join(db.executed, ","); got != want {
+		t.Errorf("executed %s, want %s", got, want)
+	}
+	if db.locks != 5 || db.unlocks != 5 {
+		t.Errorf("locked %d times, unlocked %d times; want every lock released", db.locks, db.unlocks)
+	}
+}
+
+func TestMigratorUpFailureKeepsVersion(t *testing.T) {
+	files := testMigrations()
+	files["000002_links.up.sql"] = &fstest.MapFile{Data: []byte("CREATE TABLE links; FAIL")}
+	migrator, db := newTestMigrator(t, files)
+	ctx := context.Background()
+
+	n, err := migrator.Up(ctx, 0)
+	if err == nil || n != 1 || !strings.Contains(err.Error(), "migration 2_links up") {
+		t.Fatalf("Up = %d, %v; want migration 2 to fail after 1 applied", n, err)
+	}
+	// 失敗的遷移在交易中回復，版本停在上一個且不是 dirty
+	assertVersion(t, migrator, 1)
+	if strings.Join(db.executed, ",") != "CREATE TABLE users" {
+		t.Errorf("executed %v, want only the first migration", db.executed)
+	}
+	if db.unlocks != db.locks {
+		t.Error("the lock must be released after a failure")
+	}
+}
+
+func TestMigratorRefusesDirtySchema(t *testing.T) {
+	migrator, db := newTestMigrator(t, testMigrations())
+	ctx := context.Background()
+	db.tableExists = true
+	db.rows = []versionRow{{version: 2, dirty: true}}
+
+	if _, err := migrator.Up(ctx, 0); !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("Up: err = %v, want ErrDirtySchema", err)
+	}
+	if _, err := migrator.Down(ctx, 0); !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("Down: err = %v, want ErrDirtySchema", err)
+	}
+	if err := migrator.Check(ctx); !errors.Is(err, ErrDirtySchema) {
+		t.Fatalf("Check: err = %v, want ErrDirtySchema", err)
+	}
+	if len(db.executed) != 0 {
+		t.Fatalf("executed %v on a dirty schema", db.executed)
+	}
+
+	// 手動修復後以 Force 標記版本，之後可繼續遷移
+	if err := migrator.Force(ctx, 2); err != nil {
+		t.Fatalf("Force: %v", err)
+	}
+	assertVersion(t, migrator, 2)
+	if n, err := migrator.Up(ctx, 0); err != nil || n != 1 {
+		t.Fatalf("Up after Force = %d, %v", n, err)
+	}
+	assertVersion(t, migrator, 3)
+}
+
+func TestMigratorForce(t *testing.T) {
+	migrator, db := newTestMigrator(t, testMigrations())
+	ctx := context.Background()
+
+	if err := migrator.Force(ctx, 7); err == nil || !strings.Contains(err.Error(), "unknown migration version 7") {
+		t.Fatalf("Force(7): err = %v", err)
+	}
+	if err := migrator.Force(ctx, 3); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, migrator, 3)
+	if err := migrator.Force(ctx, 0); err != nil {
+		t.Fatal(err)
+	}
+	assertVersion(t, migrator, 0)
+	if len(db.rows) != 0 || len(db.executed) != 0 {
+		t.Errorf("rows %v, executed %v; Force must only record the version", db.rows, db.executed)
+	}
+}
+
+func TestMigratorRejectsUnknownVersion(t *testing.T) {
+	migrator, db := newTestMigrator(t, testMigrations())
+	ctx := context.Background()
+	db.tableExists = true
+	db.rows = []versionRow{{version: 9}}
+
+	if _, err := migrator.Up(ctx, 0); err == nil || !strings.Contains(err.Error(), "newer than the latest migration 3") {
+		t.Fatalf("Up: err = %v", err)
+	}
+	if _, err := migrator.Down(ctx, 0); err == nil || !strings.Contains(err.Error(), "has no migration file") {
+		t.Fatalf("Down: err = %v", err)
+	}
+	if len(db.executed) != 0 {
+		t.Errorf("executed %v, want nothing", db.executed)
+	}
+}
+
+func TestMigratorCheck(t *testing.T) {
+	migrator, db := newTestMigrator(t, testMigrations())
+	ctx := context.Background()
+
+	if err := migrator.Check(ctx); err == nil || err.Error() != "no migrations applied" {
+		t.Fatalf("Check without schema_migrations: err = %v", err)
+	}
+	db.tableExists = true
+	db.rows = []versionRow{{version: 2}}
+	if err := migrator.Check(ctx); err == nil || err.Error() != "schema version 2, expected 3" {
+		t.Fatalf("Check behind: err = %v", err)
+	}
+
+	statuses, version, dirty, err := migrator.Status(ctx)
+	if err != nil || version != 2 || dirty {
+		t.Fatalf("Status = %d, %t, %v", version, dirty, err)
+	}
+	for _, status := range statuses {
+		if status.Applied != (status.Version <= 2) {
+			t.Errorf("migration %d applied = %t", status.Version, status.Applied)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package dns
+
+import (
+	"context"
+	"net"
+
+	"go_short/domain/urlshortener/service"
+)
+
+// netResolver 使用標準庫 net.Resolver 實作 TXTResolver
+type netResolver struct {
+	resolver *net.Resolver
+}
+
+// NewTXTResolver 創建使用系統 DNS 的 TXTResolver
+func NewTXTResolver() service.TXTResolver {
+	return &netResolver{resolver: net.DefaultResolver}
+}
+
+// LookupTXT 查詢指定名稱的 TXT 記錄
+func (r *netResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	return r.resolver.LookupTXT(ctx, name)
+}
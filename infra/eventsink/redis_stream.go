@@ -0,0 +1,46 @@
+package eventsink
+
+import (
+	"context"
+	"strconv"
+
+	"go_short/domain/event"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisStreamSink 將事件以 XADD 寫入 Redis Stream，供其他服務以 consumer group 讀取
+type redisStreamSink struct {
+	client *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamSink 創建 Redis Streams 目的地；maxLen 大於 0 時以近似裁剪限制 stream 長度
+func NewRedisStreamSink(client *redis.Client, stream string, maxLen int64) event.Sink {
+	return &redisStreamSink{client: client, stream: stream, maxLen: maxLen}
+}
+
+func (s *redisStreamSink) Name() string {
+	return "redis:" + s.stream
+}
+
+// Send 寫入一筆 stream 訊息，欄位為 id、type、aggregate_type、aggregate_id、occurred_at 與 payload (JSON)
+func (s *redisStreamSink) Send(ctx context.Context, record *event.Record) error {
+	args := &redis.XAddArgs{
+		Stream: s.stream,
+		Values: map[string]interface{}{
+			"id":             record.EventID,
+			"type":           record.EventType,
+			"aggregate_type": record.AggregateType,
+			"aggregate_id":   record.AggregateID,
+			"occurred_at":    strconv.FormatInt(record.OccurredAt.UnixMilli(), 10),
+			"payload":        record.Payload,
+		},
+	}
+	if s.maxLen > 0 {
+		args.MaxLen = s.maxLen
+		args.Approx = true
+	}
+	return s.client.XAdd(ctx, args).Err()
+}
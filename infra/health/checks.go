@@ -0,0 +1,69 @@
+// Package health 提供就緒檢查使用的外部依賴檢查
+package health
+
+import (
+	"context"
+
+	"go_short/infra/database"
+
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+// DatabaseCheck 以 ping 檢查資料庫連線
+type DatabaseCheck struct {
+	db *gorm.DB
+}
+
+// NewDatabaseCheck 建立資料庫連線檢查
+func NewDatabaseCheck(db *gorm.DB) *DatabaseCheck {
+	return &DatabaseCheck{db: db}
+}
+
+// Name 實作 healthapp.Checker
+func (c *DatabaseCheck) Name() string { return "database" }
+
+// Check 實作 healthapp.Checker
+func (c *DatabaseCheck) Check(ctx context.Context) error {
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// RedisCheck 以 PING 檢查 Redis 連線
+type RedisCheck struct {
+	client *redis.Client
+}
+
+// NewRedisCheck 建立 Redis 連線檢查
+func NewRedisCheck(client *redis.Client) *RedisCheck {
+	return &RedisCheck{client: client}
+}
+
+// Name 實作 healthapp.Checker
+func (c *RedisCheck) Name() string { return "redis" }
+
+// Check 實作 healthapp.Checker
+func (c *RedisCheck) Check(ctx context.Context) error {
+	return c.client.Ping(ctx).Err()
+}
+
+// SchemaCheck 檢查資料庫結構版本是否與執行檔內嵌的遷移檔一致且不是 dirty 狀態
+type SchemaCheck struct {
+	migrator *database.Migrator
+}
+
+// NewSchemaCheck 建立資料庫結構版本檢查
+func NewSchemaCheck(migrator *database.Migrator) *SchemaCheck {
+	return &SchemaCheck{migrator: migrator}
+}
+
+// Name 實作 healthapp.Checker
+func (c *SchemaCheck) Name() string { return "schema" }
+
+// Check 實作 healthapp.Checker
+func (c *SchemaCheck) Check(ctx context.Context) error {
+	return c.migrator.Check(ctx)
+}
@@ -0,0 +1,51 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	auditservice "go_short/domain/audit/service"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// contextHandler 在每筆日誌加上 context 中的請求 ID、已認證的使用者與 trace ID，
+// 讓各層只需以 slog.InfoContext(ctx, ...) 記錄即可串連同一個請求的日誌
+type contextHandler struct {
+	next slog.Handler
+}
+
+// Enabled 實作 slog.Handler
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+// Handle 實作 slog.Handler
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx != nil {
+		meta := auditservice.MetadataFrom(ctx)
+		if meta.RequestID != "" {
+			record.AddAttrs(slog.String("request_id", meta.RequestID))
+		}
+		if meta.ActorID != nil {
+			record.AddAttrs(slog.Uint64("actor_id", uint64(*meta.ActorID)))
+		}
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			record.AddAttrs(
+				slog.String("trace_id", spanContext.TraceID().String()),
+				slog.String("span_id", spanContext.SpanID().String()),
+			)
+		}
+	}
+	return h.next.Handle(ctx, record)
+}
+
+// WithAttrs 實作 slog.Handler
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{next: h.next.WithAttrs(attrs)}
+}
+
+// WithGroup 實作 slog.Handler
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{next: h.next.WithGroup(name)}
+}
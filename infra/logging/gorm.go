@@ -0,0 +1,66 @@
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// gormLogger 將 GORM 的日誌轉為結構化日誌：
+// 失敗的查詢記錄為 error、慢查詢為 warn，其餘 SQL 只在 debug 等級輸出
+type gormLogger struct {
+	logger        *slog.Logger
+	slowThreshold time.Duration
+}
+
+// NewGormLogger 返回以 logger 輸出的 GORM logger，超過 slowThreshold 的查詢記錄為慢查詢
+func NewGormLogger(logger *slog.Logger, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{logger: logger, slowThreshold: slowThreshold}
+}
+
+// LogMode 實作 gormlogger.Interface；輸出等級由 slog 的等級決定
+func (l *gormLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info 實作 gormlogger.Interface
+func (l *gormLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Warn 實作 gormlogger.Interface
+func (l *gormLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Error 實作 gormlogger.Interface
+func (l *gormLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	l.logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// ParamsFilter 實作 gorm.ParamsFilter：日誌中的 SQL 只保留佔位符，
+// 避免密碼雜湊、token 雜湊與個人資料等參數值寫入日誌
+func (l *gormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
+
+// Trace 實作 gormlogger.Interface，SQL 只在需要輸出時才組出
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		l.logger.ErrorContext(ctx, "Database query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold:
+		sql, rows := fc()
+		l.logger.WarnContext(ctx, "Slow database query", "sql", sql, "rows", rows, "duration", elapsed)
+	case l.logger.Enabled(ctx, slog.LevelDebug):
+		sql, rows := fc()
+		l.logger.DebugContext(ctx, "Database query", "sql", sql, "rows", rows, "duration", elapsed)
+	}
+}
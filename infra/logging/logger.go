@@ -0,0 +1,67 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+)
+
+// 支援的輸出格式
+const (
+	FormatJSON = "json"
+	FormatText = "text"
+)
+
+// Options 是日誌的設定
+type Options struct {
+	Level  string    // debug, info, warn 或 error
+	Format string    // json 或 text
+	Output io.Writer // 輸出目的地
+	// LevelVar 不為 nil 時以它作為輸出等級，之後可在執行中以 LevelVar.Set 調整
+	LevelVar *slog.LevelVar
+}
+
+// New 建立結構化日誌：敏感欄位會被遮蔽，並自動附上 context 中的請求 ID、使用者與 trace ID
+func New(opts Options) (*slog.Logger, error) {
+	level, err := ParseLevel(opts.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var leveler slog.Leveler = level
+	if opts.LevelVar != nil {
+		opts.LevelVar.Set(level)
+		leveler = opts.LevelVar
+	}
+	handlerOpts := &slog.HandlerOptions{
+		Level:       leveler,
+		ReplaceAttr: redact,
+	}
+	var handler slog.Handler
+	switch opts.Format {
+	case "", FormatJSON:
+		handler = slog.NewJSONHandler(opts.Output, handlerOpts)
+	case FormatText:
+		handler = slog.NewTextHandler(opts.Output, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+	return slog.New(&contextHandler{next: handler}), nil
+}
+
+// ParseLevel 解析日誌等級，空字串視為 info
+func ParseLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(value) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	default:
+		return slog.LevelInfo, fmt.Errorf("unknown log level %q", value)
+	}
+}
@@ -0,0 +1,48 @@
+package logging
+
+import (
+	"log/slog"
+	"strings"
+)
+
+// redactedValue 取代敏感欄位的值
+const redactedValue = "[REDACTED]"
+
+// sensitiveKeys 是需要遮蔽的欄位名稱片段 (不分大小寫)
+var sensitiveKeys = []string{
+	"password",
+	"passwd",
+	"secret",
+	"token",
+	"authorization",
+	"cookie",
+	"api_key",
+	"apikey",
+	"recovery_code",
+	"otp",
+}
+
+// redact 遮蔽名稱包含敏感片段的欄位，例如 password、refresh_token、webhook_secret
+// 以 _id 結尾的欄位 (例如 refresh_token_id) 只是識別碼，不遮蔽
+func redact(groups []string, attr slog.Attr) slog.Attr {
+	if attr.Value.Kind() == slog.KindGroup {
+		return attr
+	}
+	if isSensitive(attr.Key) {
+		return slog.String(attr.Key, redactedValue)
+	}
+	return attr
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	if strings.HasSuffix(key, "_id") {
+		return false
+	}
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
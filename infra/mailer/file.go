@@ -0,0 +1,38 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"go_short/domain/notification"
+)
+
+// unsafeFileChars 用於將收件人轉為安全的檔名
+var unsafeFileChars = regexp.MustCompile(`[^a-zA-Z0-9@._-]`)
+
+// fileMailer 將郵件寫入本機目錄中的 .eml 檔案，方便開發與測試時檢視
+type fileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer 創建一個將郵件寫入 dir 的 Mailer
+func NewFileMailer(dir, from string) notification.Mailer {
+	return &fileMailer{dir: dir, from: from}
+}
+
+func (m *fileMailer) Send(ctx context.Context, msg notification.Message) error {
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create mail directory: %w", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), unsafeFileChars.ReplaceAllString(msg.To, "_"))
+	if err := os.WriteFile(filepath.Join(m.dir, name), buildMessage(m.from, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write mail to %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,21 @@
+package mailer
+
+import (
+	"context"
+	"log/slog"
+
+	"go_short/domain/notification"
+)
+
+// logMailer 只將郵件內容寫入日誌，不實際寄出
+type logMailer struct{}
+
+// NewLogMailer 創建一個將郵件輸出到日誌的 Mailer
+func NewLogMailer() notification.Mailer {
+	return &logMailer{}
+}
+
+func (m *logMailer) Send(ctx context.Context, msg notification.Message) error {
+	slog.InfoContext(ctx, "Mail", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+	return nil
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"fmt"
+	"strconv"
+
+	"go_short/conf"
+	"go_short/domain/notification"
+)
+
+// 支援的 Mailer 驅動
+const (
+	DriverSMTP = "smtp"
+	DriverFile = "file"
+	DriverLog  = "log"
+)
+
+// New 根據配置創建對應的 Mailer
+func New(config *conf.Config) (notification.Mailer, error) {
+	mailer := config.Mailer
+	switch mailer.Driver {
+	case DriverSMTP:
+		if mailer.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mailer")
+		}
+		return NewSMTPMailer(mailer.SMTPHost, strconv.Itoa(mailer.SMTPPort), mailer.SMTPUsername, mailer.SMTPPassword, mailer.From), nil
+	case DriverFile:
+		return NewFileMailer(mailer.FileDir, mailer.From), nil
+	case DriverLog, "":
+		return NewLogMailer(), nil
+	default:
+		return nil, fmt.Errorf("unknown MAILER_DRIVER %q", mailer.Driver)
+	}
+}
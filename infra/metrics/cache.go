@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/urlshortener/repository"
+)
+
+// instrumentedCache 包裝快取儲存庫，統計 Get 的命中與未命中次數
+type instrumentedCache struct {
+	next    repository.CacheRepository
+	metrics *Metrics
+}
+
+// NewInstrumentedCache 返回記錄命中率的快取儲存庫，其餘操作直接交給 next
+func NewInstrumentedCache(next repository.CacheRepository, metrics *Metrics) repository.CacheRepository {
+	return &instrumentedCache{next: next, metrics: metrics}
+}
+
+// Get 從緩存中獲取 URL 映射並記錄是否命中
+func (c *instrumentedCache) Get(ctx context.Context, shortURL string) (string, bool) {
+	value, found := c.next.Get(ctx, shortURL)
+	result := "miss"
+	if found {
+		result = "hit"
+	}
+	c.metrics.cacheRequests.WithLabelValues(result).Inc()
+	return value, found
+}
+
+// Set 將 URL 映射保存到緩存
+func (c *instrumentedCache) Set(ctx context.Context, shortURL string, originalURL string, expiration time.Duration) error {
+	return c.next.Set(ctx, shortURL, originalURL, expiration)
+}
+
+// Delete 從緩存中刪除 URL 映射
+func (c *instrumentedCache) Delete(ctx context.Context, shortURL string) error {
+	return c.next.Delete(ctx, shortURL)
+}
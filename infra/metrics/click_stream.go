@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/analytics/repository"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// clickStreamTimeout 是每次抓取指標時查詢串流狀態的時間上限
+const clickStreamTimeout = 2 * time.Second
+
+// clickStreamCollector 在抓取指標時查詢點擊串流的長度、未確認數與延遲
+type clickStreamCollector struct {
+	stats     func(ctx context.Context) (*repository.StreamStats, error)
+	length    *prometheus.Desc
+	pending   *prometheus.Desc
+	lag       *prometheus.Desc
+	consumers *prometheus.Desc
+}
+
+// NewClickStreamCollector 返回匯出點擊串流狀態的 collector，查詢失敗時該次抓取不輸出這些指標
+func NewClickStreamCollector(stats func(ctx context.Context) (*repository.StreamStats, error)) prometheus.Collector {
+	return &clickStreamCollector{
+		stats:     stats,
+		length:    prometheus.NewDesc(namespace+"_click_stream_length", "Messages in the click stream.", nil, nil),
+		pending:   prometheus.NewDesc(namespace+"_click_stream_pending", "Clicks read by the consumer group but not yet acknowledged.", nil, nil),
+		lag:       prometheus.NewDesc(namespace+"_click_stream_lag", "Clicks not yet read by the consumer group.", nil, nil),
+		consumers: prometheus.NewDesc(namespace+"_click_stream_consumers", "Consumers in the click consumer group.", nil, nil),
+	}
+}
+
+// Describe 實作 prometheus.Collector
+func (c *clickStreamCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.length
+	ch <- c.pending
+	ch <- c.lag
+	ch <- c.consumers
+}
+
+// Collect 實作 prometheus.Collector
+func (c *clickStreamCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), clickStreamTimeout)
+	defer cancel()
+	stats, err := c.stats(ctx)
+	if err != nil {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.length, prometheus.GaugeValue, float64(stats.Length))
+	ch <- prometheus.MustNewConstMetric(c.pending, prometheus.GaugeValue, float64(stats.Pending))
+	ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, float64(stats.Lag))
+	ch <- prometheus.MustNewConstMetric(c.consumers, prometheus.GaugeValue, float64(stats.Consumers))
+}
@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace 是所有指標名稱的前綴
+const namespace = "goshort"
+
+// storageBuckets 是資料庫與 Redis 指令的延遲分桶 (0.5ms ~ 約 4s)
+var storageBuckets = prometheus.ExponentialBuckets(0.0005, 2, 14)
+
+// Metrics 集中管理應用程式匯出的 Prometheus 指標
+// 使用獨立的 registry，避免第三方套件註冊到全域 registry 的指標混入
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	cacheRequests *prometheus.CounterVec
+
+	dbDuration *prometheus.HistogramVec
+	dbErrors   *prometheus.CounterVec
+
+	redisDuration *prometheus.HistogramVec
+	redisErrors   *prometheus.CounterVec
+
+	cleanupRuns    *prometheus.CounterVec
+	cleanupDeleted *prometheus.CounterVec
+}
+
+// New 建立指標並註冊到新的 registry，同時匯出 Go runtime 與行程的統計
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by method, route and status code.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		cacheRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cache_requests_total",
+			Help:      "Link cache lookups by result (hit or miss).",
+		}, []string{"result"}),
+		dbDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "db_query_duration_seconds",
+			Help:      "Database query latency by operation and table.",
+			Buckets:   storageBuckets,
+		}, []string{"operation", "table"}),
+		dbErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_query_errors_total",
+			Help:      "Failed database queries by operation and table.",
+		}, []string{"operation", "table"}),
+		redisDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "redis_command_duration_seconds",
+			Help:      "Redis command latency by command.",
+			Buckets:   storageBuckets,
+		}, []string{"command"}),
+		redisErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "redis_command_errors_total",
+			Help:      "Failed Redis commands by command.",
+		}, []string{"command"}),
+		cleanupRuns: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_runs_total",
+			Help:      "Background cleanup runs by task and result.",
+		}, []string{"task", "result"}),
+		cleanupDeleted: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "cleanup_deleted_rows_total",
+			Help:      "Rows deleted by background cleanup tasks.",
+		}, []string{"task"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.cacheRequests,
+		m.dbDuration,
+		m.dbErrors,
+		m.redisDuration,
+		m.redisErrors,
+		m.cleanupRuns,
+		m.cleanupDeleted,
+	)
+	return m
+}
+
+// Handler 返回以 Prometheus 文字格式輸出指標的 HTTP handler
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{Registry: m.registry})
+}
+
+// Register 註冊額外的 collector (例如點擊串流的狀態)
+func (m *Metrics) Register(c prometheus.Collector) error {
+	return m.registry.Register(c)
+}
+
+// ObserveHTTPRequest 記錄一個 HTTP 請求，route 是路由樣板 (例如 /api/urls/:id) 以避免標籤數量失控
+func (m *Metrics) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	code := strconv.Itoa(status)
+	m.httpRequests.WithLabelValues(method, route, code).Inc()
+	m.httpDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+// ObserveCleanup 記錄一次背景清理任務的執行結果，實作 jobs.CleanupObserver
+func (m *Metrics) ObserveCleanup(task string, deleted int64, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	m.cleanupRuns.WithLabelValues(task, result).Inc()
+	if deleted > 0 {
+		m.cleanupDeleted.WithLabelValues(task).Add(float64(deleted))
+	}
+}
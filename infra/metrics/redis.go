@@ -0,0 +1,55 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisHook 以 go-redis hook 記錄每個指令的延遲與錯誤
+type redisHook struct {
+	metrics *Metrics
+}
+
+// RedisHook 返回記錄指令延遲的 go-redis hook，以 client.AddHook 安裝
+func (m *Metrics) RedisHook() redis.Hook {
+	return &redisHook{metrics: m}
+}
+
+// DialHook 實作 redis.Hook，連線建立不計入指令延遲
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 實作 redis.Hook
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmd)
+		h.observe(cmd.Name(), time.Since(start), err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 實作 redis.Hook，管線 (含 MULTI/EXEC) 以 "pipeline" 記錄整體延遲
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		start := time.Now()
+		err := next(ctx, cmds)
+		h.observe("pipeline", time.Since(start), err)
+		return err
+	}
+}
+
+func (h *redisHook) observe(command string, duration time.Duration, err error) {
+	h.metrics.redisDuration.WithLabelValues(command).Observe(duration.Seconds())
+	// redis.Nil 表示鍵不存在，屬於正常結果
+	if err != nil && !errors.Is(err, redis.Nil) {
+		h.metrics.redisErrors.WithLabelValues(command).Inc()
+	}
+}
@@ -0,0 +1,62 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"gorm.io/gorm"
+)
+
+// apiKeyRepository 是 APIKeyRepository 的 GORM 實現
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAPIKeyRepository 創建 APIKeyRepository 的 GORM 實例
+func NewGormAPIKeyRepository(db *gorm.DB) repository.APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *entity.APIKey) error {
+	return conn(ctx, r.db).Create(key).Error
+}
+
+func (r *apiKeyRepository) FindByID(ctx context.Context, id uint) (*entity.APIKey, error) {
+	var key entity.APIKey
+	result := conn(ctx, r.db).First(&key, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByHash(ctx context.Context, keyHash string) (*entity.APIKey, error) {
+	var key entity.APIKey
+	result := conn(ctx, r.db).Where("key_hash = ?", keyHash).First(&key)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.APIKey, error) {
+	var keys []*entity.APIKey
+	result := conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&keys)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) Update(ctx context.Context, key *entity.APIKey) error {
+	return conn(ctx, r.db).Save(key).Error
+}
@@ -0,0 +1,70 @@
+package gormpersistence
+
+import (
+	"context"
+	"strings"
+
+	"go_short/domain/audit/entity"
+	"go_short/domain/audit/repository"
+
+	"gorm.io/gorm"
+)
+
+// auditRepository 是 AuditRepository 的 GORM 實現
+type auditRepository struct {
+	db *gorm.DB
+}
+
+// NewGormAuditRepository 創建 AuditRepository 的 GORM 實例
+func NewGormAuditRepository(db *gorm.DB) repository.AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) Append(ctx context.Context, entry *entity.AuditEntry) error {
+	return conn(ctx, r.db).Create(entry).Error
+}
+
+func (r *auditRepository) Search(ctx context.Context, filter repository.AuditFilter, offset, limit int) ([]*entity.AuditEntry, int64, error) {
+	db := conn(ctx, r.db).Model(&entity.AuditEntry{})
+	if filter.ActorID != nil {
+		db = db.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			db = db.Where("action LIKE ?", escapeLike(filter.Action)+"%")
+		} else {
+			db = db.Where("action = ?", filter.Action)
+		}
+	}
+	if filter.TargetType != "" {
+		db = db.Where("target_type = ?", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		db = db.Where("target_id = ?", filter.TargetID)
+	}
+	if filter.RequestID != "" {
+		db = db.Where("request_id = ?", filter.RequestID)
+	}
+	if filter.Since != nil {
+		db = db.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		db = db.Where("created_at < ?", *filter.Until)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []*entity.AuditEntry
+	if err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&entries).Error; err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// escapeLike 跳脫 LIKE 樣式中的萬用字元
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,75 @@
+package gormpersistence
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"go_short/domain/analytics/entity"
+	"go_short/domain/analytics/repository"
+
+	"gorm.io/gorm"
+)
+
+// clickRepository 是 ClickRepository 的 GORM 實現
+type clickRepository struct {
+	db *gorm.DB
+}
+
+// NewGormClickRepository 創建 ClickRepository 的 GORM 實例
+func NewGormClickRepository(db *gorm.DB) repository.ClickRepository {
+	return &clickRepository{db: db}
+}
+
+// InsertBatch 以單一語句寫入點擊並累加訪問次數；ON CONFLICT 略過已處理的訊息，
+// 只有實際寫入的點擊才會計入 url_mappings.visits。已被永久刪除的連結的點擊會被略過
+func (r *clickRepository) InsertBatch(ctx context.Context, clicks []*entity.Click) error {
+	if len(clicks) == 0 {
+		return nil
+	}
+
+	values := make([]string, 0, len(clicks))
+	args := make([]interface{}, 0, len(clicks)*5)
+	for _, click := range clicks {
+		values = append(values, "(?, ?::bigint, ?::timestamptz, ?, ?)")
+		args = append(args, click.StreamID, click.LinkID, click.ClickedAt, click.Referrer, click.UserAgent)
+	}
+
+	sql := `
+		WITH incoming (stream_id, link_id, clicked_at, referrer, user_agent) AS (
+			VALUES ` + strings.Join(values, ", ") + `
+		), inserted AS (
+			INSERT INTO link_clicks (stream_id, link_id, clicked_at, referrer, user_agent)
+			SELECT i.stream_id, i.link_id, i.clicked_at, i.referrer, i.user_agent
+			FROM incoming i JOIN url_mappings m ON m.id = i.link_id
+			ON CONFLICT (stream_id) DO NOTHING
+			RETURNING link_id
+		)
+		UPDATE url_mappings SET visits = visits + c.clicks
+		FROM (SELECT link_id, COUNT(*) AS clicks FROM inserted GROUP BY link_id) c
+		WHERE url_mappings.id = c.link_id`
+	return conn(ctx, r.db).Exec(sql, args...).Error
+}
+
+func (r *clickRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.Click, error) {
+	var clicks []*entity.Click
+	err := conn(ctx, r.db).
+		Joins("JOIN url_mappings ON url_mappings.id = link_clicks.link_id").
+		Where("url_mappings.user_id = ? AND url_mappings.workspace_id IS NULL", userID).
+		Order("link_clicks.clicked_at, link_clicks.id").
+		Find(&clicks).Error
+	return clicks, err
+}
+
+// DeleteExpired 以連結建立者的方案決定保留天數；沒有建立者的連結 (匿名建立或建立者已清除) 使用 free 方案
+func (r *clickRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	result := conn(ctx, r.db).Exec(`
+		DELETE FROM link_clicks c
+		USING url_mappings m
+		LEFT JOIN users u ON u.id = m.user_id
+		JOIN plans p ON p.name = COALESCE(u.plan, 'free')
+		WHERE c.link_id = m.id
+		AND p.analytics_retention_days IS NOT NULL
+		AND c.clicked_at < ?::timestamptz - p.analytics_retention_days * INTERVAL '1 day'`, now)
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,76 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+
+	"go_short/domain/urlshortener/entity"
+	"go_short/domain/urlshortener/repository"
+
+	"gorm.io/gorm"
+)
+
+// domainRepository 是 DomainRepository 的 GORM 實現
+type domainRepository struct {
+	db *gorm.DB
+}
+
+// NewGormDomainRepository 創建 DomainRepository 的 GORM 實例
+func NewGormDomainRepository(db *gorm.DB) repository.DomainRepository {
+	return &domainRepository{db: db}
+}
+
+func (r *domainRepository) Create(ctx context.Context, domain *entity.Domain) error {
+	return conn(ctx, r.db).Create(domain).Error
+}
+
+func (r *domainRepository) FindByID(ctx context.Context, id uint) (*entity.Domain, error) {
+	var domain entity.Domain
+	result := conn(ctx, r.db).First(&domain, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &domain, nil
+}
+
+func (r *domainRepository) FindByHost(ctx context.Context, host string) (*entity.Domain, error) {
+	var domain entity.Domain
+	// 已驗證的網域優先，其次是最早的未驗證申請
+	result := conn(ctx, r.db).Where("host = ?", host).Order("verified_at IS NULL, id").First(&domain)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &domain, nil
+}
+
+func (r *domainRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.Domain, error) {
+	var domains []*entity.Domain
+	result := conn(ctx, r.db).Where("user_id = ? AND workspace_id IS NULL", userID).Order("id").Find(&domains)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return domains, nil
+}
+
+func (r *domainRepository) FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.Domain, error) {
+	var domains []*entity.Domain
+	result := conn(ctx, r.db).Where("workspace_id = ?", workspaceID).Order("id").Find(&domains)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return domains, nil
+}
+
+func (r *domainRepository) Update(ctx context.Context, domain *entity.Domain) error {
+	return conn(ctx, r.db).Save(domain).Error
+}
+
+func (r *domainRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.Domain{}, id).Error
+}
@@ -0,0 +1,124 @@
+package gormpersistence
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go_short/domain/privacy/entity"
+	"go_short/domain/privacy/repository"
+
+	"gorm.io/gorm"
+)
+
+// erasureChainLockKey 是寫入稽核雜湊鏈時使用的 advisory lock 鍵值，確保鏈結依序接續
+const erasureChainLockKey = 736101
+
+// erasureRepository 是 ErasureRepository 的 GORM 實現
+type erasureRepository struct {
+	db *gorm.DB
+}
+
+// NewGormErasureRepository 創建 ErasureRepository 的 GORM 實例
+func NewGormErasureRepository(db *gorm.DB) repository.ErasureRepository {
+	return &erasureRepository{db: db}
+}
+
+// EraseUser 清除使用者的個人資料：
+// 憑證與外部帳號直接刪除，個人連結與網域永久刪除，工作區中的連結與網域只移除建立者，
+// 尚在 outbox 中的相關事件刪除或移除建立者，稽核記錄保留動作本身但移除差異與來源資訊，users 資料列則匿名化後軟刪除，保留 ID 讓其他資料表的參照仍然有效
+func (r *erasureRepository) EraseUser(ctx context.Context, userID uint, record *entity.ErasureRecord) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		// 直接查詢資料表，已軟刪除的帳號同樣需要清除
+		var email string
+		result := tx.Table("users").Select("email").Where("id = ?", userID).Scan(&email)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return repository.ErrSubjectNotFound
+		}
+
+		now := time.Now()
+		statements := []struct {
+			name string
+			sql  string
+			args []interface{}
+		}{
+			{"api_keys", "DELETE FROM api_keys WHERE user_id = ?", []interface{}{userID}},
+			{"refresh_tokens", "DELETE FROM refresh_tokens WHERE user_id = ?", []interface{}{userID}},
+			{"recovery_codes", "DELETE FROM recovery_codes WHERE user_id = ?", []interface{}{userID}},
+			{"external_identities", "DELETE FROM external_identities WHERE user_id = ?", []interface{}{userID}},
+			{"workspace_members", "DELETE FROM workspace_members WHERE user_id = ?", []interface{}{userID}},
+			{"workspace_invitations", "DELETE FROM workspace_invitations WHERE LOWER(email) = LOWER(?)", []interface{}{email}},
+			{"outbox_events_deleted", `DELETE FROM outbox_events
+				WHERE (aggregate_type = 'user' AND aggregate_id = ?)
+				OR (aggregate_type = 'link' AND aggregate_id IN (SELECT id::text FROM url_mappings WHERE user_id = ? AND workspace_id IS NULL))`,
+				[]interface{}{strconv.FormatUint(uint64(userID), 10), userID}},
+			{"outbox_events_anonymized", `UPDATE outbox_events SET payload = payload #- '{link,user_id}'
+				WHERE aggregate_type = 'link' AND payload->'link'->>'user_id' = ?`,
+				[]interface{}{strconv.FormatUint(uint64(userID), 10)}},
+			{"url_mappings_deleted", "DELETE FROM url_mappings WHERE user_id = ? AND workspace_id IS NULL", []interface{}{userID}},
+			{"url_mappings_anonymized", "UPDATE url_mappings SET user_id = NULL WHERE user_id = ?", []interface{}{userID}},
+			{"domains_deleted", "DELETE FROM domains WHERE user_id = ? AND workspace_id IS NULL", []interface{}{userID}},
+			{"domains_anonymized", "UPDATE domains SET user_id = NULL WHERE user_id = ?", []interface{}{userID}},
+			{"webhooks_deleted", "DELETE FROM webhooks WHERE user_id = ? AND workspace_id IS NULL", []interface{}{userID}},
+			{"webhooks_anonymized", "UPDATE webhooks SET user_id = NULL WHERE user_id = ?", []interface{}{userID}},
+			{"audit_logs_redacted", `UPDATE audit_logs SET changes = NULL, ip = NULL, user_agent = NULL
+				WHERE (actor_id = ? OR (target_type = 'user' AND target_id = ?))
+				AND (changes IS NOT NULL OR ip IS NOT NULL OR user_agent IS NOT NULL)`,
+				[]interface{}{userID, strconv.FormatUint(uint64(userID), 10)}},
+			{"users_anonymized", `UPDATE users SET
+				username = ?, email = ?, password_hash = '', is_active = FALSE,
+				last_login = NULL, email_verified_at = NULL, totp_secret = NULL, totp_enabled_at = NULL,
+				updated_at = ?, deleted_at = COALESCE(deleted_at, ?)
+				WHERE id = ?`,
+				[]interface{}{fmt.Sprintf("erased-%d", userID), fmt.Sprintf("erased-%d@erased.invalid", userID), now, now, userID}},
+		}
+
+		summary := make(map[string]int64, len(statements))
+		for _, stmt := range statements {
+			result := tx.Exec(stmt.sql, stmt.args...)
+			if result.Error != nil {
+				return fmt.Errorf("erase %s: %w", stmt.name, result.Error)
+			}
+			summary[stmt.name] = result.RowsAffected
+		}
+
+		encoded, err := json.Marshal(summary)
+		if err != nil {
+			return err
+		}
+		record.Summary = string(encoded)
+
+		return appendErasureRecord(tx, record)
+	})
+}
+
+// appendErasureRecord 在交易中取得鎖後讀取鏈尾的雜湊值，計算並寫入新記錄
+func appendErasureRecord(tx *gorm.DB, record *entity.ErasureRecord) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", erasureChainLockKey).Error; err != nil {
+		return err
+	}
+
+	var last entity.ErasureRecord
+	prevHash := ""
+	err := tx.Order("id DESC").Limit(1).Find(&last).Error
+	if err != nil {
+		return err
+	}
+	if last.ID != 0 {
+		prevHash = last.Hash
+	}
+
+	record.Seal(prevHash)
+	return tx.Create(record).Error
+}
+
+func (r *erasureRepository) List(ctx context.Context) ([]*entity.ErasureRecord, error) {
+	var records []*entity.ErasureRecord
+	err := conn(ctx, r.db).Order("id ASC").Find(&records).Error
+	return records, err
+}
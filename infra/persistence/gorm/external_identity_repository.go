@@ -0,0 +1,46 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"gorm.io/gorm"
+)
+
+// externalIdentityRepository 是 ExternalIdentityRepository 的 GORM 實現
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+// NewGormExternalIdentityRepository 創建 ExternalIdentityRepository 的 GORM 實例
+func NewGormExternalIdentityRepository(db *gorm.DB) repository.ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db}
+}
+
+func (r *externalIdentityRepository) Create(ctx context.Context, identity *entity.ExternalIdentity) error {
+	return conn(ctx, r.db).Create(identity).Error
+}
+
+func (r *externalIdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalIdentity, error) {
+	var identity entity.ExternalIdentity
+	result := conn(ctx, r.db).Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &identity, nil
+}
+
+func (r *externalIdentityRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.ExternalIdentity, error) {
+	var identities []*entity.ExternalIdentity
+	result := conn(ctx, r.db).Where("user_id = ?", userID).Order("id").Find(&identities)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return identities, nil
+}
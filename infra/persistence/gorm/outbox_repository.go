@@ -0,0 +1,92 @@
+package gormpersistence
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"time"
+
+	"go_short/domain/event"
+
+	"gorm.io/gorm"
+)
+
+// outbox 是 event.Outbox 的 GORM 實現，事件與儲存庫的寫入共用 ctx 中的交易
+type outbox struct {
+	db *gorm.DB
+}
+
+// NewGormOutbox 創建 event.Outbox 的 GORM 實例
+func NewGormOutbox(db *gorm.DB) event.Outbox {
+	return &outbox{db: db}
+}
+
+func (o *outbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return withTransaction(ctx, o.db, fn)
+}
+
+func (o *outbox) Append(ctx context.Context, events ...event.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+	records := make([]*event.Record, 0, len(events))
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		record := &event.Record{
+			EventID:       e.EventID(),
+			EventType:     e.EventType(),
+			Payload:       string(payload),
+			OccurredAt:    e.OccurredAt(),
+			NextAttemptAt: e.OccurredAt(),
+		}
+		if aggregated, ok := e.(event.Aggregated); ok {
+			record.AggregateType, record.AggregateID = aggregated.Aggregate()
+		}
+		records = append(records, record)
+	}
+	return conn(ctx, o.db).Create(records).Error
+}
+
+// outboxRepository 是 event.OutboxRepository 的 GORM 實現
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+// NewGormOutboxRepository 創建 event.OutboxRepository 的 GORM 實例
+func NewGormOutboxRepository(db *gorm.DB) event.OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// ClaimDue 以 FOR UPDATE SKIP LOCKED 取得到期的記錄並延後其下次嘗試時間，多個實例不會取得同一筆
+func (r *outboxRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*event.Record, error) {
+	var records []*event.Record
+	err := conn(ctx, r.db).Raw(`
+		UPDATE outbox_events SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE published_at IS NULL AND next_attempt_at <= ?
+			ORDER BY id
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), now, limit).
+		Scan(&records).Error
+	if err != nil {
+		return nil, err
+	}
+	// RETURNING 不保證順序，依寫入順序發布
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records, nil
+}
+
+func (r *outboxRepository) Update(ctx context.Context, record *event.Record) error {
+	return conn(ctx, r.db).Save(record).Error
+}
+
+func (r *outboxRepository) DeletePublishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).Where("published_at < ?", before).Delete(&event.Record{})
+	return result.RowsAffected, result.Error
+}
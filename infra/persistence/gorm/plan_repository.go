@@ -0,0 +1,89 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_short/domain/plan/entity"
+	"go_short/domain/plan/repository"
+
+	"gorm.io/gorm"
+)
+
+// planRepository 是 PlanRepository 的 GORM 實現
+type planRepository struct {
+	db *gorm.DB
+}
+
+// NewGormPlanRepository 創建 PlanRepository 的 GORM 實例
+func NewGormPlanRepository(db *gorm.DB) repository.PlanRepository {
+	return &planRepository{db: db}
+}
+
+func (r *planRepository) List(ctx context.Context) ([]*entity.Plan, error) {
+	var plans []*entity.Plan
+	if err := conn(ctx, r.db).Order("sort_order ASC, name ASC").Find(&plans).Error; err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+func (r *planRepository) FindByName(ctx context.Context, name string) (*entity.Plan, error) {
+	var plan entity.Plan
+	result := conn(ctx, r.db).Where("name = ?", name).First(&plan)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &plan, nil
+}
+
+func (r *planRepository) FindByUserID(ctx context.Context, userID uint) (*entity.Plan, error) {
+	var plan entity.Plan
+	result := conn(ctx, r.db).
+		Joins("JOIN users ON users.plan = plans.name").
+		Where("users.id = ? AND users.deleted_at IS NULL", userID).
+		First(&plan)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &plan, nil
+}
+
+// usageRepository 是 UsageRepository 的 GORM 實現，直接由連結與網域資料表統計用量
+type usageRepository struct {
+	db *gorm.DB
+}
+
+// NewGormUsageRepository 創建 UsageRepository 的 GORM 實例
+func NewGormUsageRepository(db *gorm.DB) repository.UsageRepository {
+	return &usageRepository{db: db}
+}
+
+func (r *usageRepository) UserUsage(ctx context.Context, userID uint, periodStart time.Time) (*entity.Usage, error) {
+	var usage entity.Usage
+	err := conn(ctx, r.db).Raw(`
+		SELECT
+			COUNT(*) FILTER (WHERE deleted_at IS NULL) AS links,
+			COUNT(*) FILTER (WHERE created_at >= ?) AS monthly_links,
+			COUNT(*) FILTER (WHERE deleted_at IS NULL AND custom_alias) AS custom_aliases
+		FROM url_mappings
+		WHERE user_id = ?`, periodStart, userID).
+		Scan(&usage).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if err := conn(ctx, r.db).Table("domains").
+		Where("user_id = ? AND deleted_at IS NULL", userID).
+		Count(&usage.CustomDomains).Error; err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
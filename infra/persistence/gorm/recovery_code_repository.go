@@ -0,0 +1,57 @@
+package gormpersistence
+
+import (
+	"context"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"gorm.io/gorm"
+)
+
+// recoveryCodeRepository 是 RecoveryCodeRepository 的 GORM 實現
+type recoveryCodeRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRecoveryCodeRepository 創建 RecoveryCodeRepository 的 GORM 實例
+func NewGormRecoveryCodeRepository(db *gorm.DB) repository.RecoveryCodeRepository {
+	return &recoveryCodeRepository{db: db}
+}
+
+// ReplaceForUser 在交易中替換使用者的復原碼，舊的一組立即失效
+func (r *recoveryCodeRepository) ReplaceForUser(ctx context.Context, userID uint, codes []*entity.RecoveryCode) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error; err != nil {
+			return err
+		}
+		if len(codes) == 0 {
+			return nil
+		}
+		return tx.Create(&codes).Error
+	})
+}
+
+// Consume 以條件更新標記復原碼，確保同一組復原碼只能使用一次
+func (r *recoveryCodeRepository) Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *recoveryCodeRepository) CountUnused(ctx context.Context, userID uint) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&entity.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *recoveryCodeRepository) DeleteForUser(ctx context.Context, userID uint) error {
+	return conn(ctx, r.db).Unscoped().Where("user_id = ?", userID).Delete(&entity.RecoveryCode{}).Error
+}
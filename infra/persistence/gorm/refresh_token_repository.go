@@ -0,0 +1,64 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"gorm.io/gorm"
+)
+
+// refreshTokenRepository 是 RefreshTokenRepository 的 GORM 實現
+type refreshTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewGormRefreshTokenRepository 創建 RefreshTokenRepository 的 GORM 實例
+func NewGormRefreshTokenRepository(db *gorm.DB) repository.RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+func (r *refreshTokenRepository) Create(ctx context.Context, token *entity.RefreshToken) error {
+	return conn(ctx, r.db).Create(token).Error
+}
+
+func (r *refreshTokenRepository) FindByHash(ctx context.Context, tokenHash string) (*entity.RefreshToken, error) {
+	var token entity.RefreshToken
+	result := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&token)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &token, nil
+}
+
+// MarkUsed 以條件更新標記 token，確保同一個 token 只能被輪替一次
+func (r *refreshTokenRepository) MarkUsed(ctx context.Context, id uint, at time.Time) (bool, error) {
+	result := conn(ctx, r.db).Model(&entity.RefreshToken{}).
+		Where("id = ? AND used_at IS NULL AND revoked_at IS NULL", id).
+		Update("used_at", at)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == 1, nil
+}
+
+func (r *refreshTokenRepository) RevokeFamily(ctx context.Context, familyID string, at time.Time) error {
+	return conn(ctx, r.db).Model(&entity.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", at).Error
+}
+
+func (r *refreshTokenRepository) RevokeAllForUser(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error {
+	query := conn(ctx, r.db).Model(&entity.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptFamilyID != "" {
+		query = query.Where("family_id <> ?", exceptFamilyID)
+	}
+	return query.Update("revoked_at", at).Error
+}
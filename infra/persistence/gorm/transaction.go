@@ -0,0 +1,29 @@
+package gormpersistence
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// txKey 是 context 中存放目前交易的鍵
+type txKey struct{}
+
+// conn 返回 ctx 所在的交易；不在交易中時返回一般連線
+// 儲存庫一律透過 conn 取得連線，才能加入服務層開啟的交易
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx
+	}
+	return db.WithContext(ctx)
+}
+
+// withTransaction 在交易中執行 fn，fn 收到的 ctx 帶有該交易；ctx 已在交易中時直接加入
+func withTransaction(ctx context.Context, db *gorm.DB, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return fn(ctx)
+	}
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+}
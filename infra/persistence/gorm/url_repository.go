@@ -25,10 +25,23 @@ func NewGormURLRepository(db *gorm.DB) repository.URLRepository {
 	}
 }
 
-// FindByShortURL 根據短 URL 查找映射
-func (r *urlRepository) FindByShortURL(ctx context.Context, shortURL string) (*entity.URLMapping, error) {
+// FindByID 根據 ID 查找映射
+func (r *urlRepository) FindByID(ctx context.Context, id uint) (*entity.URLMapping, error) {
 	var mapping entity.URLMapping
-	result := r.db.WithContext(ctx).Where("short_url = ?", shortURL).First(&mapping)
+	result := conn(ctx, r.db).First(&mapping, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &mapping, nil
+}
+
+// FindByShortURL 在指定網域內根據短 URL 查找映射
+func (r *urlRepository) FindByShortURL(ctx context.Context, domainID *uint, shortURL string) (*entity.URLMapping, error) {
+	var mapping entity.URLMapping
+	result := whereDomain(conn(ctx, r.db), domainID).Where("short_url = ?", shortURL).First(&mapping)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil // 返回 nil 而不是錯誤，表示未找到記錄
@@ -38,10 +51,25 @@ func (r *urlRepository) FindByShortURL(ctx context.Context, shortURL string) (*e
 	return &mapping, nil
 }
 
-// FindByOriginalURL 根據原始 URL 查找映射
-func (r *urlRepository) FindByOriginalURL(ctx context.Context, originalURL string) (*entity.URLMapping, error) {
+// ShortURLExists 檢查短碼在指定網域內是否已被使用 (包含已刪除的連結)
+func (r *urlRepository) ShortURLExists(ctx context.Context, domainID *uint, shortURL string) (bool, error) {
+	var count int64
+	result := whereDomain(conn(ctx, r.db).Unscoped().Model(&entity.URLMapping{}), domainID).
+		Where("short_url = ?", shortURL).
+		Count(&count)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return count > 0, nil
+}
+
+// FindByOriginalURL 在相同網域與擁有者範圍內根據原始 URL 查找未停用且未過期的映射
+func (r *urlRepository) FindByOriginalURL(ctx context.Context, scope repository.LinkScope, originalURL string) (*entity.URLMapping, error) {
 	var mapping entity.URLMapping
-	result := r.db.WithContext(ctx).Where("original_url = ?", originalURL).First(&mapping)
+	db := whereDomain(conn(ctx, r.db), scope.DomainID)
+	db = whereNullable(db, "user_id", scope.UserID)
+	db = whereNullable(db, "workspace_id", scope.WorkspaceID)
+	result := db.Where("original_url = ? AND disabled_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", originalURL, time.Now()).First(&mapping)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -53,25 +81,89 @@ func (r *urlRepository) FindByOriginalURL(ctx context.Context, originalURL strin
 
 // Save 保存 URL 映射
 func (r *urlRepository) Save(ctx context.Context, mapping *entity.URLMapping) error {
-	return r.db.WithContext(ctx).Create(mapping).Error
+	return conn(ctx, r.db).Create(mapping).Error
 }
 
 // Update 更新 URL 映射
 func (r *urlRepository) Update(ctx context.Context, mapping *entity.URLMapping) error {
-	return r.db.WithContext(ctx).Save(mapping).Error
+	return conn(ctx, r.db).Save(mapping).Error
 }
 
-// FindAll 獲取所有 URL 映射
-func (r *urlRepository) FindAll(ctx context.Context) ([]*entity.URLMapping, error) {
+// FindByUserID 獲取使用者的個人連結
+func (r *urlRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.URLMapping, error) {
 	var mappings []*entity.URLMapping
-	result := r.db.WithContext(ctx).Find(&mappings)
+	result := conn(ctx, r.db).Where("user_id = ? AND workspace_id IS NULL", userID).Order("id").Find(&mappings)
 	if result.Error != nil {
 		return nil, result.Error
 	}
 	return mappings, nil
 }
 
-// DeleteExpired 刪除所有過期的 URL 映射
-func (r *urlRepository) DeleteExpired(ctx context.Context) error {
-	return r.db.WithContext(ctx).Where("expires_at IS NOT NULL AND expires_at < ?", time.Now()).Delete(&entity.URLMapping{}).Error
+// FindByWorkspaceID 獲取工作區的所有連結
+func (r *urlRepository) FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.URLMapping, error) {
+	var mappings []*entity.URLMapping
+	result := conn(ctx, r.db).Where("workspace_id = ?", workspaceID).Order("id").Find(&mappings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mappings, nil
+}
+
+// Delete 刪除 URL 映射
+func (r *urlRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.URLMapping{}, id).Error
+}
+
+// FindPage 依 ID 順序獲取一頁 URL 映射，並返回總數
+func (r *urlRepository) FindPage(ctx context.Context, offset, limit int) ([]*entity.URLMapping, int64, error) {
+	db := conn(ctx, r.db).Model(&entity.URLMapping{})
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var mappings []*entity.URLMapping
+	if err := db.Order("id").Offset(offset).Limit(limit).Find(&mappings).Error; err != nil {
+		return nil, 0, err
+	}
+	return mappings, total, nil
+}
+
+// FindExpired 獲取在 now 之前已過期的 URL 映射
+func (r *urlRepository) FindExpired(ctx context.Context, now time.Time) ([]*entity.URLMapping, error) {
+	var mappings []*entity.URLMapping
+	result := conn(ctx, r.db).Where("expires_at IS NOT NULL AND expires_at < ?", now).Order("id").Find(&mappings)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return mappings, nil
+}
+
+// Stats 返回全系統連結的統計數據
+func (r *urlRepository) Stats(ctx context.Context) (*repository.LinkStats, error) {
+	var stats repository.LinkStats
+	err := conn(ctx, r.db).Model(&entity.URLMapping{}).
+		Select(`COUNT(*) AS total_links,
+			COALESCE(SUM(visits), 0) AS total_visits,
+			COUNT(*) FILTER (WHERE expires_at IS NOT NULL AND expires_at < ?) AS expired_links,
+			COUNT(*) FILTER (WHERE disabled_at IS NOT NULL) AS disabled_links`, time.Now()).
+		Scan(&stats).Error
+	if err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// whereDomain 將查詢限制在指定網域的命名空間內 (nil 表示預設網域)
+func whereDomain(db *gorm.DB, domainID *uint) *gorm.DB {
+	return whereNullable(db, "domain_id", domainID)
+}
+
+// whereNullable 為可為 NULL 的外鍵欄位加上等值條件，nil 對應 IS NULL
+func whereNullable(db *gorm.DB, column string, value *uint) *gorm.DB {
+	if value == nil {
+		return db.Where(column + " IS NULL")
+	}
+	return db.Where(column+" = ?", *value)
 }
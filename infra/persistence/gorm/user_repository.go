@@ -21,12 +21,12 @@ func NewGormUserRepository(db *gorm.DB) repository.UserRepository {
 }
 
 func (r *userRepository) Create(ctx context.Context, user *entity.User) error {
-	return r.db.WithContext(ctx).Create(user).Error
+	return conn(ctx, r.db).Create(user).Error
 }
 
 func (r *userRepository) FindByID(ctx context.Context, id uint) (*entity.User, error) {
 	var user entity.User
-	result := r.db.WithContext(ctx).First(&user, id)
+	result := conn(ctx, r.db).First(&user, id)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil // 未找到時返回 nil, nil
@@ -38,7 +38,7 @@ func (r *userRepository) FindByID(ctx context.Context, id uint) (*entity.User, e
 
 func (r *userRepository) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
 	var user entity.User
-	result := r.db.WithContext(ctx).Where("username = ?", username).First(&user)
+	result := conn(ctx, r.db).Where("username = ?", username).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -50,7 +50,7 @@ func (r *userRepository) FindByUsername(ctx context.Context, username string) (*
 
 func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
 	var user entity.User
-	result := r.db.WithContext(ctx).Where("email = ?", email).First(&user)
+	result := conn(ctx, r.db).Where("email = ?", email).First(&user)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
 			return nil, nil
@@ -63,5 +63,39 @@ func (r *userRepository) FindByEmail(ctx context.Context, email string) (*entity
 func (r *userRepository) Update(ctx context.Context, user *entity.User) error {
 	// 使用 Save 會更新所有欄位，包括零值
 	// 如果只想更新特定欄位，可以使用 Updates
-	return r.db.WithContext(ctx).Save(user).Error
+	return conn(ctx, r.db).Save(user).Error
+}
+
+func (r *userRepository) Search(ctx context.Context, query string, offset, limit int) ([]*entity.User, int64, error) {
+	db := conn(ctx, r.db).Model(&entity.User{})
+	if query != "" {
+		pattern := "%" + query + "%"
+		db = db.Where("username ILIKE ? OR email ILIKE ?", pattern, pattern)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []*entity.User
+	if err := db.Order("id").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *userRepository) CountUsers(ctx context.Context) (int64, int64, error) {
+	var total, active int64
+	if err := conn(ctx, r.db).Model(&entity.User{}).Count(&total).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := conn(ctx, r.db).Model(&entity.User{}).Where("is_active = ?", true).Count(&active).Error; err != nil {
+		return 0, 0, err
+	}
+	return total, active, nil
+}
+
+func (r *userRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.User{}, id).Error
 }
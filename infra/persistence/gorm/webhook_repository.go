@@ -0,0 +1,147 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_short/domain/webhook/entity"
+	"go_short/domain/webhook/repository"
+
+	"gorm.io/gorm"
+)
+
+// webhookRepository 是 WebhookRepository 的 GORM 實現
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWebhookRepository 創建 WebhookRepository 的 GORM 實例
+func NewGormWebhookRepository(db *gorm.DB) repository.WebhookRepository {
+	return &webhookRepository{db: db}
+}
+
+func (r *webhookRepository) Create(ctx context.Context, webhook *entity.Webhook) error {
+	return conn(ctx, r.db).Create(webhook).Error
+}
+
+func (r *webhookRepository) FindByID(ctx context.Context, id uint) (*entity.Webhook, error) {
+	var webhook entity.Webhook
+	result := conn(ctx, r.db).First(&webhook, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &webhook, nil
+}
+
+func (r *webhookRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := conn(ctx, r.db).Where("user_id = ? AND workspace_id IS NULL", userID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	err := conn(ctx, r.db).Where("workspace_id = ?", workspaceID).Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) FindActiveForOwner(ctx context.Context, userID *uint, workspaceID *uint) ([]*entity.Webhook, error) {
+	var webhooks []*entity.Webhook
+	db := conn(ctx, r.db).Where("active = ?", true)
+	switch {
+	case workspaceID != nil:
+		db = db.Where("workspace_id = ?", *workspaceID)
+	case userID != nil:
+		db = db.Where("user_id = ? AND workspace_id IS NULL", *userID)
+	default:
+		return nil, nil
+	}
+	err := db.Order("id").Find(&webhooks).Error
+	return webhooks, err
+}
+
+func (r *webhookRepository) Update(ctx context.Context, webhook *entity.Webhook) error {
+	return conn(ctx, r.db).Save(webhook).Error
+}
+
+func (r *webhookRepository) Delete(ctx context.Context, id uint) error {
+	return conn(ctx, r.db).Delete(&entity.Webhook{}, id).Error
+}
+
+// deliveryRepository 是 DeliveryRepository 的 GORM 實現
+type deliveryRepository struct {
+	db *gorm.DB
+}
+
+// NewGormDeliveryRepository 創建 DeliveryRepository 的 GORM 實例
+func NewGormDeliveryRepository(db *gorm.DB) repository.DeliveryRepository {
+	return &deliveryRepository{db: db}
+}
+
+func (r *deliveryRepository) Create(ctx context.Context, deliveries []*entity.Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return conn(ctx, r.db).Create(deliveries).Error
+}
+
+func (r *deliveryRepository) FindByID(ctx context.Context, id uint) (*entity.Delivery, error) {
+	var delivery entity.Delivery
+	result := conn(ctx, r.db).First(&delivery, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &delivery, nil
+}
+
+func (r *deliveryRepository) FindByWebhookID(ctx context.Context, webhookID uint, status string, offset, limit int) ([]*entity.Delivery, int64, error) {
+	db := conn(ctx, r.db).Model(&entity.Delivery{}).Where("webhook_id = ?", webhookID)
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []*entity.Delivery
+	if err := db.Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, 0, err
+	}
+	return deliveries, total, nil
+}
+
+// ClaimDue 以 FOR UPDATE SKIP LOCKED 取得到期的投遞並延後其下次嘗試時間，多個實例不會取得同一筆
+func (r *deliveryRepository) ClaimDue(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*entity.Delivery, error) {
+	var deliveries []*entity.Delivery
+	err := conn(ctx, r.db).Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, now.Add(lease), entity.DeliveryPending, now, limit).
+		Scan(&deliveries).Error
+	return deliveries, err
+}
+
+func (r *deliveryRepository) Update(ctx context.Context, delivery *entity.Delivery) error {
+	return conn(ctx, r.db).Save(delivery).Error
+}
+
+func (r *deliveryRepository) DeleteSucceededBefore(ctx context.Context, before time.Time) (int64, error) {
+	result := conn(ctx, r.db).
+		Where("status = ? AND updated_at < ?", entity.DeliverySucceeded, before).
+		Delete(&entity.Delivery{})
+	return result.RowsAffected, result.Error
+}
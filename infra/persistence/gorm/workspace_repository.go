@@ -0,0 +1,150 @@
+package gormpersistence
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/repository"
+
+	"gorm.io/gorm"
+)
+
+// workspaceRepository 是 WorkspaceRepository 的 GORM 實現
+type workspaceRepository struct {
+	db *gorm.DB
+}
+
+// NewGormWorkspaceRepository 創建 WorkspaceRepository 的 GORM 實例
+func NewGormWorkspaceRepository(db *gorm.DB) repository.WorkspaceRepository {
+	return &workspaceRepository{db: db}
+}
+
+func (r *workspaceRepository) CreateWithOwner(ctx context.Context, workspace *entity.Workspace) error {
+	return conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(workspace).Error; err != nil {
+			return err
+		}
+		return tx.Create(&entity.Membership{
+			WorkspaceID: workspace.ID,
+			UserID:      workspace.OwnerID,
+			Role:        entity.RoleOwner,
+		}).Error
+	})
+}
+
+func (r *workspaceRepository) FindByID(ctx context.Context, id uint) (*entity.Workspace, error) {
+	var workspace entity.Workspace
+	result := conn(ctx, r.db).First(&workspace, id)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &workspace, nil
+}
+
+func (r *workspaceRepository) FindByUserID(ctx context.Context, userID uint) ([]*entity.Workspace, error) {
+	var workspaces []*entity.Workspace
+	result := conn(ctx, r.db).
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id AND workspace_members.deleted_at IS NULL").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.id").
+		Find(&workspaces)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return workspaces, nil
+}
+
+func (r *workspaceRepository) FindMembership(ctx context.Context, workspaceID, userID uint) (*entity.Membership, error) {
+	var membership entity.Membership
+	result := conn(ctx, r.db).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		First(&membership)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &membership, nil
+}
+
+func (r *workspaceRepository) ListMembers(ctx context.Context, workspaceID uint) ([]*entity.Membership, error) {
+	var members []*entity.Membership
+	result := conn(ctx, r.db).Where("workspace_id = ?", workspaceID).Order("id").Find(&members)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return members, nil
+}
+
+func (r *workspaceRepository) CountMembersWithRole(ctx context.Context, workspaceID uint, role entity.Role) (int64, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&entity.Membership{}).
+		Where("workspace_id = ? AND role = ?", workspaceID, role).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *workspaceRepository) SaveMembership(ctx context.Context, membership *entity.Membership) error {
+	return conn(ctx, r.db).Save(membership).Error
+}
+
+func (r *workspaceRepository) DeleteMembership(ctx context.Context, membership *entity.Membership) error {
+	return conn(ctx, r.db).Delete(membership).Error
+}
+
+func (r *workspaceRepository) SharesWorkspace(ctx context.Context, userID, otherUserID uint) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).Table("workspace_members AS a").
+		Joins("JOIN workspace_members AS b ON a.workspace_id = b.workspace_id AND b.deleted_at IS NULL").
+		Where("a.deleted_at IS NULL AND a.user_id = ? AND b.user_id = ?", userID, otherUserID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// invitationRepository 是 InvitationRepository 的 GORM 實現
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+// NewGormInvitationRepository 創建 InvitationRepository 的 GORM 實例
+func NewGormInvitationRepository(db *gorm.DB) repository.InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) Create(ctx context.Context, invitation *entity.Invitation) error {
+	return conn(ctx, r.db).Create(invitation).Error
+}
+
+func (r *invitationRepository) FindByTokenHash(ctx context.Context, tokenHash string) (*entity.Invitation, error) {
+	var invitation entity.Invitation
+	result := conn(ctx, r.db).Where("token_hash = ?", tokenHash).First(&invitation)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, result.Error
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) ListPending(ctx context.Context, workspaceID uint) ([]*entity.Invitation, error) {
+	var invitations []*entity.Invitation
+	result := conn(ctx, r.db).
+		Where("workspace_id = ? AND accepted_at IS NULL AND expires_at > ?", workspaceID, time.Now()).
+		Order("id").
+		Find(&invitations)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) Update(ctx context.Context, invitation *entity.Invitation) error {
+	return conn(ctx, r.db).Save(invitation).Error
+}
@@ -3,7 +3,7 @@ package redispersistence
 import (
 	"context"
 	"errors"
-	"log"
+	"log/slog"
 	"time"
 
 	"go_short/domain/urlshortener/repository"
@@ -49,7 +49,7 @@ func (r *cacheRepository) Set(ctx context.Context, shortURL string, originalURL
 
 	err := r.client.Set(ctx, shortURL, originalURL, expiration).Err()
 	if err != nil {
-		log.Printf("Failed to cache URL: %v", err)
+		slog.ErrorContext(ctx, "Failed to cache URL", "error", err)
 		return err
 	}
 	return nil
@@ -63,7 +63,7 @@ func (r *cacheRepository) Delete(ctx context.Context, shortURL string) error {
 
 	err := r.client.Del(ctx, shortURL).Err()
 	if err != nil {
-		log.Printf("Failed to delete URL from cache: %v", err)
+		slog.ErrorContext(ctx, "Failed to delete URL from cache", "error", err)
 		return err
 	}
 	return nil
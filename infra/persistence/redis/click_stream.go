@@ -0,0 +1,157 @@
+package redispersistence
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_short/domain/analytics/entity"
+	"go_short/domain/analytics/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 點擊訊息的欄位名稱，保持精簡以降低串流的記憶體用量
+const (
+	clickFieldLink      = "link"
+	clickFieldTime      = "ts"
+	clickFieldReferrer  = "ref"
+	clickFieldUserAgent = "ua"
+)
+
+// clickStream 是 ClickStream 的 Redis Streams 實現
+type clickStream struct {
+	client *redis.Client
+	stream string
+	group  string
+	maxLen int64
+}
+
+// NewRedisClickStream 創建點擊串流；maxLen 大於 0 時以近似裁剪限制串流長度
+// 裁剪可能移除尚未處理的訊息，maxLen 應遠大於正常情況下的延遲
+func NewRedisClickStream(client *redis.Client, stream, group string, maxLen int64) repository.ClickStream {
+	return &clickStream{client: client, stream: stream, group: group, maxLen: maxLen}
+}
+
+func (s *clickStream) Add(ctx context.Context, click *entity.Click) error {
+	args := &redis.XAddArgs{
+		Stream: s.stream,
+		Values: []interface{}{
+			clickFieldLink, strconv.FormatUint(uint64(click.LinkID), 10),
+			clickFieldTime, strconv.FormatInt(click.ClickedAt.UnixMilli(), 10),
+			clickFieldReferrer, click.Referrer,
+			clickFieldUserAgent, click.UserAgent,
+		},
+	}
+	if s.maxLen > 0 {
+		args.MaxLen = s.maxLen
+		args.Approx = true
+	}
+	return s.client.XAdd(ctx, args).Err()
+}
+
+func (s *clickStream) EnsureGroup(ctx context.Context) error {
+	err := s.client.XGroupCreateMkStream(ctx, s.stream, s.group, "0").Err()
+	if err != nil && strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return nil
+	}
+	return err
+}
+
+func (s *clickStream) Read(ctx context.Context, consumer string, count int64, block time.Duration) ([]repository.StreamMessage, error) {
+	streams, err := s.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    s.group,
+		Consumer: consumer,
+		Streams:  []string{s.stream, ">"},
+		Count:    count,
+		Block:    block,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []repository.StreamMessage
+	for _, stream := range streams {
+		messages = append(messages, toStreamMessages(stream.Messages)...)
+	}
+	return messages, nil
+}
+
+func (s *clickStream) Reclaim(ctx context.Context, consumer string, minIdle time.Duration, count int64) ([]repository.StreamMessage, error) {
+	messages, _, err := s.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   s.stream,
+		Group:    s.group,
+		MinIdle:  minIdle,
+		Start:    "0-0",
+		Count:    count,
+		Consumer: consumer,
+	}).Result()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return toStreamMessages(messages), nil
+}
+
+func (s *clickStream) Ack(ctx context.Context, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return s.client.XAck(ctx, s.stream, s.group, ids...).Err()
+}
+
+func (s *clickStream) Stats(ctx context.Context) (*repository.StreamStats, error) {
+	length, err := s.client.XLen(ctx, s.stream).Result()
+	if err != nil {
+		return nil, err
+	}
+	stats := &repository.StreamStats{Length: length}
+
+	groups, err := s.client.XInfoGroups(ctx, s.stream).Result()
+	if err != nil {
+		// 串流尚未建立時沒有 consumer group
+		if strings.Contains(err.Error(), "no such key") {
+			return stats, nil
+		}
+		return nil, err
+	}
+	for _, group := range groups {
+		if group.Name == s.group {
+			stats.Pending = group.Pending
+			stats.Lag = group.Lag
+			stats.Consumers = group.Consumers
+		}
+	}
+	return stats, nil
+}
+
+// toStreamMessages 解析點擊訊息，欄位缺漏或格式錯誤時 Click 為 nil
+func toStreamMessages(messages []redis.XMessage) []repository.StreamMessage {
+	result := make([]repository.StreamMessage, 0, len(messages))
+	for _, message := range messages {
+		result = append(result, repository.StreamMessage{ID: message.ID, Click: parseClick(message.Values)})
+	}
+	return result
+}
+
+func parseClick(values map[string]interface{}) *entity.Click {
+	linkValue, _ := values[clickFieldLink].(string)
+	timeValue, _ := values[clickFieldTime].(string)
+	linkID, err := strconv.ParseUint(linkValue, 10, 64)
+	if err != nil || linkID == 0 {
+		return nil
+	}
+	millis, err := strconv.ParseInt(timeValue, 10, 64)
+	if err != nil {
+		return nil
+	}
+	referrer, _ := values[clickFieldReferrer].(string)
+	userAgent, _ := values[clickFieldUserAgent].(string)
+	return entity.NewClick(uint(linkID), time.UnixMilli(millis), referrer, userAgent)
+}
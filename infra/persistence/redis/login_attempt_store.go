@@ -0,0 +1,85 @@
+package redispersistence
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go_short/domain/identity/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// 登入失敗計數與鎖定在 Redis 中的鍵前綴
+const (
+	loginFailuresKeyPrefix = "login:fail:"
+	loginLockKeyPrefix     = "login:lock:"
+)
+
+// loginAttemptStore 是 LoginAttemptStore 的 Redis 實現
+type loginAttemptStore struct {
+	client *redis.Client
+}
+
+// NewRedisLoginAttemptStore 創建一個新的 Redis 登入嘗試記錄實例
+// Redis 無法使用時所有操作都視為未鎖定，不阻擋登入
+func NewRedisLoginAttemptStore(client *redis.Client) repository.LoginAttemptStore {
+	return &loginAttemptStore{
+		client: client,
+	}
+}
+
+func (s *loginAttemptStore) IncrementFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	if s.client == nil {
+		return 0, nil
+	}
+
+	pipe := s.client.TxPipeline()
+	incr := pipe.Incr(ctx, loginFailuresKeyPrefix+key)
+	pipe.Expire(ctx, loginFailuresKeyPrefix+key, window)
+	if _, err := pipe.Exec(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to record login failure", "error", err)
+		return 0, err
+	}
+	return incr.Val(), nil
+}
+
+func (s *loginAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	if s.client == nil {
+		return nil
+	}
+
+	if err := s.client.Set(ctx, loginLockKeyPrefix+key, "1", ttl).Err(); err != nil {
+		slog.ErrorContext(ctx, "Failed to lock login key", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (s *loginAttemptStore) LockRemaining(ctx context.Context, key string) (time.Duration, error) {
+	if s.client == nil {
+		return 0, nil
+	}
+
+	ttl, err := s.client.PTTL(ctx, loginLockKeyPrefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// 鍵不存在時 PTTL 返回負值
+	if ttl < 0 {
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+func (s *loginAttemptStore) Reset(ctx context.Context, key string) error {
+	if s.client == nil {
+		return nil
+	}
+
+	if err := s.client.Del(ctx, loginFailuresKeyPrefix+key, loginLockKeyPrefix+key).Err(); err != nil {
+		slog.ErrorContext(ctx, "Failed to reset login attempts", "error", err)
+		return err
+	}
+	return nil
+}
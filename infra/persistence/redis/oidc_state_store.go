@@ -0,0 +1,62 @@
+package redispersistence
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// oidcStateKeyPrefix 是 OIDC 登入狀態在 Redis 中的鍵前綴
+const oidcStateKeyPrefix = "oidc:state:"
+
+// oidcStateStore 是 OIDCStateStore 的 Redis 實現
+type oidcStateStore struct {
+	client *redis.Client
+}
+
+// NewRedisOIDCStateStore 創建一個新的 Redis OIDC 狀態儲存實例
+func NewRedisOIDCStateStore(client *redis.Client) repository.OIDCStateStore {
+	return &oidcStateStore{
+		client: client,
+	}
+}
+
+func (s *oidcStateStore) Save(ctx context.Context, state string, data *entity.OIDCState, ttl time.Duration) error {
+	if s.client == nil {
+		return errors.New("redis client is not configured")
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode OIDC state: %w", err)
+	}
+	return s.client.Set(ctx, oidcStateKeyPrefix+state, payload, ttl).Err()
+}
+
+// Take 以 GETDEL 原子地取出狀態，確保同一個 state 只能完成一次登入
+func (s *oidcStateStore) Take(ctx context.Context, state string) (*entity.OIDCState, error) {
+	if s.client == nil {
+		return nil, errors.New("redis client is not configured")
+	}
+
+	payload, err := s.client.GetDel(ctx, oidcStateKeyPrefix+state).Bytes()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var data entity.OIDCState
+	if err := json.Unmarshal(payload, &data); err != nil {
+		return nil, fmt.Errorf("failed to decode OIDC state: %w", err)
+	}
+	return &data, nil
+}
@@ -0,0 +1,56 @@
+package redispersistence
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_short/domain/identity/repository"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// denylistKeyPrefix 是存取權杖黑名單在 Redis 中的鍵前綴
+const denylistKeyPrefix = "denylist:jti:"
+
+// tokenDenylist 是 TokenDenylist 的 Redis 實現
+type tokenDenylist struct {
+	client *redis.Client
+}
+
+// NewRedisTokenDenylist 創建一個新的 Redis 權杖黑名單實例
+func NewRedisTokenDenylist(client *redis.Client) repository.TokenDenylist {
+	return &tokenDenylist{
+		client: client,
+	}
+}
+
+// Add 將 jti 加入黑名單，ttl 應為權杖剩餘的有效時間
+func (d *tokenDenylist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	if d.client == nil || ttl <= 0 {
+		return nil
+	}
+
+	if err := d.client.Set(ctx, denylistKeyPrefix+jti, "1", ttl).Err(); err != nil {
+		slog.ErrorContext(ctx, "Failed to add token to denylist", "error", err)
+		return err
+	}
+	return nil
+}
+
+// Contains 檢查 jti 是否在黑名單中
+func (d *tokenDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	if d.client == nil {
+		return false, nil
+	}
+
+	err := d.client.Get(ctx, denylistKeyPrefix+jti).Err()
+	if err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
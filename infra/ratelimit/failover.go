@@ -0,0 +1,41 @@
+package ratelimit
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// failoverLogInterval 是記錄備援切換警告的最小間隔，避免 Redis 故障時洗版
+const failoverLogInterval = time.Minute
+
+// failoverLimiter 優先使用主要限流器，發生錯誤時改用備援限流器 (fail open 到單機限流)
+type failoverLimiter struct {
+	primary  Limiter
+	fallback Limiter
+
+	mu      sync.Mutex
+	lastLog time.Time
+}
+
+// NewFailoverLimiter 創建具備援的限流器
+func NewFailoverLimiter(primary, fallback Limiter) Limiter {
+	return &failoverLimiter{primary: primary, fallback: fallback}
+}
+
+func (l *failoverLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	result, err := l.primary.Allow(ctx, key, rule)
+	if err == nil {
+		return result, nil
+	}
+
+	l.mu.Lock()
+	if time.Since(l.lastLog) > failoverLogInterval {
+		slog.WarnContext(ctx, "Rate limiter backend unavailable, using in-memory limiter", "error", err)
+		l.lastLog = time.Now()
+	}
+	l.mu.Unlock()
+
+	return l.fallback.Allow(ctx, key, rule)
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// stubLimiter 返回固定結果並記錄呼叫次數
+type stubLimiter struct {
+	result Result
+	err    error
+	calls  int
+}
+
+func (l *stubLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	l.calls++
+	return l.result, l.err
+}
+
+func TestFailoverLimiterUsesPrimaryWhenHealthy(t *testing.T) {
+	primary := &stubLimiter{result: Result{Allowed: false, Limit: 1}}
+	fallback := &stubLimiter{result: Result{Allowed: true}}
+	limiter := NewFailoverLimiter(primary, fallback)
+
+	result, err := limiter.Allow(context.Background(), "k", Rule{Limit: 1, Window: time.Second})
+	if err != nil || result.Allowed {
+		t.Fatalf("Allow = %+v, %v; want the primary's rejection", result, err)
+	}
+	if fallback.calls != 0 {
+		t.Error("the fallback must not be used while the primary works")
+	}
+}
+
+func TestFailoverLimiterFallsBackOnError(t *testing.T) {
+	primary := &stubLimiter{err: errors.New("connection refused")}
+	fallback := &stubLimiter{result: Result{Allowed: true, Limit: 5, Remaining: 4}}
+	limiter := NewFailoverLimiter(primary, fallback)
+
+	for i := 0; i < 3; i++ {
+		result, err := limiter.Allow(context.Background(), "k", Rule{Limit: 5, Window: time.Second})
+		if err != nil || result != fallback.result {
+			t.Fatalf("Allow = %+v, %v; want the fallback's result", result, err)
+		}
+	}
+	if primary.calls != 3 || fallback.calls != 3 {
+		t.Errorf("primary called %d times, fallback %d; want the primary retried on every request", primary.calls, fallback.calls)
+	}
+}
+
+func TestRedisLimiterFailsOverToMemory(t *testing.T) {
+	// 沒有服務監聽的位址，連線立即失敗
+	client := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer client.Close()
+	rule := Rule{Limit: 2, Window: time.Minute}
+
+	if _, err := NewRedisLimiter(client).Allow(context.Background(), "k", rule); err == nil {
+		t.Fatal("the Redis limiter must report an unreachable server")
+	}
+	if _, err := NewRedisLimiter(nil).Allow(context.Background(), "k", rule); err == nil {
+		t.Fatal("the Redis limiter must report a missing client")
+	}
+
+	limiter := NewFailoverLimiter(NewRedisLimiter(client), NewMemoryLimiter())
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if result, err := limiter.Allow(context.Background(), "k", rule); err != nil || !result.Allowed {
+			t.Fatalf("request %d: %+v, %v; want allowed by the in-memory limiter", i+1, result, err)
+		}
+	}
+	if result, _ := limiter.Allow(context.Background(), "k", rule); result.Allowed {
+		t.Fatal("the in-memory limiter must still enforce the rule")
+	}
+	if elapsed := time.Since(start); elapsed > 3*redisTimeout {
+		t.Errorf("failover took %s, want each check bounded by the Redis timeout", elapsed)
+	}
+}
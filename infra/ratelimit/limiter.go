@@ -0,0 +1,68 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Rule 是一條限流規則：每個 Window 最多 Limit 次請求，允許在額度內瞬間用完
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Result 是一次限流檢查的結果
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	ResetAfter time.Duration // 額度完全恢復所需的時間
+	RetryAfter time.Duration // 被拒絕時，下一次請求可以通過的時間
+}
+
+// Limiter 對指定的鍵執行限流檢查
+type Limiter interface {
+	Allow(ctx context.Context, key string, rule Rule) (Result, error)
+}
+
+// 以下為 GCRA (Generic Cell Rate Algorithm) 的計算，等同於容量為 Limit、每 Window/Limit 補充一次的 token bucket，
+// 每個鍵只需儲存一個「理論到達時間」(TAT)，Redis 與記憶體實作共用相同的語意
+
+// emissionInterval 返回補充一次額度所需的時間
+func (r Rule) emissionInterval() time.Duration {
+	return r.Window / time.Duration(r.Limit)
+}
+
+// gcra 根據目前的 TAT 判斷是否允許，返回結果與新的 TAT；請求被拒絕時 TAT 不變
+func gcra(now, tat time.Time, rule Rule) (Result, time.Time) {
+	if tat.Before(now) {
+		tat = now
+	}
+	newTAT := tat.Add(rule.emissionInterval())
+	if now.Before(newTAT.Add(-rule.Window)) {
+		return newResult(now, tat, false, rule), tat
+	}
+	return newResult(now, newTAT, true, rule), newTAT
+}
+
+// newResult 由檢查後的 TAT 計算剩餘額度與等待時間
+func newResult(now, tat time.Time, allowed bool, rule Rule) Result {
+	emission := rule.emissionInterval()
+	result := Result{
+		Allowed:    allowed,
+		Limit:      rule.Limit,
+		ResetAfter: tat.Sub(now),
+	}
+	if allowed {
+		result.Remaining = int(now.Sub(tat.Add(-rule.Window)) / emission)
+	} else {
+		result.RetryAfter = tat.Add(emission - rule.Window).Sub(now)
+	}
+	if result.Remaining < 0 {
+		result.Remaining = 0
+	}
+	if result.RetryAfter < 0 {
+		result.RetryAfter = 0
+	}
+	return result
+}
@@ -0,0 +1,117 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// fakeClock 是可手動推進的時鐘
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+func newTestMemoryLimiter() (*memoryLimiter, *fakeClock) {
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	limiter := NewMemoryLimiter().(*memoryLimiter)
+	limiter.now = clock.Now
+	return limiter, clock
+}
+
+func TestMemoryLimiterAllowsBurstThenRejects(t *testing.T) {
+	limiter, _ := newTestMemoryLimiter()
+	rule := Rule{Limit: 3, Window: time.Minute}
+	ctx := context.Background()
+
+	for i, wantRemaining := range []int{2, 1, 0} {
+		result, err := limiter.Allow(ctx, "ip:1", rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !result.Allowed || result.Remaining != wantRemaining || result.Limit != 3 {
+			t.Fatalf("request %d: %+v, want allowed with %d remaining", i+1, result, wantRemaining)
+		}
+	}
+
+	result, _ := limiter.Allow(ctx, "ip:1", rule)
+	if result.Allowed {
+		t.Fatal("the request after the burst must be rejected")
+	}
+	if result.RetryAfter != 20*time.Second || result.ResetAfter != time.Minute {
+		t.Errorf("retry after %s, reset after %s; want 20s and 1m", result.RetryAfter, result.ResetAfter)
+	}
+
+	if result, _ := limiter.Allow(ctx, "ip:2", rule); !result.Allowed {
+		t.Error("other keys must have their own quota")
+	}
+}
+
+func TestMemoryLimiterRefillsOverTime(t *testing.T) {
+	limiter, clock := newTestMemoryLimiter()
+	rule := Rule{Limit: 2, Window: 10 * time.Second}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "user:1", rule)
+	limiter.Allow(ctx, "user:1", rule)
+	if result, _ := limiter.Allow(ctx, "user:1", rule); result.Allowed {
+		t.Fatal("quota must be used up")
+	}
+
+	// 每 Window/Limit 補充一次額度
+	clock.Advance(4 * time.Second)
+	if result, _ := limiter.Allow(ctx, "user:1", rule); result.Allowed {
+		t.Fatal("no quota is refilled before the emission interval")
+	}
+	clock.Advance(time.Second)
+	result, _ := limiter.Allow(ctx, "user:1", rule)
+	if !result.Allowed || result.Remaining != 0 {
+		t.Fatalf("after one interval: %+v, want allowed with 0 remaining", result)
+	}
+
+	clock.Advance(10 * time.Second)
+	if result, _ := limiter.Allow(ctx, "user:1", rule); !result.Allowed || result.Remaining != 1 {
+		t.Fatalf("after a full window: %+v, want the whole quota back", result)
+	}
+}
+
+func TestMemoryLimiterRejectedRequestsDoNotConsumeQuota(t *testing.T) {
+	limiter, clock := newTestMemoryLimiter()
+	rule := Rule{Limit: 1, Window: time.Second}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "k", rule)
+	for i := 0; i < 5; i++ {
+		if result, _ := limiter.Allow(ctx, "k", rule); result.Allowed {
+			t.Fatal("quota must be used up")
+		}
+	}
+	clock.Advance(time.Second)
+	if result, _ := limiter.Allow(ctx, "k", rule); !result.Allowed {
+		t.Fatal("rejected requests must not push back the next allowed request")
+	}
+}
+
+func TestMemoryLimiterSweepsExpiredKeys(t *testing.T) {
+	limiter, clock := newTestMemoryLimiter()
+	rule := Rule{Limit: 10, Window: time.Second}
+	ctx := context.Background()
+
+	limiter.Allow(ctx, "old", rule)
+	clock.Advance(2 * sweepInterval)
+	limiter.Allow(ctx, "new", rule)
+
+	if _, ok := limiter.tats["old"]; ok {
+		t.Error("keys whose quota is fully restored must be swept")
+	}
+	if _, ok := limiter.tats["new"]; !ok {
+		t.Error("the current key must be kept")
+	}
+}
@@ -0,0 +1,48 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval 是清除過期鍵的最小間隔
+const sweepInterval = time.Minute
+
+// memoryLimiter 是單一程序內的限流實作，作為 Redis 無法使用時的備援
+// 多個實例各自計算，實際允許的總量會是設定值乘以實例數
+type memoryLimiter struct {
+	mu        sync.Mutex
+	tats      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter 創建記憶體限流器
+func NewMemoryLimiter() Limiter {
+	return &memoryLimiter{
+		tats: make(map[string]time.Time),
+		now:  time.Now,
+	}
+}
+
+func (l *memoryLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) > sweepInterval {
+		for k, tat := range l.tats {
+			if tat.Before(now) {
+				delete(l.tats, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	result, tat := gcra(now, l.tats[key], rule)
+	if result.Allowed {
+		l.tats[key] = tat
+	}
+	return result, nil
+}
@@ -0,0 +1,70 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// keyPrefix 是限流狀態在 Redis 中的鍵前綴
+const keyPrefix = "ratelimit:"
+
+// redisTimeout 是單次限流檢查等待 Redis 的上限，逾時即改用備援限流器，不拖慢請求
+const redisTimeout = 200 * time.Millisecond
+
+// gcraScript 在 Redis 中以原子操作執行 GCRA，時間單位為微秒
+// 返回 {是否允許, 檢查後的 TAT 與現在的差距}
+var gcraScript = redis.NewScript(`
+local now = tonumber(ARGV[1])
+local emission = tonumber(ARGV[2])
+local window = tonumber(ARGV[3])
+
+local tat = tonumber(redis.call('GET', KEYS[1]) or now)
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + emission
+if now < new_tat - window then
+	return {0, tat - now}
+end
+
+-- 以 %d 格式化，避免微秒時間戳被轉為科學記號而失去精度
+redis.call('SET', KEYS[1], string.format('%d', new_tat), 'PX', math.ceil((new_tat - now) / 1000))
+return {1, new_tat - now}
+`)
+
+// redisLimiter 是以 Redis 共享狀態的分散式限流實作
+type redisLimiter struct {
+	client *redis.Client
+}
+
+// NewRedisLimiter 創建 Redis 限流器
+func NewRedisLimiter(client *redis.Client) Limiter {
+	return &redisLimiter{client: client}
+}
+
+func (l *redisLimiter) Allow(ctx context.Context, key string, rule Rule) (Result, error) {
+	if l.client == nil {
+		return Result{}, errors.New("redis client is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	now := time.Now().Truncate(time.Microsecond)
+	values, err := gcraScript.Run(ctx, l.client, []string{keyPrefix + key},
+		now.UnixMicro(), rule.emissionInterval().Microseconds(), rule.Window.Microseconds(),
+	).Int64Slice()
+	if err != nil {
+		return Result{}, err
+	}
+	if len(values) != 2 {
+		return Result{}, errors.New("unexpected rate limit script reply")
+	}
+
+	tat := now.Add(time.Duration(values[1]) * time.Microsecond)
+	return newResult(now, tat, values[0] == 1, rule), nil
+}
@@ -0,0 +1,77 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// redisHook 以 go-redis hook 為每個指令建立 span
+type redisHook struct {
+	tracer trace.Tracer
+}
+
+// RedisHook 返回建立指令 span 的 go-redis hook，以 client.AddHook 安裝
+func RedisHook() redis.Hook {
+	return &redisHook{tracer: otel.Tracer("go_short/infra/tracing/redis")}
+}
+
+// DialHook 實作 redis.Hook，連線建立不另外建立 span
+func (h *redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+// ProcessHook 實作 redis.Hook；只記錄指令名稱，參數可能包含 token 等敏感資料
+func (h *redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return next(ctx, cmd)
+		}
+		ctx, span := h.start(ctx, "redis."+cmd.Name(), attribute.String("db.operation", cmd.Name()))
+		err := next(ctx, cmd)
+		end(span, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook 實作 redis.Hook，整個管線 (含 MULTI/EXEC) 以一個 span 記錄
+func (h *redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if !trace.SpanFromContext(ctx).SpanContext().IsValid() {
+			return next(ctx, cmds)
+		}
+		names := make([]string, 0, len(cmds))
+		for _, cmd := range cmds {
+			names = append(names, cmd.Name())
+		}
+		ctx, span := h.start(ctx, "redis.pipeline",
+			attribute.StringSlice("db.redis.commands", names),
+			attribute.Int("db.redis.num_cmd", len(cmds)),
+		)
+		err := next(ctx, cmds)
+		end(span, err)
+		return err
+	}
+}
+
+func (h *redisHook) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	attrs = append(attrs, attribute.String("db.system", "redis"))
+	return h.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// end 結束 span；redis.Nil 表示鍵不存在，屬於正常結果
+func end(span trace.Span, err error) {
+	if err != nil && !errors.Is(err, redis.Nil) {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,97 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// 支援的 exporter
+const (
+	ExporterNone   = "none"   // 不輸出 span，只傳遞 traceparent
+	ExporterStdout = "stdout" // 以 JSON 輸出到標準輸出
+	ExporterFile   = "file"   // 以 JSON 逐行附加到檔案，離線時可事後查看
+	ExporterOTLP   = "otlp"   // 以 OTLP/HTTP 送到 collector，端點由 OTEL_EXPORTER_OTLP_* 環境變數設定
+)
+
+// Options 是追蹤的設定
+type Options struct {
+	ServiceName string  // 資源屬性 service.name
+	Exporter    string  // none, stdout, file 或 otlp
+	File        string  // file exporter 的輸出路徑
+	SampleRatio float64 // 新 trace 的取樣比例 (0~1)；上游已決定取樣時沿用上游的決定
+}
+
+// Setup 設定全域的 TracerProvider 與 W3C Trace Context 傳遞格式，返回用於關閉時送出剩餘 span 的函式
+// exporter 為 none 時仍會設定傳遞格式，讓上游的 traceparent 能傳遞到下游
+func Setup(ctx context.Context, opts Options) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	if opts.Exporter == "" || opts.Exporter == ExporterNone {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, closeOutput, err := newExporter(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(attribute.String("service.name", opts.ServiceName)))
+	if err != nil {
+		return nil, err
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(opts.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if closeOutput != nil {
+			err = errors.Join(err, closeOutput.Close())
+		}
+		return err
+	}, nil
+}
+
+// newExporter 依設定建立 exporter，file exporter 同時返回需要在關閉時關閉的檔案
+func newExporter(ctx context.Context, opts Options) (sdktrace.SpanExporter, io.Closer, error) {
+	switch opts.Exporter {
+	case ExporterStdout:
+		exporter, err := stdouttrace.New()
+		return exporter, nil, err
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0o755); err != nil {
+			return nil, nil, err
+		}
+		f, err := os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, nil, err
+		}
+		exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+		if err != nil {
+			f.Close()
+			return nil, nil, err
+		}
+		return exporter, f, nil
+	case ExporterOTLP:
+		exporter, err := otlptracehttp.New(ctx)
+		return exporter, nil, err
+	default:
+		return nil, nil, fmt.Errorf("unknown tracing exporter %q", opts.Exporter)
+	}
+}
@@ -0,0 +1,120 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"go_short/domain/webhook/service"
+)
+
+// ErrForbiddenAddress 表示端點解析到不允許的網路位址 (迴路、私有或鏈路本地位址)
+var ErrForbiddenAddress = errors.New("webhook endpoint resolves to a private or loopback address")
+
+// userAgent 是發送 webhook 時使用的 User-Agent
+const userAgent = "go-short-webhooks/1.0"
+
+// httpSender 以 HTTP POST 發送 webhook，實作 service.Sender
+// 不跟隨重定向 (3xx 視為失敗)，只讀取回應的前 4KB
+type httpSender struct {
+	client *http.Client
+}
+
+// NewHTTPSender 創建 HTTP 發送器；allowPrivate 為 false 時拒絕連線到內部網路，避免 SSRF
+func NewHTTPSender(timeout time.Duration, allowPrivate bool) service.Sender {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !allowPrivate {
+		// 在建立連線時檢查實際連線的 IP，DNS 重新綁定也無法繞過
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			ip := net.ParseIP(host)
+			if ip == nil || isForbiddenIP(ip) {
+				return ErrForbiddenAddress
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &httpSender{
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Send 發送 payload 並返回回應狀態碼
+func (s *httpSender) Send(ctx context.Context, url string, headers map[string]string, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// 讀取部分回應內容以便重用連線
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// forbiddenNetworks 是 net.IP 的方法未涵蓋、但同樣不應從外部連入的特殊用途位址
+var forbiddenNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // 本網路 ("this network")，部分系統會連到本機
+	"100.64.0.0/10", // 電信級 NAT 共用位址，常用於雲端內部網路
+	"192.0.0.0/24",  // IETF 協定指派
+	"198.18.0.0/15", // 網路設備效能測試
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// isForbiddenIP 檢查位址是否為迴路、私有、鏈路本地、多播或其他特殊用途位址
+// (IPv4 對應的 IPv6 位址以 IPv4 檢查)
+func isForbiddenIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range forbiddenNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,144 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIsForbiddenIP(t *testing.T) {
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"127.0.0.1", true},
+		{"::1", true},
+		{"10.1.2.3", true},
+		{"172.16.0.1", true},
+		{"192.168.1.1", true},
+		{"169.254.169.254", true},
+		{"fe80::1", true},
+		{"fc00::1", true},
+		{"224.0.0.1", true},
+		{"0.0.0.0", true},
+		{"::", true},
+		{"0.1.2.3", true},
+		{"100.64.0.1", true},
+		{"100.127.255.254", true},
+		{"192.0.0.8", true},
+		{"198.18.0.1", true},
+		{"198.19.255.254", true},
+		{"::ffff:100.64.0.1", true},
+		{"::ffff:198.18.0.1", true},
+		{"93.184.216.34", false},
+		{"100.63.255.255", false},
+		{"100.128.0.0", false},
+		{"192.0.1.1", false},
+		{"198.17.255.255", false},
+		{"198.20.0.0", false},
+		{"2606:4700::1111", false},
+	}
+	for _, tt := range tests {
+		if got := isForbiddenIP(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("isForbiddenIP(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+}
+
+func TestSendPostsSignedPayload(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		b, _ := io.ReadAll(r.Body)
+		body = string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	sender := NewHTTPSender(time.Second, true)
+	status, err := sender.Send(context.Background(), server.URL+"/hooks", map[string]string{
+		"Content-Type":        "application/json",
+		"X-GoShort-Signature": "t=1,v1=abc",
+	}, []byte(`{"id":"e1"}`))
+	if err != nil || status != http.StatusAccepted {
+		t.Fatalf("Send = %d, %v", status, err)
+	}
+	if got.Method != http.MethodPost || got.URL.Path != "/hooks" || body != `{"id":"e1"}` {
+		t.Errorf("request %s %s with body %q", got.Method, got.URL.Path, body)
+	}
+	if got.Header.Get("X-GoShort-Signature") != "t=1,v1=abc" || got.Header.Get("User-Agent") != userAgent {
+		t.Errorf("headers %v", got.Header)
+	}
+}
+
+func TestSendFailsOnNon2xx(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"server error", http.StatusInternalServerError},
+		{"client error", http.StatusGone},
+		{"redirect is not followed", http.StatusFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			followed := false
+			mux := http.NewServeMux()
+			mux.HandleFunc("/hooks", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Location", "/elsewhere")
+				w.WriteHeader(tt.status)
+				io.WriteString(w, strings.Repeat("x", 64<<10))
+			})
+			mux.HandleFunc("/elsewhere", func(w http.ResponseWriter, r *http.Request) {
+				followed = true
+			})
+			server := httptest.NewServer(mux)
+			defer server.Close()
+
+			status, err := NewHTTPSender(time.Second, true).Send(context.Background(), server.URL+"/hooks", nil, []byte("{}"))
+			if err == nil || status != tt.status {
+				t.Fatalf("Send = %d, %v; want status %d with an error", status, err, tt.status)
+			}
+			if followed {
+				t.Error("the sender must not follow redirects")
+			}
+		})
+	}
+}
+
+func TestSendRejectsPrivateAddresses(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	defer server.Close()
+
+	// httptest 伺服器監聽在 127.0.0.1
+	_, err := NewHTTPSender(time.Second, false).Send(context.Background(), server.URL, nil, []byte("{}"))
+	if !errors.Is(err, ErrForbiddenAddress) {
+		t.Fatalf("err = %v, want ErrForbiddenAddress", err)
+	}
+	if called {
+		t.Error("the request must not reach a loopback endpoint")
+	}
+}
+
+func TestSendTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	if _, err := NewHTTPSender(50*time.Millisecond, true).Send(context.Background(), server.URL, nil, []byte("{}")); err == nil {
+		t.Fatal("Send must fail when the endpoint does not answer in time")
+	}
+}
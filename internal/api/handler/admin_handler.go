@@ -0,0 +1,250 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	auditrepository "go_short/domain/audit/repository"
+	"go_short/internal/api/middleware"
+	adminapp "go_short/internal/application/admin"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminHandler 處理管理後台的 HTTP 請求
+type AdminHandler struct {
+	adminApp *adminapp.App
+}
+
+// NewAdminHandler 創建 Admin Handler 實例
+func NewAdminHandler(adminApp *adminapp.App) *AdminHandler {
+	return &AdminHandler{
+		adminApp: adminApp,
+	}
+}
+
+// ListUsers 處理列出/搜尋使用者的請求 (?q=&page=&page_size=)
+func (h *AdminHandler) ListUsers(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.adminApp.ListUsers(c.Request.Context(), c.Query("q"), page, pageSize)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// ActivateUser 處理啟用使用者的請求
+func (h *AdminHandler) ActivateUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminApp.ActivateUser(c.Request.Context(), userID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User activated"})
+}
+
+// DeactivateUser 處理停用使用者的請求
+func (h *AdminHandler) DeactivateUser(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminApp.DeactivateUser(c.Request.Context(), actorID, userID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deactivated"})
+}
+
+// UnlockUser 處理解除使用者登入鎖定的請求
+func (h *AdminHandler) UnlockUser(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminApp.UnlockUser(c.Request.Context(), userID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User login unlocked"})
+}
+
+// SetUserRole 處理變更使用者角色的請求
+func (h *AdminHandler) SetUserRole(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, err := h.adminApp.SetUserRole(c.Request.Context(), actorID, userID, request.Role)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// SetUserPlan 處理變更使用者方案的請求
+func (h *AdminHandler) SetUserPlan(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Plan string `json:"plan" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, err := h.adminApp.SetUserPlan(c.Request.Context(), userID, request.Plan)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user})
+}
+
+// ListLinks 處理分頁列出全系統連結的請求 (?page=&page_size=)
+func (h *AdminHandler) ListLinks(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.adminApp.ListLinks(c.Request.Context(), page, pageSize)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DisableLink 處理停用連結的請求
+func (h *AdminHandler) DisableLink(c *gin.Context) {
+	h.setLinkDisabled(c, true)
+}
+
+// EnableLink 處理重新啟用連結的請求
+func (h *AdminHandler) EnableLink(c *gin.Context) {
+	h.setLinkDisabled(c, false)
+}
+
+func (h *AdminHandler) setLinkDisabled(c *gin.Context, disabled bool) {
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	link, err := h.adminApp.SetLinkDisabled(c.Request.Context(), linkID, disabled)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": link})
+}
+
+// DeleteLink 處理刪除任意連結的請求
+func (h *AdminHandler) DeleteLink(c *gin.Context) {
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	if err := h.adminApp.DeleteLink(c.Request.Context(), linkID); err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// Stats 處理全系統統計的請求
+func (h *AdminHandler) Stats(c *gin.Context) {
+	stats, err := h.adminApp.Stats(c.Request.Context())
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, stats)
+}
+
+// ListAuditLog 處理查詢稽核記錄的請求
+// (?actor_id=&action=&target_type=&target_id=&request_id=&since=&until=&page=&page_size=，時間為 RFC 3339)
+func (h *AdminHandler) ListAuditLog(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	filter := auditrepository.AuditFilter{
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		RequestID:  c.Query("request_id"),
+	}
+	if value := c.Query("actor_id"); value != "" {
+		actorID, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid actor_id"})
+			return
+		}
+		id := uint(actorID)
+		filter.ActorID = &id
+	}
+	var ok bool
+	if filter.Since, ok = parseTimeQuery(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = parseTimeQuery(c, "until"); !ok {
+		return
+	}
+
+	result, err := h.adminApp.ListAuditLog(c.Request.Context(), filter, page, pageSize)
+	if err != nil {
+		respondAdminError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// parseTimeQuery 解析 RFC 3339 格式的查詢參數，格式錯誤時直接回應 400 並返回 false
+func parseTimeQuery(c *gin.Context, name string) (*time.Time, bool) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, true
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name + ", expected RFC 3339 time"})
+		return nil, false
+	}
+	return &t, true
+}
+
+// respondAdminError 將管理後台用例的錯誤轉換為 HTTP 回應
+func respondAdminError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, adminapp.ErrInvalidRole), errors.Is(err, adminapp.ErrInvalidPlan):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, adminapp.ErrSelfAction):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, adminapp.ErrUserNotFound), errors.Is(err, adminapp.ErrLinkNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
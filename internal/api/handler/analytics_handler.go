@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"net/http"
+
+	analyticsapp "go_short/internal/application/analytics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AnalyticsHandler 處理點擊分析相關的 HTTP 請求
+type AnalyticsHandler struct {
+	analyticsApp *analyticsapp.App
+}
+
+// NewAnalyticsHandler 創建 Analytics Handler 實例
+func NewAnalyticsHandler(analyticsApp *analyticsapp.App) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		analyticsApp: analyticsApp,
+	}
+}
+
+// ClickStreamStats 處理查詢點擊串流狀態的請求 (長度、未確認數與尚未讀取的延遲)
+func (h *AnalyticsHandler) ClickStreamStats(c *gin.Context) {
+	stats, err := h.analyticsApp.StreamStats(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": stats})
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/internal/api/middleware"
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIKeyHandler 處理 API 金鑰管理的 HTTP 請求
+type APIKeyHandler struct {
+	identityApp *identityapp.App
+}
+
+// NewAPIKeyHandler 創建 APIKey Handler 實例
+func NewAPIKeyHandler(identityApp *identityapp.App) *APIKeyHandler {
+	return &APIKeyHandler{
+		identityApp: identityApp,
+	}
+}
+
+// CreateAPIKey 處理建立 API 金鑰的請求，明文金鑰只在此回應中出現一次
+func (h *APIKeyHandler) CreateAPIKey(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var request struct {
+		Name          string   `json:"name" binding:"required,max=100"`
+		Scopes        []string `json:"scopes" binding:"required"`
+		ExpiresInDays *int     `json:"expires_in_days,omitempty" binding:"omitempty,min=1"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	var expiresIn *time.Duration
+	if request.ExpiresInDays != nil {
+		duration := time.Duration(*request.ExpiresInDays) * 24 * time.Hour
+		expiresIn = &duration
+	}
+
+	key, plaintext, err := h.identityApp.CreateAPIKey(c.Request.Context(), userID, request.Name, request.Scopes, expiresIn)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	response := apiKeyResponse(key)
+	response["key"] = plaintext
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Store this key now, it will not be shown again",
+		"api_key": response,
+	})
+}
+
+// ListAPIKeys 處理列出目前使用者 API 金鑰的請求
+func (h *APIKeyHandler) ListAPIKeys(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	keys, err := h.identityApp.ListAPIKeys(c.Request.Context(), userID)
+	if err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+
+	data := make([]gin.H, 0, len(keys))
+	for _, key := range keys {
+		data = append(data, apiKeyResponse(key))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// RevokeAPIKey 處理撤銷 API 金鑰的請求
+func (h *APIKeyHandler) RevokeAPIKey(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	keyID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.identityApp.RevokeAPIKey(c.Request.Context(), userID, keyID); err != nil {
+		respondAPIKeyError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// apiKeyResponse 將金鑰轉為回應格式 (不含雜湊)
+func apiKeyResponse(key *entity.APIKey) gin.H {
+	return gin.H{
+		"id":           key.ID,
+		"name":         key.Name,
+		"prefix":       key.Prefix,
+		"scopes":       key.ScopeList(),
+		"created_at":   key.CreatedAt,
+		"expires_at":   key.ExpiresAt,
+		"last_used_at": key.LastUsedAt,
+		"revoked_at":   key.RevokedAt,
+	}
+}
+
+// respondAPIKeyError 將 API 金鑰用例的錯誤轉換為 HTTP 回應
+func respondAPIKeyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, identityapp.ErrInvalidScope):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "valid_scopes": entity.AllScopes})
+	case errors.Is(err, identityapp.ErrAPIKeyNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
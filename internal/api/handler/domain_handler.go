@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"go_short/internal/api/middleware"
+	urlshortenerapp "go_short/internal/application/urlshortener"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DomainHandler 處理自訂網域相關的 HTTP 請求
+type DomainHandler struct {
+	urlApp *urlshortenerapp.App
+}
+
+// NewDomainHandler 創建一個新的網域處理器
+func NewDomainHandler(urlApp *urlshortenerapp.App) *DomainHandler {
+	return &DomainHandler{
+		urlApp: urlApp,
+	}
+}
+
+// RegisterDomain 處理註冊自訂網域的請求
+func (h *DomainHandler) RegisterDomain(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var request struct {
+		Host        string  `json:"host" binding:"required"`
+		FallbackURL *string `json:"fallback_url,omitempty"`
+		WorkspaceID *uint   `json:"workspace_id,omitempty"` // 註冊為工作區網域 (需為 owner)
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	domain, err := h.urlApp.RegisterDomain(c.Request.Context(), userID, request.WorkspaceID, request.Host, request.FallbackURL)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"domain": domain,
+		"verification": gin.H{
+			"type":  "TXT",
+			"name":  domain.VerificationRecordName(),
+			"value": domain.VerificationRecordValue(),
+		},
+	})
+}
+
+// ListDomains 處理列出目前使用者 (或指定工作區) 網域的請求
+func (h *DomainHandler) ListDomains(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseOptionalIDQuery(c, "workspace_id")
+	if !ok {
+		return
+	}
+
+	domains, err := h.urlApp.ListDomains(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": domains})
+}
+
+// VerifyDomain 處理網域 DNS 驗證的請求
+func (h *DomainHandler) VerifyDomain(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	domain, err := h.urlApp.VerifyDomain(c.Request.Context(), userID, domainID)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"domain": domain})
+}
+
+// UpdateDomain 處理更新網域 fallback URL 的請求
+func (h *DomainHandler) UpdateDomain(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		FallbackURL *string `json:"fallback_url"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	domain, err := h.urlApp.UpdateDomainFallback(c.Request.Context(), userID, domainID, request.FallbackURL)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"domain": domain})
+}
+
+// DeleteDomain 處理刪除網域的請求
+func (h *DomainHandler) DeleteDomain(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	domainID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.urlApp.DeleteDomain(c.Request.Context(), userID, domainID); err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// parseIDParam 解析路徑中的數字 ID 參數，失敗時直接返回 400
+func parseIDParam(c *gin.Context, name string) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param(name), 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return 0, false
+	}
+	return uint(id), true
+}
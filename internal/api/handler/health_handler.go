@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	healthapp "go_short/internal/application/health"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler 處理存活與就緒檢查的 HTTP 請求
+type HealthHandler struct {
+	healthApp *healthapp.App
+}
+
+// NewHealthHandler 創建 Health Handler 實例
+func NewHealthHandler(healthApp *healthapp.App) *HealthHandler {
+	return &HealthHandler{
+		healthApp: healthApp,
+	}
+}
+
+// Liveness 處理存活檢查：只要行程能回應就返回 200，不檢查外部依賴
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"status": healthapp.StatusOK,
+		"time":   time.Now().Format(time.RFC3339),
+	})
+}
+
+// Readiness 處理就緒檢查：所有檢查通過時返回 200，否則或關閉流程中返回 503，回應包含各項目的結果
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.healthApp.Readiness(c.Request.Context())
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
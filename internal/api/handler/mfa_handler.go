@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go_short/internal/api/middleware"
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LoginMFA 處理兩步驟登入的第二步，以 MFA 挑戰權杖與驗證碼 (或復原碼) 換取權杖組
+func (h *UserHandler) LoginMFA(c *gin.Context) {
+	var request struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	tokens, err := h.identityApp.CompleteMFALogin(c.Request.Context(), request.MFAToken, request.Code, c.ClientIP())
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, tokenResponse("Login successful", tokens))
+}
+
+// GetMFAStatus 返回目前使用者的兩步驟驗證狀態
+func (h *UserHandler) GetMFAStatus(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	status, err := h.identityApp.GetMFAStatus(c.Request.Context(), userID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": status})
+}
+
+// EnrollTOTP 開始註冊 TOTP，返回密鑰、otpauth URI 與 QR code
+func (h *UserHandler) EnrollTOTP(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	enrollment, err := h.identityApp.BeginTOTPEnrollment(c.Request.Context(), userID)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scan the QR code with an authenticator app, then confirm with a code",
+		"data": gin.H{
+			"secret":      enrollment.Secret,
+			"otpauth_uri": enrollment.URI,
+			"qr_code":     enrollment.QRCode,
+		},
+	})
+}
+
+// ConfirmTOTP 以驗證碼確認 TOTP 註冊，返回一次性的復原碼
+func (h *UserHandler) ConfirmTOTP(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	codes, err := h.identityApp.ConfirmTOTPEnrollment(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "Two-factor authentication enabled. Store the recovery codes somewhere safe, they are shown only once",
+		"recovery_codes": codes,
+	})
+}
+
+// DisableTOTP 停用兩步驟驗證，需要密碼與驗證碼 (或復原碼)
+func (h *UserHandler) DisableTOTP(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	var request struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.DisableTOTP(c.Request.Context(), userID, request.Password, request.Code); err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes 產生一組新的復原碼
+func (h *UserHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	var request struct {
+		Code string `json:"code" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	codes, err := h.identityApp.RegenerateRecoveryCodes(c.Request.Context(), userID, request.Code)
+	if err != nil {
+		respondMFAError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+func respondMFAError(c *gin.Context, err error) {
+	if respondLoginLocked(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, identityapp.ErrInvalidMFACode),
+		errors.Is(err, identityapp.ErrInvalidActionToken),
+		errors.Is(err, identityapp.ErrAuthenticationFailed):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrMFAAlreadyEnabled),
+		errors.Is(err, identityapp.ErrMFANotEnabled),
+		errors.Is(err, identityapp.ErrMFAEnrollmentNotStarted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// oidcStateCookie 保存發起登入的瀏覽器所持有的 state，回呼時必須相符
+const oidcStateCookie = "oidc_state"
+
+// OIDCHandler 處理 OpenID Connect 登入相關的 HTTP 請求
+type OIDCHandler struct {
+	oidcApp *identityapp.OIDCApp
+}
+
+// NewOIDCHandler 創建 OIDC Handler 實例
+func NewOIDCHandler(oidcApp *identityapp.OIDCApp) *OIDCHandler {
+	return &OIDCHandler{
+		oidcApp: oidcApp,
+	}
+}
+
+// ListProviders 列出可用的身分提供者
+func (h *OIDCHandler) ListProviders(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": h.oidcApp.Providers()})
+}
+
+// Login 將使用者導向身分提供者的授權頁面
+func (h *OIDCHandler) Login(c *gin.Context) {
+	authorization, err := h.oidcApp.BeginLogin(c.Request.Context(), c.Param("provider"))
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	setOIDCStateCookie(c, authorization.State, int(authorization.ExpiresIn.Seconds()))
+	c.Redirect(http.StatusFound, authorization.URL)
+}
+
+// Callback 處理身分提供者導回的授權碼，登入成功時返回權杖組 (或 MFA 挑戰)
+func (h *OIDCHandler) Callback(c *gin.Context) {
+	// state cookie 只能使用一次
+	browserState, _ := c.Cookie(oidcStateCookie)
+	setOIDCStateCookie(c, "", -1)
+
+	if errCode := c.Query("error"); errCode != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Identity provider returned an error: " + errCode})
+		return
+	}
+
+	state, code := c.Query("state"), c.Query("code")
+	if state == "" || code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing state or code"})
+		return
+	}
+
+	result, err := h.oidcApp.CompleteLogin(c.Request.Context(), c.Param("provider"), identityapp.OIDCCallback{
+		State:        state,
+		BrowserState: browserState,
+		Code:         code,
+		ClientIP:     c.ClientIP(),
+	})
+	if err != nil {
+		respondOIDCError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, loginResponse(result))
+}
+
+// setOIDCStateCookie 設定只在該提供者的 OIDC 路徑送出的 state cookie，maxAge 為負數時刪除
+// 身分提供者以跨站的頂層導向回到回呼網址，因此使用 SameSite=Lax (Strict 時 cookie 不會送出)
+func setOIDCStateCookie(c *gin.Context, state string, maxAge int) {
+	secure := c.Request.TLS != nil || strings.EqualFold(c.GetHeader("X-Forwarded-Proto"), "https")
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, state, maxAge, "/auth/oidc/"+c.Param("provider"), "", secure, true)
+}
+
+func respondOIDCError(c *gin.Context, err error) {
+	if respondLoginLocked(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, identityapp.ErrOIDCProviderNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrOIDCInvalidState):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrOIDCEmailNotVerified),
+		errors.Is(err, identityapp.ErrOIDCAccountNotFound),
+		errors.Is(err, identityapp.ErrAuthenticationFailed):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrOIDCExchangeFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
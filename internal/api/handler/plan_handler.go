@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	planservice "go_short/domain/plan/service"
+	"go_short/internal/api/middleware"
+	planapp "go_short/internal/application/plan"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PlanHandler 處理方案與用量的 HTTP 請求
+type PlanHandler struct {
+	planApp *planapp.App
+}
+
+// NewPlanHandler 創建 Plan Handler 實例
+func NewPlanHandler(planApp *planapp.App) *PlanHandler {
+	return &PlanHandler{
+		planApp: planApp,
+	}
+}
+
+// ListPlans 處理列出所有方案的請求
+func (h *PlanHandler) ListPlans(c *gin.Context) {
+	plans, err := h.planApp.ListPlans(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"plans": plans})
+}
+
+// GetUsage 處理查詢目前使用者方案與用量的請求
+func (h *PlanHandler) GetUsage(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	report, err := h.planApp.Usage(c.Request.Context(), userID)
+	if err != nil {
+		if errors.Is(err, planapp.ErrUserNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// respondQuotaExceeded 在錯誤為超過配額時回應 403 與結構化的配額資訊並返回 true
+// 用戶端可依 code 判斷並以 quota、limit 與 upgrade_url 顯示升級提示
+func respondQuotaExceeded(c *gin.Context, err error) bool {
+	var quotaErr *planservice.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	body := gin.H{
+		"error": quotaErr.Error(),
+		"code":  "quota_exceeded",
+		"quota": quotaErr.Quota,
+		"plan":  quotaErr.Plan,
+		"limit": quotaErr.Limit,
+		"used":  quotaErr.Used,
+	}
+	if quotaErr.UpgradeURL != "" {
+		body["upgrade_url"] = quotaErr.UpgradeURL
+	}
+	c.JSON(http.StatusForbidden, body)
+	return true
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go_short/internal/api/middleware"
+	privacyapp "go_short/internal/application/privacy"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PrivacyHandler 處理個人資料匯出與清除的 HTTP 請求
+type PrivacyHandler struct {
+	privacyApp *privacyapp.App
+}
+
+// NewPrivacyHandler 創建 Privacy Handler 實例
+func NewPrivacyHandler(privacyApp *privacyapp.App) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyApp: privacyApp,
+	}
+}
+
+// ExportMyData 處理目前使用者下載自己個人資料的請求
+func (h *PrivacyHandler) ExportMyData(c *gin.Context) {
+	userID, ok := middleware.CurrentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	h.export(c, userID)
+}
+
+// ExportUserData 處理管理員代為匯出使用者個人資料的請求 (回應資料查閱請求)
+func (h *PrivacyHandler) ExportUserData(c *gin.Context) {
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	h.export(c, userID)
+}
+
+func (h *PrivacyHandler) export(c *gin.Context, userID uint) {
+	archive, err := h.privacyApp.ExportUserData(c.Request.Context(), userID)
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+archive.Filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(http.StatusOK, "application/zip", archive.Data)
+}
+
+// EraseUserData 處理管理員清除使用者個人資料的請求
+func (h *PrivacyHandler) EraseUserData(c *gin.Context) {
+	actorID, _ := middleware.CurrentUserID(c)
+	userID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	record, err := h.privacyApp.EraseUserData(c.Request.Context(), actorID, userID, request.Reason)
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Personal data erased", "record": record})
+}
+
+// ErasureLog 處理查詢清除稽核記錄與雜湊鏈檢查結果的請求
+func (h *PrivacyHandler) ErasureLog(c *gin.Context) {
+	result, err := h.privacyApp.ErasureLog(c.Request.Context())
+	if err != nil {
+		respondPrivacyError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// respondPrivacyError 將個人資料用例的錯誤轉換為 HTTP 回應
+func respondPrivacyError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, privacyapp.ErrReasonRequired):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, privacyapp.ErrSelfErasure):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, privacyapp.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
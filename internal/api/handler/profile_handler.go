@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go_short/internal/api/middleware"
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetProfile 返回目前使用者的個人資料
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	user, err := h.identityApp.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// UpdateProfile 修改目前使用者的使用者名稱或電子郵件，變更電子郵件需要提供目前的密碼
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	var request struct {
+		Username        *string `json:"username" binding:"omitempty,min=3"`
+		Email           *string `json:"email" binding:"omitempty,email"`
+		CurrentPassword string  `json:"current_password"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	user, err := h.identityApp.UpdateProfile(c.Request.Context(), userID, identityapp.ProfileUpdate{
+		Username:        request.Username,
+		Email:           request.Email,
+		CurrentPassword: request.CurrentPassword,
+	})
+	if err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": user})
+}
+
+// ChangePassword 修改密碼，需要提供目前的密碼；其他裝置上的登入會被登出
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	principal, _ := middleware.CurrentPrincipal(c)
+	var request struct {
+		OldPassword string `json:"old_password" binding:"required"`
+		NewPassword string `json:"new_password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.ChangePassword(c.Request.Context(), principal, request.OldPassword, request.NewPassword); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed, other sessions have been logged out"})
+}
+
+// DeleteAccount 刪除目前使用者的帳號，需要提供密碼確認；啟用兩步驟驗證時還需要驗證碼或復原碼
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	principal, _ := middleware.CurrentPrincipal(c)
+	var request struct {
+		Password string `json:"password" binding:"required"`
+		Code     string `json:"code"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.DeleteAccount(c.Request.Context(), principal, request.Password, request.Code); err != nil {
+		respondProfileError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
+
+func respondProfileError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, identityapp.ErrInvalidPassword), errors.Is(err, identityapp.ErrInvalidMFACode):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrUserAlreadyExists), errors.Is(err, identityapp.ErrSoleWorkspaceOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrInvalidProfile):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, identityapp.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
@@ -1,31 +1,39 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"go_short/domain/event"
 	"go_short/domain/urlshortener/service"
+	"go_short/internal/api/middleware"
+	urlshortenerapp "go_short/internal/application/urlshortener"
 
 	"github.com/gin-gonic/gin"
 )
 
 // URLHandler 處理 URL 相關的 HTTP 請求
 type URLHandler struct {
-	urlService service.URLShortenerService
+	urlApp *urlshortenerapp.App
 }
 
 // NewURLHandler 創建一個新的 URL 處理器
-func NewURLHandler(urlService service.URLShortenerService) *URLHandler {
+func NewURLHandler(urlApp *urlshortenerapp.App) *URLHandler {
 	return &URLHandler{
-		urlService: urlService,
+		urlApp: urlApp,
 	}
 }
 
 // CreateShortURL 處理創建短 URL 的請求
 func (h *URLHandler) CreateShortURL(c *gin.Context) {
 	var request struct {
-		URL       string `json:"url" binding:"required"`
-		ExpiresIn *int   `json:"expires_in,omitempty"` // 過期時間（以小時為單位）
+		URL         string `json:"url" binding:"required"`
+		ExpiresIn   *int   `json:"expires_in,omitempty"`   // 過期時間（以小時為單位）
+		Domain      string `json:"domain,omitempty"`       // 自訂網域（需已驗證且有使用權限）
+		WorkspaceID *uint  `json:"workspace_id,omitempty"` // 建立在工作區中（需為 editor 以上）
+		Alias       string `json:"alias,omitempty"`        // 自訂短碼（需登入，計入方案配額）
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
@@ -41,33 +49,59 @@ func (h *URLHandler) CreateShortURL(c *gin.Context) {
 		algorithm = "base62" // 默認算法
 	}
 
+	input := urlshortenerapp.CreateLinkInput{
+		URL:         request.URL,
+		Algorithm:   algorithm,
+		Domain:      request.Domain,
+		WorkspaceID: request.WorkspaceID,
+		Alias:       request.Alias,
+	}
+
 	// 設置過期時間（如果有）
-	var expiresIn *time.Duration
 	if request.ExpiresIn != nil {
 		duration := time.Duration(*request.ExpiresIn) * time.Hour
-		expiresIn = &duration
+		input.ExpiresIn = &duration
+	}
+
+	// 已登入的使用者成為連結的擁有者
+	var actorID *uint
+	if userID, ok := middleware.CurrentUserID(c); ok {
+		actorID = &userID
 	}
 
 	// 創建短 URL
-	urlMapping, err := h.urlService.CreateShortURL(c.Request.Context(), request.URL, algorithm, expiresIn)
+	urlMapping, err := h.urlApp.CreateLink(c.Request.Context(), actorID, input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
+		respondLinkError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"short_url":  urlMapping.ShortURL,
-		"algorithm":  urlMapping.Algorithm,
-		"expires_at": urlMapping.ExpiresAt,
+		"id":           urlMapping.ID,
+		"short_url":    urlMapping.ShortURL,
+		"domain":       request.Domain,
+		"workspace_id": urlMapping.WorkspaceID,
+		"algorithm":    urlMapping.Algorithm,
+		"custom_alias": urlMapping.CustomAlias,
+		"expires_at":   urlMapping.ExpiresAt,
 	})
 }
 
-// GetAllURLMappings 處理獲取所有 URL 映射的請求
+// GetAllURLMappings 處理獲取 URL 映射列表的請求
+// 預設返回目前使用者的個人連結，帶 workspace_id 時返回該工作區的連結
 func (h *URLHandler) GetAllURLMappings(c *gin.Context) {
-	mappings, err := h.urlService.GetAllURLMappings(c.Request.Context())
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseOptionalIDQuery(c, "workspace_id")
+	if !ok {
+		return
+	}
+
+	mappings, err := h.urlApp.ListLinks(c.Request.Context(), userID, workspaceID)
 	if err != nil {
+		if errors.Is(err, urlshortenerapp.ErrForbidden) {
+			respondLinkError(c, err)
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"code": http.StatusInternalServerError,
 			"msg":  "Error while fetching data",
@@ -82,11 +116,131 @@ func (h *URLHandler) GetAllURLMappings(c *gin.Context) {
 	})
 }
 
+// GetURLMapping 處理獲取單一連結的請求
+func (h *URLHandler) GetURLMapping(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	mapping, err := h.urlApp.GetLink(c.Request.Context(), userID, linkID)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": mapping})
+}
+
+// GetURLMappingStats 處理獲取單一連結統計的請求
+func (h *URLHandler) GetURLMappingStats(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	mapping, err := h.urlApp.GetLink(c.Request.Context(), userID, linkID)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"data": gin.H{
+			"id":          mapping.ID,
+			"short_url":   mapping.ShortURL,
+			"visits":      mapping.Visits,
+			"created_at":  mapping.CreatedAt,
+			"expires_at":  mapping.ExpiresAt,
+			"disabled_at": mapping.DisabledAt,
+		},
+	})
+}
+
+// UpdateURLMapping 處理修改連結目標網址或過期時間的請求
+func (h *URLHandler) UpdateURLMapping(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		URL         *string `json:"url,omitempty"`
+		ExpiresIn   *int    `json:"expires_in,omitempty"` // 以小時為單位，從現在起算
+		ClearExpiry bool    `json:"clear_expiry,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	input := urlshortenerapp.UpdateLinkInput{
+		OriginalURL: request.URL,
+		ClearExpiry: request.ClearExpiry,
+	}
+	if request.ExpiresIn != nil {
+		duration := time.Duration(*request.ExpiresIn) * time.Hour
+		input.ExpiresIn = &duration
+	}
+
+	mapping, err := h.urlApp.UpdateLink(c.Request.Context(), userID, linkID, input)
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": mapping})
+}
+
+// DeleteURLMapping 處理刪除連結的請求
+func (h *URLHandler) DeleteURLMapping(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.urlApp.DeleteLink(c.Request.Context(), userID, linkID); err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// TransferURLMapping 處理將連結轉移給其他成員或工作區的請求
+func (h *URLHandler) TransferURLMapping(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	linkID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		ToUserID      *uint `json:"to_user_id,omitempty"`
+		ToWorkspaceID *uint `json:"to_workspace_id,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	mapping, err := h.urlApp.TransferLink(c.Request.Context(), userID, linkID, urlshortenerapp.TransferTarget{
+		UserID:      request.ToUserID,
+		WorkspaceID: request.ToWorkspaceID,
+	})
+	if err != nil {
+		respondLinkError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": mapping})
+}
+
 // RedirectToOriginalURL 處理重定向到原始 URL 的請求
 func (h *URLHandler) RedirectToOriginalURL(c *gin.Context) {
 	shortURL := c.Param("shortURL")
 
-	originalURL, err := h.urlService.GetOriginalURL(c.Request.Context(), shortURL)
+	visit := event.Visit{Referrer: c.Request.Referer(), UserAgent: c.Request.UserAgent()}
+	originalURL, err := h.urlApp.URLService.GetOriginalURL(c.Request.Context(), c.Request.Host, shortURL, visit)
 	if err != nil {
 		switch err {
 		case service.ErrURLNotFound:
@@ -99,6 +253,11 @@ func (h *URLHandler) RedirectToOriginalURL(c *gin.Context) {
 				"code": http.StatusGone,
 				"msg":  "URL has expired",
 			})
+		case service.ErrURLDisabled:
+			c.JSON(http.StatusForbidden, gin.H{
+				"code": http.StatusForbidden,
+				"msg":  "URL has been disabled",
+			})
 		default:
 			c.JSON(http.StatusInternalServerError, gin.H{
 				"code": http.StatusInternalServerError,
@@ -118,3 +277,42 @@ func (h *URLHandler) HealthCheck(c *gin.Context) {
 		"time":   time.Now().Format(time.RFC3339),
 	})
 }
+
+// respondLinkError 將連結與網域用例的錯誤轉換為 HTTP 回應
+func respondLinkError(c *gin.Context, err error) {
+	if respondQuotaExceeded(c, err) {
+		return
+	}
+	switch {
+	case errors.Is(err, urlshortenerapp.ErrInvalidTarget), errors.Is(err, service.ErrInvalidDomain),
+		errors.Is(err, service.ErrInvalidAlias):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, urlshortenerapp.ErrForbidden), errors.Is(err, urlshortenerapp.ErrBlockedDomain):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, urlshortenerapp.ErrLinkNotFound), errors.Is(err, service.ErrDomainNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDomainNotVerified), errors.Is(err, service.ErrDomainAlreadyExists),
+		errors.Is(err, urlshortenerapp.ErrTargetNotMember), errors.Is(err, urlshortenerapp.ErrDomainNotShared),
+		errors.Is(err, service.ErrAliasTaken):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrDomainVerificationFailed):
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
+
+// parseOptionalIDQuery 解析可選的數字 ID 查詢參數，格式錯誤時直接返回 400
+func parseOptionalIDQuery(c *gin.Context, name string) (*uint, bool) {
+	raw := c.Query(name)
+	if raw == "" {
+		return nil, true
+	}
+	id, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil || id == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + name})
+		return nil, false
+	}
+	value := uint(id)
+	return &value, true
+}
@@ -2,8 +2,11 @@ package handler
 
 import (
 	"errors" // 引入 errors
+	"math"
 	"net/http"
+	"strconv"
 
+	"go_short/internal/api/middleware"
 	identityapp "go_short/internal/application/identity" // 引入 Identity 應用服務
 
 	"github.com/gin-gonic/gin"
@@ -68,12 +71,17 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 呼叫 App 層進行認證，獲取 token
-	tokenString, err := h.identityApp.AuthenticateUser(c.Request.Context(), request.Username, request.Password)
+	// 呼叫 App 層進行認證，獲取權杖組
+	result, err := h.identityApp.AuthenticateUser(c.Request.Context(), request.Username, request.Password, c.ClientIP())
 	if err != nil {
+		if respondLoginLocked(c, err) {
+			return
+		}
 		// 根據錯誤類型返回不同狀態碼
 		if errors.Is(err, identityapp.ErrAuthenticationFailed) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		} else if errors.Is(err, identityapp.ErrEmailNotVerified) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
 		} else {
 			// 其他錯誤（如 Token 生成失敗）視為內部錯誤
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
@@ -81,11 +89,167 @@ func (h *UserHandler) Login(c *gin.Context) {
 		return
 	}
 
-	// 登入成功，返回 JWT
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"token":   tokenString, // 在回應中包含 token
-	})
+	// 登入成功，返回存取權杖與 refresh token；已啟用兩步驟驗證時返回 MFA 挑戰權杖，須再呼叫 /auth/login/mfa
+	c.JSON(http.StatusOK, loginResponse(result))
+}
+
+// Refresh 以 refresh token 換發新的權杖組
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var request struct {
+		RefreshToken string `json:"refresh_token" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	tokens, err := h.identityApp.RefreshSession(c.Request.Context(), request.RefreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, identityapp.ErrInvalidRefreshToken), errors.Is(err, identityapp.ErrRefreshTokenReused):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token"})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, tokenResponse("Token refreshed", tokens))
+}
+
+// Logout 結束目前的登入工作階段
+func (h *UserHandler) Logout(c *gin.Context) {
+	principal, ok := middleware.CurrentPrincipal(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+		return
+	}
+
+	if err := h.identityApp.Logout(c.Request.Context(), principal); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to log out"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
+
+// VerifyEmail 確認電子郵件，權杖可來自查詢參數 (點擊郵件連結) 或 JSON body
+func (h *UserHandler) VerifyEmail(c *gin.Context) {
+	token := c.Query("token")
+	if token == "" {
+		var request struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+			return
+		}
+		token = request.Token
+	}
+
+	if err := h.identityApp.VerifyEmail(c.Request.Context(), token); err != nil {
+		respondActionTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified"})
+}
+
+// ResendVerification 重新寄送驗證信，不論帳號是否存在都返回相同回應
+func (h *UserHandler) ResendVerification(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.ResendVerificationEmail(c.Request.Context(), request.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists and is not verified yet, a verification email has been sent"})
+}
+
+// ForgotPassword 寄出密碼重設信，不論帳號是否存在都返回相同回應
+func (h *UserHandler) ForgotPassword(c *gin.Context) {
+	var request struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.RequestPasswordReset(c.Request.Context(), request.Email); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send password reset email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "If the account exists, a password reset email has been sent"})
+}
+
+// ResetPassword 使用重設權杖設定新密碼
+func (h *UserHandler) ResetPassword(c *gin.Context) {
+	var request struct {
+		Token    string `json:"token" binding:"required"`
+		Password string `json:"password" binding:"required,min=6"`
+	}
+
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	if err := h.identityApp.ResetPassword(c.Request.Context(), request.Token, request.Password); err != nil {
+		respondActionTokenError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset, please log in again"})
+}
+
+// respondLoginLocked 在登入被鎖定時返回 429 與 Retry-After，回應內容與帳號是否存在無關
+func respondLoginLocked(c *gin.Context, err error) bool {
+	var locked *identityapp.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many failed login attempts, please try again later"})
+	return true
+}
+
+func respondActionTokenError(c *gin.Context, err error) {
+	if errors.Is(err, identityapp.ErrInvalidActionToken) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+}
+
+// loginResponse 返回權杖組，或在需要兩步驟驗證時返回 MFA 挑戰權杖
+func loginResponse(result *identityapp.LoginResult) gin.H {
+	if result.MFARequired() {
+		return gin.H{
+			"message":        "Two-factor authentication required",
+			"mfa_required":   true,
+			"mfa_token":      result.MFAToken,
+			"mfa_expires_at": result.MFAExpiresAt,
+		}
+	}
+	return tokenResponse("Login successful", result.Tokens)
+}
+
+func tokenResponse(message string, tokens *identityapp.TokenPair) gin.H {
+	return gin.H{
+		"message":            message,
+		"token":              tokens.AccessToken,
+		"expires_at":         tokens.AccessExpiresAt,
+		"refresh_token":      tokens.RefreshToken,
+		"refresh_expires_at": tokens.RefreshExpiresAt,
+	}
 }
 
 // --- 可以添加其他 Handler 方法，如 GetProfile, Logout 等 ---
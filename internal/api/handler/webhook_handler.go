@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"go_short/domain/webhook/entity"
+	"go_short/domain/webhook/service"
+	"go_short/internal/api/middleware"
+	webhookapp "go_short/internal/application/webhook"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WebhookHandler 處理 webhook 端點管理與投遞記錄相關的 HTTP 請求
+type WebhookHandler struct {
+	webhookApp *webhookapp.App
+}
+
+// NewWebhookHandler 創建 Webhook Handler 實例
+func NewWebhookHandler(webhookApp *webhookapp.App) *WebhookHandler {
+	return &WebhookHandler{
+		webhookApp: webhookApp,
+	}
+}
+
+// webhookResponse 是 webhook 的回應格式，簽章密鑰只在建立與輪替時回傳
+type webhookResponse struct {
+	*entity.Webhook
+	Events []string `json:"events"`
+	Secret string   `json:"secret,omitempty"`
+}
+
+func newWebhookResponse(webhook *entity.Webhook, withSecret bool) webhookResponse {
+	response := webhookResponse{Webhook: webhook, Events: webhook.EventList()}
+	if withSecret {
+		response.Secret = webhook.Secret
+	}
+	return response
+}
+
+// CreateWebhook 處理建立 webhook 的請求
+func (h *WebhookHandler) CreateWebhook(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var request struct {
+		URL         string   `json:"url" binding:"required,max=2048"`
+		Events      []string `json:"events" binding:"required,min=1"`
+		Description string   `json:"description" binding:"max=255"`
+		WorkspaceID *uint    `json:"workspace_id"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	webhook, err := h.webhookApp.CreateWebhook(c.Request.Context(), userID, webhookapp.CreateWebhookInput{
+		URL:         request.URL,
+		Events:      request.Events,
+		Description: request.Description,
+		WorkspaceID: request.WorkspaceID,
+	})
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"webhook": newWebhookResponse(webhook, true)})
+}
+
+// ListWebhooks 處理列出 webhook 的請求 (?workspace_id= 列出工作區的 webhook)
+func (h *WebhookHandler) ListWebhooks(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseOptionalIDQuery(c, "workspace_id")
+	if !ok {
+		return
+	}
+
+	webhooks, err := h.webhookApp.ListWebhooks(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	data := make([]webhookResponse, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		data = append(data, newWebhookResponse(webhook, false))
+	}
+	c.JSON(http.StatusOK, gin.H{"data": data, "supported_events": service.SupportedEvents()})
+}
+
+// GetWebhook 處理獲取 webhook 詳情的請求
+func (h *WebhookHandler) GetWebhook(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookApp.GetWebhook(c.Request.Context(), userID, webhookID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": newWebhookResponse(webhook, false)})
+}
+
+// UpdateWebhook 處理修改 webhook 的請求，未提供的欄位保持不變
+func (h *WebhookHandler) UpdateWebhook(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		URL         *string  `json:"url" binding:"omitempty,max=2048"`
+		Events      []string `json:"events"`
+		Description *string  `json:"description" binding:"omitempty,max=255"`
+		Active      *bool    `json:"active"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	webhook, err := h.webhookApp.UpdateWebhook(c.Request.Context(), userID, webhookID, webhookapp.UpdateWebhookInput{
+		URL:         request.URL,
+		Events:      request.Events,
+		Description: request.Description,
+		Active:      request.Active,
+	})
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": newWebhookResponse(webhook, false)})
+}
+
+// DeleteWebhook 處理刪除 webhook 的請求
+func (h *WebhookHandler) DeleteWebhook(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	if err := h.webhookApp.DeleteWebhook(c.Request.Context(), userID, webhookID); err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// RotateSecret 處理輪替簽章密鑰的請求，舊密鑰立即失效
+func (h *WebhookHandler) RotateSecret(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	webhook, err := h.webhookApp.RotateSecret(c.Request.Context(), userID, webhookID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"webhook": newWebhookResponse(webhook, true)})
+}
+
+// PingWebhook 處理發送測試事件的請求
+func (h *WebhookHandler) PingWebhook(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookApp.PingWebhook(c.Request.Context(), userID, webhookID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
+
+// ListDeliveries 處理列出投遞記錄的請求 (?status=&page=&page_size=)
+func (h *WebhookHandler) ListDeliveries(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	status := c.Query("status")
+	switch status {
+	case "", entity.DeliveryPending, entity.DeliverySucceeded, entity.DeliveryDead:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status"})
+		return
+	}
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "20"))
+
+	result, err := h.webhookApp.ListDeliveries(c.Request.Context(), userID, webhookID, status, page, pageSize)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// Redeliver 處理重新投遞 dead letter 的請求
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	webhookID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := parseIDParam(c, "deliveryID")
+	if !ok {
+		return
+	}
+
+	delivery, err := h.webhookApp.Redeliver(c.Request.Context(), userID, webhookID, deliveryID)
+	if err != nil {
+		respondWebhookError(c, err)
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"delivery": delivery})
+}
+
+// respondWebhookError 將 webhook 相關錯誤轉換為 HTTP 回應
+func respondWebhookError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidURL), errors.Is(err, service.ErrInvalidEvents):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, webhookapp.ErrForbidden):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, webhookapp.ErrWebhookNotFound), errors.Is(err, webhookapp.ErrDeliveryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrNotDead):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
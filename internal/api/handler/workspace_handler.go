@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/service"
+	"go_short/internal/api/middleware"
+	workspaceapp "go_short/internal/application/workspace"
+
+	"github.com/gin-gonic/gin"
+)
+
+// WorkspaceHandler 處理工作區、成員與邀請相關的 HTTP 請求
+type WorkspaceHandler struct {
+	workspaceApp *workspaceapp.App
+}
+
+// NewWorkspaceHandler 創建 Workspace Handler 實例
+func NewWorkspaceHandler(workspaceApp *workspaceapp.App) *WorkspaceHandler {
+	return &WorkspaceHandler{
+		workspaceApp: workspaceApp,
+	}
+}
+
+// CreateWorkspace 處理建立工作區的請求
+func (h *WorkspaceHandler) CreateWorkspace(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var request struct {
+		Name string `json:"name" binding:"required,min=1,max=100"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	workspace, err := h.workspaceApp.CreateWorkspace(c.Request.Context(), userID, request.Name)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"workspace": workspace})
+}
+
+// ListWorkspaces 處理列出目前使用者工作區的請求
+func (h *WorkspaceHandler) ListWorkspaces(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	workspaces, err := h.workspaceApp.ListWorkspaces(c.Request.Context(), userID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": workspaces})
+}
+
+// GetWorkspace 處理獲取工作區詳情的請求
+func (h *WorkspaceHandler) GetWorkspace(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	workspace, err := h.workspaceApp.GetWorkspace(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"workspace": workspace})
+}
+
+// ListMembers 處理列出工作區成員的請求
+func (h *WorkspaceHandler) ListMembers(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	members, err := h.workspaceApp.ListMembers(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+// UpdateMemberRole 處理變更成員角色的請求
+func (h *WorkspaceHandler) UpdateMemberRole(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userID")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Role entity.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	membership, err := h.workspaceApp.UpdateMemberRole(c.Request.Context(), userID, workspaceID, memberID, request.Role)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"member": membership})
+}
+
+// RemoveMember 處理移除成員 (或自行離開) 的請求
+func (h *WorkspaceHandler) RemoveMember(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+	memberID, ok := parseIDParam(c, "userID")
+	if !ok {
+		return
+	}
+
+	if err := h.workspaceApp.RemoveMember(c.Request.Context(), userID, workspaceID, memberID); err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// InviteMember 處理邀請成員的請求，回應中的 token 只會顯示這一次
+func (h *WorkspaceHandler) InviteMember(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	var request struct {
+		Email string      `json:"email" binding:"required,email"`
+		Role  entity.Role `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	invitation, token, err := h.workspaceApp.InviteMember(c.Request.Context(), userID, workspaceID, request.Email, request.Role)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"invitation": invitation,
+		"token":      token,
+	})
+}
+
+// ListInvitations 處理列出待處理邀請的請求
+func (h *WorkspaceHandler) ListInvitations(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+	workspaceID, ok := parseIDParam(c, "id")
+	if !ok {
+		return
+	}
+
+	invitations, err := h.workspaceApp.ListInvitations(c.Request.Context(), userID, workspaceID)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"data": invitations})
+}
+
+// AcceptInvitation 處理接受邀請的請求
+func (h *WorkspaceHandler) AcceptInvitation(c *gin.Context) {
+	userID, _ := middleware.CurrentUserID(c)
+
+	var request struct {
+		Token string `json:"token" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input: " + err.Error()})
+		return
+	}
+
+	membership, err := h.workspaceApp.AcceptInvitation(c.Request.Context(), userID, request.Token)
+	if err != nil {
+		respondWorkspaceError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"member": membership})
+}
+
+// respondWorkspaceError 將工作區相關錯誤轉換為 HTTP 回應
+func respondWorkspaceError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrInvitationMismatch),
+		errors.Is(err, workspaceapp.ErrEmailNotVerified):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrWorkspaceNotFound), errors.Is(err, service.ErrMemberNotFound),
+		errors.Is(err, service.ErrInvitationNotFound), errors.Is(err, workspaceapp.ErrUserNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAlreadyMember), errors.Is(err, service.ErrLastOwner):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal server error"})
+	}
+}
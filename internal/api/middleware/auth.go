@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	auditservice "go_short/domain/audit/service"
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 存放在 gin.Context 中的鍵
+const (
+	ContextUserID    = "userID"
+	ContextUsername  = "username"
+	ContextPrincipal = "principal"
+)
+
+// APIKeyHeader 是傳遞 API 金鑰的替代標頭
+const APIKeyHeader = "X-API-Key"
+
+// RequireAuth 要求請求攜帶有效的 JWT 或 API 金鑰，否則返回 401
+func RequireAuth(identityApp *identityapp.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticate(c, identityApp) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// OptionalAuth 在請求攜帶有效憑證時設定使用者身分，但不強制要求登入
+// 只有完全未攜帶憑證的請求視為匿名；攜帶了無效或過期的憑證時返回 401，
+// 避免使用者的權杖過期後連結被默默建立為無法管理的匿名連結
+func OptionalAuth(identityApp *identityapp.App) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !authenticate(c, identityApp) && hasCredentials(c) {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired credentials"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireScope 要求以 API 金鑰認證的請求擁有指定權限範圍；JWT 登入不受限制，匿名請求直接放行
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal, ok := CurrentPrincipal(c)
+		if ok && !principal.HasScope(scope) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "API key is missing required scope: " + scope})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireSession 拒絕以 API 金鑰認證的請求，用於帳號管理等只允許互動式登入的路由
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if principal, ok := CurrentPrincipal(c); ok && principal.IsAPIKey() {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "This endpoint is not available to API keys"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireRole 要求已認證的使用者擁有指定角色，必須放在 RequireAuth 之後
+// 角色每次從資料庫讀取，降級或停用帳號後立即生效
+func RequireRole(identityApp *identityapp.App, role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, ok := CurrentUserID(c)
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			return
+		}
+
+		user, err := identityApp.GetUser(c.Request.Context(), userID)
+		if err != nil || !user.IsActive || user.Role != role {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// CurrentUserID 返回目前已認證的使用者 ID
+func CurrentUserID(c *gin.Context) (uint, bool) {
+	value, exists := c.Get(ContextUserID)
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok
+}
+
+// CurrentPrincipal 返回目前請求的已認證身分
+func CurrentPrincipal(c *gin.Context) (*identityapp.Principal, bool) {
+	value, exists := c.Get(ContextPrincipal)
+	if !exists {
+		return nil, false
+	}
+	principal, ok := value.(*identityapp.Principal)
+	return principal, ok
+}
+
+// hasCredentials 檢查請求是否攜帶了 X-API-Key 或 Authorization 標頭
+func hasCredentials(c *gin.Context) bool {
+	return strings.TrimSpace(c.GetHeader(APIKeyHeader)) != "" || strings.TrimSpace(c.GetHeader("Authorization")) != ""
+}
+
+// authenticate 解析 X-API-Key 或 Authorization 標頭，成功時將使用者身分寫入 context
+func authenticate(c *gin.Context, identityApp *identityapp.App) bool {
+	var principal *identityapp.Principal
+	var err error
+
+	if apiKey := strings.TrimSpace(c.GetHeader(APIKeyHeader)); apiKey != "" {
+		principal, err = identityApp.AuthenticateAPIKey(c.Request.Context(), apiKey)
+	} else {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			return false
+		}
+		credential := strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+		if credential == "" {
+			return false
+		}
+		principal, err = identityApp.Authenticate(c.Request.Context(), credential)
+	}
+	if err != nil {
+		return false
+	}
+
+	c.Set(ContextPrincipal, principal)
+	c.Set(ContextUserID, principal.UserID)
+	c.Set(ContextUsername, principal.Username)
+	c.Request = c.Request.WithContext(auditservice.WithActor(c.Request.Context(), principal.UserID))
+	return true
+}
@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPBlocklist 是可在執行中替換的來源 IP 封鎖清單
+type IPBlocklist struct {
+	prefixes atomic.Pointer[[]netip.Prefix]
+}
+
+// NewIPBlocklist 建立空的封鎖清單
+func NewIPBlocklist() *IPBlocklist {
+	b := &IPBlocklist{}
+	b.prefixes.Store(&[]netip.Prefix{})
+	return b
+}
+
+// Update 以 IP 或 CIDR 清單替換封鎖清單，有任何項目無效時不替換
+func (b *IPBlocklist) Update(entries []string) error {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return fmt.Errorf("invalid IP or CIDR %q", entry)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	b.prefixes.Store(&prefixes)
+	return nil
+}
+
+// Blocked 表示 ip 在封鎖清單中
+func (b *IPBlocklist) Blocked(ip string) bool {
+	prefixes := *b.prefixes.Load()
+	if len(prefixes) == 0 {
+		return false
+	}
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Blocklist 拒絕來源 IP 在封鎖清單中的請求，返回 403；來源 IP 依可信任代理的設定計算
+func Blocklist(blocklist *IPBlocklist) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if blocklist.Blocked(c.ClientIP()) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"go_short/infra/metrics"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 是沒有對應路由 (404) 的請求所使用的 route 標籤
+const unmatchedRoute = "unmatched"
+
+// Metrics 記錄每個請求的次數與延遲，以 gin 的路由樣板區分，避免短網址等路徑參數造成標籤數量失控
+func Metrics(m *metrics.Metrics) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		m.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// RequireBearerToken 要求請求帶上 Authorization: Bearer <token>，token 為空時直接放行
+// 用於保護 /metrics 等不經過使用者認證的端點
+func RequireBearerToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		provided := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			return
+		}
+		c.Next()
+	}
+}
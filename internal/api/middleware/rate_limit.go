@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go_short/infra/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimit 依 API 金鑰、使用者或來源 IP 限制請求頻率，超過時返回 429 與 Retry-After
+// 回應一律帶上 RateLimit-Limit、RateLimit-Remaining 與 RateLimit-Reset 標頭
+// 需要依使用者計算時必須放在 RequireAuth 或 OptionalAuth 之後；限流器發生錯誤時放行
+func RateLimit(limiter ratelimit.Limiter, group string, rule ratelimit.Rule) gin.HandlerFunc {
+	return RateLimitFunc(limiter, group, func(*gin.Context) ratelimit.Rule { return rule })
+}
+
+// RateLimitFunc 與 RateLimit 相同，但每個請求的限流規則由 ruleFor 決定 (例如依使用者的方案或重新載入的設定)
+// 規則的 Limit 為 0 時不限流
+func RateLimitFunc(limiter ratelimit.Limiter, group string, ruleFor func(c *gin.Context) ratelimit.Rule) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rule := ruleFor(c)
+		if rule.Limit <= 0 {
+			c.Next()
+			return
+		}
+		result, err := limiter.Allow(c.Request.Context(), group+":"+rateLimitSubject(c), rule)
+		if err != nil {
+			c.Next()
+			return
+		}
+
+		c.Header("RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.ResetAfter)))
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please slow down"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// rateLimitSubject 返回限流計算的對象：API 金鑰、已登入的使用者，否則為來源 IP
+func rateLimitSubject(c *gin.Context) string {
+	if principal, ok := CurrentPrincipal(c); ok {
+		if principal.IsAPIKey() {
+			return "key:" + strconv.FormatUint(uint64(*principal.APIKeyID), 10)
+		}
+		return "user:" + strconv.FormatUint(uint64(principal.UserID), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,110 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go_short/infra/ratelimit"
+	identityapp "go_short/internal/application/identity"
+
+	"github.com/gin-gonic/gin"
+)
+
+// brokenLimiter 模擬所有後端都無法使用的限流器
+type brokenLimiter struct{}
+
+func (brokenLimiter) Allow(context.Context, string, ratelimit.Rule) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("backend unavailable")
+}
+
+func newRateLimitRouter(limiter ratelimit.Limiter, rule ratelimit.Rule, principal *identityapp.Principal) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", func(c *gin.Context) {
+		if principal != nil {
+			c.Set(ContextPrincipal, principal)
+		}
+	}, RateLimit(limiter, "api", rule), func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+	return router
+}
+
+func serve(router *gin.Engine, remoteAddr string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.RemoteAddr = remoteAddr
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitRejectsWithRetryAfter(t *testing.T) {
+	router := newRateLimitRouter(ratelimit.NewMemoryLimiter(), ratelimit.Rule{Limit: 2, Window: time.Minute}, nil)
+
+	for i, wantRemaining := range []string{"1", "0"} {
+		w := serve(router, "192.0.2.1:1234")
+		if w.Code != http.StatusNoContent {
+			t.Fatalf("request %d: status %d", i+1, w.Code)
+		}
+		if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != wantRemaining {
+			t.Errorf("request %d: headers %v", i+1, w.Header())
+		}
+	}
+
+	w := serve(router, "192.0.2.1:1234")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") != "30" {
+		t.Errorf("Retry-After = %q, want 30", w.Header().Get("Retry-After"))
+	}
+
+	if w := serve(router, "192.0.2.2:1234"); w.Code != http.StatusNoContent {
+		t.Errorf("another IP: status %d, want its own quota", w.Code)
+	}
+}
+
+func TestRateLimitCountsPerPrincipal(t *testing.T) {
+	keyID := uint(9)
+	rule := ratelimit.Rule{Limit: 1, Window: time.Minute}
+	limiter := ratelimit.NewMemoryLimiter()
+	user := newRateLimitRouter(limiter, rule, &identityapp.Principal{UserID: 9})
+	apiKey := newRateLimitRouter(limiter, rule, &identityapp.Principal{UserID: 9, APIKeyID: &keyID})
+
+	// 同一 IP 上的使用者與 API 金鑰各自計算
+	if w := serve(user, "192.0.2.1:1"); w.Code != http.StatusNoContent {
+		t.Fatalf("user: status %d", w.Code)
+	}
+	if w := serve(apiKey, "192.0.2.1:1"); w.Code != http.StatusNoContent {
+		t.Fatalf("API key: status %d, want a quota separate from the user", w.Code)
+	}
+	if w := serve(user, "192.0.2.3:1"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("user from another IP: status %d, want 429", w.Code)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	router := newRateLimitRouter(brokenLimiter{}, ratelimit.Rule{Limit: 1, Window: time.Minute}, nil)
+	for i := 0; i < 3; i++ {
+		w := serve(router, "192.0.2.1:1")
+		if w.Code != http.StatusNoContent || w.Header().Get("RateLimit-Limit") != "" {
+			t.Fatalf("request %d: status %d, headers %v; want the request let through", i+1, w.Code, w.Header())
+		}
+	}
+}
+
+func TestRateLimitFuncSkipsDisabledRule(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/", RateLimitFunc(brokenLimiter{}, "redirect", func(*gin.Context) ratelimit.Rule {
+		return ratelimit.Rule{}
+	}), func(c *gin.Context) { c.Status(http.StatusNoContent) })
+
+	if w := serve(router, "192.0.2.1:1"); w.Code != http.StatusNoContent {
+		t.Fatalf("status %d, want a rule with limit 0 to let every request through", w.Code)
+	}
+}
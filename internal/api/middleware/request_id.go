@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	auditservice "go_short/domain/audit/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestIDHeader 是傳遞請求 ID 的標頭，回應中一律帶上此標頭
+const RequestIDHeader = "X-Request-ID"
+
+// ContextRequestID 是存放請求 ID 的 gin.Context 鍵
+const ContextRequestID = "requestID"
+
+// maxRequestIDLength 與 audit_logs.request_id 欄位長度一致
+const maxRequestIDLength = 64
+
+// RequestMetadata 沿用上游代理提供的 X-Request-ID (格式不符時重新產生)，
+// 並將請求 ID、來源 IP 與 User-Agent 放入 request context，供應用層寫入稽核記錄
+func RequestMetadata() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(ContextRequestID, requestID)
+		c.Header(RequestIDHeader, requestID)
+
+		ctx := auditservice.WithRequestMetadata(c.Request.Context(), auditservice.RequestMetadata{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: requestID,
+		})
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+func isValidRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
@@ -1,27 +1,89 @@
 package api
 
 import (
+	"log/slog"
+	"sync/atomic"
+	"time"
+
 	"go_short/conf"
+	identityentity "go_short/domain/identity/entity"
+	"go_short/infra/metrics"
+	"go_short/infra/ratelimit"
 	"go_short/internal/api/handler"
+	"go_short/internal/api/middleware"
+	identityapp "go_short/internal/application/identity"
+	planapp "go_short/internal/application/plan"
 
 	"github.com/gin-gonic/gin"
 )
 
 // Router 負責集中管理所有 API 路由
 type Router struct {
-	engine      *gin.Engine
-	urlHandler  *handler.URLHandler
-	userHandler *handler.UserHandler
-	config      *conf.Config
+	engine           *gin.Engine
+	urlHandler       *handler.URLHandler
+	userHandler      *handler.UserHandler
+	domainHandler    *handler.DomainHandler
+	workspaceHandler *handler.WorkspaceHandler
+	adminHandler     *handler.AdminHandler
+	apiKeyHandler    *handler.APIKeyHandler
+	oidcHandler      *handler.OIDCHandler
+	privacyHandler   *handler.PrivacyHandler
+	planHandler      *handler.PlanHandler
+	webhookHandler   *handler.WebhookHandler
+	analyticsHandler *handler.AnalyticsHandler
+	healthHandler    *handler.HealthHandler
+	identityApp      *identityapp.App
+	planApp          *planapp.App
+	limiter          ratelimit.Limiter
+	metrics          *metrics.Metrics
+	logger           *slog.Logger
+	settings         *conf.Watcher
+	config           *conf.Config // 啟動時的設定，只用於不可熱更新的項目
+
+	// 以下由設定的訂閱更新，每個請求讀取最新的值
+	algorithm  atomic.Pointer[string]
+	rateLimits atomic.Pointer[conf.RateLimiting]
+	blocklist  *middleware.IPBlocklist
 }
 
 // NewRouter 建立一個新的路由管理器
-func NewRouter(engine *gin.Engine, urlHandler *handler.URLHandler, userHandler *handler.UserHandler, config *conf.Config) *Router {
-	return &Router{
-		engine:      engine,
-		urlHandler:  urlHandler,
-		userHandler: userHandler,
-		config:      config,
+func NewRouter(engine *gin.Engine, urlHandler *handler.URLHandler, userHandler *handler.UserHandler, domainHandler *handler.DomainHandler, workspaceHandler *handler.WorkspaceHandler, adminHandler *handler.AdminHandler, apiKeyHandler *handler.APIKeyHandler, oidcHandler *handler.OIDCHandler, privacyHandler *handler.PrivacyHandler, planHandler *handler.PlanHandler, webhookHandler *handler.WebhookHandler, analyticsHandler *handler.AnalyticsHandler, healthHandler *handler.HealthHandler, identityApp *identityapp.App, planApp *planapp.App, limiter ratelimit.Limiter, metrics *metrics.Metrics, logger *slog.Logger, settings *conf.Watcher) *Router {
+	r := &Router{
+		engine:           engine,
+		urlHandler:       urlHandler,
+		userHandler:      userHandler,
+		domainHandler:    domainHandler,
+		workspaceHandler: workspaceHandler,
+		adminHandler:     adminHandler,
+		apiKeyHandler:    apiKeyHandler,
+		oidcHandler:      oidcHandler,
+		privacyHandler:   privacyHandler,
+		planHandler:      planHandler,
+		webhookHandler:   webhookHandler,
+		analyticsHandler: analyticsHandler,
+		healthHandler:    healthHandler,
+		identityApp:      identityApp,
+		planApp:          planApp,
+		limiter:          limiter,
+		metrics:          metrics,
+		logger:           logger,
+		settings:         settings,
+		config:           settings.Current(),
+		blocklist:        middleware.NewIPBlocklist(),
+	}
+	settings.Subscribe(r.applySettings)
+	return r
+}
+
+// applySettings 套用重新載入的設定：預設演算法、限流規則與來源 IP 封鎖清單
+func (r *Router) applySettings(config *conf.Config) {
+	algorithm := config.Shortener.Algorithm
+	r.algorithm.Store(&algorithm)
+	rateLimits := config.RateLimit
+	r.rateLimits.Store(&rateLimits)
+	// 設定已在載入時檢查過，這裡不會失敗
+	if err := r.blocklist.Update(config.Blocklist.IPs); err != nil {
+		slog.Error("Failed to update IP blocklist", "error", err)
 	}
 }
 
@@ -29,8 +91,15 @@ func NewRouter(engine *gin.Engine, urlHandler *handler.URLHandler, userHandler *
 func (r *Router) SetupRoutes() {
 	r.setupMiddlewares()
 	r.setupHealthCheckRoutes()
+	r.setupMetricsRoutes()
 	r.setupURLShortenerRoutes()
 	r.setupUserRoutes()
+	r.setupDomainRoutes()
+	r.setupWorkspaceRoutes()
+	r.setupAdminRoutes()
+	r.setupAPIKeyRoutes()
+	r.setupPlanRoutes()
+	r.setupWebhookRoutes()
 
 	// 在未來可以增加更多其他領域的路由設定
 	// r.setupUserRoutes()
@@ -40,35 +109,253 @@ func (r *Router) SetupRoutes() {
 
 // setupMiddlewares 設定全域中間件
 func (r *Router) setupMiddlewares() {
-	// 設置中間件，將配置傳遞給處理器
+	// 設置中間件，將配置傳遞給處理器 (預設演算法可熱更新)
 	r.engine.Use(func(c *gin.Context) {
-		c.Set("algorithm", r.config.ShortenerAlgorithm)
+		c.Set("algorithm", *r.algorithm.Load())
 		c.Next()
 	})
 
-	// 可添加其他全域中間件如 CORS、認證、限流等
+	// 追蹤：沿用上游的 traceparent 並為每個請求建立 span
+	r.engine.Use(middleware.Tracing())
+
+	// 請求 ID 與來源資訊，供稽核記錄與日誌使用
+	r.engine.Use(middleware.RequestMetadata())
+
+	// 結構化存取日誌，帶上請求 ID、使用者與 trace ID
+	if r.logger != nil {
+		r.engine.Use(middleware.AccessLog(r.logger))
+	}
+
+	// 請求次數與延遲指標
+	if r.metrics != nil {
+		r.engine.Use(middleware.Metrics(r.metrics))
+	}
+
+	// 封鎖清單中的來源 IP，放在日誌與指標之後以便記錄被拒絕的請求
+	r.engine.Use(middleware.Blocklist(r.blocklist))
+
+	// 可添加其他全域中間件如 CORS 等；限流依路由群組設定，見 rateLimit
+}
+
+// rateLimit 返回指定路由群組的限流中間件，規則在每個請求時讀取，限流停用或群組設為 off 時放行
+func (r *Router) rateLimit(group string) gin.HandlerFunc {
+	if r.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RateLimitFunc(r.limiter, group, func(*gin.Context) ratelimit.Rule {
+		limit, ok := r.currentRateLimit(group)
+		if !ok {
+			return ratelimit.Rule{}
+		}
+		return ratelimit.Rule{Limit: limit.Limit, Window: limit.Window}
+	})
+}
+
+// apiRateLimit 返回 API 群組的限流中間件，已認證的使用者依其方案的每分鐘上限計算
+// 方案未設定上限時使用 RATE_LIMIT_API 的全域設定
+func (r *Router) apiRateLimit() gin.HandlerFunc {
+	if r.limiter == nil {
+		return func(c *gin.Context) { c.Next() }
+	}
+	return middleware.RateLimitFunc(r.limiter, conf.RateLimitAPI, func(c *gin.Context) ratelimit.Rule {
+		limit, ok := r.currentRateLimit(conf.RateLimitAPI)
+		if !ok {
+			return ratelimit.Rule{}
+		}
+		fallback := ratelimit.Rule{Limit: limit.Limit, Window: limit.Window}
+		userID, ok := middleware.CurrentUserID(c)
+		if !ok || r.planApp == nil {
+			return fallback
+		}
+		if perMinute, ok := r.planApp.APIRateLimit(c.Request.Context(), userID); ok {
+			return ratelimit.Rule{Limit: perMinute, Window: time.Minute}
+		}
+		return fallback
+	})
+}
+
+// currentRateLimit 返回路由群組目前的限流設定，限流停用或群組設為 off 時返回 false
+func (r *Router) currentRateLimit(group string) (conf.RateLimit, bool) {
+	limits := r.rateLimits.Load()
+	if !limits.Enabled {
+		return conf.RateLimit{}, false
+	}
+	return limits.Rule(group)
 }
 
 // setupHealthCheckRoutes 設定健康檢查路由
+// /healthz 只表示行程存活，/readyz 檢查資料庫、Redis、資料庫結構版本與背景任務，供負載平衡器判斷是否導入流量
 func (r *Router) setupHealthCheckRoutes() {
 	r.engine.GET("/ping", r.urlHandler.HealthCheck)
+	if r.healthHandler != nil {
+		r.engine.GET("/healthz", r.healthHandler.Liveness)
+		r.engine.GET("/readyz", r.healthHandler.Readiness)
+	}
+}
+
+// setupMetricsRoutes 提供 Prometheus 抓取的 /metrics，設定 METRICS_TOKEN 時須帶上 Bearer token
+func (r *Router) setupMetricsRoutes() {
+	if r.metrics == nil {
+		return
+	}
+	r.engine.GET("/metrics", middleware.RequireBearerToken(r.config.Metrics.Token), gin.WrapH(r.metrics.Handler()))
 }
 
 // setupURLShortenerRoutes 設定短連結相關路由
 func (r *Router) setupURLShortenerRoutes() {
-	// URL 映射 API
-	r.engine.GET("/url_mapping", r.urlHandler.GetAllURLMappings)
-	r.engine.POST("/url_mapping", r.urlHandler.CreateShortURL)
+	// URL 映射 API (匿名可建立連結，其餘操作需登入並依擁有者/工作區角色授權)
+	// 以 API 金鑰存取時另外檢查金鑰的權限範圍
+	readScope := middleware.RequireScope(identityentity.ScopeLinksRead)
+	writeScope := middleware.RequireScope(identityentity.ScopeLinksWrite)
+	statsScope := middleware.RequireScope(identityentity.ScopeStatsRead)
+
+	r.engine.POST("/url_mapping", middleware.OptionalAuth(r.identityApp), r.rateLimit(conf.RateLimitCreate), writeScope, r.urlHandler.CreateShortURL)
+	linkGroup := r.engine.Group("/url_mapping", middleware.RequireAuth(r.identityApp), r.apiRateLimit())
+	{
+		linkGroup.GET("", readScope, r.urlHandler.GetAllURLMappings)
+		linkGroup.GET("/:id", readScope, r.urlHandler.GetURLMapping)
+		linkGroup.GET("/:id/stats", statsScope, r.urlHandler.GetURLMappingStats)
+		linkGroup.PATCH("/:id", writeScope, r.urlHandler.UpdateURLMapping)
+		linkGroup.DELETE("/:id", writeScope, r.urlHandler.DeleteURLMapping)
+		linkGroup.POST("/:id/transfer", writeScope, r.urlHandler.TransferURLMapping)
+	}
 
-	// 重定向 API
-	r.engine.GET("/:shortURL", r.urlHandler.RedirectToOriginalURL)
+	// 重定向 API (依據 Host 標頭決定短碼所屬的網域)
+	r.engine.GET("/:shortURL", r.rateLimit(conf.RateLimitRedirect), r.urlHandler.RedirectToOriginalURL)
 }
 
 // setupUserRoutes 設定使用者相關路由
 func (r *Router) setupUserRoutes() {
-	userGroup := r.engine.Group("/auth")
+	userGroup := r.engine.Group("/auth", r.rateLimit(conf.RateLimitAuth))
 	{
 		userGroup.POST("/register", r.userHandler.Register)
 		userGroup.POST("/login", r.userHandler.Login)
+		userGroup.POST("/login/mfa", r.userHandler.LoginMFA)
+		userGroup.POST("/refresh", r.userHandler.Refresh)
+		userGroup.GET("/verify", r.userHandler.VerifyEmail)
+		userGroup.POST("/verify", r.userHandler.VerifyEmail)
+		userGroup.POST("/verify/resend", r.userHandler.ResendVerification)
+		userGroup.POST("/forgot", r.userHandler.ForgotPassword)
+		userGroup.POST("/reset", r.userHandler.ResetPassword)
+		userGroup.POST("/logout", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.userHandler.Logout)
+	}
+
+	// 目前使用者的帳號管理 (只允許互動式登入)
+	meGroup := r.engine.Group("/me", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		meGroup.GET("", r.userHandler.GetProfile)
+		meGroup.PATCH("", r.userHandler.UpdateProfile)
+		meGroup.DELETE("", r.userHandler.DeleteAccount)
+		meGroup.POST("/password", r.userHandler.ChangePassword)
+		meGroup.GET("/export", r.privacyHandler.ExportMyData)
+		meGroup.GET("/usage", r.planHandler.GetUsage)
+	}
+
+	// OpenID Connect 登入
+	oidcGroup := r.engine.Group("/auth/oidc", r.rateLimit(conf.RateLimitAuth))
+	{
+		oidcGroup.GET("", r.oidcHandler.ListProviders)
+		oidcGroup.GET("/:provider/login", r.oidcHandler.Login)
+		oidcGroup.GET("/:provider/callback", r.oidcHandler.Callback)
+	}
+
+	// 兩步驟驗證管理 (只允許互動式登入)
+	mfaGroup := r.engine.Group("/auth/2fa", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		mfaGroup.GET("", r.userHandler.GetMFAStatus)
+		mfaGroup.POST("/enroll", r.userHandler.EnrollTOTP)
+		mfaGroup.POST("/confirm", r.userHandler.ConfirmTOTP)
+		mfaGroup.POST("/disable", r.userHandler.DisableTOTP)
+		mfaGroup.POST("/recovery-codes", r.userHandler.RegenerateRecoveryCodes)
+	}
+}
+
+// setupDomainRoutes 設定自訂網域相關路由
+func (r *Router) setupDomainRoutes() {
+	domainGroup := r.engine.Group("/domains", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		domainGroup.GET("", r.domainHandler.ListDomains)
+		domainGroup.POST("", r.domainHandler.RegisterDomain)
+		domainGroup.POST("/:id/verify", r.domainHandler.VerifyDomain)
+		domainGroup.PATCH("/:id", r.domainHandler.UpdateDomain)
+		domainGroup.DELETE("/:id", r.domainHandler.DeleteDomain)
+	}
+}
+
+// setupWorkspaceRoutes 設定工作區、成員與邀請相關路由
+func (r *Router) setupWorkspaceRoutes() {
+	workspaceGroup := r.engine.Group("/workspaces", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		workspaceGroup.GET("", r.workspaceHandler.ListWorkspaces)
+		workspaceGroup.POST("", r.workspaceHandler.CreateWorkspace)
+		workspaceGroup.GET("/:id", r.workspaceHandler.GetWorkspace)
+		workspaceGroup.GET("/:id/members", r.workspaceHandler.ListMembers)
+		workspaceGroup.PATCH("/:id/members/:userID", r.workspaceHandler.UpdateMemberRole)
+		workspaceGroup.DELETE("/:id/members/:userID", r.workspaceHandler.RemoveMember)
+		workspaceGroup.GET("/:id/invitations", r.workspaceHandler.ListInvitations)
+		workspaceGroup.POST("/:id/invitations", r.workspaceHandler.InviteMember)
+	}
+
+	r.engine.POST("/invitations/accept", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit(), r.workspaceHandler.AcceptInvitation)
+}
+
+// setupAdminRoutes 設定管理後台路由，僅限 admin 角色
+func (r *Router) setupAdminRoutes() {
+	adminGroup := r.engine.Group("/admin",
+		middleware.RequireAuth(r.identityApp),
+		middleware.RequireSession(),
+		middleware.RequireRole(r.identityApp, identityentity.RoleAdmin),
+		r.rateLimit(conf.RateLimitAdmin),
+	)
+	{
+		adminGroup.GET("/users", r.adminHandler.ListUsers)
+		adminGroup.POST("/users/:id/activate", r.adminHandler.ActivateUser)
+		adminGroup.POST("/users/:id/deactivate", r.adminHandler.DeactivateUser)
+		adminGroup.POST("/users/:id/unlock", r.adminHandler.UnlockUser)
+		adminGroup.PUT("/users/:id/role", r.adminHandler.SetUserRole)
+		adminGroup.PUT("/users/:id/plan", r.adminHandler.SetUserPlan)
+		adminGroup.GET("/users/:id/export", r.privacyHandler.ExportUserData)
+		adminGroup.POST("/users/:id/erase", r.privacyHandler.EraseUserData)
+		adminGroup.GET("/erasures", r.privacyHandler.ErasureLog)
+
+		adminGroup.GET("/links", r.adminHandler.ListLinks)
+		adminGroup.POST("/links/:id/disable", r.adminHandler.DisableLink)
+		adminGroup.POST("/links/:id/enable", r.adminHandler.EnableLink)
+		adminGroup.DELETE("/links/:id", r.adminHandler.DeleteLink)
+
+		adminGroup.GET("/stats", r.adminHandler.Stats)
+		adminGroup.GET("/audit", r.adminHandler.ListAuditLog)
+		adminGroup.GET("/clicks/stream", r.analyticsHandler.ClickStreamStats)
+	}
+}
+
+// setupAPIKeyRoutes 設定 API 金鑰管理路由 (只允許互動式登入，API 金鑰不能管理金鑰)
+func (r *Router) setupAPIKeyRoutes() {
+	keyGroup := r.engine.Group("/api-keys", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		keyGroup.GET("", r.apiKeyHandler.ListAPIKeys)
+		keyGroup.POST("", r.apiKeyHandler.CreateAPIKey)
+		keyGroup.DELETE("/:id", r.apiKeyHandler.RevokeAPIKey)
+	}
+}
+
+// setupPlanRoutes 設定方案相關路由 (公開，供價目頁與升級提示使用)
+func (r *Router) setupPlanRoutes() {
+	r.engine.GET("/plans", r.rateLimit(conf.RateLimitAPI), r.planHandler.ListPlans)
+}
+
+// setupWebhookRoutes 設定 webhook 端點管理與投遞記錄路由 (只允許互動式登入，避免 API 金鑰讀取簽章密鑰)
+func (r *Router) setupWebhookRoutes() {
+	webhookGroup := r.engine.Group("/webhooks", middleware.RequireAuth(r.identityApp), middleware.RequireSession(), r.apiRateLimit())
+	{
+		webhookGroup.GET("", r.webhookHandler.ListWebhooks)
+		webhookGroup.POST("", r.webhookHandler.CreateWebhook)
+		webhookGroup.GET("/:id", r.webhookHandler.GetWebhook)
+		webhookGroup.PATCH("/:id", r.webhookHandler.UpdateWebhook)
+		webhookGroup.DELETE("/:id", r.webhookHandler.DeleteWebhook)
+		webhookGroup.POST("/:id/rotate-secret", r.webhookHandler.RotateSecret)
+		webhookGroup.POST("/:id/ping", r.webhookHandler.PingWebhook)
+		webhookGroup.GET("/:id/deliveries", r.webhookHandler.ListDeliveries)
+		webhookGroup.POST("/:id/deliveries/:deliveryID/redeliver", r.webhookHandler.Redeliver)
 	}
 }
@@ -0,0 +1,394 @@
+package adminapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
+
+	auditentity "go_short/domain/audit/entity"
+	auditrepository "go_short/domain/audit/repository"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/identity/entity"
+	identityrepository "go_short/domain/identity/repository"
+	identityservice "go_short/domain/identity/service"
+	planservice "go_short/domain/plan/service"
+	urlentity "go_short/domain/urlshortener/entity"
+	urlrepository "go_short/domain/urlshortener/repository"
+	urlservice "go_short/domain/urlshortener/service"
+)
+
+// 管理員用例的錯誤
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrLinkNotFound = errors.New("link not found")
+	ErrInvalidRole  = errors.New("invalid user role")
+	ErrInvalidPlan  = errors.New("unknown plan")
+	ErrSelfAction   = errors.New("administrators cannot deactivate or demote themselves")
+	ErrUserExists   = errors.New("username or email already exists")
+	ErrInvalidUser  = errors.New("username must be at least 3 characters, email must be valid and password at least 6 characters")
+	ErrInternal     = errors.New("internal server error")
+)
+
+// 分頁預設值
+const (
+	defaultPageSize = 20
+	// MaxPageSize 是每頁筆數的上限，較大的 page_size 會被調整為此值
+	MaxPageSize = 100
+)
+
+// UserPage 是分頁的使用者搜尋結果
+type UserPage struct {
+	Users    []*entity.User `json:"users"`
+	Total    int64          `json:"total"`
+	Page     int            `json:"page"`
+	PageSize int            `json:"page_size"`
+}
+
+// LinkPage 是分頁的連結列表
+type LinkPage struct {
+	Links    []*urlentity.URLMapping `json:"links"`
+	Total    int64                   `json:"total"`
+	Page     int                     `json:"page"`
+	PageSize int                     `json:"page_size"`
+}
+
+// AuditPage 是分頁的稽核記錄查詢結果
+type AuditPage struct {
+	Entries  []*auditentity.AuditEntry `json:"entries"`
+	Total    int64                     `json:"total"`
+	Page     int                       `json:"page"`
+	PageSize int                       `json:"page_size"`
+}
+
+// SystemStats 是全系統統計數據
+type SystemStats struct {
+	TotalUsers  int64                    `json:"total_users"`
+	ActiveUsers int64                    `json:"active_users"`
+	Links       *urlrepository.LinkStats `json:"links"`
+}
+
+// App 是管理後台的應用服務，負責使用者與連結的審核管理
+type App struct {
+	userRepo        identityrepository.UserRepository
+	identityService identityservice.IdentityService
+	loginGuard      *identityservice.LoginGuard
+	urlService      urlservice.URLShortenerService
+	quotaService    *planservice.QuotaService
+	auditRepo       auditrepository.AuditRepository
+	audit           *auditservice.Recorder
+}
+
+// NewApp 創建管理後台應用服務實例
+func NewApp(userRepo identityrepository.UserRepository, identityService identityservice.IdentityService, loginGuard *identityservice.LoginGuard, urlService urlservice.URLShortenerService, quotaService *planservice.QuotaService, auditRepo auditrepository.AuditRepository, audit *auditservice.Recorder) *App {
+	return &App{
+		userRepo:        userRepo,
+		identityService: identityService,
+		loginGuard:      loginGuard,
+		urlService:      urlService,
+		quotaService:    quotaService,
+		auditRepo:       auditRepo,
+		audit:           audit,
+	}
+}
+
+// ListUsers 分頁列出或搜尋使用者 (依使用者名稱或電子郵件)
+func (a *App) ListUsers(ctx context.Context, query string, page, pageSize int) (*UserPage, error) {
+	page, pageSize = normalizePage(page, pageSize)
+	users, total, err := a.userRepo.Search(ctx, query, (page-1)*pageSize, pageSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error searching users with query", "query", query, "error", err)
+		return nil, ErrInternal
+	}
+	return &UserPage{Users: users, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// CreateUserInput 是建立使用者用例的輸入
+type CreateUserInput struct {
+	Username      string
+	Email         string
+	Password      string
+	Role          string // 空字串表示一般使用者
+	EmailVerified bool   // 直接標記為已驗證，不寄送驗證信
+}
+
+// CreateUser 由管理員直接建立使用者 (例如建立第一個管理員)，規則與註冊相同
+func (a *App) CreateUser(ctx context.Context, input CreateUserInput) (*entity.User, error) {
+	if input.Role == "" {
+		input.Role = entity.RoleUser
+	}
+	if !entity.IsValidRole(input.Role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := mail.ParseAddress(input.Email); err != nil || len(input.Username) < 3 || len(input.Password) < 6 {
+		return nil, ErrInvalidUser
+	}
+
+	existing, err := a.userRepo.FindByUsername(ctx, input.Username)
+	if err == nil && existing == nil {
+		existing, err = a.userRepo.FindByEmail(ctx, input.Email)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking existing user", "username", input.Username, "error", err)
+		return nil, ErrInternal
+	}
+	if existing != nil {
+		return nil, ErrUserExists
+	}
+
+	user := &entity.User{
+		Username: input.Username,
+		Email:    input.Email,
+		Role:     input.Role,
+		IsActive: true,
+	}
+	if input.EmailVerified {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := user.SetPassword(input.Password); err != nil {
+		slog.ErrorContext(ctx, "Error hashing password for user", "username", input.Username, "error", err)
+		return nil, ErrInternal
+	}
+	if err := a.userRepo.Create(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error creating user", "username", input.Username, "error", err)
+		return nil, ErrInternal
+	}
+	a.record(ctx, "user.create", auditentity.TargetUser, user.ID, nil, user)
+	return user, nil
+}
+
+// FindUser 以 ID、電子郵件或使用者名稱查找使用者
+func (a *App) FindUser(ctx context.Context, ref string) (*entity.User, error) {
+	var user *entity.User
+	var err error
+	if id, parseErr := strconv.ParseUint(ref, 10, 64); parseErr == nil {
+		user, err = a.userRepo.FindByID(ctx, uint(id))
+	} else if strings.Contains(ref, "@") {
+		user, err = a.userRepo.FindByEmail(ctx, ref)
+	} else {
+		user, err = a.userRepo.FindByUsername(ctx, ref)
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "user", ref, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// ActivateUser 啟用使用者帳號
+func (a *App) ActivateUser(ctx context.Context, userID uint) error {
+	if err := a.identityService.ActivateUser(ctx, userID); err != nil {
+		return mapIdentityError(err)
+	}
+	a.record(ctx, "user.activate", auditentity.TargetUser, userID, nil, nil)
+	return nil
+}
+
+// DeactivateUser 停用使用者帳號，管理員不可停用自己
+func (a *App) DeactivateUser(ctx context.Context, actorID, userID uint) error {
+	if actorID == userID {
+		return ErrSelfAction
+	}
+	if err := a.identityService.DeactivateUser(ctx, userID); err != nil {
+		return mapIdentityError(err)
+	}
+	a.record(ctx, "user.deactivate", auditentity.TargetUser, userID, nil, nil)
+	return nil
+}
+
+// UnlockUser 解除使用者因登入失敗次數過多造成的暫時鎖定
+func (a *App) UnlockUser(ctx context.Context, userID uint) error {
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for unlock", "user_id", userID, "error", err)
+		return ErrInternal
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	if err := a.loginGuard.Unlock(ctx, user.Username); err != nil {
+		return ErrInternal
+	}
+	a.record(ctx, "user.unlock", auditentity.TargetUser, userID, nil, nil)
+	return nil
+}
+
+// SetUserRole 變更使用者角色，管理員不可降級自己
+func (a *App) SetUserRole(ctx context.Context, actorID, userID uint, role string) (*entity.User, error) {
+	if !entity.IsValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if actorID == userID && role != entity.RoleAdmin {
+		return nil, ErrSelfAction
+	}
+
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for role change", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	before := *user
+	user.Role = role
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating role of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	a.record(ctx, "user.role_change", auditentity.TargetUser, userID, &before, user)
+	return user, nil
+}
+
+// SetUserPlan 變更使用者的方案；降級時既有資源不會被刪除，但在用量低於新上限前無法再建立
+func (a *App) SetUserPlan(ctx context.Context, userID uint, planName string) (*entity.User, error) {
+	if _, err := a.quotaService.FindPlan(ctx, planName); err != nil {
+		if errors.Is(err, planservice.ErrPlanNotFound) {
+			return nil, ErrInvalidPlan
+		}
+		slog.ErrorContext(ctx, "Error finding plan", "plan_name", planName, "error", err)
+		return nil, ErrInternal
+	}
+
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for plan change", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	before := *user
+	user.Plan = planName
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating plan of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	a.record(ctx, "user.plan_change", auditentity.TargetUser, userID, &before, user)
+	return user, nil
+}
+
+// ListLinks 分頁列出全系統的連結
+func (a *App) ListLinks(ctx context.Context, page, pageSize int) (*LinkPage, error) {
+	page, pageSize = normalizePage(page, pageSize)
+	links, total, err := a.urlService.ListURLMappings(ctx, (page-1)*pageSize, pageSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing links", "page", page, "error", err)
+		return nil, ErrInternal
+	}
+	return &LinkPage{Links: links, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// SetLinkDisabled 停用或重新啟用任意連結
+func (a *App) SetLinkDisabled(ctx context.Context, linkID uint, disabled bool) (*urlentity.URLMapping, error) {
+	mapping, err := a.findLink(ctx, linkID)
+	if err != nil {
+		return nil, err
+	}
+	before := *mapping
+	if err := a.urlService.SetURLMappingDisabled(ctx, mapping, disabled); err != nil {
+		return nil, err
+	}
+	action := "link.enable"
+	if disabled {
+		action = "link.disable"
+	}
+	a.record(ctx, action, auditentity.TargetLink, linkID, &before, mapping)
+	return mapping, nil
+}
+
+// DeleteLink 刪除任意連結
+func (a *App) DeleteLink(ctx context.Context, linkID uint) error {
+	mapping, err := a.findLink(ctx, linkID)
+	if err != nil {
+		return err
+	}
+	if err := a.urlService.DeleteURLMapping(ctx, mapping); err != nil {
+		return err
+	}
+	a.record(ctx, "link.delete", auditentity.TargetLink, linkID, mapping, nil)
+	return nil
+}
+
+// ListAuditLog 依條件分頁查詢稽核記錄 (由新到舊)
+func (a *App) ListAuditLog(ctx context.Context, filter auditrepository.AuditFilter, page, pageSize int) (*AuditPage, error) {
+	page, pageSize = normalizePage(page, pageSize)
+	entries, total, err := a.auditRepo.Search(ctx, filter, (page-1)*pageSize, pageSize)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error searching audit log", "error", err)
+		return nil, ErrInternal
+	}
+	return &AuditPage{Entries: entries, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// Stats 返回全系統統計數據
+func (a *App) Stats(ctx context.Context) (*SystemStats, error) {
+	total, active, err := a.userRepo.CountUsers(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error counting users", "error", err)
+		return nil, ErrInternal
+	}
+	links, err := a.urlService.GetLinkStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &SystemStats{TotalUsers: total, ActiveUsers: active, Links: links}, nil
+}
+
+// record 將管理操作寫入稽核記錄，操作者取自 context 中已認證的管理員
+func (a *App) record(ctx context.Context, action, targetType string, targetID uint, before, after interface{}) {
+	a.audit.Record(ctx, auditservice.Event{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   strconv.FormatUint(uint64(targetID), 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+func (a *App) findLink(ctx context.Context, linkID uint) (*urlentity.URLMapping, error) {
+	mapping, err := a.urlService.GetURLMapping(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, urlservice.ErrURLNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// normalizePage 將分頁參數限制在合理範圍內
+func normalizePage(page, pageSize int) (int, int) {
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+	return page, pageSize
+}
+
+// mapIdentityError 將 Identity 領域錯誤轉為應用層錯誤
+func mapIdentityError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, identityservice.ErrUserNotFound):
+		return ErrUserNotFound
+	default:
+		return ErrInternal
+	}
+}
@@ -0,0 +1,137 @@
+package analyticsapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_short/domain/analytics/entity"
+	"go_short/domain/analytics/repository"
+	"go_short/domain/analytics/service"
+	"go_short/domain/event"
+	"go_short/internal/application/jobs"
+)
+
+// 應用層錯誤
+var ErrStreamUnavailable = errors.New("click stream unavailable")
+
+// 點擊處理任務設定
+const (
+	batchSize      = 200              // 每次讀取與寫入的點擊數
+	readBlock      = 2 * time.Second  // 沒有新點擊時等待的時間
+	retryDelay     = time.Second      // 串流或資料庫錯誤後的等待時間
+	reclaimEvery   = 30 * time.Second // 檢查閒置未確認點擊的間隔
+	reclaimMinIdle = time.Minute      // 未確認超過此時間的點擊視為消費者已崩潰
+	pruneEvery     = time.Hour        // 依方案保留天數清除點擊記錄的間隔
+	workerMaxIdle  = time.Minute      // 超過此時間沒有心跳即視為點擊處理停滯
+)
+
+// App 負責收集重定向的點擊並在背景批次寫入資料庫
+type App struct {
+	clickService *service.ClickService
+	consumer     string
+}
+
+// NewApp 創建點擊分析應用服務實例，consumer 是本實例在 consumer group 中的名稱，各實例須不同
+func NewApp(clickService *service.ClickService, consumer string) *App {
+	return &App{
+		clickService: clickService,
+		consumer:     consumer,
+	}
+}
+
+// HandleClick 是點擊事件的訂閱者，在重定向請求中將點擊加入串流
+func (a *App) HandleClick(ctx context.Context, e event.Event) error {
+	linkEvent, ok := e.(*event.LinkEvent)
+	if !ok || linkEvent.EventType() != event.TypeLinkClicked {
+		return nil
+	}
+	var visit event.Visit
+	if linkEvent.Visit != nil {
+		visit = *linkEvent.Visit
+	}
+	return a.clickService.Record(ctx, entity.NewClick(linkEvent.Link.ID, linkEvent.OccurredAt(), visit.Referrer, visit.UserAgent))
+}
+
+// StreamStats 返回點擊串流的長度、未確認數與延遲
+func (a *App) StreamStats(ctx context.Context) (*repository.StreamStats, error) {
+	stats, err := a.clickService.Stats(ctx)
+	if err != nil {
+		return nil, ErrStreamUnavailable
+	}
+	return stats, nil
+}
+
+// StartWorker 啟動點擊處理任務：讀取新點擊、接手崩潰消費者留下的點擊，並依方案清除過期的點擊記錄，清除結果與心跳交給 monitor
+// 可在 API 伺服器中執行，也可由獨立的 worker 行程執行；多個實例以 consumer group 分攤點擊
+func (a *App) StartWorker(ctx context.Context, monitor jobs.Monitor) {
+	monitor.Register(jobs.WorkerClickProcessor, workerMaxIdle)
+	slog.InfoContext(ctx, "Starting click worker...", "consumer", a.consumer)
+
+	go func() {
+		defer slog.InfoContext(ctx, "Click worker stopped")
+		// 無法建立 consumer group 時不發出心跳，讓就緒檢查反映點擊處理停滯
+		for ctx.Err() == nil {
+			if err := a.clickService.EnsureGroup(ctx); err == nil {
+				break
+			}
+			sleep(ctx, retryDelay)
+		}
+		for ctx.Err() == nil {
+			if _, err := a.clickService.ProcessNew(ctx, a.consumer, batchSize, readBlock); err != nil && ctx.Err() == nil {
+				sleep(ctx, retryDelay)
+				continue
+			}
+			monitor.Beat(jobs.WorkerClickProcessor)
+		}
+	}()
+
+	go func() {
+		reclaimTicker := time.NewTicker(reclaimEvery)
+		pruneTicker := time.NewTicker(pruneEvery)
+		defer reclaimTicker.Stop()
+		defer pruneTicker.Stop()
+		for {
+			select {
+			case <-reclaimTicker.C:
+				a.reclaim(ctx)
+			case <-pruneTicker.C:
+				deleted, err := a.clickService.PruneExpired(ctx)
+				monitor.ObserveCleanup(jobs.TaskLinkClicks, deleted, err)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to prune expired clicks", "error", err)
+				} else if deleted > 0 {
+					slog.InfoContext(ctx, "Pruned expired clicks", "deleted", deleted)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// reclaim 持續接手閒置的未確認點擊，直到沒有滿批為止
+func (a *App) reclaim(ctx context.Context) {
+	total := 0
+	for ctx.Err() == nil {
+		n, err := a.clickService.ReclaimPending(ctx, a.consumer, reclaimMinIdle, batchSize)
+		total += n
+		if err != nil || n < batchSize {
+			break
+		}
+	}
+	if total > 0 {
+		slog.InfoContext(ctx, "Reclaimed pending clicks", "count", total)
+	}
+}
+
+// sleep 等待 d 或直到 ctx 結束
+func sleep(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
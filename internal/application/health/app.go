@@ -0,0 +1,142 @@
+package healthapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go_short/internal/application/jobs"
+)
+
+// 檢查與報告的狀態
+const (
+	StatusOK          = "ok"
+	StatusUnavailable = "unavailable"
+	StatusDraining    = "draining"
+)
+
+// Checker 是就緒檢查的一個項目，例如資料庫或 Redis 連線
+type Checker interface {
+	Name() string
+	Check(ctx context.Context) error
+}
+
+// CheckResult 是單一檢查項目的結果
+type CheckResult struct {
+	Name     string              `json:"name"`
+	Status   string              `json:"status"`
+	Duration string              `json:"duration"`
+	Error    string              `json:"error,omitempty"`
+	Workers  []jobs.WorkerStatus `json:"workers,omitempty"`
+}
+
+// Report 是就緒檢查的結果
+type Report struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks"`
+}
+
+// Ready 表示所有檢查皆通過且未進入關閉流程
+func (r *Report) Ready() bool {
+	return r.Status == StatusOK
+}
+
+// App 負責存活與就緒檢查
+type App struct {
+	checkers   []Checker
+	heartbeats *jobs.Heartbeats
+	timeout    time.Duration
+	draining   atomic.Bool
+}
+
+// NewApp 創建健康檢查應用服務實例，每個檢查項目最多執行 timeout
+// heartbeats 不為 nil 時會加入背景任務心跳的檢查
+func NewApp(checkers []Checker, heartbeats *jobs.Heartbeats, timeout time.Duration) *App {
+	return &App{
+		checkers:   checkers,
+		heartbeats: heartbeats,
+		timeout:    timeout,
+	}
+}
+
+// StartDraining 進入關閉流程：之後的就緒檢查都返回 draining，讓負載平衡器停止導入流量
+func (a *App) StartDraining() {
+	a.draining.Store(true)
+}
+
+// Readiness 同時執行所有檢查項目並返回結果；關閉流程中不執行檢查
+func (a *App) Readiness(ctx context.Context) *Report {
+	if a.draining.Load() {
+		return &Report{Status: StatusDraining, Checks: []CheckResult{}}
+	}
+
+	results := make([]CheckResult, len(a.checkers))
+	var wg sync.WaitGroup
+	for i, checker := range a.checkers {
+		wg.Add(1)
+		go func(i int, checker Checker) {
+			defer wg.Done()
+			results[i] = a.run(ctx, checker)
+		}(i, checker)
+	}
+	wg.Wait()
+	if a.heartbeats != nil {
+		results = append(results, a.checkWorkers())
+	}
+
+	report := &Report{Status: StatusOK, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusOK {
+			report.Status = StatusUnavailable
+		}
+	}
+	return report
+}
+
+// run 在逾時限制內執行單一檢查項目
+func (a *App) run(ctx context.Context, checker Checker) CheckResult {
+	checkCtx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	start := time.Now()
+	errc := make(chan error, 1)
+	go func() { errc <- checker.Check(checkCtx) }()
+
+	var err error
+	select {
+	case err = <-errc:
+	case <-checkCtx.Done():
+		// 檢查未遵守 context 時不等待它結束
+		err = checkCtx.Err()
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		err = fmt.Errorf("timed out after %s", a.timeout)
+	}
+
+	result := CheckResult{Name: checker.Name(), Status: StatusOK, Duration: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusUnavailable
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// checkWorkers 檢查已登記的背景任務是否都在期限內發出心跳
+func (a *App) checkWorkers() CheckResult {
+	result := CheckResult{Name: "workers", Status: StatusOK, Duration: "0s", Workers: a.heartbeats.Statuses(time.Now())}
+	var stalled []string
+	for _, worker := range result.Workers {
+		if !worker.Healthy {
+			stalled = append(stalled, worker.Name)
+		}
+	}
+	if len(stalled) > 0 {
+		result.Status = StatusUnavailable
+		result.Error = "stalled: " + strings.Join(stalled, ", ")
+	}
+	return result
+}
@@ -0,0 +1,240 @@
+package identityapp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/identity/entity"
+)
+
+// API 金鑰相關錯誤
+var ErrInvalidScope = errors.New("invalid or empty API key scopes")
+var ErrAPIKeyNotFound = errors.New("API key not found")
+
+// apiKeyLastUsedResolution 是更新 LastUsedAt 的最小間隔，避免每個請求都寫入資料庫
+const apiKeyLastUsedResolution = time.Minute
+
+// Principal 代表一次請求的已認證身分
+type Principal struct {
+	UserID   uint
+	Username string
+	APIKeyID *uint         // 透過 API 金鑰認證時不為 nil
+	Scopes   []string      // API 金鑰的權限範圍；以 JWT 登入時為 nil，代表擁有全部權限
+	Token    *AccessClaims // 以 JWT 登入時的權杖資訊，登出時使用
+}
+
+// IsAPIKey 檢查此身分是否透過 API 金鑰認證
+func (p *Principal) IsAPIKey() bool {
+	return p.APIKeyID != nil
+}
+
+// HasScope 檢查此身分是否擁有指定權限範圍
+func (p *Principal) HasScope(scope string) bool {
+	if !p.IsAPIKey() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate 驗證 Bearer 憑證，依前綴判斷為 API 金鑰或 JWT，擁有者必須仍為啟用狀態
+func (a *App) Authenticate(ctx context.Context, credential string) (*Principal, error) {
+	if strings.HasPrefix(credential, entity.APIKeyPrefix) {
+		return a.AuthenticateAPIKey(ctx, credential)
+	}
+
+	claims, err := a.ParseAccessToken(credential)
+	if err != nil {
+		return nil, err
+	}
+
+	revoked, err := a.denylist.Contains(ctx, claims.TokenID)
+	if err != nil {
+		// Redis 無法使用時不阻擋請求，權杖仍受短效期限制
+		slog.ErrorContext(ctx, "Error checking token denylist", "error", err)
+	}
+	if revoked {
+		return nil, ErrInvalidToken
+	}
+
+	// 權杖簽發後帳號可能已被停用或刪除，與 API 金鑰相同，每次請求都確認使用者仍為啟用狀態
+	user, err := a.userRepo.FindByID(ctx, claims.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for access token", "user_id", claims.UserID, "error", err)
+		return nil, ErrInvalidToken
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	return &Principal{UserID: user.ID, Username: user.Username, Token: claims}, nil
+}
+
+// CreateAPIKey 為使用者建立一把 API 金鑰，返回的明文金鑰只會出現這一次
+func (a *App) CreateAPIKey(ctx context.Context, userID uint, name string, scopes []string, expiresIn *time.Duration) (*entity.APIKey, string, error) {
+	if len(scopes) == 0 {
+		return nil, "", ErrInvalidScope
+	}
+	for _, scope := range scopes {
+		if !entity.IsValidScope(scope) {
+			return nil, "", ErrInvalidScope
+		}
+	}
+
+	prefix, err := randomHex(4)
+	if err != nil {
+		return nil, "", ErrInternal
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return nil, "", ErrInternal
+	}
+	plaintext := entity.APIKeyPrefix + prefix + "_" + secret
+
+	key := &entity.APIKey{
+		UserID:  userID,
+		Name:    strings.TrimSpace(name),
+		Prefix:  entity.APIKeyPrefix + prefix,
+		KeyHash: hashToken(plaintext),
+		Scopes:  strings.Join(scopes, ","),
+	}
+	if expiresIn != nil {
+		expiresAt := time.Now().Add(*expiresIn)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := a.apiKeyRepo.Create(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "Error creating API key for user", "user_id", userID, "error", err)
+		return nil, "", ErrInternal
+	}
+	a.recordAPIKey(ctx, userID, "api_key.create", nil, key)
+	return key, plaintext, nil
+}
+
+// ListAPIKeys 列出使用者的所有 API 金鑰 (不含明文)
+func (a *App) ListAPIKeys(ctx context.Context, userID uint) ([]*entity.APIKey, error) {
+	keys, err := a.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing API keys for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey 撤銷使用者的 API 金鑰
+func (a *App) RevokeAPIKey(ctx context.Context, userID uint, keyID uint) error {
+	key, err := a.apiKeyRepo.FindByID(ctx, keyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding API key", "api_key_id", keyID, "error", err)
+		return ErrInternal
+	}
+	if key == nil || key.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+
+	before := *key
+	now := time.Now()
+	key.RevokedAt = &now
+	if err := a.apiKeyRepo.Update(ctx, key); err != nil {
+		slog.ErrorContext(ctx, "Error revoking API key", "api_key_id", keyID, "error", err)
+		return ErrInternal
+	}
+	a.recordAPIKey(ctx, userID, "api_key.revoke", &before, key)
+	return nil
+}
+
+// recordAPIKey 將 API 金鑰的變更寫入稽核記錄 (金鑰雜湊不會出現在差異中)
+func (a *App) recordAPIKey(ctx context.Context, userID uint, action string, before, after *entity.APIKey) {
+	a.audit.Record(ctx, auditservice.Event{
+		ActorID:    &userID,
+		Action:     action,
+		TargetType: auditentity.TargetAPIKey,
+		TargetID:   strconv.FormatUint(uint64(after.ID), 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+// revokeAllAPIKeys 撤銷使用者所有尚未撤銷的 API 金鑰
+func (a *App) revokeAllAPIKeys(ctx context.Context, userID uint) error {
+	keys, err := a.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing API keys for user", "user_id", userID, "error", err)
+		return ErrInternal
+	}
+
+	now := time.Now()
+	for _, key := range keys {
+		if key.RevokedAt != nil {
+			continue
+		}
+		key.RevokedAt = &now
+		if err := a.apiKeyRepo.Update(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Error revoking API key", "api_key_id", key.ID, "error", err)
+			return ErrInternal
+		}
+	}
+	return nil
+}
+
+// AuthenticateAPIKey 驗證 API 金鑰，金鑰必須有效且擁有者仍為啟用狀態
+func (a *App) AuthenticateAPIKey(ctx context.Context, plaintext string) (*Principal, error) {
+	key, err := a.apiKeyRepo.FindByHash(ctx, hashToken(plaintext))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding API key", "error", err)
+		return nil, ErrInvalidToken
+	}
+	if key == nil || !key.IsUsable() {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := a.userRepo.FindByID(ctx, key.UserID)
+	if err != nil || user == nil || !user.IsActive {
+		return nil, ErrInvalidToken
+	}
+
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyLastUsedResolution {
+		now := time.Now()
+		key.LastUsedAt = &now
+		if err := a.apiKeyRepo.Update(ctx, key); err != nil {
+			slog.ErrorContext(ctx, "Error updating last used time of API key", "api_key_id", key.ID, "error", err)
+		}
+	}
+
+	return &Principal{
+		UserID:   user.ID,
+		Username: user.Username,
+		APIKeyID: &key.ID,
+		Scopes:   key.ScopeList(),
+	}, nil
+}
+
+// hashToken 計算不透明權杖 (API 金鑰、refresh token) 的 SHA-256 雜湊
+func hashToken(plaintext string) string {
+	sum := sha256.Sum256([]byte(plaintext))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,46 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+)
+
+// fakeDenylist 是空的權杖黑名單
+type fakeDenylist struct {
+	repository.TokenDenylist
+}
+
+func (fakeDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	return false, nil
+}
+
+func TestAuthenticateJWTRequiresActiveUser(t *testing.T) {
+	user := &entity.User{Username: "alice", IsActive: true}
+	user.ID = 42
+	users := &fakeUserRepo{users: []*entity.User{user}}
+	app := &App{userRepo: users, denylist: fakeDenylist{}, jwtSecret: []byte("test-secret"), accessTokenTTL: time.Hour}
+
+	token, err := app.generateJWT(user, "session")
+	if err != nil {
+		t.Fatalf("generateJWT: %v", err)
+	}
+	principal, err := app.Authenticate(context.Background(), token)
+	if err != nil || principal.UserID != user.ID {
+		t.Fatalf("Authenticate active user = %v, %v", principal, err)
+	}
+
+	user.IsActive = false
+	if _, err := app.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate deactivated user: err = %v, want ErrInvalidToken", err)
+	}
+
+	users.users = nil
+	if _, err := app.Authenticate(context.Background(), token); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Authenticate deleted user: err = %v, want ErrInvalidToken", err)
+	}
+}
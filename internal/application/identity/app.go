@@ -4,13 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"strconv"
+	"strings"
 	"time"
 
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
 	"go_short/domain/identity/entity"
 	"go_short/domain/identity/repository"
 	"go_short/domain/identity/service"
+	"go_short/domain/notification"
 
 	"github.com/golang-jwt/jwt/v5"
 )
@@ -21,35 +25,92 @@ var ErrUserAlreadyExists = errors.New("username or email already exists")
 var ErrAuthenticationFailed = errors.New("authentication failed")
 var ErrInternal = errors.New("internal server error")
 var ErrTokenGeneration = errors.New("failed to generate token")
+var ErrInvalidToken = errors.New("invalid or expired token")
+var ErrEmailNotVerified = errors.New("email address has not been verified")
+
+// ErrTooManyAttempts 表示登入因失敗次數過多而暫時被鎖定，實際返回的錯誤為帶有剩餘時間的 *LockedError
+var ErrTooManyAttempts = service.ErrLoginLocked
+
+// LockedError 帶有鎖定剩餘時間的登入鎖定錯誤
+type LockedError = service.LockedError
+
+// dummyUser 用於使用者不存在時仍執行一次 bcrypt 比對
+var dummyUser = func() *entity.User {
+	user := &entity.User{}
+	_ = user.SetPassword("dummy-password-for-constant-time")
+	return user
+}()
+
+// AccessClaims 是從存取權杖中解析出的使用者身分
+type AccessClaims struct {
+	UserID    uint
+	Username  string
+	TokenID   string    // jti，登出時加入黑名單
+	SessionID string    // sid，對應 refresh token 的 family
+	ExpiresAt time.Time // 權杖到期時間
+}
 
 // App 是 Identity 領域的應用服務
 type App struct {
-	userRepo        repository.UserRepository
-	identityService service.IdentityService
-	jwtSecret       []byte
-	jwtExpiration   time.Duration
+	userRepo         repository.UserRepository
+	apiKeyRepo       repository.APIKeyRepository
+	refreshTokenRepo repository.RefreshTokenRepository
+	recoveryCodeRepo repository.RecoveryCodeRepository
+	loginGuard       *service.LoginGuard
+	denylist         repository.TokenDenylist
+	identityService  service.IdentityService
+	mailer           notification.Mailer
+	linkReleaser     LinkReleaser
+	webhookReleaser  WebhookReleaser
+	workspaces       WorkspaceOwnership
+	transactor       Transactor
+	audit            *auditservice.Recorder
+	jwtSecret        []byte
+	accessTokenTTL   time.Duration
+	refreshTokenTTL  time.Duration
+	// requireEmailVerification 為 true 時，未驗證電子郵件的帳號無法登入
+	requireEmailVerification bool
+	// publicBaseURL 用於組出郵件中的驗證與重設連結
+	publicBaseURL string
+	// deletedUserLinksTo 為刪除帳號時個人連結的轉移對象，nil 表示停用連結
+	deletedUserLinksTo *uint
 }
 
-// NewApp 創建 Identity 應用服務實例
-func NewApp(userRepo repository.UserRepository, identityService service.IdentityService) *App {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		log.Println("Warning: JWT_SECRET environment variable not set. Using default insecure key.")
-		secret = "a_very_insecure_default_secret_key_change_me"
-	}
-
-	expStr := os.Getenv("JWT_EXPIRATION_HOURS")
-	expHours, err := time.ParseDuration(expStr + "h")
-	if err != nil || expHours <= 0 {
-		log.Printf("Warning: Invalid or missing JWT_EXPIRATION_HOURS. Using default 24 hours. Error: %v", err)
-		expHours = 24 * time.Hour
-	}
+// Config 是 Identity 應用服務的設定
+type Config struct {
+	JWTSecret                string
+	AccessTokenTTL           time.Duration
+	RefreshTokenTTL          time.Duration
+	RequireEmailVerification bool
+	// PublicBaseURL 用於組出郵件中的驗證與重設連結
+	PublicBaseURL string
+	// DeletedUserLinksTo 為刪除帳號時個人連結的轉移對象，nil 表示停用連結
+	DeletedUserLinksTo *uint
+}
 
+// NewApp 創建 Identity 應用服務實例
+func NewApp(userRepo repository.UserRepository, apiKeyRepo repository.APIKeyRepository, refreshTokenRepo repository.RefreshTokenRepository, recoveryCodeRepo repository.RecoveryCodeRepository, loginGuard *service.LoginGuard, denylist repository.TokenDenylist, mailer notification.Mailer, linkReleaser LinkReleaser, webhookReleaser WebhookReleaser, workspaces WorkspaceOwnership, transactor Transactor, audit *auditservice.Recorder, identityService service.IdentityService, config Config) *App {
 	return &App{
-		userRepo:        userRepo,
-		identityService: identityService,
-		jwtSecret:       []byte(secret),
-		jwtExpiration:   expHours,
+		userRepo:         userRepo,
+		apiKeyRepo:       apiKeyRepo,
+		refreshTokenRepo: refreshTokenRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+		loginGuard:       loginGuard,
+		denylist:         denylist,
+		identityService:  identityService,
+		mailer:           mailer,
+		linkReleaser:     linkReleaser,
+		webhookReleaser:  webhookReleaser,
+		workspaces:       workspaces,
+		transactor:       transactor,
+		audit:            audit,
+		jwtSecret:        []byte(config.JWTSecret),
+		accessTokenTTL:   config.AccessTokenTTL,
+		refreshTokenTTL:  config.RefreshTokenTTL,
+
+		requireEmailVerification: config.RequireEmailVerification,
+		publicBaseURL:            strings.TrimSuffix(config.PublicBaseURL, "/"),
+		deletedUserLinksTo:       config.DeletedUserLinksTo,
 	}
 }
 
@@ -57,7 +118,7 @@ func NewApp(userRepo repository.UserRepository, identityService service.Identity
 func (a *App) RegisterUser(ctx context.Context, username, email, password string) (*entity.User, error) {
 	existingUser, err := a.userRepo.FindByUsername(ctx, username)
 	if err != nil {
-		log.Printf("Error finding user by username %s: %v", username, err)
+		slog.ErrorContext(ctx, "Error finding user by username", "username", username, "error", err)
 		return nil, ErrInternal
 	}
 	if existingUser != nil {
@@ -65,7 +126,7 @@ func (a *App) RegisterUser(ctx context.Context, username, email, password string
 	}
 	existingUser, err = a.userRepo.FindByEmail(ctx, email)
 	if err != nil {
-		log.Printf("Error finding user by email %s: %v", email, err)
+		slog.ErrorContext(ctx, "Error finding user by email", "email", email, "error", err)
 		return nil, ErrInternal
 	}
 	if existingUser != nil {
@@ -75,61 +136,108 @@ func (a *App) RegisterUser(ctx context.Context, username, email, password string
 	user := &entity.User{
 		Username: username,
 		Email:    email,
+		Role:     entity.RoleUser,
 		IsActive: true,
 	}
 	if err := user.SetPassword(password); err != nil {
-		log.Printf("Error hashing password for user %s: %v", username, err)
+		slog.ErrorContext(ctx, "Error hashing password for user", "username", username, "error", err)
 		return nil, ErrInternal
 	}
 
 	if err := a.userRepo.Create(ctx, user); err != nil {
-		log.Printf("Error creating user %s: %v", username, err)
+		slog.ErrorContext(ctx, "Error creating user", "username", username, "error", err)
 		return nil, ErrInternal
 	}
+	a.recordUser(ctx, &user.ID, "user.register", user.ID, nil, user)
+
+	// 寄送驗證信失敗不影響註冊，使用者可稍後要求重寄
+	if err := a.sendVerificationEmail(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error sending verification email to user", "username", username, "error", err)
+	}
 
 	return user, nil
 }
 
-// AuthenticateUser 處理使用者登入認證的用例，並返回 JWT
-func (a *App) AuthenticateUser(ctx context.Context, username, password string) (string, error) {
+// AuthenticateUser 處理使用者登入認證的用例
+// 未啟用兩步驟驗證時直接返回權杖組，否則只返回 MFA 挑戰權杖
+func (a *App) AuthenticateUser(ctx context.Context, username, password, clientIP string) (*LoginResult, error) {
+	if err := a.loginGuard.Check(ctx, username, clientIP); err != nil {
+		return nil, err
+	}
+
 	user, err := a.userRepo.FindByUsername(ctx, username)
 	if err != nil {
-		log.Printf("Error finding user by username %s during auth: %v", username, err)
-		return "", ErrAuthenticationFailed
+		slog.ErrorContext(ctx, "Error finding user by username during auth", "username", username, "error", err)
+		return nil, ErrAuthenticationFailed
 	}
 	if user == nil {
-		return "", ErrAuthenticationFailed
+		// 使用者不存在時仍執行一次密碼比對，使回應時間與存在的帳號一致
+		dummyUser.CheckPassword(password)
+		return nil, a.loginFailed(ctx, nil, username, clientIP)
+	}
+
+	if !user.CheckPassword(password) {
+		return nil, a.loginFailed(ctx, user, username, clientIP)
 	}
 	if !user.IsActive {
-		log.Printf("User %s is inactive", username)
-		return "", ErrAuthenticationFailed
+		slog.InfoContext(ctx, "User is inactive", "username", username)
+		return nil, ErrAuthenticationFailed
+	}
+	if a.requireEmailVerification && !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
 	}
 
-	if !user.CheckPassword(password) {
-		return "", ErrAuthenticationFailed
+	// 兩步驟驗證完成前不清除失敗次數，避免以正確密碼反覆重置 TOTP 的嘗試次數
+	if user.IsTOTPEnabled() {
+		return a.beginMFAChallenge(user)
 	}
 
-	now := time.Now()
-	user.LastLogin = &now
-	if err := a.userRepo.Update(ctx, user); err != nil {
-		log.Printf("Error updating last login for user %s: %v", username, err)
+	tokens, err := a.startSession(ctx, user)
+	if err != nil {
+		return nil, err
 	}
+	a.loginGuard.RecordSuccess(ctx, username)
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// loginFailed 記錄一次登入失敗並寫入稽核記錄；達到門檻時返回鎖定錯誤，否則返回一般的認證失敗
+// user 為 nil 表示帳號不存在，稽核記錄改以嘗試的使用者名稱作為目標
+func (a *App) loginFailed(ctx context.Context, user *entity.User, username, clientIP string) error {
+	err := a.loginGuard.RecordFailure(ctx, username, clientIP)
 
-	tokenString, err := a.generateJWT(user)
+	action := "user.login_failed"
 	if err != nil {
-		log.Printf("Error generating JWT for user %s: %v", username, err)
-		return "", ErrTokenGeneration
+		action = "user.login_locked"
 	}
+	event := auditservice.Event{Action: action, TargetType: auditentity.TargetUsername, TargetID: username}
+	if user != nil {
+		event.ActorID = &user.ID
+		event.TargetType = auditentity.TargetUser
+		event.TargetID = strconv.FormatUint(uint64(user.ID), 10)
+	}
+	a.audit.Record(ctx, event)
 
-	return tokenString, nil
+	if err != nil {
+		return err
+	}
+	return ErrAuthenticationFailed
 }
 
-func (a *App) generateJWT(user *entity.User) (string, error) {
+func (a *App) generateJWT(user *entity.User, sessionID string) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	now := time.Now()
 	claims := jwt.MapClaims{
 		"sub": user.ID,
 		"usn": user.Username,
-		"iat": time.Now().Unix(),
-		"exp": time.Now().Add(a.jwtExpiration).Unix(),
+		"typ": tokenTypeAccess,
+		"jti": jti,
+		"sid": sessionID,
+		"iat": now.Unix(),
+		"exp": now.Add(a.accessTokenTTL).Unix(),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
@@ -142,12 +250,82 @@ func (a *App) generateJWT(user *entity.User) (string, error) {
 	return tokenString, nil
 }
 
+// ParseAccessToken 驗證 JWT 並返回其中的使用者身分
+func (a *App) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if typ, _ := claims["typ"].(string); typ != tokenTypeAccess {
+		return nil, ErrInvalidToken
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return nil, ErrInvalidToken
+	}
+	username, _ := claims["usn"].(string)
+	jti, _ := claims["jti"].(string)
+	sid, _ := claims["sid"].(string)
+	if jti == "" {
+		return nil, ErrInvalidToken
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return nil, ErrInvalidToken
+	}
+
+	return &AccessClaims{
+		UserID:    uint(sub),
+		Username:  username,
+		TokenID:   jti,
+		SessionID: sid,
+		ExpiresAt: exp.Time,
+	}, nil
+}
+
+// GetUser 根據 ID 獲取使用者
+func (a *App) GetUser(ctx context.Context, userID uint) (*entity.User, error) {
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
 func (a *App) ActivateUser(ctx context.Context, userID uint) error {
-	return a.identityService.ActivateUser(ctx, userID)
+	if err := a.identityService.ActivateUser(ctx, userID); err != nil {
+		return err
+	}
+	a.recordUser(ctx, nil, "user.activate", userID, nil, nil)
+	return nil
 }
 
 func (a *App) DeactivateUser(ctx context.Context, userID uint) error {
-	return a.identityService.DeactivateUser(ctx, userID)
+	if err := a.identityService.DeactivateUser(ctx, userID); err != nil {
+		return err
+	}
+	a.recordUser(ctx, nil, "user.deactivate", userID, nil, nil)
+	return nil
+}
+
+// recordUser 將帳號相關的動作寫入稽核記錄，actorID 為 nil 時使用 context 中已認證的使用者
+func (a *App) recordUser(ctx context.Context, actorID *uint, action string, userID uint, before, after *entity.User) {
+	a.audit.Record(ctx, auditservice.Event{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: auditentity.TargetUser,
+		TargetID:   strconv.FormatUint(uint64(userID), 10),
+		Before:     before,
+		After:      after,
+	})
 }
 
 // --- 可以添加其他用例，如 GetUserProfile, ChangePassword 等 ---
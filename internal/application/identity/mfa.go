@@ -0,0 +1,314 @@
+package identityapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"image/png"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go_short/domain/identity/entity"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// 兩步驟驗證相關設定
+const (
+	totpIssuer          = "Go Short"
+	totpQRCodeSize      = 256
+	mfaChallengeTTL     = 5 * time.Minute
+	recoveryCodeCount   = 10
+	totpReplayKeyPrefix = "totp:"
+	// totpReplayWindow 涵蓋允許的時間偏移 (前後各一個 30 秒週期)
+	totpReplayWindow = 90 * time.Second
+)
+
+// 兩步驟驗證相關錯誤
+var ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrMFANotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrMFAEnrollmentNotStarted = errors.New("two-factor enrollment has not been started")
+var ErrInvalidMFACode = errors.New("invalid two-factor authentication code")
+
+// LoginResult 是帳號密碼登入的結果
+// 啟用兩步驟驗證的帳號只會取得 MFAToken，須再以驗證碼呼叫 CompleteMFALogin 換取權杖組
+type LoginResult struct {
+	Tokens       *TokenPair
+	MFAToken     string
+	MFAExpiresAt time.Time
+}
+
+// MFARequired 檢查登入是否還需要第二步驗證
+func (r *LoginResult) MFARequired() bool {
+	return r.Tokens == nil
+}
+
+// TOTPEnrollment 是開始註冊 TOTP 時返回給使用者的設定資訊
+type TOTPEnrollment struct {
+	Secret string // Base32 密鑰，供無法掃描 QR code 時手動輸入
+	URI    string // otpauth:// URI
+	QRCode string // otpauth URI 的 QR code (PNG data URI)
+}
+
+// MFAStatus 是使用者兩步驟驗證的狀態
+type MFAStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	RecoveryCodesRemaining int64      `json:"recovery_codes_remaining"`
+}
+
+// beginMFAChallenge 為已通過密碼驗證的使用者簽發短效 MFA 挑戰權杖
+func (a *App) beginMFAChallenge(user *entity.User) (*LoginResult, error) {
+	token, err := a.generateActionToken(user, tokenTypeMFAChallenge, mfaChallengeTTL)
+	if err != nil {
+		slog.Error("Error generating MFA challenge for user", "username", user.Username, "error", err)
+		return nil, ErrTokenGeneration
+	}
+	return &LoginResult{MFAToken: token, MFAExpiresAt: time.Now().Add(mfaChallengeTTL)}, nil
+}
+
+// CompleteMFALogin 以 MFA 挑戰權杖與 TOTP 驗證碼 (或復原碼) 完成登入
+// 錯誤的驗證碼與錯誤的密碼計入相同的失敗次數與鎖定
+func (a *App) CompleteMFALogin(ctx context.Context, challengeToken, code, clientIP string) (*TokenPair, error) {
+	user, claims, err := a.parseActionToken(ctx, challengeToken, tokenTypeMFAChallenge)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, ErrInvalidActionToken
+	}
+	if err := a.loginGuard.Check(ctx, user.Username, clientIP); err != nil {
+		return nil, err
+	}
+
+	if err := a.verifySecondFactor(ctx, user, code); err != nil {
+		if errors.Is(err, ErrInvalidMFACode) {
+			if lockErr := a.loginFailed(ctx, user, user.Username, clientIP); errors.Is(lockErr, ErrTooManyAttempts) {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+	a.markActionTokenUsed(ctx, claims)
+
+	tokens, err := a.startSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	a.loginGuard.RecordSuccess(ctx, user.Username)
+	return tokens, nil
+}
+
+// GetMFAStatus 返回使用者的兩步驟驗證狀態
+func (a *App) GetMFAStatus(ctx context.Context, userID uint) (*MFAStatus, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &MFAStatus{Enabled: user.IsTOTPEnabled(), EnabledAt: user.TOTPEnabledAt}
+	if status.Enabled {
+		remaining, err := a.recoveryCodeRepo.CountUnused(ctx, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error counting recovery codes of user", "user_id", userID, "error", err)
+			return nil, ErrInternal
+		}
+		status.RecoveryCodesRemaining = remaining
+	}
+	return status, nil
+}
+
+// BeginTOTPEnrollment 產生新的 TOTP 密鑰 (尚未啟用)，使用者須以 ConfirmTOTPEnrollment 確認
+func (a *App) BeginTOTPEnrollment(ctx context.Context, userID uint) (*TOTPEnrollment, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Username,
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating TOTP secret for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	qrCode, err := qrCodeDataURI(key)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error rendering TOTP QR code for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	secret := key.Secret()
+	user.TOTPSecret = &secret
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error storing TOTP secret for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	return &TOTPEnrollment{Secret: secret, URI: key.URL(), QRCode: qrCode}, nil
+}
+
+// ConfirmTOTPEnrollment 以驗證器 App 產生的驗證碼確認註冊並啟用兩步驟驗證，返回一組新的復原碼
+func (a *App) ConfirmTOTPEnrollment(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.IsTOTPEnabled() {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == nil {
+		return nil, ErrMFAEnrollmentNotStarted
+	}
+	if !a.validateTOTP(ctx, user, code) {
+		return nil, ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	user.TOTPEnabledAt = &now
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error enabling TOTP for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	a.recordUser(ctx, &userID, "user.mfa_enable", userID, nil, nil)
+
+	return a.replaceRecoveryCodes(ctx, userID)
+}
+
+// DisableTOTP 在驗證密碼與第二因素後停用兩步驟驗證
+func (a *App) DisableTOTP(ctx context.Context, userID uint, password, code string) error {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.IsTOTPEnabled() {
+		return ErrMFANotEnabled
+	}
+	if !user.CheckPassword(password) {
+		return ErrAuthenticationFailed
+	}
+	if err := a.verifySecondFactor(ctx, user, code); err != nil {
+		return err
+	}
+
+	user.TOTPSecret = nil
+	user.TOTPEnabledAt = nil
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error disabling TOTP for user", "user_id", userID, "error", err)
+		return ErrInternal
+	}
+	if err := a.recoveryCodeRepo.DeleteForUser(ctx, userID); err != nil {
+		slog.ErrorContext(ctx, "Error deleting recovery codes of user", "user_id", userID, "error", err)
+		return ErrInternal
+	}
+	a.recordUser(ctx, &userID, "user.mfa_disable", userID, nil, nil)
+	return nil
+}
+
+// RegenerateRecoveryCodes 驗證第二因素後產生一組新的復原碼，舊的一組立即失效
+func (a *App) RegenerateRecoveryCodes(ctx context.Context, userID uint, code string) ([]string, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.IsTOTPEnabled() {
+		return nil, ErrMFANotEnabled
+	}
+	if err := a.verifySecondFactor(ctx, user, code); err != nil {
+		return nil, err
+	}
+	codes, err := a.replaceRecoveryCodes(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	a.recordUser(ctx, &userID, "user.recovery_codes_regenerate", userID, nil, nil)
+	return codes, nil
+}
+
+// verifySecondFactor 接受 TOTP 驗證碼或未使用過的復原碼
+func (a *App) verifySecondFactor(ctx context.Context, user *entity.User, code string) error {
+	if a.validateTOTP(ctx, user, code) {
+		return nil
+	}
+
+	consumed, err := a.recoveryCodeRepo.Consume(ctx, user.ID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		slog.ErrorContext(ctx, "Error consuming recovery code of user", "user_id", user.ID, "error", err)
+		return ErrInternal
+	}
+	if !consumed {
+		return ErrInvalidMFACode
+	}
+	slog.InfoContext(ctx, "User used a recovery code", "user_id", user.ID)
+	return nil
+}
+
+// validateTOTP 驗證 TOTP 驗證碼，同一組驗證碼在有效視窗內只能使用一次
+func (a *App) validateTOTP(ctx context.Context, user *entity.User, code string) bool {
+	code = strings.TrimSpace(code)
+	if user.TOTPSecret == nil || !totp.Validate(code, *user.TOTPSecret) {
+		return false
+	}
+
+	replayKey := fmt.Sprintf("%s%d:%s", totpReplayKeyPrefix, user.ID, code)
+	used, err := a.denylist.Contains(ctx, replayKey)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking TOTP replay for user", "user_id", user.ID, "error", err)
+	}
+	if used {
+		return false
+	}
+	if err := a.denylist.Add(ctx, replayKey, totpReplayWindow); err != nil {
+		slog.ErrorContext(ctx, "Error recording TOTP use for user", "user_id", user.ID, "error", err)
+	}
+	return true
+}
+
+// replaceRecoveryCodes 產生新的復原碼並只儲存其雜湊，返回明文供使用者保存
+func (a *App) replaceRecoveryCodes(ctx context.Context, userID uint) ([]string, error) {
+	plaintexts := make([]string, 0, recoveryCodeCount)
+	codes := make([]*entity.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomHex(5)
+		if err != nil {
+			return nil, ErrInternal
+		}
+		plaintexts = append(plaintexts, raw[:5]+"-"+raw[5:])
+		codes = append(codes, &entity.RecoveryCode{UserID: userID, CodeHash: hashToken(raw)})
+	}
+
+	if err := a.recoveryCodeRepo.ReplaceForUser(ctx, userID, codes); err != nil {
+		slog.ErrorContext(ctx, "Error storing recovery codes of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	return plaintexts, nil
+}
+
+// normalizeRecoveryCode 去除使用者輸入中的空白與連字號並轉為小寫
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// qrCodeDataURI 將 otpauth URI 繪製為 PNG QR code 並編碼為 data URI
+func qrCodeDataURI(key *otp.Key) (string, error) {
+	img, err := key.Image(totpQRCodeSize, totpQRCodeSize)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
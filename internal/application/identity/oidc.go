@@ -0,0 +1,410 @@
+package identityapp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+)
+
+// oidcStateTTL 是授權請求發出到回呼之間允許的最長時間
+const oidcStateTTL = 10 * time.Minute
+
+// OIDC 登入相關錯誤
+var ErrOIDCProviderNotFound = errors.New("unknown identity provider")
+var ErrOIDCInvalidState = errors.New("invalid or expired login state")
+var ErrOIDCExchangeFailed = errors.New("failed to complete login with the identity provider")
+var ErrOIDCEmailNotVerified = errors.New("identity provider did not return a verified email address")
+var ErrOIDCAccountNotFound = errors.New("no account is linked to this identity")
+
+// usernameUnsafeChars 用於從外部帳號資訊產生使用者名稱
+var usernameUnsafeChars = regexp.MustCompile(`[^a-z0-9._-]`)
+
+// OIDCProviderConfig 是單一 OpenID Connect 身分提供者的設定
+type OIDCProviderConfig struct {
+	Name          string // 路由與資料庫中使用的提供者名稱
+	IssuerURL     string // 用於 discovery (/.well-known/openid-configuration)
+	ClientID      string
+	ClientSecret  string
+	RedirectURL   string   // 為空時使用 PUBLIC_BASE_URL/auth/oidc/<name>/callback
+	Scopes        []string // 額外的 scope，openid/email/profile 一律包含
+	AutoProvision bool     // 沒有相符帳號時自動建立使用者
+}
+
+// OIDCAuthorization 是發起外部登入的結果
+// State 必須以短效的 cookie 綁定到發起登入的瀏覽器，回呼時一併送回，避免登入 CSRF
+type OIDCAuthorization struct {
+	URL       string        // 導向身分提供者的授權網址
+	State     string        // 授權請求的 state
+	ExpiresIn time.Duration // state 的有效時間
+}
+
+// OIDCCallback 是身分提供者導回時的請求內容
+type OIDCCallback struct {
+	State        string // 回呼網址中的 state
+	BrowserState string // 發起登入時設定在瀏覽器的 state cookie
+	Code         string // 授權碼
+	ClientIP     string // 用於登入失敗的計數與鎖定
+}
+
+// oidcProvider 是已完成 discovery 的提供者
+type oidcProvider struct {
+	config   OIDCProviderConfig
+	oauth2   *oauth2.Config
+	verifier *oidc.IDTokenVerifier
+}
+
+// oidcClaims 是從 ID Token 讀取的使用者資訊
+type oidcClaims struct {
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	PreferredUsername string `json:"preferred_username"`
+	Nonce             string `json:"nonce"`
+}
+
+// OIDCApp 處理 OpenID Connect 授權碼 (含 PKCE) 登入流程
+// 提供者在第一次使用時才進行 discovery，身分提供者暫時無法連線不影響服務啟動
+type OIDCApp struct {
+	app          *App
+	identityRepo repository.ExternalIdentityRepository
+	stateStore   repository.OIDCStateStore
+	httpClient   *http.Client
+	configs      map[string]OIDCProviderConfig
+
+	mu        sync.Mutex
+	providers map[string]*oidcProvider
+}
+
+// NewOIDCApp 創建 OIDC 登入應用服務實例，httpClient 為 nil 時使用 http.DefaultClient
+func NewOIDCApp(app *App, identityRepo repository.ExternalIdentityRepository, stateStore repository.OIDCStateStore, httpClient *http.Client, configs []OIDCProviderConfig) *OIDCApp {
+	byName := make(map[string]OIDCProviderConfig, len(configs))
+	for _, cfg := range configs {
+		if cfg.RedirectURL == "" {
+			cfg.RedirectURL = app.publicBaseURL + "/auth/oidc/" + cfg.Name + "/callback"
+		}
+		byName[cfg.Name] = cfg
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &OIDCApp{
+		app:          app,
+		identityRepo: identityRepo,
+		stateStore:   stateStore,
+		httpClient:   httpClient,
+		configs:      byName,
+		providers:    make(map[string]*oidcProvider),
+	}
+}
+
+// Providers 返回已設定的提供者名稱
+func (o *OIDCApp) Providers() []string {
+	names := make([]string, 0, len(o.configs))
+	for name := range o.configs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BeginLogin 產生 state、nonce 與 PKCE verifier，返回導向身分提供者的授權網址與需綁定到瀏覽器的 state
+func (o *OIDCApp) BeginLogin(ctx context.Context, providerName string) (*OIDCAuthorization, error) {
+	provider, err := o.provider(ctx, providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	state, err := randomHex(16)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	nonce, err := randomHex(16)
+	if err != nil {
+		return nil, ErrInternal
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	data := &entity.OIDCState{Provider: providerName, CodeVerifier: verifier, Nonce: nonce}
+	if err := o.stateStore.Save(ctx, state, data, oidcStateTTL); err != nil {
+		slog.ErrorContext(ctx, "Error saving OIDC state for provider", "provider", providerName, "error", err)
+		return nil, ErrInternal
+	}
+
+	return &OIDCAuthorization{
+		URL:       provider.oauth2.AuthCodeURL(state, oidc.Nonce(nonce), oauth2.S256ChallengeOption(verifier)),
+		State:     state,
+		ExpiresIn: oidcStateTTL,
+	}, nil
+}
+
+// CompleteLogin 處理身分提供者的回呼：驗證 state、以授權碼換取 ID Token、連結或建立使用者後登入
+// 失敗與密碼登入相同地計入來源 IP 的失敗次數並寫入稽核記錄，鎖定期間直接拒絕
+func (o *OIDCApp) CompleteLogin(ctx context.Context, providerName string, callback OIDCCallback) (*LoginResult, error) {
+	provider, err := o.provider(ctx, providerName)
+	if err != nil {
+		return nil, err
+	}
+	if err := o.app.loginGuard.Check(ctx, "", callback.ClientIP); err != nil {
+		return nil, err
+	}
+
+	user, err := o.authenticate(ctx, provider, callback)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrOIDCInvalidState), errors.Is(err, ErrOIDCExchangeFailed),
+			errors.Is(err, ErrOIDCEmailNotVerified), errors.Is(err, ErrOIDCAccountNotFound):
+			if lockErr := o.loginFailed(ctx, providerName, callback.ClientIP); lockErr != nil {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+	if !user.IsActive {
+		return nil, ErrAuthenticationFailed
+	}
+
+	// 外部登入不取代本系統的兩步驟驗證
+	if user.IsTOTPEnabled() {
+		return o.app.beginMFAChallenge(user)
+	}
+	tokens, err := o.app.startSession(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	return &LoginResult{Tokens: tokens}, nil
+}
+
+// loginFailed 記錄一次外部登入失敗：尚未確認身分，因此只計入來源 IP，稽核記錄以身分提供者作為目標
+// 達到門檻時返回鎖定錯誤
+func (o *OIDCApp) loginFailed(ctx context.Context, providerName, clientIP string) error {
+	err := o.app.loginGuard.RecordFailure(ctx, "", clientIP)
+
+	action := "user.login_failed"
+	if err != nil {
+		action = "user.login_locked"
+	}
+	o.app.audit.Record(ctx, auditservice.Event{Action: action, TargetType: auditentity.TargetProvider, TargetID: providerName})
+	return err
+}
+
+// authenticate 確認回呼屬於發起登入的瀏覽器，以授權碼與 PKCE verifier 換取並驗證 ID Token，返回對應的使用者
+func (o *OIDCApp) authenticate(ctx context.Context, provider *oidcProvider, callback OIDCCallback) (*entity.User, error) {
+	// state 必須與發起登入時設定在同一個瀏覽器的 cookie 相同，否則攻擊者可讓受害者登入攻擊者的帳號
+	if callback.BrowserState == "" || subtle.ConstantTimeCompare([]byte(callback.State), []byte(callback.BrowserState)) != 1 {
+		return nil, ErrOIDCInvalidState
+	}
+
+	providerName := provider.config.Name
+	data, err := o.stateStore.Take(ctx, callback.State)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error loading OIDC state", "error", err)
+		return nil, ErrInternal
+	}
+	if data == nil || data.Provider != providerName {
+		return nil, ErrOIDCInvalidState
+	}
+
+	httpCtx := oidc.ClientContext(ctx, o.httpClient)
+	token, err := provider.oauth2.Exchange(httpCtx, callback.Code, oauth2.VerifierOption(data.CodeVerifier))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error exchanging OIDC code with provider", "provider", providerName, "error", err)
+		return nil, ErrOIDCExchangeFailed
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		slog.WarnContext(ctx, "OIDC provider returned no id_token", "provider", providerName)
+		return nil, ErrOIDCExchangeFailed
+	}
+	idToken, err := provider.verifier.Verify(httpCtx, rawIDToken)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error verifying ID token from provider", "provider", providerName, "error", err)
+		return nil, ErrOIDCExchangeFailed
+	}
+
+	var claims oidcClaims
+	if err := idToken.Claims(&claims); err != nil {
+		slog.ErrorContext(ctx, "Error decoding ID token claims from provider", "provider", providerName, "error", err)
+		return nil, ErrOIDCExchangeFailed
+	}
+	if claims.Nonce != data.Nonce {
+		return nil, ErrOIDCInvalidState
+	}
+
+	return o.resolveUser(ctx, provider.config, idToken.Subject, &claims)
+}
+
+// resolveUser 依序以既有連結、已驗證的電子郵件或自動建立帳號找出登入的使用者
+func (o *OIDCApp) resolveUser(ctx context.Context, cfg OIDCProviderConfig, subject string, claims *oidcClaims) (*entity.User, error) {
+	link, err := o.identityRepo.FindByProviderSubject(ctx, cfg.Name, subject)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding external identity", "provider", cfg.Name, "subject", subject, "error", err)
+		return nil, ErrInternal
+	}
+	if link != nil {
+		user, err := o.app.userRepo.FindByID(ctx, link.UserID)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error finding user for external identity", "user_id", link.UserID, "error", err)
+			return nil, ErrInternal
+		}
+		if user == nil {
+			return nil, ErrOIDCAccountNotFound
+		}
+		return user, nil
+	}
+
+	// 只有提供者確認過的電子郵件才能用來連結既有帳號，否則可冒用他人信箱接管帳號
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCEmailNotVerified
+	}
+
+	user, err := o.app.userRepo.FindByEmail(ctx, claims.Email)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user by email during OIDC login", "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		if !cfg.AutoProvision {
+			return nil, ErrOIDCAccountNotFound
+		}
+		if user, err = o.provisionUser(ctx, claims); err != nil {
+			return nil, err
+		}
+	} else if !user.IsEmailVerified() {
+		// 本地帳號未驗證信箱時，任何人都能以他人的信箱註冊，自動連結會讓信箱的真正擁有者登入到對方的帳號
+		slog.WarnContext(ctx, "Refusing to link identity to account with unverified email", "provider", cfg.Name, "user_id", user.ID)
+		return nil, ErrOIDCAccountNotFound
+	}
+
+	email := claims.Email
+	link = &entity.ExternalIdentity{UserID: user.ID, Provider: cfg.Name, Subject: subject, Email: &email}
+	if err := o.identityRepo.Create(ctx, link); err != nil {
+		slog.ErrorContext(ctx, "Error linking external identity to user", "provider", cfg.Name, "subject", subject, "user_id", user.ID, "error", err)
+		return nil, ErrInternal
+	}
+	slog.InfoContext(ctx, "Linked identity to user", "provider", cfg.Name, "subject", subject, "user_id", user.ID)
+	o.app.recordUser(ctx, &user.ID, "user.identity_link", user.ID, nil, nil)
+	return user, nil
+}
+
+// provisionUser 為外部身分建立新的本地帳號，密碼為隨機值 (需要時可透過重設密碼設定)
+func (o *OIDCApp) provisionUser(ctx context.Context, claims *oidcClaims) (*entity.User, error) {
+	username, err := o.availableUsername(ctx, claims)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomHex(32)
+	if err != nil {
+		return nil, ErrInternal
+	}
+
+	now := time.Now()
+	user := &entity.User{
+		Username:        username,
+		Email:           claims.Email,
+		Role:            entity.RoleUser,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+	}
+	if err := user.SetPassword(password); err != nil {
+		slog.ErrorContext(ctx, "Error hashing password for provisioned user", "username", username, "error", err)
+		return nil, ErrInternal
+	}
+	if err := o.app.userRepo.Create(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error provisioning user", "username", username, "error", err)
+		return nil, ErrInternal
+	}
+	slog.InfoContext(ctx, "Provisioned user from external identity", "username", username)
+	o.app.recordUser(ctx, &user.ID, "user.register", user.ID, nil, user)
+	return user, nil
+}
+
+// availableUsername 從 preferred_username 或電子郵件產生一個尚未使用的使用者名稱
+func (o *OIDCApp) availableUsername(ctx context.Context, claims *oidcClaims) (string, error) {
+	base := claims.PreferredUsername
+	if base == "" {
+		base = strings.SplitN(claims.Email, "@", 2)[0]
+	}
+	base = usernameUnsafeChars.ReplaceAllString(strings.ToLower(base), "")
+	if len(base) < 3 {
+		base = "user"
+	}
+	if len(base) > 90 {
+		base = base[:90]
+	}
+
+	candidate := base
+	for i := 2; i <= 10; i++ {
+		existing, err := o.app.userRepo.FindByUsername(ctx, candidate)
+		if err != nil {
+			slog.ErrorContext(ctx, "Error checking username", "candidate", candidate, "error", err)
+			return "", ErrInternal
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+
+	suffix, err := randomHex(4)
+	if err != nil {
+		return "", ErrInternal
+	}
+	return base + "-" + suffix, nil
+}
+
+// provider 返回已完成 discovery 的提供者，第一次呼叫時才連線取得設定
+func (o *OIDCApp) provider(ctx context.Context, name string) (*oidcProvider, error) {
+	cfg, ok := o.configs[name]
+	if !ok {
+		return nil, ErrOIDCProviderNotFound
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if p, ok := o.providers[name]; ok {
+		return p, nil
+	}
+
+	discovered, err := oidc.NewProvider(oidc.ClientContext(ctx, o.httpClient), cfg.IssuerURL)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error discovering OIDC provider", "provider", name, "issuer_url", cfg.IssuerURL, "error", err)
+		return nil, ErrOIDCExchangeFailed
+	}
+
+	scopes := []string{oidc.ScopeOpenID, "email", "profile"}
+	for _, scope := range cfg.Scopes {
+		if scope != oidc.ScopeOpenID && scope != "email" && scope != "profile" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	p := &oidcProvider{
+		config: cfg,
+		oauth2: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     discovered.Endpoint(),
+			Scopes:       scopes,
+		},
+		verifier: discovered.Verifier(&oidc.Config{ClientID: cfg.ClientID}),
+	}
+	o.providers[name] = p
+	return p, nil
+}
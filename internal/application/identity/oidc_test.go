@@ -0,0 +1,438 @@
+package identityapp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	auditentity "go_short/domain/audit/entity"
+	auditrepository "go_short/domain/audit/repository"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+	"go_short/domain/identity/service"
+
+	"golang.org/x/oauth2"
+)
+
+// fakeUserRepo 只實作 resolveUser 與 Authenticate 會用到的查詢
+type fakeUserRepo struct {
+	repository.UserRepository
+	users []*entity.User
+}
+
+func (r *fakeUserRepo) FindByID(ctx context.Context, id uint) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeUserRepo) FindByEmail(ctx context.Context, email string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Email == email {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+// fakeIdentityRepo 記錄建立的外部身分連結
+type fakeIdentityRepo struct {
+	repository.ExternalIdentityRepository
+	links []*entity.ExternalIdentity
+}
+
+func (r *fakeIdentityRepo) Create(ctx context.Context, identity *entity.ExternalIdentity) error {
+	r.links = append(r.links, identity)
+	return nil
+}
+
+func (r *fakeIdentityRepo) FindByProviderSubject(ctx context.Context, provider, subject string) (*entity.ExternalIdentity, error) {
+	for _, link := range r.links {
+		if link.Provider == provider && link.Subject == subject {
+			return link, nil
+		}
+	}
+	return nil, nil
+}
+
+func newTestOIDCApp(user *entity.User) (*OIDCApp, *fakeIdentityRepo) {
+	identities := &fakeIdentityRepo{}
+	app := &App{userRepo: &fakeUserRepo{users: []*entity.User{user}}}
+	return &OIDCApp{app: app, identityRepo: identities}, identities
+}
+
+// 以受害者信箱註冊但未驗證的本地帳號，不可在受害者第一次外部登入時被連結
+func TestResolveUserRejectsUnverifiedLocalAccount(t *testing.T) {
+	attacker := &entity.User{Username: "attacker", Email: "victim@example.com", IsActive: true}
+	attacker.ID = 1
+	o, identities := newTestOIDCApp(attacker)
+
+	claims := &oidcClaims{Email: "victim@example.com", EmailVerified: true}
+	_, err := o.resolveUser(context.Background(), OIDCProviderConfig{Name: "company"}, "subject-1", claims)
+	if !errors.Is(err, ErrOIDCAccountNotFound) {
+		t.Fatalf("got error %v, want ErrOIDCAccountNotFound", err)
+	}
+	if len(identities.links) != 0 {
+		t.Fatalf("identity was linked to an unverified account: %+v", identities.links[0])
+	}
+}
+
+func TestResolveUserLinksVerifiedLocalAccount(t *testing.T) {
+	verifiedAt := time.Now()
+	owner := &entity.User{Username: "owner", Email: "owner@example.com", IsActive: true, EmailVerifiedAt: &verifiedAt}
+	owner.ID = 2
+	o, identities := newTestOIDCApp(owner)
+
+	claims := &oidcClaims{Email: "owner@example.com", EmailVerified: true}
+	user, err := o.resolveUser(context.Background(), OIDCProviderConfig{Name: "company"}, "subject-2", claims)
+	if err != nil {
+		t.Fatalf("resolveUser: %v", err)
+	}
+	if user.ID != owner.ID || len(identities.links) != 1 || identities.links[0].UserID != owner.ID {
+		t.Fatalf("identity not linked to the verified account: user %d, links %+v", user.ID, identities.links)
+	}
+}
+
+// fakeStateStore 以記憶體保存 OIDC state，Take 後刪除
+type fakeStateStore struct {
+	states map[string]*entity.OIDCState
+}
+
+func (s *fakeStateStore) Save(ctx context.Context, state string, data *entity.OIDCState, ttl time.Duration) error {
+	s.states[state] = data
+	return nil
+}
+
+func (s *fakeStateStore) Take(ctx context.Context, state string) (*entity.OIDCState, error) {
+	data := s.states[state]
+	delete(s.states, state)
+	return data, nil
+}
+
+// fakeAttemptStore 以記憶體記錄登入失敗次數與鎖定
+type fakeAttemptStore struct {
+	failures map[string]int64
+	locked   map[string]time.Duration
+}
+
+func (s *fakeAttemptStore) IncrementFailures(ctx context.Context, key string, window time.Duration) (int64, error) {
+	s.failures[key]++
+	return s.failures[key], nil
+}
+
+func (s *fakeAttemptStore) Lock(ctx context.Context, key string, ttl time.Duration) error {
+	s.locked[key] = ttl
+	return nil
+}
+
+func (s *fakeAttemptStore) LockRemaining(ctx context.Context, key string) (time.Duration, error) {
+	return s.locked[key], nil
+}
+
+func (s *fakeAttemptStore) Reset(ctx context.Context, key string) error {
+	delete(s.failures, key)
+	delete(s.locked, key)
+	return nil
+}
+
+type fakeAuditRepo struct {
+	auditrepository.AuditRepository
+	entries []*auditentity.AuditEntry
+}
+
+func (r *fakeAuditRepo) Append(ctx context.Context, entry *auditentity.AuditEntry) error {
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+// fakeIssuer 是以 RS256 簽署 ID Token 的 OpenID Connect 身分提供者
+// 只接受最近一次授權請求的授權碼，且 code_verifier 必須符合該請求的 PKCE challenge
+type fakeIssuer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	clientID  string
+	code      string
+	challenge string
+	nonce     string
+	subject   string
+	email     string
+	// tamperNonce 不為空時以此取代授權請求的 nonce
+	tamperNonce   string
+	tokenRequests int
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &fakeIssuer{key: key, clientID: "go-short", code: "auth-code", subject: "subject-1", email: "owner@example.com"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{
+			"issuer":                                issuer.URL,
+			"authorization_endpoint":                issuer.URL + "/authorize",
+			"token_endpoint":                        issuer.URL + "/token",
+			"jwks_uri":                              issuer.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", issuer.token)
+	issuer.Server = httptest.NewServer(mux)
+	t.Cleanup(issuer.Close)
+	return issuer
+}
+
+// authorize 模擬使用者在身分提供者登入：記錄授權網址中的 PKCE challenge 與 nonce
+func (i *fakeIssuer) authorize(t *testing.T, authURL string) {
+	t.Helper()
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	query := parsed.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" || query.Get("nonce") == "" {
+		t.Fatalf("authorization URL %s lacks PKCE or nonce", authURL)
+	}
+	i.challenge = query.Get("code_challenge")
+	i.nonce = query.Get("nonce")
+}
+
+func (i *fakeIssuer) token(w http.ResponseWriter, r *http.Request) {
+	i.tokenRequests++
+	r.ParseForm()
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if r.PostForm.Get("code") != i.code || base64.RawURLEncoding.EncodeToString(verifier[:]) != i.challenge {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"invalid_grant"}`))
+		return
+	}
+	nonce := i.nonce
+	if i.tamperNonce != "" {
+		nonce = i.tamperNonce
+	}
+	now := time.Now()
+	writeJSON(w, map[string]any{
+		"access_token": "access",
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token": i.sign(map[string]any{
+			"iss":            i.URL,
+			"sub":            i.subject,
+			"aud":            i.clientID,
+			"iat":            now.Unix(),
+			"exp":            now.Add(time.Hour).Unix(),
+			"nonce":          nonce,
+			"email":          i.email,
+			"email_verified": true,
+		}),
+	})
+}
+
+// sign 以 RS256 簽署 JWT
+func (i *fakeIssuer) sign(claims map[string]any) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": "test"})
+	payload, _ := json.Marshal(claims)
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, i.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(value)
+}
+
+type oidcFixture struct {
+	oidc     *OIDCApp
+	issuer   *fakeIssuer
+	states   *fakeStateStore
+	attempts *fakeAttemptStore
+	audit    *fakeAuditRepo
+}
+
+// newOIDCFixture 建立連線到假身分提供者的 OIDCApp；使用者 owner 已驗證信箱並啟用兩步驟驗證，
+// 登入成功時返回 MFA 挑戰，不需要 refresh token 儲存庫
+func newOIDCFixture(t *testing.T, policy service.LockoutPolicy) *oidcFixture {
+	t.Helper()
+	now := time.Now()
+	secret := "JBSWY3DPEHPK3PXP"
+	owner := &entity.User{Username: "owner", Email: "owner@example.com", IsActive: true, EmailVerifiedAt: &now, TOTPEnabledAt: &now, TOTPSecret: &secret}
+	owner.ID = 2
+
+	f := &oidcFixture{
+		issuer:   newFakeIssuer(t),
+		states:   &fakeStateStore{states: make(map[string]*entity.OIDCState)},
+		attempts: &fakeAttemptStore{failures: make(map[string]int64), locked: make(map[string]time.Duration)},
+		audit:    &fakeAuditRepo{},
+	}
+	app := &App{
+		userRepo:   &fakeUserRepo{users: []*entity.User{owner}},
+		loginGuard: service.NewLoginGuard(f.attempts, policy),
+		audit:      auditservice.NewRecorder(f.audit),
+		jwtSecret:  []byte("test-secret"),
+	}
+	f.oidc = NewOIDCApp(app, &fakeIdentityRepo{}, f.states, f.issuer.Client(), []OIDCProviderConfig{{
+		Name:        "company",
+		IssuerURL:   f.issuer.URL,
+		ClientID:    f.issuer.clientID,
+		RedirectURL: "https://sho.rt/auth/oidc/company/callback",
+	}})
+	return f
+}
+
+// begin 發起登入並模擬使用者在身分提供者完成登入，返回 state
+func (f *oidcFixture) begin(t *testing.T) string {
+	t.Helper()
+	authorization, err := f.oidc.BeginLogin(context.Background(), "company")
+	if err != nil {
+		t.Fatalf("BeginLogin: %v", err)
+	}
+	if authorization.State == "" || authorization.ExpiresIn != oidcStateTTL {
+		t.Fatalf("authorization = %+v", authorization)
+	}
+	f.issuer.authorize(t, authorization.URL)
+	return authorization.State
+}
+
+func (f *oidcFixture) complete(state, browserState string) (*LoginResult, error) {
+	return f.oidc.CompleteLogin(context.Background(), "company", OIDCCallback{
+		State:        state,
+		BrowserState: browserState,
+		Code:         f.issuer.code,
+		ClientIP:     "192.0.2.1",
+	})
+}
+
+func TestOIDCLoginWithFakeIssuer(t *testing.T) {
+	f := newOIDCFixture(t, service.DefaultLockoutPolicy())
+	state := f.begin(t)
+
+	result, err := f.complete(state, state)
+	if err != nil {
+		t.Fatalf("CompleteLogin: %v", err)
+	}
+	if !result.MFARequired() || result.MFAToken == "" {
+		t.Fatalf("result = %+v, want an MFA challenge for an account with 2FA", result)
+	}
+	if f.issuer.tokenRequests != 1 || len(f.attempts.failures) != 0 {
+		t.Errorf("token requests %d, failures %v", f.issuer.tokenRequests, f.attempts.failures)
+	}
+
+	// state 只能使用一次
+	if _, err := f.complete(state, state); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("replayed state: err = %v, want ErrOIDCInvalidState", err)
+	}
+}
+
+func TestOIDCLoginRejectsStateFromAnotherBrowser(t *testing.T) {
+	f := newOIDCFixture(t, service.DefaultLockoutPolicy())
+	state := f.begin(t)
+
+	for _, browserState := range []string{"", "another-browser"} {
+		if _, err := f.complete(state, browserState); !errors.Is(err, ErrOIDCInvalidState) {
+			t.Fatalf("browser state %q: err = %v, want ErrOIDCInvalidState", browserState, err)
+		}
+	}
+	if f.issuer.tokenRequests != 0 {
+		t.Error("the code must not be exchanged without the state cookie")
+	}
+	if _, ok := f.states.states[state]; !ok {
+		t.Error("a rejected callback must not consume the state of the browser that started the login")
+	}
+	if f.attempts.failures["ip:192.0.2.1"] != 2 {
+		t.Errorf("failures = %v, want both attempts counted for the IP", f.attempts.failures)
+	}
+	if len(f.audit.entries) != 2 || f.audit.entries[0].Action != "user.login_failed" ||
+		f.audit.entries[0].TargetType != auditentity.TargetProvider || f.audit.entries[0].TargetID != "company" {
+		t.Errorf("audit entries %+v, want a login_failed entry per attempt", f.audit.entries)
+	}
+}
+
+func TestOIDCLoginRejectsWrongCodeVerifier(t *testing.T) {
+	f := newOIDCFixture(t, service.DefaultLockoutPolicy())
+	state := f.begin(t)
+	// 攔截授權碼的人沒有 verifier，身分提供者拒絕交換
+	f.states.states[state].CodeVerifier = oauth2.GenerateVerifier()
+
+	if _, err := f.complete(state, state); !errors.Is(err, ErrOIDCExchangeFailed) {
+		t.Fatalf("err = %v, want ErrOIDCExchangeFailed", err)
+	}
+	// oauth2 在交換失敗時會改用另一種用戶端認證方式重試，至少送出一次即可
+	if f.issuer.tokenRequests == 0 || f.attempts.failures["ip:192.0.2.1"] != 1 {
+		t.Errorf("token requests %d, failures %v", f.issuer.tokenRequests, f.attempts.failures)
+	}
+}
+
+func TestOIDCLoginRejectsNonceMismatch(t *testing.T) {
+	f := newOIDCFixture(t, service.DefaultLockoutPolicy())
+	state := f.begin(t)
+	f.issuer.tamperNonce = "replayed-id-token-nonce"
+
+	if _, err := f.complete(state, state); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("err = %v, want ErrOIDCInvalidState", err)
+	}
+	if f.attempts.failures["ip:192.0.2.1"] != 1 || len(f.audit.entries) != 1 {
+		t.Errorf("failures %v, audit %d; want the failure recorded", f.attempts.failures, len(f.audit.entries))
+	}
+}
+
+func TestOIDCLoginLocksOutAfterRepeatedFailures(t *testing.T) {
+	policy := service.DefaultLockoutPolicy()
+	policy.MaxIPFailures = 2
+	f := newOIDCFixture(t, policy)
+
+	if _, err := f.complete("forged", "forged"); !errors.Is(err, ErrOIDCInvalidState) {
+		t.Fatalf("first failure: err = %v", err)
+	}
+	var locked *LockedError
+	if _, err := f.complete("forged", "forged"); !errors.As(err, &locked) {
+		t.Fatalf("second failure: err = %v, want a lockout", err)
+	}
+	if f.audit.entries[1].Action != "user.login_locked" {
+		t.Errorf("audit action %q, want user.login_locked", f.audit.entries[1].Action)
+	}
+
+	// 鎖定期間即使 state 正確也拒絕
+	state := f.begin(t)
+	if _, err := f.complete(state, state); !errors.As(err, &locked) {
+		t.Fatalf("valid login while locked: err = %v, want a lockout", err)
+	}
+	if f.issuer.tokenRequests != 0 {
+		t.Error("a locked IP must not reach the token endpoint")
+	}
+	if _, ok := f.attempts.failures["user:"]; ok {
+		t.Error("failures before the identity is known must not share a username key")
+	}
+}
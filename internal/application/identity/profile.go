@@ -0,0 +1,230 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/service"
+	workspaceentity "go_short/domain/workspace/entity"
+)
+
+// 帳號自助管理相關錯誤
+var ErrInvalidPassword = errors.New("current password is incorrect")
+var ErrInvalidProfile = errors.New("invalid profile data")
+var ErrSoleWorkspaceOwner = errors.New("promote another owner in every workspace you are the only owner of before deleting the account")
+
+// LinkReleaser 在刪除帳號時處理使用者的個人連結與網域，由短網址應用層實作
+type LinkReleaser interface {
+	// ReleaseUserLinks 在 transferTo 為 nil 時停用連結，否則將連結轉移給該使用者
+	ReleaseUserLinks(ctx context.Context, userID uint, transferTo *uint) error
+	// ReleaseUserDomains 移除使用者的個人網域
+	ReleaseUserDomains(ctx context.Context, userID uint) error
+}
+
+// WebhookReleaser 在刪除帳號時停用使用者的個人 webhook，由 webhook 應用層實作
+type WebhookReleaser interface {
+	DeactivateUserWebhooks(ctx context.Context, userID uint) error
+}
+
+// WorkspaceOwnership 找出使用者是唯一 owner 且還有其他成員的工作區，由工作區領域服務實作
+type WorkspaceOwnership interface {
+	SoleOwnedWorkspaces(ctx context.Context, userID uint) ([]*workspaceentity.Workspace, error)
+}
+
+// Transactor 在資料庫交易中執行 fn；fn 內以同一個 ctx 呼叫的儲存庫操作一起提交或回滾
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// ProfileUpdate 是使用者可自行修改的個人資料，nil 代表不修改
+type ProfileUpdate struct {
+	Username *string
+	Email    *string
+	// CurrentPassword 是變更電子郵件時必須提供的目前密碼
+	CurrentPassword string
+}
+
+// UpdateProfile 修改使用者名稱或電子郵件；變更電子郵件需要目前的密碼，變更後需要重新驗證
+// 否則竊得存取權杖的人可以換成自己的信箱，再以重設密碼接管帳號
+func (a *App) UpdateProfile(ctx context.Context, userID uint, update ProfileUpdate) (*entity.User, error) {
+	user, err := a.GetUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	before := *user
+
+	if update.Username != nil {
+		username := strings.TrimSpace(*update.Username)
+		if len(username) < 3 {
+			return nil, ErrInvalidProfile
+		}
+		if username != user.Username {
+			existing, err := a.userRepo.FindByUsername(ctx, username)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error finding user by username", "username", username, "error", err)
+				return nil, ErrInternal
+			}
+			if existing != nil {
+				return nil, ErrUserAlreadyExists
+			}
+			user.Username = username
+		}
+	}
+
+	emailChanged := false
+	if update.Email != nil {
+		email := strings.TrimSpace(*update.Email)
+		if email == "" {
+			return nil, ErrInvalidProfile
+		}
+		if !strings.EqualFold(email, user.Email) {
+			if !user.CheckPassword(update.CurrentPassword) {
+				return nil, ErrInvalidPassword
+			}
+			existing, err := a.userRepo.FindByEmail(ctx, email)
+			if err != nil {
+				slog.ErrorContext(ctx, "Error finding user by email", "email", email, "error", err)
+				return nil, ErrInternal
+			}
+			if existing != nil {
+				return nil, ErrUserAlreadyExists
+			}
+			user.Email = email
+			user.EmailVerifiedAt = nil
+			emailChanged = true
+		}
+	}
+
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating profile of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	a.recordUser(ctx, &userID, "user.update", userID, &before, user)
+
+	if emailChanged {
+		if err := a.sendVerificationEmail(ctx, user); err != nil {
+			slog.ErrorContext(ctx, "Error sending verification email to user", "user_id", userID, "error", err)
+		}
+	}
+	return user, nil
+}
+
+// ChangePassword 驗證舊密碼後設定新密碼，並登出目前工作階段以外的所有登入
+func (a *App) ChangePassword(ctx context.Context, principal *Principal, oldPassword, newPassword string) error {
+	err := a.identityService.ChangeUserPassword(ctx, principal.UserID, oldPassword, newPassword)
+	switch {
+	case errors.Is(err, service.ErrInvalidPassword):
+		return ErrInvalidPassword
+	case errors.Is(err, service.ErrUserNotFound):
+		return ErrUserNotFound
+	case err != nil:
+		return ErrInternal
+	}
+	a.recordUser(ctx, &principal.UserID, "user.password_change", principal.UserID, nil, nil)
+
+	currentSession := ""
+	if principal.Token != nil {
+		currentSession = principal.Token.SessionID
+	}
+	return a.RevokeAllSessions(ctx, principal.UserID, currentSession)
+}
+
+// DeleteAccount 驗證密碼 (啟用兩步驟驗證時還需要驗證碼或復原碼) 後軟刪除帳號：
+// 依設定停用或轉移個人連結、移除個人網域、停用個人 webhook、撤銷所有登入與 API 金鑰
+// 這些寫入在同一個交易中完成，任何一步失敗時帳號維持原狀
+// 使用者是某個工作區唯一的 owner 且還有其他成員時拒絕刪除，需先指派另一位 owner
+func (a *App) DeleteAccount(ctx context.Context, principal *Principal, password, code string) error {
+	user, err := a.GetUser(ctx, principal.UserID)
+	if err != nil {
+		return err
+	}
+	if !user.CheckPassword(password) {
+		return ErrInvalidPassword
+	}
+	if user.IsTOTPEnabled() {
+		if err := a.verifySecondFactor(ctx, user, code); err != nil {
+			return err
+		}
+	}
+
+	owned, err := a.workspaces.SoleOwnedWorkspaces(ctx, user.ID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking workspace ownership of user", "user_id", user.ID, "error", err)
+		return ErrInternal
+	}
+	if len(owned) > 0 {
+		return ErrSoleWorkspaceOwner
+	}
+
+	err = a.transaction(ctx, func(ctx context.Context) error {
+		if err := a.linkReleaser.ReleaseUserLinks(ctx, user.ID, a.linkTransferTarget(ctx, user.ID)); err != nil {
+			slog.ErrorContext(ctx, "Error releasing links of user", "user_id", user.ID, "error", err)
+			return ErrInternal
+		}
+		if err := a.linkReleaser.ReleaseUserDomains(ctx, user.ID); err != nil {
+			slog.ErrorContext(ctx, "Error releasing domains of user", "user_id", user.ID, "error", err)
+			return ErrInternal
+		}
+		if err := a.webhookReleaser.DeactivateUserWebhooks(ctx, user.ID); err != nil {
+			slog.ErrorContext(ctx, "Error deactivating webhooks of user", "user_id", user.ID, "error", err)
+			return ErrInternal
+		}
+
+		if err := a.RevokeAllSessions(ctx, user.ID, ""); err != nil {
+			return err
+		}
+		if err := a.revokeAllAPIKeys(ctx, user.ID); err != nil {
+			return err
+		}
+
+		user.IsActive = false
+		if err := a.userRepo.Update(ctx, user); err != nil {
+			slog.ErrorContext(ctx, "Error deactivating user before deletion", "user_id", user.ID, "error", err)
+			return ErrInternal
+		}
+		if err := a.userRepo.Delete(ctx, user.ID); err != nil {
+			slog.ErrorContext(ctx, "Error deleting user", "user_id", user.ID, "error", err)
+			return ErrInternal
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// 目前的存取權杖立即失效
+	if principal.Token != nil {
+		if err := a.denylist.Add(ctx, principal.Token.TokenID, time.Until(principal.Token.ExpiresAt)); err != nil {
+			slog.ErrorContext(ctx, "Error adding token of user to denylist", "user_id", user.ID, "error", err)
+		}
+	}
+
+	slog.InfoContext(ctx, "User deleted their account", "user_id", user.ID)
+	a.recordUser(ctx, &user.ID, "user.delete", user.ID, nil, nil)
+	return nil
+}
+
+// transaction 在資料庫交易中執行 fn；未設定 transactor 時直接執行
+func (a *App) transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if a.transactor == nil {
+		return fn(ctx)
+	}
+	return a.transactor.Transaction(ctx, fn)
+}
+
+// linkTransferTarget 返回刪除帳號時連結的轉移對象；未設定、對象不存在或為本人時返回 nil (停用連結)
+func (a *App) linkTransferTarget(ctx context.Context, userID uint) *uint {
+	if a.deletedUserLinksTo == nil || *a.deletedUserLinksTo == userID {
+		return nil
+	}
+	target, err := a.userRepo.FindByID(ctx, *a.deletedUserLinksTo)
+	if err != nil || target == nil {
+		slog.WarnContext(ctx, "Link transfer target user not found, disabling links instead", "target_user_id", *a.deletedUserLinksTo, "user_id", userID)
+		return nil
+	}
+	return a.deletedUserLinksTo
+}
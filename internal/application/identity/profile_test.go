@@ -0,0 +1,353 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/identity/repository"
+	"go_short/domain/notification"
+	workspaceentity "go_short/domain/workspace/entity"
+
+	"github.com/pquerna/otp/totp"
+)
+
+// accountUserRepo 在 fakeUserRepo 之上記錄更新與刪除
+type accountUserRepo struct {
+	*fakeUserRepo
+	deleted []uint
+}
+
+func (r *accountUserRepo) FindByUsername(ctx context.Context, username string) (*entity.User, error) {
+	for _, u := range r.users {
+		if u.Username == username {
+			return u, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *accountUserRepo) Update(ctx context.Context, user *entity.User) error {
+	return nil
+}
+
+func (r *accountUserRepo) Delete(ctx context.Context, id uint) error {
+	r.deleted = append(r.deleted, id)
+	return nil
+}
+
+type fakeRefreshTokenRepo struct {
+	repository.RefreshTokenRepository
+	revoked []uint
+}
+
+func (r *fakeRefreshTokenRepo) RevokeAllForUser(ctx context.Context, userID uint, exceptFamilyID string, at time.Time) error {
+	r.revoked = append(r.revoked, userID)
+	return nil
+}
+
+type fakeAPIKeyRepo struct {
+	repository.APIKeyRepository
+}
+
+func (fakeAPIKeyRepo) FindByUserID(ctx context.Context, userID uint) ([]*entity.APIKey, error) {
+	return nil, nil
+}
+
+// fakeRecoveryCodeRepo 保存一組復原碼的雜湊，使用過即失效
+type fakeRecoveryCodeRepo struct {
+	repository.RecoveryCodeRepository
+	hashes map[string]bool
+}
+
+func (r *fakeRecoveryCodeRepo) Consume(ctx context.Context, userID uint, codeHash string, at time.Time) (bool, error) {
+	if !r.hashes[codeHash] {
+		return false, nil
+	}
+	delete(r.hashes, codeHash)
+	return true, nil
+}
+
+// memoryDenylist 以記憶體記錄黑名單中的 jti 與使用過的 TOTP 驗證碼
+type memoryDenylist struct {
+	entries map[string]bool
+}
+
+func (d *memoryDenylist) Add(ctx context.Context, jti string, ttl time.Duration) error {
+	d.entries[jti] = true
+	return nil
+}
+
+func (d *memoryDenylist) Contains(ctx context.Context, jti string) (bool, error) {
+	return d.entries[jti], nil
+}
+
+type fakeMailer struct {
+	sent []notification.Message
+}
+
+func (m *fakeMailer) Send(ctx context.Context, msg notification.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+type txKey struct{}
+
+// fakeTransactor 以 ctx 標記交易範圍，讓測試檢查哪些寫入在交易中執行
+type fakeTransactor struct {
+	calls int
+}
+
+func (t *fakeTransactor) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	t.calls++
+	return fn(context.WithValue(ctx, txKey{}, true))
+}
+
+func inTransaction(ctx context.Context) bool {
+	return ctx.Value(txKey{}) != nil
+}
+
+// fakeReleaser 記錄在交易中被釋放的資源，webhookErr 不為 nil 時停用 webhook 失敗
+type fakeReleaser struct {
+	released   []string
+	webhookErr error
+}
+
+func (r *fakeReleaser) release(ctx context.Context, resource string) {
+	if inTransaction(ctx) {
+		r.released = append(r.released, resource)
+	}
+}
+
+func (r *fakeReleaser) ReleaseUserLinks(ctx context.Context, userID uint, transferTo *uint) error {
+	r.release(ctx, "links")
+	return nil
+}
+
+func (r *fakeReleaser) ReleaseUserDomains(ctx context.Context, userID uint) error {
+	r.release(ctx, "domains")
+	return nil
+}
+
+func (r *fakeReleaser) DeactivateUserWebhooks(ctx context.Context, userID uint) error {
+	if r.webhookErr != nil {
+		return r.webhookErr
+	}
+	r.release(ctx, "webhooks")
+	return nil
+}
+
+type fakeWorkspaceOwnership struct {
+	owned []*workspaceentity.Workspace
+}
+
+func (w *fakeWorkspaceOwnership) SoleOwnedWorkspaces(ctx context.Context, userID uint) ([]*workspaceentity.Workspace, error) {
+	return w.owned, nil
+}
+
+type accountFixture struct {
+	app        *App
+	user       *entity.User
+	users      *accountUserRepo
+	sessions   *fakeRefreshTokenRepo
+	denylist   *memoryDenylist
+	releaser   *fakeReleaser
+	workspaces *fakeWorkspaceOwnership
+	tx         *fakeTransactor
+	mailer     *fakeMailer
+}
+
+const (
+	testPassword     = "correct horse"
+	testRecoveryCode = "abcde-12345"
+)
+
+func newAccountFixture(t *testing.T) *accountFixture {
+	t.Helper()
+	now := time.Now()
+	user := &entity.User{Username: "alice", Email: "alice@example.com", IsActive: true, EmailVerifiedAt: &now}
+	user.ID = 7
+	if err := user.SetPassword(testPassword); err != nil {
+		t.Fatal(err)
+	}
+	f := &accountFixture{
+		user:       user,
+		users:      &accountUserRepo{fakeUserRepo: &fakeUserRepo{users: []*entity.User{user}}},
+		sessions:   &fakeRefreshTokenRepo{},
+		denylist:   &memoryDenylist{entries: make(map[string]bool)},
+		releaser:   &fakeReleaser{},
+		workspaces: &fakeWorkspaceOwnership{},
+		tx:         &fakeTransactor{},
+		mailer:     &fakeMailer{},
+	}
+	f.app = &App{
+		userRepo:         f.users,
+		apiKeyRepo:       fakeAPIKeyRepo{},
+		refreshTokenRepo: f.sessions,
+		recoveryCodeRepo: &fakeRecoveryCodeRepo{hashes: map[string]bool{hashToken(normalizeRecoveryCode(testRecoveryCode)): true}},
+		denylist:         f.denylist,
+		mailer:           f.mailer,
+		linkReleaser:     f.releaser,
+		webhookReleaser:  f.releaser,
+		workspaces:       f.workspaces,
+		transactor:       f.tx,
+		jwtSecret:        []byte("test-secret"),
+	}
+	return f
+}
+
+// enableTOTP 為使用者啟用兩步驟驗證並返回目前有效的驗證碼
+func (f *accountFixture) enableTOTP(t *testing.T) string {
+	t.Helper()
+	key, err := totp.Generate(totp.GenerateOpts{Issuer: "go_short", AccountName: f.user.Email})
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret := key.Secret()
+	now := time.Now()
+	f.user.TOTPSecret = &secret
+	f.user.TOTPEnabledAt = &now
+	code, err := totp.GenerateCode(secret, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestDeleteAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		setup    func(t *testing.T, f *accountFixture) (password, code string)
+		wantErr  error
+		released []string
+	}{
+		{
+			name: "password only",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				return testPassword, ""
+			},
+			released: []string{"links", "domains", "webhooks"},
+		},
+		{
+			name: "wrong password",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				return "wrong", ""
+			},
+			wantErr: ErrInvalidPassword,
+		},
+		{
+			name: "2FA without a code",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				f.enableTOTP(t)
+				return testPassword, ""
+			},
+			wantErr: ErrInvalidMFACode,
+		},
+		{
+			name: "2FA with a TOTP code",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				return testPassword, f.enableTOTP(t)
+			},
+			released: []string{"links", "domains", "webhooks"},
+		},
+		{
+			name: "2FA with a recovery code",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				f.enableTOTP(t)
+				return testPassword, testRecoveryCode
+			},
+			released: []string{"links", "domains", "webhooks"},
+		},
+		{
+			name: "only owner of a shared workspace",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				f.workspaces.owned = []*workspaceentity.Workspace{{Name: "team"}}
+				return testPassword, ""
+			},
+			wantErr: ErrSoleWorkspaceOwner,
+		},
+		{
+			name: "a failing step keeps the account",
+			setup: func(t *testing.T, f *accountFixture) (string, string) {
+				f.releaser.webhookErr = errors.New("database is down")
+				return testPassword, ""
+			},
+			wantErr:  ErrInternal,
+			released: []string{"links", "domains"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newAccountFixture(t)
+			password, code := tt.setup(t, f)
+			principal := &Principal{UserID: f.user.ID, Token: &AccessClaims{UserID: f.user.ID, TokenID: "jti-1", ExpiresAt: time.Now().Add(time.Hour)}}
+
+			err := f.app.DeleteAccount(context.Background(), principal, password, code)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(f.users.deleted) != 0 || !f.user.IsActive || f.denylist.entries["jti-1"] {
+					t.Errorf("a refused deletion changed the account: deleted %v, active %t", f.users.deleted, f.user.IsActive)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("DeleteAccount: %v", err)
+				}
+				if len(f.users.deleted) != 1 || f.user.IsActive || len(f.sessions.revoked) != 1 {
+					t.Errorf("deleted %v, active %t, revoked %v", f.users.deleted, f.user.IsActive, f.sessions.revoked)
+				}
+				if !f.denylist.entries["jti-1"] {
+					t.Error("the current access token must be denylisted")
+				}
+			}
+			if got, want := len(f.releaser.released), len(tt.released); got != want {
+				t.Fatalf("released %v inside the transaction, want %v", f.releaser.released, tt.released)
+			}
+			for i, resource := range tt.released {
+				if f.releaser.released[i] != resource {
+					t.Errorf("released %v, want %v", f.releaser.released, tt.released)
+				}
+			}
+			if len(tt.released) > 0 && f.tx.calls != 1 {
+				t.Errorf("ran %d transactions, want 1", f.tx.calls)
+			}
+		})
+	}
+}
+
+func TestUpdateProfileEmailRequiresPassword(t *testing.T) {
+	f := newAccountFixture(t)
+	ctx := context.Background()
+	email := "mallory@example.com"
+
+	for _, password := range []string{"", "wrong"} {
+		_, err := f.app.UpdateProfile(ctx, f.user.ID, ProfileUpdate{Email: &email, CurrentPassword: password})
+		if !errors.Is(err, ErrInvalidPassword) {
+			t.Fatalf("password %q: err = %v, want ErrInvalidPassword", password, err)
+		}
+	}
+	if f.user.Email != "alice@example.com" || !f.user.IsEmailVerified() || len(f.mailer.sent) != 0 {
+		t.Fatalf("a refused change modified the account: %+v", f.user)
+	}
+
+	// 只修改使用者名稱或大小寫不同的同一個信箱不需要密碼
+	username := "alice2"
+	sameEmail := "Alice@example.com"
+	if _, err := f.app.UpdateProfile(ctx, f.user.ID, ProfileUpdate{Username: &username, Email: &sameEmail}); err != nil {
+		t.Fatalf("UpdateProfile username: %v", err)
+	}
+
+	user, err := f.app.UpdateProfile(ctx, f.user.ID, ProfileUpdate{Email: &email, CurrentPassword: testPassword})
+	if err != nil {
+		t.Fatalf("UpdateProfile email: %v", err)
+	}
+	if user.Email != email || user.IsEmailVerified() {
+		t.Errorf("email %q, verified %t; want the new address unverified", user.Email, user.IsEmailVerified())
+	}
+	if len(f.mailer.sent) != 1 || f.mailer.sent[0].To != email {
+		t.Errorf("sent %+v, want a verification email to the new address", f.mailer.sent)
+	}
+}
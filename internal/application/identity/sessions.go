@@ -0,0 +1,157 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"go_short/domain/identity/entity"
+)
+
+// Refresh token 相關錯誤
+var ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected, session revoked")
+
+// TokenPair 是登入或換發時返回給客戶端的權杖組
+type TokenPair struct {
+	AccessToken      string
+	AccessExpiresAt  time.Time
+	RefreshToken     string
+	RefreshExpiresAt time.Time
+}
+
+// startSession 為使用者開啟新的登入工作階段 (新的 refresh token family)，並更新最後登入時間
+func (a *App) startSession(ctx context.Context, user *entity.User) (*TokenPair, error) {
+	now := time.Now()
+	user.LastLogin = &now
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating last login for user", "username", user.Username, "error", err)
+	}
+
+	familyID, err := randomHex(16)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating session id for user", "user_id", user.ID, "error", err)
+		return nil, ErrTokenGeneration
+	}
+	tokens, err := a.issueTokens(ctx, user, familyID)
+	if err != nil {
+		return nil, err
+	}
+	a.recordUser(ctx, &user.ID, "user.login", user.ID, nil, nil)
+	return tokens, nil
+}
+
+// issueTokens 在指定 family 中簽發新的存取權杖與 refresh token
+func (a *App) issueTokens(ctx context.Context, user *entity.User, familyID string) (*TokenPair, error) {
+	accessToken, err := a.generateJWT(user, familyID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating JWT for user", "username", user.Username, "error", err)
+		return nil, ErrTokenGeneration
+	}
+
+	secret, err := randomHex(32)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating refresh token for user", "username", user.Username, "error", err)
+		return nil, ErrTokenGeneration
+	}
+
+	now := time.Now()
+	refreshToken := &entity.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(secret),
+		ExpiresAt: now.Add(a.refreshTokenTTL),
+	}
+	if err := a.refreshTokenRepo.Create(ctx, refreshToken); err != nil {
+		slog.ErrorContext(ctx, "Error storing refresh token for user", "username", user.Username, "error", err)
+		return nil, ErrTokenGeneration
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		AccessExpiresAt:  now.Add(a.accessTokenTTL),
+		RefreshToken:     secret,
+		RefreshExpiresAt: refreshToken.ExpiresAt,
+	}, nil
+}
+
+// RefreshSession 以 refresh token 換發新的權杖組，舊 token 立即失效
+// 已使用過的 token 再次出現代表可能外洩，整個 family 會被撤銷
+func (a *App) RefreshSession(ctx context.Context, plaintext string) (*TokenPair, error) {
+	token, err := a.refreshTokenRepo.FindByHash(ctx, hashToken(plaintext))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding refresh token", "error", err)
+		return nil, ErrInternal
+	}
+	if token == nil || token.RevokedAt != nil || token.IsExpired() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	now := time.Now()
+	if token.UsedAt != nil {
+		return nil, a.revokeReusedFamily(ctx, token, now)
+	}
+
+	marked, err := a.refreshTokenRepo.MarkUsed(ctx, token.ID, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error rotating refresh token", "token_id", token.ID, "error", err)
+		return nil, ErrInternal
+	}
+	if !marked {
+		// 並行請求已搶先使用此 token
+		return nil, a.revokeReusedFamily(ctx, token, now)
+	}
+
+	user, err := a.userRepo.FindByID(ctx, token.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user during refresh", "user_id", token.UserID, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil || !user.IsActive {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	return a.issueTokens(ctx, user, token.FamilyID)
+}
+
+func (a *App) revokeReusedFamily(ctx context.Context, token *entity.RefreshToken, at time.Time) error {
+	slog.WarnContext(ctx, "Refresh token reuse detected, revoking session", "user_id", token.UserID, "family_id", token.FamilyID)
+	if err := a.refreshTokenRepo.RevokeFamily(ctx, token.FamilyID, at); err != nil {
+		slog.ErrorContext(ctx, "Error revoking refresh token family", "family_id", token.FamilyID, "error", err)
+		return ErrInternal
+	}
+	return ErrRefreshTokenReused
+}
+
+// Logout 結束目前的登入工作階段：撤銷其 refresh token family，並將目前的存取權杖加入黑名單
+func (a *App) Logout(ctx context.Context, principal *Principal) error {
+	if principal == nil || principal.Token == nil {
+		return ErrInvalidToken
+	}
+	claims := principal.Token
+
+	if claims.SessionID != "" {
+		if err := a.refreshTokenRepo.RevokeFamily(ctx, claims.SessionID, time.Now()); err != nil {
+			slog.ErrorContext(ctx, "Error revoking session", "session_id", claims.SessionID, "error", err)
+			return ErrInternal
+		}
+	}
+
+	if err := a.denylist.Add(ctx, claims.TokenID, time.Until(claims.ExpiresAt)); err != nil {
+		slog.ErrorContext(ctx, "Error adding token of user to denylist", "user_id", claims.UserID, "error", err)
+		return ErrInternal
+	}
+	a.recordUser(ctx, &claims.UserID, "user.logout", claims.UserID, nil, nil)
+	return nil
+}
+
+// RevokeAllSessions 撤銷使用者所有的 refresh token，已簽發的存取權杖會在短效期後失效
+// exceptSessionID 不為空時保留該工作階段 (例如修改密碼時保留目前的登入)
+func (a *App) RevokeAllSessions(ctx context.Context, userID uint, exceptSessionID string) error {
+	if err := a.refreshTokenRepo.RevokeAllForUser(ctx, userID, exceptSessionID, time.Now()); err != nil {
+		slog.ErrorContext(ctx, "Error revoking sessions of user", "user_id", userID, "error", err)
+		return ErrInternal
+	}
+	return nil
+}
@@ -0,0 +1,242 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/notification"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// 權杖用途，寫入 JWT 的 typ，避免不同用途的權杖互相冒用
+const (
+	tokenTypeAccess        = "access"
+	tokenTypeVerifyEmail   = "verify_email"
+	tokenTypeResetPassword = "reset_password"
+	tokenTypeMFAChallenge  = "mfa_challenge"
+)
+
+// 一次性權杖的有效期
+const (
+	verifyEmailTokenTTL   = 48 * time.Hour
+	resetPasswordTokenTTL = time.Hour
+)
+
+// 一次性權杖相關錯誤
+var ErrInvalidActionToken = errors.New("invalid, expired or already used link")
+
+// sendVerificationEmail 寄出電子郵件驗證連結
+func (a *App) sendVerificationEmail(ctx context.Context, user *entity.User) error {
+	token, err := a.generateActionToken(user, tokenTypeVerifyEmail, verifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+	link := a.publicBaseURL + "/auth/verify?token=" + url.QueryEscape(token)
+
+	return a.mailer.Send(ctx, notification.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s\n\nThe link expires in %d hours.\n",
+			user.Username, link, int(verifyEmailTokenTTL.Hours())),
+	})
+}
+
+// ResendVerificationEmail 重新寄送驗證信；帳號不存在或已驗證時靜默忽略，避免洩漏帳號是否存在
+func (a *App) ResendVerificationEmail(ctx context.Context, email string) error {
+	user, err := a.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user by email during verification resend", "error", err)
+		return ErrInternal
+	}
+	if user == nil || !user.IsActive || user.IsEmailVerified() {
+		return nil
+	}
+
+	// 寄送失敗只記錄日誌，回應與帳號不存在時相同
+	if err := a.sendVerificationEmail(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error sending verification email to user", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// VerifyEmail 使用驗證連結中的權杖確認電子郵件
+func (a *App) VerifyEmail(ctx context.Context, token string) error {
+	user, err := a.consumeActionToken(ctx, token, tokenTypeVerifyEmail)
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return nil
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error marking email as verified", "user_id", user.ID, "error", err)
+		return ErrInternal
+	}
+	a.recordUser(ctx, &user.ID, "user.email_verify", user.ID, nil, nil)
+	return nil
+}
+
+// RequestPasswordReset 寄出密碼重設連結；帳號不存在時靜默忽略，避免洩漏帳號是否存在
+// 產生權杖或寄送失敗也只記錄日誌，否則錯誤回應本身就透露了帳號存在
+func (a *App) RequestPasswordReset(ctx context.Context, email string) error {
+	user, err := a.userRepo.FindByEmail(ctx, email)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user by email during password reset", "error", err)
+		return ErrInternal
+	}
+	if user == nil || !user.IsActive {
+		return nil
+	}
+
+	token, err := a.generateActionToken(user, tokenTypeResetPassword, resetPasswordTokenTTL)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error generating password reset token for user", "user_id", user.ID, "error", err)
+		return nil
+	}
+
+	err = a.mailer.Send(ctx, notification.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the token below with POST /auth/reset to choose a new password:\n\n%s\n\nThe token expires in %d minutes. If you did not request a reset you can ignore this email.\n",
+			user.Username, token, int(resetPasswordTokenTTL.Minutes())),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "Error sending password reset email to user", "user_id", user.ID, "error", err)
+	}
+	return nil
+}
+
+// ResetPassword 使用重設權杖設定新密碼，並登出該使用者所有的工作階段
+func (a *App) ResetPassword(ctx context.Context, token, newPassword string) error {
+	user, err := a.consumeActionToken(ctx, token, tokenTypeResetPassword)
+	if err != nil {
+		return err
+	}
+
+	if err := user.SetPassword(newPassword); err != nil {
+		slog.ErrorContext(ctx, "Error hashing new password for user", "user_id", user.ID, "error", err)
+		return ErrInternal
+	}
+	// 能收到重設信也代表擁有此信箱
+	if !user.IsEmailVerified() {
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+	}
+	if err := a.userRepo.Update(ctx, user); err != nil {
+		slog.ErrorContext(ctx, "Error updating password for user", "user_id", user.ID, "error", err)
+		return ErrInternal
+	}
+	a.recordUser(ctx, &user.ID, "user.password_reset", user.ID, nil, nil)
+
+	return a.RevokeAllSessions(ctx, user.ID, "")
+}
+
+// generateActionToken 簽發一次性權杖，權杖綁定使用者目前的狀態 (電子郵件或密碼雜湊)，狀態改變後自動失效
+func (a *App) generateActionToken(user *entity.User, purpose string, ttl time.Duration) (string, error) {
+	jti, err := randomHex(16)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token id: %w", err)
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"sub": user.ID,
+		"typ": purpose,
+		"jti": jti,
+		"bnd": actionTokenBinding(user, purpose),
+		"iat": now.Unix(),
+		"exp": now.Add(ttl).Unix(),
+	}
+
+	tokenString, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(a.jwtSecret)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return tokenString, nil
+}
+
+// consumeActionToken 驗證一次性權杖並將其標記為已使用，返回權杖所屬的使用者
+func (a *App) consumeActionToken(ctx context.Context, tokenString, purpose string) (*entity.User, error) {
+	user, claims, err := a.parseActionToken(ctx, tokenString, purpose)
+	if err != nil {
+		return nil, err
+	}
+	a.markActionTokenUsed(ctx, claims)
+	return user, nil
+}
+
+// actionTokenClaims 是一次性權杖中用來標記已使用的資訊
+type actionTokenClaims struct {
+	TokenID   string
+	ExpiresAt time.Time
+}
+
+// parseActionToken 驗證一次性權杖 (簽章、用途、是否已使用、綁定狀態)，但不標記為已使用
+func (a *App) parseActionToken(ctx context.Context, tokenString, purpose string) (*entity.User, *actionTokenClaims, error) {
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		return a.jwtSecret, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	if typ, _ := claims["typ"].(string); typ != purpose {
+		return nil, nil, ErrInvalidActionToken
+	}
+	sub, ok := claims["sub"].(float64)
+	if !ok || sub <= 0 {
+		return nil, nil, ErrInvalidActionToken
+	}
+	jti, _ := claims["jti"].(string)
+	binding, _ := claims["bnd"].(string)
+	exp, err := claims.GetExpirationTime()
+	if jti == "" || err != nil || exp == nil {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	used, err := a.denylist.Contains(ctx, jti)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error checking token denylist", "error", err)
+	}
+	if used {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	user, err := a.userRepo.FindByID(ctx, uint(sub))
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for action token", "user_id", uint(sub), "error", err)
+		return nil, nil, ErrInternal
+	}
+	if user == nil || !user.IsActive || binding != actionTokenBinding(user, purpose) {
+		return nil, nil, ErrInvalidActionToken
+	}
+
+	return user, &actionTokenClaims{TokenID: jti, ExpiresAt: exp.Time}, nil
+}
+
+// markActionTokenUsed 將一次性權杖的 jti 加入黑名單直到過期
+func (a *App) markActionTokenUsed(ctx context.Context, claims *actionTokenClaims) {
+	if err := a.denylist.Add(ctx, claims.TokenID, time.Until(claims.ExpiresAt)); err != nil {
+		slog.ErrorContext(ctx, "Error marking action token as used", "error", err)
+	}
+}
+
+// actionTokenBinding 計算權杖綁定的使用者狀態指紋
+// 驗證信綁定電子郵件；重設密碼與 MFA 挑戰綁定目前的密碼雜湊，密碼一經變更舊權杖即失效
+func actionTokenBinding(user *entity.User, purpose string) string {
+	state := user.Email
+	if purpose == tokenTypeResetPassword || purpose == tokenTypeMFAChallenge {
+		state = user.PasswordHash
+	}
+	return hashToken(purpose + ":" + state)[:32]
+}
@@ -0,0 +1,53 @@
+package identityapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"go_short/domain/identity/entity"
+	"go_short/domain/notification"
+)
+
+// failingMailer 模擬無法連線的郵件伺服器
+type failingMailer struct {
+	attempts int
+}
+
+func (m *failingMailer) Send(ctx context.Context, msg notification.Message) error {
+	m.attempts++
+	return errors.New("smtp: connection refused")
+}
+
+// 寄送失敗時的回應必須與帳號不存在時相同，否則可以用來探測帳號
+func TestEmailRequestsDoNotRevealAccounts(t *testing.T) {
+	active := &entity.User{Username: "alice", Email: "alice@example.com", IsActive: true}
+	active.ID = 1
+	inactive := &entity.User{Username: "bob", Email: "bob@example.com"}
+	inactive.ID = 2
+	if err := active.SetPassword("password"); err != nil {
+		t.Fatal(err)
+	}
+
+	requests := []struct {
+		name string
+		call func(a *App, email string) error
+	}{
+		{"password reset", func(a *App, email string) error { return a.RequestPasswordReset(context.Background(), email) }},
+		{"verification resend", func(a *App, email string) error { return a.ResendVerificationEmail(context.Background(), email) }},
+	}
+	for _, request := range requests {
+		t.Run(request.name, func(t *testing.T) {
+			mailer := &failingMailer{}
+			app := &App{userRepo: &fakeUserRepo{users: []*entity.User{active, inactive}}, mailer: mailer, jwtSecret: []byte("test-secret")}
+			for _, email := range []string{"alice@example.com", "bob@example.com", "nobody@example.com"} {
+				if err := request.call(app, email); err != nil {
+					t.Errorf("%s: err = %v, want the same success for every address", email, err)
+				}
+			}
+			if mailer.attempts != 1 {
+				t.Errorf("mailer called %d times, want once for the active account", mailer.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// 背景任務名稱，作為心跳與就緒檢查中的名稱
+const (
+	WorkerCleanup         = "cleanup"
+	WorkerOutboxRelay     = "outbox_relay"
+	WorkerWebhookDelivery = "webhook_delivery"
+	WorkerClickProcessor  = "click_processor"
+)
+
+// WorkerStatus 是背景任務最近一次心跳的狀態
+type WorkerStatus struct {
+	Name     string        `json:"name"`
+	LastBeat time.Time     `json:"last_beat"`
+	MaxAge   time.Duration `json:"-"`
+	Healthy  bool          `json:"healthy"`
+}
+
+// Heartbeats 記錄背景任務的心跳，用於判斷任務是否停滯
+type Heartbeats struct {
+	mu      sync.Mutex
+	workers map[string]*WorkerStatus
+}
+
+// NewHeartbeats 建立心跳記錄
+func NewHeartbeats() *Heartbeats {
+	return &Heartbeats{workers: make(map[string]*WorkerStatus)}
+}
+
+// Register 登記背景任務並視為剛發出心跳，超過 maxAge 沒有心跳即視為停滯
+func (h *Heartbeats) Register(name string, maxAge time.Duration) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.workers[name] = &WorkerStatus{Name: name, LastBeat: time.Now(), MaxAge: maxAge}
+}
+
+// Beat 記錄背景任務的心跳，未登記的任務忽略
+func (h *Heartbeats) Beat(name string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if w, ok := h.workers[name]; ok {
+		w.LastBeat = time.Now()
+	}
+}
+
+// Statuses 返回所有已登記任務在 now 時的狀態，依名稱排序
+func (h *Heartbeats) Statuses(now time.Time) []WorkerStatus {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	statuses := make([]WorkerStatus, 0, len(h.workers))
+	for _, w := range h.workers {
+		status := *w
+		status.Healthy = now.Sub(w.LastBeat) <= w.MaxAge
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
@@ -0,0 +1,42 @@
+package jobs
+
+import "time"
+
+// 清理任務名稱，作為監控指標的 task 標籤
+const (
+	TaskExpiredLinks      = "expired_links"
+	TaskOutboxEvents      = "outbox_events"
+	TaskWebhookDeliveries = "webhook_deliveries"
+	TaskLinkClicks        = "link_clicks"
+)
+
+// CleanupObserver 接收背景清理任務每次執行的結果，例如匯出為監控指標
+// deleted 是本次刪除的記錄數，err 不為 nil 表示本次執行失敗
+type CleanupObserver interface {
+	ObserveCleanup(task string, deleted int64, err error)
+}
+
+// Monitor 是交給背景任務的監控設定：清理結果交給 Cleanups，心跳記錄在 Heartbeats
+// 兩者皆可為 nil (例如未啟用監控指標)，零值的 Monitor 不做任何事
+type Monitor struct {
+	Cleanups   CleanupObserver
+	Heartbeats *Heartbeats
+}
+
+// ObserveCleanup 將清理結果交給 Cleanups
+func (m Monitor) ObserveCleanup(task string, deleted int64, err error) {
+	if m.Cleanups == nil {
+		return
+	}
+	m.Cleanups.ObserveCleanup(task, deleted, err)
+}
+
+// Register 登記背景任務的心跳，見 Heartbeats.Register
+func (m Monitor) Register(worker string, maxAge time.Duration) {
+	m.Heartbeats.Register(worker, maxAge)
+}
+
+// Beat 記錄背景任務的心跳
+func (m Monitor) Beat(worker string) {
+	m.Heartbeats.Beat(worker)
+}
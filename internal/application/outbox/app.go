@@ -0,0 +1,73 @@
+package outboxapp
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go_short/domain/event"
+	"go_short/internal/application/jobs"
+)
+
+// 背景任務設定
+const (
+	relayBatchSize  = 100                // 每次取得的 outbox 記錄筆數
+	relayInterval   = time.Second        // 檢查待發布記錄的間隔
+	pruneInterval   = time.Hour          // 清除已發布記錄的間隔
+	recordRetention = 7 * 24 * time.Hour // 已發布記錄的保留時間，供排查與重新發送
+	relayMaxIdle    = 2 * time.Minute    // 超過此時間沒有心跳即視為 relay 停滯
+)
+
+// App 負責在背景執行 outbox relay
+type App struct {
+	relay *event.Relay
+}
+
+// NewApp 創建 outbox 應用服務實例
+func NewApp(relay *event.Relay) *App {
+	return &App{relay: relay}
+}
+
+// Start 啟動背景任務：定期發布已提交的事件並清除過期的已發布記錄，清除結果與心跳交給 monitor
+func (a *App) Start(ctx context.Context, monitor jobs.Monitor) {
+	monitor.Register(jobs.WorkerOutboxRelay, relayMaxIdle)
+	slog.InfoContext(ctx, "Starting outbox relay...")
+	go func() {
+		defer slog.InfoContext(ctx, "Outbox relay stopped")
+		relayTicker := time.NewTicker(relayInterval)
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer relayTicker.Stop()
+		defer pruneTicker.Stop()
+		for {
+			select {
+			case <-relayTicker.C:
+				a.relayDue(ctx, monitor)
+			case <-pruneTicker.C:
+				deleted, err := a.relay.Prune(ctx, recordRetention)
+				monitor.ObserveCleanup(jobs.TaskOutboxEvents, deleted, err)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to prune outbox events", "error", err)
+				} else if deleted > 0 {
+					slog.InfoContext(ctx, "Pruned published outbox events", "deleted", deleted)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// relayDue 持續發布到期的記錄，直到沒有滿批的待發布記錄為止，每批發出一次心跳
+func (a *App) relayDue(ctx context.Context, monitor jobs.Monitor) {
+	for ctx.Err() == nil {
+		n, err := a.relay.RelayDue(ctx, relayBatchSize)
+		monitor.Beat(jobs.WorkerOutboxRelay)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to relay outbox events", "error", err)
+			return
+		}
+		if n < relayBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package planapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"go_short/domain/plan/entity"
+	"go_short/domain/plan/service"
+)
+
+// 方案用例的錯誤
+var (
+	ErrUserNotFound = errors.New("user not found")
+	ErrInternal     = errors.New("internal server error")
+)
+
+// rateLimitCacheTTL 是 API 限流查詢使用者方案的快取時間，變更方案後最多經過此時間才會生效
+const rateLimitCacheTTL = time.Minute
+
+type cachedRateLimit struct {
+	limit     int
+	expiresAt time.Time
+}
+
+// App 是方案與用量的應用服務
+type App struct {
+	quotaService *service.QuotaService
+
+	mu         sync.Mutex
+	rateLimits map[uint]cachedRateLimit
+	lastSweep  time.Time
+}
+
+// NewApp 創建方案應用服務實例
+func NewApp(quotaService *service.QuotaService) *App {
+	return &App{
+		quotaService: quotaService,
+		rateLimits:   make(map[uint]cachedRateLimit),
+	}
+}
+
+// ListPlans 列出所有方案及其配額
+func (a *App) ListPlans(ctx context.Context) ([]*entity.Plan, error) {
+	plans, err := a.quotaService.ListPlans(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing plans", "error", err)
+		return nil, ErrInternal
+	}
+	return plans, nil
+}
+
+// Usage 返回使用者的方案與本月用量
+func (a *App) Usage(ctx context.Context, userID uint) (*service.UsageReport, error) {
+	report, err := a.quotaService.Usage(ctx, userID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			return nil, ErrUserNotFound
+		}
+		slog.ErrorContext(ctx, "Error computing usage of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	return report, nil
+}
+
+// APIRateLimit 返回使用者方案的每分鐘 API 請求上限
+// 方案未設定或查詢失敗時返回 false，由呼叫端使用全域設定
+func (a *App) APIRateLimit(ctx context.Context, userID uint) (int, bool) {
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.rateLimits[userID]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.limit, cached.limit > 0
+	}
+
+	plan, err := a.quotaService.UserPlan(ctx, userID)
+	if err != nil {
+		if !errors.Is(err, service.ErrUserNotFound) {
+			slog.ErrorContext(ctx, "Error finding plan of user for rate limiting", "user_id", userID, "error", err)
+		}
+		return 0, false
+	}
+
+	a.mu.Lock()
+	// 定期清除過期的項目，避免快取隨使用者數量無限成長
+	if now.Sub(a.lastSweep) > rateLimitCacheTTL {
+		for id, entry := range a.rateLimits {
+			if now.After(entry.expiresAt) {
+				delete(a.rateLimits, id)
+			}
+		}
+		a.lastSweep = now
+	}
+	a.rateLimits[userID] = cachedRateLimit{limit: plan.APIRateLimit, expiresAt: now.Add(rateLimitCacheTTL)}
+	a.mu.Unlock()
+	return plan.APIRateLimit, plan.APIRateLimit > 0
+}
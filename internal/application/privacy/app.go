@@ -0,0 +1,342 @@
+package privacyapp
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	analyticsservice "go_short/domain/analytics/service"
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
+	identityentity "go_short/domain/identity/entity"
+	identityrepository "go_short/domain/identity/repository"
+	"go_short/domain/privacy/entity"
+	"go_short/domain/privacy/repository"
+	urlentity "go_short/domain/urlshortener/entity"
+	urlservice "go_short/domain/urlshortener/service"
+	workspaceservice "go_short/domain/workspace/service"
+)
+
+// 個人資料請求相關錯誤
+var (
+	ErrUserNotFound   = errors.New("user not found")
+	ErrReasonRequired = errors.New("an erasure reason is required")
+	ErrSelfErasure    = errors.New("administrators cannot erase their own account")
+	ErrInternal       = errors.New("internal server error")
+)
+
+// exportFormatVersion 是匯出檔案格式的版本，格式變更時遞增
+const exportFormatVersion = 2
+
+// maxReasonLength 與 erasure_records.reason 欄位長度一致
+const maxReasonLength = 500
+
+// Archive 是使用者個人資料的匯出檔 (zip)
+type Archive struct {
+	Filename string
+	Data     []byte
+}
+
+// ErasureLog 是清除稽核記錄與雜湊鏈的檢查結果
+type ErasureLog struct {
+	Records []*entity.ErasureRecord `json:"records"`
+	Valid   bool                    `json:"valid"`
+	// BrokenAt 為第一筆雜湊不一致的記錄 ID，鏈結完整時省略
+	BrokenAt *uint `json:"broken_at,omitempty"`
+}
+
+// App 負責處理個人資料的查閱 (匯出) 與清除請求
+type App struct {
+	userRepo             identityrepository.UserRepository
+	apiKeyRepo           identityrepository.APIKeyRepository
+	externalIdentityRepo identityrepository.ExternalIdentityRepository
+	urlService           urlservice.URLShortenerService
+	domainService        *urlservice.DomainService
+	workspaceService     *workspaceservice.WorkspaceService
+	clickService         *analyticsservice.ClickService
+	erasureRepo          repository.ErasureRepository
+	audit                *auditservice.Recorder
+}
+
+// NewApp 創建個人資料應用服務實例
+func NewApp(userRepo identityrepository.UserRepository, apiKeyRepo identityrepository.APIKeyRepository, externalIdentityRepo identityrepository.ExternalIdentityRepository, urlService urlservice.URLShortenerService, domainService *urlservice.DomainService, workspaceService *workspaceservice.WorkspaceService, clickService *analyticsservice.ClickService, erasureRepo repository.ErasureRepository, audit *auditservice.Recorder) *App {
+	return &App{
+		userRepo:             userRepo,
+		apiKeyRepo:           apiKeyRepo,
+		externalIdentityRepo: externalIdentityRepo,
+		urlService:           urlService,
+		domainService:        domainService,
+		workspaceService:     workspaceService,
+		clickService:         clickService,
+		erasureRepo:          erasureRepo,
+		audit:                audit,
+	}
+}
+
+// apiKeyExport 是匯出檔中的 API 金鑰資訊 (只包含中繼資料，不含金鑰本身或雜湊值)
+type apiKeyExport struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+}
+
+// linkClickStats 是單一連結的點擊統計
+type linkClickStats struct {
+	LinkID    uint       `json:"link_id"`
+	ShortURL  *string    `json:"short_url"`
+	DomainID  *uint      `json:"domain_id,omitempty"`
+	Visits    int        `json:"visits"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ExportUserData 收集使用者的個人資料並打包為 zip 匯出檔
+func (a *App) ExportUserData(ctx context.Context, userID uint) (*Archive, error) {
+	user, err := a.findUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := a.urlService.ListUserURLMappings(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing links of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	domains, err := a.domainService.ListUserDomains(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing domains of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	keys, err := a.apiKeyRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing API keys of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	identities, err := a.externalIdentityRepo.FindByUserID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing external identities of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	workspaces, err := a.workspaceService.ListWorkspaces(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing workspaces of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	clicks, err := a.clickService.ListUserClicks(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing clicks of user for export", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	now := time.Now().UTC()
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", user},
+		{"links.json", links},
+		{"click_stats.json", clickStats(links)},
+		{"clicks.json", clicks},
+		{"domains.json", domains},
+		{"api_keys.json", apiKeyExports(keys)},
+		{"external_identities.json", identities},
+		{"workspaces.json", workspaces},
+	}
+
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		names = append(names, f.name)
+	}
+	manifest := map[string]interface{}{
+		"format_version": exportFormatVersion,
+		"user_id":        user.ID,
+		"generated_at":   now,
+		"files":          names,
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if err := writeJSONFile(zw, "manifest.json", manifest, now); err != nil {
+		slog.ErrorContext(ctx, "Error writing export archive for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	for _, f := range files {
+		if err := writeJSONFile(zw, f.name, f.content, now); err != nil {
+			slog.ErrorContext(ctx, "Error writing export archive for user", "user_id", userID, "error", err)
+			return nil, ErrInternal
+		}
+	}
+	if err := zw.Close(); err != nil {
+		slog.ErrorContext(ctx, "Error closing export archive for user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	return &Archive{
+		Filename: fmt.Sprintf("go-short-export-%d-%s.zip", user.ID, now.Format("20060102T150405Z")),
+		Data:     buf.Bytes(),
+	}, nil
+}
+
+// EraseUserData 清除使用者的個人資料並寫入稽核記錄
+// 先透過領域服務刪除個人連結與網域以清除重定向緩存，再由儲存庫在單一交易中完成清除與記錄
+func (a *App) EraseUserData(ctx context.Context, actorID, userID uint, reason string) (*entity.ErasureRecord, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > maxReasonLength {
+		return nil, ErrReasonRequired
+	}
+	if actorID == userID {
+		return nil, ErrSelfErasure
+	}
+
+	// 已軟刪除的帳號查不到，其連結已在刪除時停用，直接交由儲存庫清除即可
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user for erasure", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if user != nil {
+		if err := a.evictUserContent(ctx, userID); err != nil {
+			slog.ErrorContext(ctx, "Error removing links and domains of user before erasure", "user_id", userID, "error", err)
+			return nil, ErrInternal
+		}
+	}
+
+	record := &entity.ErasureRecord{
+		SubjectID:   userID,
+		RequestedBy: actorID,
+		Reason:      reason,
+	}
+	if err := a.erasureRepo.EraseUser(ctx, userID, record); err != nil {
+		if errors.Is(err, repository.ErrSubjectNotFound) {
+			return nil, ErrUserNotFound
+		}
+		slog.ErrorContext(ctx, "Error erasing personal data of user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+
+	slog.InfoContext(ctx, "Personal data erased", "user_id", userID, "actor_id", actorID, "record_id", record.ID)
+	a.audit.Record(ctx, auditservice.Event{
+		ActorID:    &actorID,
+		Action:     "user.erase",
+		TargetType: auditentity.TargetUser,
+		TargetID:   strconv.FormatUint(uint64(userID), 10),
+	})
+	return record, nil
+}
+
+// ErasureLog 返回所有清除稽核記錄並檢查雜湊鏈是否完整
+func (a *App) ErasureLog(ctx context.Context) (*ErasureLog, error) {
+	records, err := a.erasureRepo.List(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error listing erasure records", "error", err)
+		return nil, ErrInternal
+	}
+
+	result := &ErasureLog{Records: records, Valid: true}
+	if broken := entity.VerifyChain(records); broken != 0 {
+		slog.WarnContext(ctx, "Erasure audit chain is broken", "record_id", broken)
+		result.Valid = false
+		result.BrokenAt = &broken
+	}
+	return result, nil
+}
+
+// evictUserContent 刪除使用者的個人連結與網域，同時清除對應的緩存
+func (a *App) evictUserContent(ctx context.Context, userID uint) error {
+	links, err := a.urlService.ListUserURLMappings(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, link := range links {
+		if err := a.urlService.DeleteURLMapping(ctx, link); err != nil {
+			return err
+		}
+	}
+
+	domains, err := a.domainService.ListUserDomains(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, domain := range domains {
+		if err := a.domainService.DeleteDomain(ctx, domain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *App) findUser(ctx context.Context, userID uint) (*identityentity.User, error) {
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user", "user_id", userID, "error", err)
+		return nil, ErrInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+func clickStats(links []*urlentity.URLMapping) map[string]interface{} {
+	stats := make([]linkClickStats, 0, len(links))
+	total := 0
+	for _, link := range links {
+		total += link.Visits
+		stats = append(stats, linkClickStats{
+			LinkID:    link.ID,
+			ShortURL:  link.ShortURL,
+			DomainID:  link.DomainID,
+			Visits:    link.Visits,
+			CreatedAt: link.CreatedAt,
+			ExpiresAt: link.ExpiresAt,
+		})
+	}
+	return map[string]interface{}{
+		"total_visits": total,
+		"links":        stats,
+	}
+}
+
+func apiKeyExports(keys []*identityentity.APIKey) []apiKeyExport {
+	exports := make([]apiKeyExport, 0, len(keys))
+	for _, key := range keys {
+		exports = append(exports, apiKeyExport{
+			ID:         key.ID,
+			Name:       key.Name,
+			Prefix:     key.Prefix,
+			Scopes:     key.ScopeList(),
+			CreatedAt:  key.CreatedAt,
+			ExpiresAt:  key.ExpiresAt,
+			LastUsedAt: key.LastUsedAt,
+			RevokedAt:  key.RevokedAt,
+		})
+	}
+	return exports
+}
+
+// writeJSONFile 將內容以縮排 JSON 寫入 zip 中的檔案
+func writeJSONFile(zw *zip.Writer, name string, content interface{}, modified time.Time) error {
+	w, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: modified,
+	})
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(content)
+}
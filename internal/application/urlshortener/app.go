@@ -2,22 +2,107 @@ package urlshortenerapp
 
 import (
 	"context"
-	"log"
+	"errors"
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
+	planservice "go_short/domain/plan/service"
+	"go_short/domain/urlshortener/entity"
 	"go_short/domain/urlshortener/service"
+	workspaceentity "go_short/domain/workspace/entity"
+	workspaceservice "go_short/domain/workspace/service"
+	"go_short/internal/application/jobs"
 )
 
+// 應用層錯誤
+var (
+	ErrLinkNotFound    = errors.New("link not found")
+	ErrForbidden       = errors.New("permission denied")
+	ErrInvalidTarget   = errors.New("transfer requires exactly one of to_user_id or to_workspace_id")
+	ErrTargetNotMember = errors.New("target user is not a member of a shared workspace")
+	ErrDomainNotShared = errors.New("link's custom domain does not belong to the transfer target")
+	ErrBlockedDomain   = errors.New("destination domain is blocked")
+)
+
+// CreateLinkInput 是建立連結用例的輸入
+type CreateLinkInput struct {
+	URL         string
+	Algorithm   string
+	ExpiresIn   *time.Duration
+	Domain      string // 自訂網域主機名稱，空字串表示預設網域
+	WorkspaceID *uint  // 建立在工作區中 (需為 editor 以上)
+	Alias       string // 自訂短碼 (需登入，計入方案的自訂短碼配額)
+}
+
+// UpdateLinkInput 是修改連結用例的輸入，nil 欄位表示不修改
+type UpdateLinkInput struct {
+	OriginalURL *string
+	ExpiresIn   *time.Duration
+	ClearExpiry bool // 移除過期時間
+}
+
+// TransferTarget 指定連結轉移的目標，UserID 與 WorkspaceID 必須擇一
+type TransferTarget struct {
+	UserID      *uint
+	WorkspaceID *uint
+}
+
 // App 是 URL 縮短服務的應用層
 type App struct {
-	URLService service.URLShortenerService // 依賴 Domain Service Interface
+	URLService       service.URLShortenerService // 依賴 Domain Service Interface
+	DomainService    *service.DomainService
+	workspaceService *workspaceservice.WorkspaceService
+	quotaService     *planservice.QuotaService
+	audit            *auditservice.Recorder
+	// blockedDomains 是目標網址的封鎖清單，可在執行中替換
+	blockedDomains atomic.Pointer[[]string]
 }
 
-// NewApp 創建應用服務實例，接收 Service Interface 作為依賴
-func NewApp(urlService service.URLShortenerService /*, urlRepo repository.URLRepository, cacheRepo repository.CacheRepository*/) *App {
+// NewApp 創建應用服務實例，接收 Service 作為依賴
+func NewApp(urlService service.URLShortenerService, domainService *service.DomainService, workspaceService *workspaceservice.WorkspaceService, quotaService *planservice.QuotaService, audit *auditservice.Recorder) *App {
 	return &App{
-		URLService: urlService,
+		URLService:       urlService,
+		DomainService:    domainService,
+		workspaceService: workspaceService,
+		quotaService:     quotaService,
+		audit:            audit,
+	}
+}
+
+// SetBlockedDomains 替換目標網址的封鎖清單，封鎖的網域及其子網域無法作為連結的目標
+func (app *App) SetBlockedDomains(domains []string) {
+	blocked := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		if domain = strings.Trim(strings.ToLower(strings.TrimSpace(domain)), "."); domain != "" {
+			blocked = append(blocked, domain)
+		}
+	}
+	app.blockedDomains.Store(&blocked)
+}
+
+// checkDestination 檢查目標網址的主機是否在封鎖清單中
+func (app *App) checkDestination(rawURL string) error {
+	blocked := app.blockedDomains.Load()
+	if blocked == nil || len(*blocked) == 0 {
+		return nil
 	}
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return nil
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	for _, domain := range *blocked {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return ErrBlockedDomain
+		}
+	}
+	return nil
 }
 
 // GetURLService 返回 URL 服務 (現在只是返回注入的 service)
@@ -30,27 +115,319 @@ func (app *App) GetURLService() service.URLShortenerService {
 func (app *App) InitDatabase() error {
 	// 如果 Service 需要 DB 連接，可以透過 Service 的方法檢查
 	// 或者直接移除此方法，讓 bootstrap 或 infra 處理連接檢查
-	log.Println("Database initialization/check responsibility moved.")
+	slog.Info("Database initialization/check responsibility moved")
+	return nil
+}
+
+// --- 連結用例 ---
+
+// CreateLink 建立短連結；actorID 為 nil 時為匿名建立，不可指定工作區、自訂網域或自訂短碼
+// 已登入的使用者建立的連結 (含工作區連結) 計入其方案的配額
+func (app *App) CreateLink(ctx context.Context, actorID *uint, input CreateLinkInput) (*entity.URLMapping, error) {
+	opts := service.CreateOptions{
+		ExpiresIn:   input.ExpiresIn,
+		UserID:      actorID,
+		WorkspaceID: input.WorkspaceID,
+		Alias:       input.Alias,
+	}
+
+	if input.Alias != "" && actorID == nil {
+		return nil, ErrForbidden
+	}
+	if err := app.checkDestination(input.URL); err != nil {
+		return nil, err
+	}
+
+	if input.WorkspaceID != nil {
+		if actorID == nil {
+			return nil, ErrForbidden
+		}
+		if err := app.authorizeWorkspace(ctx, *actorID, *input.WorkspaceID, workspaceentity.RoleEditor); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.Domain != "" {
+		if actorID == nil {
+			return nil, ErrForbidden
+		}
+		domain, err := app.findLinkDomain(ctx, *actorID, input.WorkspaceID, input.Domain)
+		if err != nil {
+			return nil, err
+		}
+		opts.DomainID = &domain.ID
+	}
+
+	if actorID != nil {
+		if err := app.quotaService.CheckLinkCreation(ctx, *actorID, input.Alias != ""); err != nil {
+			return nil, err
+		}
+	}
+
+	mapping, err := app.URLService.CreateShortURL(ctx, input.URL, input.Algorithm, opts)
+	if err != nil {
+		return nil, err
+	}
+	app.recordLink(ctx, actorID, "link.create", nil, mapping)
+	return mapping, nil
+}
+
+// ListLinks 列出使用者的個人連結，或指定工作區的連結 (需為成員)
+func (app *App) ListLinks(ctx context.Context, actorID uint, workspaceID *uint) ([]*entity.URLMapping, error) {
+	if workspaceID != nil {
+		if err := app.authorizeWorkspace(ctx, actorID, *workspaceID, workspaceentity.RoleViewer); err != nil {
+			return nil, err
+		}
+		return app.URLService.ListWorkspaceURLMappings(ctx, *workspaceID)
+	}
+	return app.URLService.ListUserURLMappings(ctx, actorID)
+}
+
+// GetLink 獲取單一連結 (需有查看權限)
+func (app *App) GetLink(ctx context.Context, actorID uint, linkID uint) (*entity.URLMapping, error) {
+	return app.loadLink(ctx, actorID, linkID, workspaceentity.RoleViewer)
+}
+
+// UpdateLink 修改連結的目標網址或過期時間 (需有編輯權限)
+func (app *App) UpdateLink(ctx context.Context, actorID uint, linkID uint, input UpdateLinkInput) (*entity.URLMapping, error) {
+	mapping, err := app.loadLink(ctx, actorID, linkID, workspaceentity.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	before := *mapping
+
+	if input.OriginalURL != nil {
+		if err := app.checkDestination(*input.OriginalURL); err != nil {
+			return nil, err
+		}
+		mapping.OriginalURL = *input.OriginalURL
+	}
+	if input.ClearExpiry {
+		mapping.ExpiresAt = nil
+	} else if input.ExpiresIn != nil {
+		expiresAt := time.Now().Add(*input.ExpiresIn)
+		mapping.ExpiresAt = &expiresAt
+	}
+
+	if err := app.URLService.UpdateURLMapping(ctx, mapping); err != nil {
+		return nil, err
+	}
+	app.recordLink(ctx, &actorID, "link.update", &before, mapping)
+	return mapping, nil
+}
+
+// DeleteLink 刪除連結 (需有編輯權限)
+func (app *App) DeleteLink(ctx context.Context, actorID uint, linkID uint) error {
+	mapping, err := app.loadLink(ctx, actorID, linkID, workspaceentity.RoleEditor)
+	if err != nil {
+		return err
+	}
+	if err := app.URLService.DeleteURLMapping(ctx, mapping); err != nil {
+		return err
+	}
+	app.recordLink(ctx, &actorID, "link.delete", mapping, nil)
+	return nil
+}
+
+// TransferLink 將連結轉移給另一位成員或另一個工作區
+//   - 轉移給使用者：工作區連結的目標須為同工作區成員；個人連結的目標須與操作者同屬某個工作區
+//   - 轉移給工作區：操作者在目標工作區中須為 editor 以上
+//
+// 轉移給使用者後連結成為其個人連結；使用自訂網域的連結只能轉移給擁有該已驗證網域的使用者或工作區
+func (app *App) TransferLink(ctx context.Context, actorID uint, linkID uint, target TransferTarget) (*entity.URLMapping, error) {
+	if (target.UserID == nil) == (target.WorkspaceID == nil) {
+		return nil, ErrInvalidTarget
+	}
+
+	mapping, err := app.loadLink(ctx, actorID, linkID, workspaceentity.RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+	before := *mapping
+
+	if target.WorkspaceID != nil {
+		if err := app.authorizeWorkspace(ctx, actorID, *target.WorkspaceID, workspaceentity.RoleEditor); err != nil {
+			return nil, err
+		}
+		if err := app.checkTransferDomain(ctx, mapping, nil, target.WorkspaceID); err != nil {
+			return nil, err
+		}
+		mapping.WorkspaceID = target.WorkspaceID
+	} else {
+		var allowed bool
+		if mapping.WorkspaceID != nil {
+			allowed, err = app.workspaceService.IsMember(ctx, *target.UserID, *mapping.WorkspaceID)
+		} else {
+			allowed, err = app.workspaceService.SharesWorkspace(ctx, actorID, *target.UserID)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, ErrTargetNotMember
+		}
+		if err := app.checkTransferDomain(ctx, mapping, target.UserID, nil); err != nil {
+			return nil, err
+		}
+		mapping.UserID = target.UserID
+		mapping.WorkspaceID = nil
+	}
+
+	if err := app.URLService.UpdateURLMapping(ctx, mapping); err != nil {
+		return nil, err
+	}
+	app.recordLink(ctx, &actorID, "link.transfer", &before, mapping)
+	return mapping, nil
+}
+
+// checkTransferDomain 檢查使用自訂網域的連結在轉移後，其網域仍屬於新的擁有者且已通過驗證
+func (app *App) checkTransferDomain(ctx context.Context, mapping *entity.URLMapping, userID *uint, workspaceID *uint) error {
+	if mapping.DomainID == nil {
+		return nil
+	}
+	domain, err := app.DomainService.FindByID(ctx, *mapping.DomainID)
+	if err != nil {
+		if errors.Is(err, service.ErrDomainNotFound) {
+			return ErrDomainNotShared
+		}
+		return err
+	}
+	var owned bool
+	if workspaceID != nil {
+		owned = domain.WorkspaceID != nil && *domain.WorkspaceID == *workspaceID
+	} else {
+		owned = domain.IsOwnedBy(*userID)
+	}
+	if !owned || !domain.IsVerified() {
+		return ErrDomainNotShared
+	}
+	return nil
+}
+
+// ReleaseUserLinks 在帳號刪除時處理使用者的個人連結 (工作區連結仍屬於工作區)
+// transferTo 為 nil 時停用所有連結，否則轉移給該使用者
+func (app *App) ReleaseUserLinks(ctx context.Context, userID uint, transferTo *uint) error {
+	mappings, err := app.URLService.ListUserURLMappings(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, mapping := range mappings {
+		before := *mapping
+		action := "link.transfer"
+		if transferTo != nil {
+			mapping.UserID = transferTo
+			err = app.URLService.UpdateURLMapping(ctx, mapping)
+		} else {
+			action = "link.disable"
+			err = app.URLService.SetURLMappingDisabled(ctx, mapping, true)
+		}
+		if err != nil {
+			return err
+		}
+		app.recordLink(ctx, &userID, action, &before, mapping)
+	}
+	return nil
+}
+
+// ReleaseUserDomains 在帳號刪除時移除使用者的個人網域 (工作區網域仍屬於工作區)，主機名稱可由他人重新申請
+func (app *App) ReleaseUserDomains(ctx context.Context, userID uint) error {
+	domains, err := app.DomainService.ListUserDomains(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, domain := range domains {
+		if err := app.DomainService.DeleteDomain(ctx, domain); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// StartCleanupTask 啟動背景任務
-func (app *App) StartCleanupTask(ctx context.Context) {
+// recordLink 將連結的變更寫入稽核記錄
+func (app *App) recordLink(ctx context.Context, actorID *uint, action string, before, after *entity.URLMapping) {
+	target := after
+	if target == nil {
+		target = before
+	}
+	app.audit.Record(ctx, auditservice.Event{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: auditentity.TargetLink,
+		TargetID:   strconv.FormatUint(uint64(target.ID), 10),
+		Before:     before,
+		After:      after,
+	})
+}
+
+// --- 網域用例 ---
+
+// RegisterDomain 註冊個人網域，或在工作區中註冊網域 (需為 owner)，計入註冊者方案的網域配額
+func (app *App) RegisterDomain(ctx context.Context, actorID uint, workspaceID *uint, host string, fallbackURL *string) (*entity.Domain, error) {
+	if workspaceID != nil {
+		if err := app.authorizeWorkspace(ctx, actorID, *workspaceID, workspaceentity.RoleOwner); err != nil {
+			return nil, err
+		}
+	}
+	if err := app.quotaService.CheckDomainCreation(ctx, actorID); err != nil {
+		return nil, err
+	}
+	return app.DomainService.RegisterDomain(ctx, actorID, workspaceID, host, fallbackURL)
+}
+
+// ListDomains 列出使用者的個人網域，或指定工作區的網域 (需為成員)
+func (app *App) ListDomains(ctx context.Context, actorID uint, workspaceID *uint) ([]*entity.Domain, error) {
+	if workspaceID != nil {
+		if err := app.authorizeWorkspace(ctx, actorID, *workspaceID, workspaceentity.RoleViewer); err != nil {
+			return nil, err
+		}
+		return app.DomainService.ListWorkspaceDomains(ctx, *workspaceID)
+	}
+	return app.DomainService.ListUserDomains(ctx, actorID)
+}
+
+// VerifyDomain 執行網域的 DNS 驗證 (需有管理權限)
+func (app *App) VerifyDomain(ctx context.Context, actorID uint, domainID uint) (*entity.Domain, error) {
+	domain, err := app.loadDomain(ctx, actorID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return app.DomainService.VerifyDomain(ctx, domain)
+}
+
+// UpdateDomainFallback 更新網域的 fallback URL (需有管理權限)
+func (app *App) UpdateDomainFallback(ctx context.Context, actorID uint, domainID uint, fallbackURL *string) (*entity.Domain, error) {
+	domain, err := app.loadDomain(ctx, actorID, domainID)
+	if err != nil {
+		return nil, err
+	}
+	return app.DomainService.UpdateFallbackURL(ctx, domain, fallbackURL)
+}
+
+// DeleteDomain 刪除網域 (需有管理權限)
+func (app *App) DeleteDomain(ctx context.Context, actorID uint, domainID uint) error {
+	domain, err := app.loadDomain(ctx, actorID, domainID)
+	if err != nil {
+		return err
+	}
+	return app.DomainService.DeleteDomain(ctx, domain)
+}
+
+// --- 背景任務 ---
+
+// StartCleanupTask 啟動背景任務，每隔 interval 清理過期連結，每次清理的結果與心跳交給 monitor
+func (app *App) StartCleanupTask(ctx context.Context, interval time.Duration, monitor jobs.Monitor) {
 	// 這個邏輯可以保留在 App 層，因為它協調了 Service 的操作
-	ticker := time.NewTicker(1 * time.Hour)
-	log.Println("Starting background cleanup task...")
+	ticker := time.NewTicker(interval)
+	monitor.Register(jobs.WorkerCleanup, 2*interval)
+	slog.InfoContext(ctx, "Starting background cleanup task...")
 	go func() {
-		defer log.Println("Background cleanup task stopped.")
+		defer slog.InfoContext(ctx, "Background cleanup task stopped")
 		for {
 			select {
 			case <-ticker.C:
-				log.Println("Running expired URLs cleanup...")
-				// 呼叫注入的 Service
-				if err := app.URLService.CleanupExpiredURLs(ctx); err != nil {
-					log.Printf("Failed to cleanup expired URLs: %v", err)
-				} else {
-					log.Println("Expired URLs cleanup completed successfully")
-				}
+				app.RunCleanup(ctx, monitor)
+				monitor.Beat(jobs.WorkerCleanup)
 			case <-ctx.Done():
 				ticker.Stop()
 				return
@@ -58,3 +435,109 @@ func (app *App) StartCleanupTask(ctx context.Context) {
 		}
 	}()
 }
+
+// RunCleanup 清理一次過期連結並返回刪除的數量，結果交給 monitor
+func (app *App) RunCleanup(ctx context.Context, monitor jobs.Monitor) (int64, error) {
+	slog.InfoContext(ctx, "Running expired URLs cleanup...")
+	// 呼叫注入的 Service
+	deleted, err := app.URLService.CleanupExpiredURLs(ctx)
+	monitor.ObserveCleanup(jobs.TaskExpiredLinks, deleted, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to cleanup expired URLs", "error", err)
+		return deleted, err
+	}
+	slog.InfoContext(ctx, "Expired URLs cleanup completed successfully", "deleted", deleted)
+	return deleted, nil
+}
+
+// --- 授權輔助函式 ---
+
+// loadLink 載入連結並檢查權限；無查看權限時視為不存在，避免洩漏連結資訊
+func (app *App) loadLink(ctx context.Context, actorID uint, linkID uint, min workspaceentity.Role) (*entity.URLMapping, error) {
+	mapping, err := app.URLService.GetURLMapping(ctx, linkID)
+	if err != nil {
+		if errors.Is(err, service.ErrURLNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+	if err := app.authorize(ctx, actorID, mapping.UserID, mapping.WorkspaceID, workspaceentity.RoleViewer); err != nil {
+		return nil, ErrLinkNotFound
+	}
+	if err := app.authorize(ctx, actorID, mapping.UserID, mapping.WorkspaceID, min); err != nil {
+		return nil, err
+	}
+	return mapping, nil
+}
+
+// findLinkDomain 找出可用來建立連結的已驗證網域，使用者需能在網域的擁有者範圍內編輯連結
+// 同一主機可有多筆申請，未驗證時只回報使用者自己 (或工作區) 的申請，其他人較早的申請不影響結果
+func (app *App) findLinkDomain(ctx context.Context, actorID uint, workspaceID *uint, host string) (*entity.Domain, error) {
+	domain, err := app.DomainService.FindByHost(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	if domain.IsVerified() {
+		if err := app.authorize(ctx, actorID, domain.UserID, domain.WorkspaceID, workspaceentity.RoleEditor); err != nil {
+			return nil, service.ErrDomainNotFound
+		}
+		return domain, nil
+	}
+
+	claims, err := app.DomainService.ListUserDomains(ctx, actorID)
+	if err != nil {
+		return nil, err
+	}
+	if workspaceID != nil {
+		workspaceClaims, err := app.DomainService.ListWorkspaceDomains(ctx, *workspaceID)
+		if err != nil {
+			return nil, err
+		}
+		claims = append(claims, workspaceClaims...)
+	}
+	for _, claim := range claims {
+		if claim.Host == domain.Host {
+			return nil, service.ErrDomainNotVerified
+		}
+	}
+	return nil, service.ErrDomainNotFound
+}
+
+// loadDomain 載入網域並檢查管理權限 (個人網域的擁有者或工作區 owner)
+func (app *App) loadDomain(ctx context.Context, actorID uint, domainID uint) (*entity.Domain, error) {
+	domain, err := app.DomainService.FindByID(ctx, domainID)
+	if err != nil {
+		return nil, err
+	}
+	if err := app.authorize(ctx, actorID, domain.UserID, domain.WorkspaceID, workspaceentity.RoleViewer); err != nil {
+		return nil, service.ErrDomainNotFound
+	}
+	if err := app.authorize(ctx, actorID, domain.UserID, domain.WorkspaceID, workspaceentity.RoleOwner); err != nil {
+		return nil, err
+	}
+	return domain, nil
+}
+
+// authorize 檢查使用者對某資源的權限：工作區資源依成員角色判斷，個人資源僅擁有者可存取
+func (app *App) authorize(ctx context.Context, actorID uint, ownerID *uint, workspaceID *uint, min workspaceentity.Role) error {
+	if workspaceID != nil {
+		return app.authorizeWorkspace(ctx, actorID, *workspaceID, min)
+	}
+	if ownerID != nil && *ownerID == actorID {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// authorizeWorkspace 檢查使用者在工作區中的角色，並將領域錯誤轉為應用層錯誤
+func (app *App) authorizeWorkspace(ctx context.Context, actorID uint, workspaceID uint, min workspaceentity.Role) error {
+	if _, err := app.workspaceService.Authorize(ctx, actorID, workspaceID, min); err != nil {
+		switch {
+		case errors.Is(err, workspaceservice.ErrWorkspaceNotFound), errors.Is(err, workspaceservice.ErrForbidden):
+			return ErrForbidden
+		default:
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,415 @@
+package urlshortenerapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	planentity "go_short/domain/plan/entity"
+	planrepository "go_short/domain/plan/repository"
+	planservice "go_short/domain/plan/service"
+	"go_short/domain/urlshortener/entity"
+	"go_short/domain/urlshortener/repository"
+	"go_short/domain/urlshortener/service"
+	workspaceentity "go_short/domain/workspace/entity"
+	workspacerepository "go_short/domain/workspace/repository"
+	workspaceservice "go_short/domain/workspace/service"
+)
+
+// fakeURLService 以記憶體保存連結，只實作應用層用到的方法
+type fakeURLService struct {
+	service.URLShortenerService
+	links   map[uint]*entity.URLMapping
+	created []service.CreateOptions
+}
+
+func (s *fakeURLService) CreateShortURL(ctx context.Context, originalURL string, algorithm string, opts service.CreateOptions) (*entity.URLMapping, error) {
+	s.created = append(s.created, opts)
+	mapping := &entity.URLMapping{OriginalURL: originalURL, UserID: opts.UserID, WorkspaceID: opts.WorkspaceID, DomainID: opts.DomainID}
+	mapping.ID = uint(len(s.links) + 1)
+	s.links[mapping.ID] = mapping
+	return mapping, nil
+}
+
+func (s *fakeURLService) GetURLMapping(ctx context.Context, id uint) (*entity.URLMapping, error) {
+	mapping, ok := s.links[id]
+	if !ok {
+		return nil, service.ErrURLNotFound
+	}
+	copied := *mapping
+	return &copied, nil
+}
+
+func (s *fakeURLService) UpdateURLMapping(ctx context.Context, mapping *entity.URLMapping) error {
+	s.links[mapping.ID] = mapping
+	return nil
+}
+
+// fakeDomainRepo 以記憶體保存網域，FindByHost 與 GORM 實作相同：已驗證的網域優先，其次是最早的申請
+type fakeDomainRepo struct {
+	repository.DomainRepository
+	domains []*entity.Domain
+}
+
+func (r *fakeDomainRepo) Create(ctx context.Context, domain *entity.Domain) error {
+	domain.ID = uint(len(r.domains) + 1)
+	r.domains = append(r.domains, domain)
+	return nil
+}
+
+func (r *fakeDomainRepo) FindByID(ctx context.Context, id uint) (*entity.Domain, error) {
+	for _, domain := range r.domains {
+		if domain.ID == id {
+			return domain, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDomainRepo) FindByHost(ctx context.Context, host string) (*entity.Domain, error) {
+	var found *entity.Domain
+	for _, domain := range r.domains {
+		if domain.Host != host {
+			continue
+		}
+		if domain.IsVerified() {
+			return domain, nil
+		}
+		if found == nil {
+			found = domain
+		}
+	}
+	return found, nil
+}
+
+func (r *fakeDomainRepo) FindByUserID(ctx context.Context, userID uint) ([]*entity.Domain, error) {
+	var domains []*entity.Domain
+	for _, domain := range r.domains {
+		if domain.IsOwnedBy(userID) {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+func (r *fakeDomainRepo) FindByWorkspaceID(ctx context.Context, workspaceID uint) ([]*entity.Domain, error) {
+	var domains []*entity.Domain
+	for _, domain := range r.domains {
+		if domain.WorkspaceID != nil && *domain.WorkspaceID == workspaceID {
+			domains = append(domains, domain)
+		}
+	}
+	return domains, nil
+}
+
+// fakeWorkspaceRepo 以 工作區 -> 使用者 -> 角色 記錄成員
+type fakeWorkspaceRepo struct {
+	workspacerepository.WorkspaceRepository
+	members map[uint]map[uint]workspaceentity.Role
+}
+
+func (r *fakeWorkspaceRepo) FindMembership(ctx context.Context, workspaceID, userID uint) (*workspaceentity.Membership, error) {
+	role, ok := r.members[workspaceID][userID]
+	if !ok {
+		return nil, nil
+	}
+	return &workspaceentity.Membership{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeWorkspaceRepo) SharesWorkspace(ctx context.Context, userID, otherUserID uint) (bool, error) {
+	for _, members := range r.members {
+		_, a := members[userID]
+		_, b := members[otherUserID]
+		if a && b {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakePlanRepo 讓所有使用者屬於同一個方案
+type fakePlanRepo struct {
+	planrepository.PlanRepository
+	plan *planentity.Plan
+}
+
+func (r *fakePlanRepo) FindByUserID(ctx context.Context, userID uint) (*planentity.Plan, error) {
+	return r.plan, nil
+}
+
+type fakeUsageRepo struct {
+	usage planentity.Usage
+}
+
+func (r *fakeUsageRepo) UserUsage(ctx context.Context, userID uint, periodStart time.Time) (*planentity.Usage, error) {
+	usage := r.usage
+	return &usage, nil
+}
+
+// testApp 是以假儲存庫組成的應用層
+type testApp struct {
+	*App
+	links      *fakeURLService
+	domains    *fakeDomainRepo
+	workspaces *fakeWorkspaceRepo
+	usage      *fakeUsageRepo
+}
+
+func newTestApp(plan *planentity.Plan) *testApp {
+	links := &fakeURLService{links: make(map[uint]*entity.URLMapping)}
+	domains := &fakeDomainRepo{}
+	workspaces := &fakeWorkspaceRepo{members: make(map[uint]map[uint]workspaceentity.Role)}
+	usage := &fakeUsageRepo{}
+	app := NewApp(
+		links,
+		service.NewDomainService(domains, nil, nil),
+		workspaceservice.NewWorkspaceService(workspaces, nil),
+		planservice.NewQuotaService(&fakePlanRepo{plan: plan}, usage, ""),
+		nil,
+	)
+	return &testApp{App: app, links: links, domains: domains, workspaces: workspaces, usage: usage}
+}
+
+func (a *testApp) addMember(workspaceID, userID uint, role workspaceentity.Role) {
+	if a.workspaces.members[workspaceID] == nil {
+		a.workspaces.members[workspaceID] = make(map[uint]workspaceentity.Role)
+	}
+	a.workspaces.members[workspaceID][userID] = role
+}
+
+func limit(n int) *int {
+	return &n
+}
+
+func uintPtr(n uint) *uint {
+	return &n
+}
+
+func TestCreateLinkEnforcesQuota(t *testing.T) {
+	app := newTestApp(&planentity.Plan{Name: planentity.PlanFree, MaxLinks: limit(2), MaxCustomAliases: limit(1)})
+	ctx := context.Background()
+
+	app.usage.usage = planentity.Usage{Links: 1, CustomAliases: 1}
+	if _, err := app.CreateLink(ctx, uintPtr(1), CreateLinkInput{URL: "https://example.com"}); err != nil {
+		t.Fatalf("CreateLink under the quota: %v", err)
+	}
+	_, err := app.CreateLink(ctx, uintPtr(1), CreateLinkInput{URL: "https://example.com", Alias: "launch"})
+	var exceeded *planservice.QuotaExceededError
+	if !errors.As(err, &exceeded) || exceeded.Quota != planentity.QuotaCustomAliases {
+		t.Fatalf("alias over quota: err = %v, want the custom_aliases quota", err)
+	}
+
+	app.usage.usage = planentity.Usage{Links: 2}
+	if _, err := app.CreateLink(ctx, uintPtr(1), CreateLinkInput{URL: "https://example.com"}); !errors.Is(err, planservice.ErrQuotaExceeded) {
+		t.Fatalf("links over quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if len(app.links.created) != 1 {
+		t.Errorf("created %d links, want only the one under the quota", len(app.links.created))
+	}
+
+	// 匿名連結不屬於任何方案
+	if _, err := app.CreateLink(ctx, nil, CreateLinkInput{URL: "https://example.com"}); err != nil {
+		t.Fatalf("anonymous CreateLink: %v", err)
+	}
+}
+
+func TestRegisterDomainEnforcesQuota(t *testing.T) {
+	app := newTestApp(&planentity.Plan{Name: planentity.PlanFree, MaxCustomDomains: limit(1)})
+	ctx := context.Background()
+
+	if _, err := app.RegisterDomain(ctx, 1, nil, "go.example.com", nil); err != nil {
+		t.Fatalf("RegisterDomain under the quota: %v", err)
+	}
+	app.usage.usage = planentity.Usage{CustomDomains: 1}
+	if _, err := app.RegisterDomain(ctx, 1, nil, "links.example.com", nil); !errors.Is(err, planservice.ErrQuotaExceeded) {
+		t.Fatalf("RegisterDomain over the quota: err = %v, want ErrQuotaExceeded", err)
+	}
+	if len(app.domains.domains) != 1 {
+		t.Errorf("registered %d domains, want 1", len(app.domains.domains))
+	}
+}
+
+func TestTransferLink(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name          string
+		link          entity.URLMapping
+		domain        *entity.Domain
+		target        TransferTarget
+		wantErr       error
+		wantUser      uint
+		wantWorkspace *uint
+	}{
+		{
+			name:     "workspace link to a member becomes personal",
+			link:     entity.URLMapping{UserID: uintPtr(1), WorkspaceID: uintPtr(10)},
+			target:   TransferTarget{UserID: uintPtr(2)},
+			wantUser: 2,
+		},
+		{
+			name:    "workspace link to a non-member",
+			link:    entity.URLMapping{UserID: uintPtr(1), WorkspaceID: uintPtr(10)},
+			target:  TransferTarget{UserID: uintPtr(3)},
+			wantErr: ErrTargetNotMember,
+		},
+		{
+			name:          "personal link to a workspace",
+			link:          entity.URLMapping{UserID: uintPtr(1)},
+			target:        TransferTarget{WorkspaceID: uintPtr(10)},
+			wantUser:      1,
+			wantWorkspace: uintPtr(10),
+		},
+		{
+			name:    "personal link to a workspace the actor cannot edit",
+			link:    entity.URLMapping{UserID: uintPtr(1)},
+			target:  TransferTarget{WorkspaceID: uintPtr(20)},
+			wantErr: ErrForbidden,
+		},
+		{
+			name:    "link on a personal domain to a workspace",
+			link:    entity.URLMapping{UserID: uintPtr(1)},
+			domain:  &entity.Domain{UserID: uintPtr(1), VerifiedAt: &verifiedAt},
+			target:  TransferTarget{WorkspaceID: uintPtr(10)},
+			wantErr: ErrDomainNotShared,
+		},
+		{
+			name:          "link on the workspace's domain to that workspace",
+			link:          entity.URLMapping{UserID: uintPtr(1)},
+			domain:        &entity.Domain{UserID: uintPtr(1), WorkspaceID: uintPtr(10), VerifiedAt: &verifiedAt},
+			target:        TransferTarget{WorkspaceID: uintPtr(10)},
+			wantUser:      1,
+			wantWorkspace: uintPtr(10),
+		},
+		{
+			name:    "link on an unverified workspace domain",
+			link:    entity.URLMapping{UserID: uintPtr(1)},
+			domain:  &entity.Domain{UserID: uintPtr(1), WorkspaceID: uintPtr(10)},
+			target:  TransferTarget{WorkspaceID: uintPtr(10)},
+			wantErr: ErrDomainNotShared,
+		},
+		{
+			name:    "link on a workspace domain to a member",
+			link:    entity.URLMapping{UserID: uintPtr(1), WorkspaceID: uintPtr(10)},
+			domain:  &entity.Domain{UserID: uintPtr(1), WorkspaceID: uintPtr(10), VerifiedAt: &verifiedAt},
+			target:  TransferTarget{UserID: uintPtr(2)},
+			wantErr: ErrDomainNotShared,
+		},
+		{
+			name:    "both targets",
+			link:    entity.URLMapping{UserID: uintPtr(1)},
+			target:  TransferTarget{UserID: uintPtr(2), WorkspaceID: uintPtr(10)},
+			wantErr: ErrInvalidTarget,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(&planentity.Plan{Name: planentity.PlanFree})
+			app.addMember(10, 1, workspaceentity.RoleEditor)
+			app.addMember(10, 2, workspaceentity.RoleViewer)
+			app.addMember(20, 1, workspaceentity.RoleViewer)
+			link := tt.link
+			link.ID = 1
+			if tt.domain != nil {
+				app.domains.Create(context.Background(), tt.domain)
+				link.DomainID = &tt.domain.ID
+			}
+			app.links.links[1] = &link
+
+			mapping, err := app.TransferLink(context.Background(), 1, 1, tt.target)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if stored := app.links.links[1]; *stored.UserID != 1 || (stored.WorkspaceID == nil) != (tt.link.WorkspaceID == nil) {
+					t.Errorf("a rejected transfer changed the link: %+v", stored)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *mapping.UserID != tt.wantUser {
+				t.Errorf("user = %d, want %d", *mapping.UserID, tt.wantUser)
+			}
+			if (mapping.WorkspaceID == nil) != (tt.wantWorkspace == nil) || (mapping.WorkspaceID != nil && *mapping.WorkspaceID != *tt.wantWorkspace) {
+				t.Errorf("workspace = %v, want %v", mapping.WorkspaceID, tt.wantWorkspace)
+			}
+		})
+	}
+}
+
+func TestCreateLinkOnCustomDomain(t *testing.T) {
+	verifiedAt := time.Now()
+	tests := []struct {
+		name       string
+		domains    []*entity.Domain
+		workspace  *uint
+		wantErr    error
+		wantDomain uint
+	}{
+		{
+			name:       "own verified domain",
+			domains:    []*entity.Domain{{UserID: uintPtr(2)}, {UserID: uintPtr(1), VerifiedAt: &verifiedAt}},
+			wantDomain: 2,
+		},
+		{
+			name:    "another user's verified domain",
+			domains: []*entity.Domain{{UserID: uintPtr(1)}, {UserID: uintPtr(2), VerifiedAt: &verifiedAt}},
+			wantErr: service.ErrDomainNotFound,
+		},
+		{
+			name:    "own claim behind another user's earlier claim",
+			domains: []*entity.Domain{{UserID: uintPtr(2)}, {UserID: uintPtr(1)}},
+			wantErr: service.ErrDomainNotVerified,
+		},
+		{
+			name:    "only another user's claim",
+			domains: []*entity.Domain{{UserID: uintPtr(2)}},
+			wantErr: service.ErrDomainNotFound,
+		},
+		{
+			name:       "verified workspace domain",
+			domains:    []*entity.Domain{{UserID: uintPtr(2), WorkspaceID: uintPtr(10), VerifiedAt: &verifiedAt}},
+			workspace:  uintPtr(10),
+			wantDomain: 1,
+		},
+		{
+			name:      "workspace claim behind another user's earlier claim",
+			domains:   []*entity.Domain{{UserID: uintPtr(3)}, {UserID: uintPtr(2), WorkspaceID: uintPtr(10)}},
+			workspace: uintPtr(10),
+			wantErr:   service.ErrDomainNotVerified,
+		},
+		{
+			name:    "no claim",
+			wantErr: service.ErrDomainNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(&planentity.Plan{Name: planentity.PlanFree})
+			app.addMember(10, 1, workspaceentity.RoleEditor)
+			for _, domain := range tt.domains {
+				domain.Host = "go.example.com"
+				app.domains.Create(context.Background(), domain)
+			}
+
+			mapping, err := app.CreateLink(context.Background(), uintPtr(1), CreateLinkInput{URL: "https://example.com", Domain: "Go.Example.com", WorkspaceID: tt.workspace})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				if len(app.links.created) != 0 {
+					t.Error("no link may be created on a domain the user cannot use")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mapping.DomainID == nil || *mapping.DomainID != tt.wantDomain {
+				t.Errorf("domain = %v, want %d", mapping.DomainID, tt.wantDomain)
+			}
+		})
+	}
+}
@@ -0,0 +1,371 @@
+package webhookapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strconv"
+	"time"
+
+	auditentity "go_short/domain/audit/entity"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/event"
+	"go_short/domain/webhook/entity"
+	"go_short/domain/webhook/service"
+	workspaceentity "go_short/domain/workspace/entity"
+	workspaceservice "go_short/domain/workspace/service"
+	"go_short/internal/application/jobs"
+)
+
+// 應用層錯誤
+var (
+	ErrWebhookNotFound  = errors.New("webhook not found")
+	ErrDeliveryNotFound = errors.New("delivery not found")
+	ErrForbidden        = errors.New("permission denied")
+	ErrInternal         = errors.New("internal server error")
+)
+
+// 背景任務設定
+const (
+	eventQueueSize    = 1024            // 等待建立投遞的點擊事件緩衝區大小，滿了之後新事件會被丟棄
+	deliveryBatchSize = 20              // 每次取得的投遞筆數
+	deliveryInterval  = 2 * time.Second // 檢查到期投遞的間隔
+	pruneInterval     = time.Hour       // 清除舊投遞記錄的間隔
+	deliveryRetention = 30 * 24 * time.Hour
+	deliveryMaxIdle   = 5 * time.Minute // 超過此時間沒有心跳即視為投遞任務停滯
+	defaultPageSize   = 20
+	maxPageSize       = 100
+)
+
+// CreateWebhookInput 是建立 webhook 用例的輸入
+type CreateWebhookInput struct {
+	URL         string
+	Events      []string
+	Description string
+	WorkspaceID *uint // 建立為工作區 webhook (需為 owner)
+}
+
+// UpdateWebhookInput 是修改 webhook 用例的輸入，nil 欄位表示不修改
+type UpdateWebhookInput struct {
+	URL         *string
+	Events      []string
+	Description *string
+	Active      *bool
+}
+
+// DeliveryPage 是分頁的投遞記錄
+type DeliveryPage struct {
+	Deliveries []*entity.Delivery `json:"deliveries"`
+	Total      int64              `json:"total"`
+	Page       int                `json:"page"`
+	PageSize   int                `json:"page_size"`
+}
+
+// App 是 webhook 的應用層，負責管理端點並在背景將領域事件轉為投遞
+type App struct {
+	webhookService   *service.WebhookService
+	workspaceService *workspaceservice.WorkspaceService
+	audit            *auditservice.Recorder
+	queue            chan event.Event
+}
+
+// NewApp 創建 webhook 應用服務實例
+func NewApp(webhookService *service.WebhookService, workspaceService *workspaceservice.WorkspaceService, audit *auditservice.Recorder) *App {
+	return &App{
+		webhookService:   webhookService,
+		workspaceService: workspaceService,
+		audit:            audit,
+		queue:            make(chan event.Event, eventQueueSize),
+	}
+}
+
+// HandleEvent 是 outbox 事件的訂閱者，直接建立投遞記錄；失敗時由 outbox relay 稍後重新發送
+func (a *App) HandleEvent(ctx context.Context, e event.Event) error {
+	return a.webhookService.Enqueue(ctx, e)
+}
+
+// QueueEvent 是點擊事件的訂閱者，只將事件放入佇列，不阻塞發布事件的請求 (例如重定向)
+// 佇列已滿時丟棄事件
+func (a *App) QueueEvent(ctx context.Context, e event.Event) error {
+	select {
+	case a.queue <- e:
+	default:
+		slog.WarnContext(ctx, "Webhook event queue is full, dropping event", "event_type", e.EventType(), "event_id", e.EventID())
+	}
+	return nil
+}
+
+// Start 啟動背景任務：將佇列中的點擊事件轉為投遞、發送到期的投遞並定期清除舊記錄，清除結果與心跳交給 monitor
+func (a *App) Start(ctx context.Context, monitor jobs.Monitor) {
+	monitor.Register(jobs.WorkerWebhookDelivery, deliveryMaxIdle)
+	slog.InfoContext(ctx, "Starting webhook delivery worker...")
+
+	go func() {
+		for {
+			select {
+			case e := <-a.queue:
+				if err := a.webhookService.Enqueue(ctx, e); err != nil {
+					slog.ErrorContext(ctx, "Failed to enqueue webhook deliveries for event", "event_type", e.EventType(), "event_id", e.EventID(), "error", err)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		defer slog.InfoContext(ctx, "Webhook delivery worker stopped")
+		deliveryTicker := time.NewTicker(deliveryInterval)
+		pruneTicker := time.NewTicker(pruneInterval)
+		defer deliveryTicker.Stop()
+		defer pruneTicker.Stop()
+		for {
+			select {
+			case <-deliveryTicker.C:
+				a.deliverDue(ctx, monitor)
+			case <-pruneTicker.C:
+				deleted, err := a.webhookService.PruneDeliveries(ctx, deliveryRetention)
+				monitor.ObserveCleanup(jobs.TaskWebhookDeliveries, deleted, err)
+				if err != nil {
+					slog.ErrorContext(ctx, "Failed to prune webhook deliveries", "error", err)
+				} else if deleted > 0 {
+					slog.InfoContext(ctx, "Pruned old webhook deliveries", "deleted", deleted)
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// deliverDue 持續發送到期的投遞，直到沒有滿批的待發送投遞為止，每批發出一次心跳
+func (a *App) deliverDue(ctx context.Context, monitor jobs.Monitor) {
+	for ctx.Err() == nil {
+		n, err := a.webhookService.DeliverDue(ctx, deliveryBatchSize)
+		monitor.Beat(jobs.WorkerWebhookDelivery)
+		if err != nil {
+			slog.ErrorContext(ctx, "Failed to deliver webhooks", "error", err)
+			return
+		}
+		if n < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+// CreateWebhook 建立個人或工作區 webhook，返回的實體包含只在此時顯示的簽章密鑰
+func (a *App) CreateWebhook(ctx context.Context, actorID uint, input CreateWebhookInput) (*entity.Webhook, error) {
+	if input.WorkspaceID != nil {
+		if err := a.authorizeWorkspace(ctx, actorID, *input.WorkspaceID); err != nil {
+			return nil, err
+		}
+	}
+	webhook, err := a.webhookService.Register(ctx, actorID, input.WorkspaceID, input.URL, input.Events, input.Description)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	a.record(ctx, "webhook.create", webhook.ID, nil, webhook)
+	return webhook, nil
+}
+
+// ListWebhooks 列出使用者的個人 webhook，或指定工作區的 webhook (需為 owner)
+func (a *App) ListWebhooks(ctx context.Context, actorID uint, workspaceID *uint) ([]*entity.Webhook, error) {
+	var (
+		webhooks []*entity.Webhook
+		err      error
+	)
+	if workspaceID != nil {
+		if err := a.authorizeWorkspace(ctx, actorID, *workspaceID); err != nil {
+			return nil, err
+		}
+		webhooks, err = a.webhookService.ListWorkspaceWebhooks(ctx, *workspaceID)
+	} else {
+		webhooks, err = a.webhookService.ListUserWebhooks(ctx, actorID)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return webhooks, nil
+}
+
+// GetWebhook 獲取單一 webhook
+func (a *App) GetWebhook(ctx context.Context, actorID, webhookID uint) (*entity.Webhook, error) {
+	return a.loadWebhook(ctx, actorID, webhookID)
+}
+
+// UpdateWebhook 修改 webhook
+func (a *App) UpdateWebhook(ctx context.Context, actorID, webhookID uint, input UpdateWebhookInput) (*entity.Webhook, error) {
+	webhook, err := a.loadWebhook(ctx, actorID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	before := *webhook
+	if err := a.webhookService.Update(ctx, webhook, input.URL, input.Events, input.Description, input.Active); err != nil {
+		return nil, mapError(err)
+	}
+	a.record(ctx, "webhook.update", webhook.ID, &before, webhook)
+	return webhook, nil
+}
+
+// RotateSecret 產生新的簽章密鑰，返回的實體包含只在此時顯示的新密鑰
+func (a *App) RotateSecret(ctx context.Context, actorID, webhookID uint) (*entity.Webhook, error) {
+	webhook, err := a.loadWebhook(ctx, actorID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.webhookService.RotateSecret(ctx, webhook); err != nil {
+		return nil, mapError(err)
+	}
+	a.record(ctx, "webhook.rotate_secret", webhook.ID, nil, nil)
+	return webhook, nil
+}
+
+// DeleteWebhook 刪除 webhook
+func (a *App) DeleteWebhook(ctx context.Context, actorID, webhookID uint) error {
+	webhook, err := a.loadWebhook(ctx, actorID, webhookID)
+	if err != nil {
+		return err
+	}
+	if err := a.webhookService.Delete(ctx, webhook); err != nil {
+		return mapError(err)
+	}
+	a.record(ctx, "webhook.delete", webhook.ID, webhook, nil)
+	return nil
+}
+
+// DeactivateUserWebhooks 在帳號刪除時停用使用者的個人 webhook (工作區 webhook 仍屬於工作區)
+func (a *App) DeactivateUserWebhooks(ctx context.Context, userID uint) error {
+	webhooks, err := a.webhookService.ListUserWebhooks(ctx, userID)
+	if err != nil {
+		return mapError(err)
+	}
+	inactive := false
+	for _, webhook := range webhooks {
+		if !webhook.Active {
+			continue
+		}
+		before := *webhook
+		if err := a.webhookService.Update(ctx, webhook, nil, nil, nil, &inactive); err != nil {
+			return mapError(err)
+		}
+		a.record(ctx, "webhook.update", webhook.ID, &before, webhook)
+	}
+	return nil
+}
+
+// PingWebhook 排入一個測試事件，由背景任務發送
+func (a *App) PingWebhook(ctx context.Context, actorID, webhookID uint) (*entity.Delivery, error) {
+	webhook, err := a.loadWebhook(ctx, actorID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+	delivery, err := a.webhookService.EnqueuePing(ctx, webhook)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return delivery, nil
+}
+
+// ListDeliveries 分頁列出 webhook 的投遞記錄，status 可篩選 pending、succeeded 或 dead
+func (a *App) ListDeliveries(ctx context.Context, actorID, webhookID uint, status string, page, pageSize int) (*DeliveryPage, error) {
+	if _, err := a.loadWebhook(ctx, actorID, webhookID); err != nil {
+		return nil, err
+	}
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = defaultPageSize
+	}
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	deliveries, total, err := a.webhookService.ListDeliveries(ctx, webhookID, status, (page-1)*pageSize, pageSize)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	return &DeliveryPage{Deliveries: deliveries, Total: total, Page: page, PageSize: pageSize}, nil
+}
+
+// Redeliver 將 dead letter 重新排入佇列
+func (a *App) Redeliver(ctx context.Context, actorID, webhookID, deliveryID uint) (*entity.Delivery, error) {
+	if _, err := a.loadWebhook(ctx, actorID, webhookID); err != nil {
+		return nil, err
+	}
+	delivery, err := a.webhookService.FindDelivery(ctx, deliveryID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if delivery.WebhookID != webhookID {
+		return nil, ErrDeliveryNotFound
+	}
+	if err := a.webhookService.Redeliver(ctx, delivery); err != nil {
+		return nil, mapError(err)
+	}
+	return delivery, nil
+}
+
+// loadWebhook 載入 webhook 並檢查管理權限 (個人 webhook 的擁有者或工作區 owner)
+// 無權限時視為不存在，避免洩漏 webhook 資訊
+func (a *App) loadWebhook(ctx context.Context, actorID, webhookID uint) (*entity.Webhook, error) {
+	webhook, err := a.webhookService.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if webhook.WorkspaceID != nil {
+		if err := a.authorizeWorkspace(ctx, actorID, *webhook.WorkspaceID); err != nil {
+			return nil, ErrWebhookNotFound
+		}
+		return webhook, nil
+	}
+	if !webhook.IsOwnedBy(actorID) {
+		return nil, ErrWebhookNotFound
+	}
+	return webhook, nil
+}
+
+// authorizeWorkspace 檢查使用者是否為工作區 owner
+func (a *App) authorizeWorkspace(ctx context.Context, actorID, workspaceID uint) error {
+	if _, err := a.workspaceService.Authorize(ctx, actorID, workspaceID, workspaceentity.RoleOwner); err != nil {
+		switch {
+		case errors.Is(err, workspaceservice.ErrWorkspaceNotFound), errors.Is(err, workspaceservice.ErrForbidden):
+			return ErrForbidden
+		default:
+			slog.ErrorContext(ctx, "Error authorizing user in workspace", "user_id", actorID, "workspace_id", workspaceID, "error", err)
+			return ErrInternal
+		}
+	}
+	return nil
+}
+
+// record 將 webhook 的變更寫入稽核記錄 (簽章密鑰不會出現在差異中)
+func (a *App) record(ctx context.Context, action string, webhookID uint, before, after *entity.Webhook) {
+	event := auditservice.Event{
+		Action:     action,
+		TargetType: auditentity.TargetWebhook,
+		TargetID:   strconv.FormatUint(uint64(webhookID), 10),
+	}
+	if before != nil {
+		event.Before = before
+	}
+	if after != nil {
+		event.After = after
+	}
+	a.audit.Record(ctx, event)
+}
+
+// mapError 將 webhook 領域錯誤轉為應用層錯誤，驗證錯誤原樣返回
+func mapError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrWebhookNotFound):
+		return ErrWebhookNotFound
+	case errors.Is(err, service.ErrDeliveryNotFound):
+		return ErrDeliveryNotFound
+	case errors.Is(err, service.ErrInvalidURL), errors.Is(err, service.ErrInvalidEvents), errors.Is(err, service.ErrNotDead):
+		return err
+	default:
+		slog.Error("Webhook operation failed", "error", err)
+		return ErrInternal
+	}
+}
@@ -0,0 +1,88 @@
+package workspaceapp
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+
+	identityrepository "go_short/domain/identity/repository"
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/service"
+)
+
+// 應用層錯誤
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrEmailNotVerified = errors.New("verify your email address before accepting invitations")
+)
+
+// App 是 Workspace 領域的應用服務
+type App struct {
+	workspaceService *service.WorkspaceService
+	userRepo         identityrepository.UserRepository
+}
+
+// NewApp 創建 Workspace 應用服務實例
+func NewApp(workspaceService *service.WorkspaceService, userRepo identityrepository.UserRepository) *App {
+	return &App{
+		workspaceService: workspaceService,
+		userRepo:         userRepo,
+	}
+}
+
+// CreateWorkspace 建立工作區，建立者成為 owner
+func (a *App) CreateWorkspace(ctx context.Context, userID uint, name string) (*entity.Workspace, error) {
+	return a.workspaceService.CreateWorkspace(ctx, userID, name)
+}
+
+// ListWorkspaces 列出使用者所屬的工作區
+func (a *App) ListWorkspaces(ctx context.Context, userID uint) ([]*entity.Workspace, error) {
+	return a.workspaceService.ListWorkspaces(ctx, userID)
+}
+
+// GetWorkspace 獲取工作區詳情
+func (a *App) GetWorkspace(ctx context.Context, userID, workspaceID uint) (*entity.Workspace, error) {
+	return a.workspaceService.GetWorkspace(ctx, userID, workspaceID)
+}
+
+// ListMembers 列出工作區成員
+func (a *App) ListMembers(ctx context.Context, userID, workspaceID uint) ([]*entity.Membership, error) {
+	return a.workspaceService.ListMembers(ctx, userID, workspaceID)
+}
+
+// UpdateMemberRole 變更成員角色
+func (a *App) UpdateMemberRole(ctx context.Context, actorID, workspaceID, memberID uint, role entity.Role) (*entity.Membership, error) {
+	return a.workspaceService.UpdateMemberRole(ctx, actorID, workspaceID, memberID, role)
+}
+
+// RemoveMember 移除成員或自行離開工作區
+func (a *App) RemoveMember(ctx context.Context, actorID, workspaceID, memberID uint) error {
+	return a.workspaceService.RemoveMember(ctx, actorID, workspaceID, memberID)
+}
+
+// InviteMember 建立邀請並返回一次性的邀請 token
+func (a *App) InviteMember(ctx context.Context, actorID, workspaceID uint, email string, role entity.Role) (*entity.Invitation, string, error) {
+	return a.workspaceService.CreateInvitation(ctx, actorID, workspaceID, email, role)
+}
+
+// ListInvitations 列出待處理的邀請
+func (a *App) ListInvitations(ctx context.Context, actorID, workspaceID uint) ([]*entity.Invitation, error) {
+	return a.workspaceService.ListInvitations(ctx, actorID, workspaceID)
+}
+
+// AcceptInvitation 以目前使用者的身分接受邀請，邀請的電子郵件須與帳號相符且已驗證，
+// 否則任何人都能以他人的信箱註冊並接受寄給該信箱的邀請
+func (a *App) AcceptInvitation(ctx context.Context, userID uint, token string) (*entity.Membership, error) {
+	user, err := a.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		slog.ErrorContext(ctx, "Error finding user when accepting invitation", "user_id", userID, "error", err)
+		return nil, service.ErrServiceInternal
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.IsEmailVerified() {
+		return nil, ErrEmailNotVerified
+	}
+	return a.workspaceService.AcceptInvitation(ctx, user.ID, user.Email, token)
+}
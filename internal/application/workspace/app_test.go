@@ -0,0 +1,103 @@
+package workspaceapp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	identityentity "go_short/domain/identity/entity"
+	identityrepository "go_short/domain/identity/repository"
+	"go_short/domain/workspace/entity"
+	"go_short/domain/workspace/repository"
+	"go_short/domain/workspace/service"
+)
+
+type fakeUserRepo struct {
+	identityrepository.UserRepository
+	users map[uint]*identityentity.User
+}
+
+func (r *fakeUserRepo) FindByID(ctx context.Context, id uint) (*identityentity.User, error) {
+	return r.users[id], nil
+}
+
+// fakeWorkspaceRepo 以 使用者 -> 角色 記錄單一工作區的成員
+type fakeWorkspaceRepo struct {
+	repository.WorkspaceRepository
+	members map[uint]entity.Role
+}
+
+func (r *fakeWorkspaceRepo) FindMembership(ctx context.Context, workspaceID, userID uint) (*entity.Membership, error) {
+	role, ok := r.members[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &entity.Membership{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (r *fakeWorkspaceRepo) SaveMembership(ctx context.Context, membership *entity.Membership) error {
+	r.members[membership.UserID] = membership.Role
+	return nil
+}
+
+type fakeInvitationRepo struct {
+	repository.InvitationRepository
+	invitations []*entity.Invitation
+}
+
+func (r *fakeInvitationRepo) Create(ctx context.Context, invitation *entity.Invitation) error {
+	r.invitations = append(r.invitations, invitation)
+	return nil
+}
+
+func (r *fakeInvitationRepo) FindByTokenHash(ctx context.Context, tokenHash string) (*entity.Invitation, error) {
+	for _, invitation := range r.invitations {
+		if invitation.TokenHash == tokenHash {
+			return invitation, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeInvitationRepo) Update(ctx context.Context, invitation *entity.Invitation) error {
+	return nil
+}
+
+func TestAcceptInvitationRequiresVerifiedEmail(t *testing.T) {
+	now := time.Now()
+	users := &fakeUserRepo{users: map[uint]*identityentity.User{
+		2: {Email: "editor@example.com"},
+		3: {Email: "Editor@example.com", EmailVerifiedAt: &now},
+	}}
+	users.users[2].ID = 2
+	users.users[3].ID = 3
+	workspaces := &fakeWorkspaceRepo{members: map[uint]entity.Role{1: entity.RoleOwner}}
+	app := NewApp(service.NewWorkspaceService(workspaces, &fakeInvitationRepo{}), users)
+	ctx := context.Background()
+
+	_, token, err := app.InviteMember(ctx, 1, 7, "editor@example.com", entity.RoleEditor)
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+
+	// 以他人信箱註冊但未驗證的帳號不能接受邀請
+	if _, err := app.AcceptInvitation(ctx, 2, token); !errors.Is(err, ErrEmailNotVerified) {
+		t.Fatalf("unverified account: err = %v, want ErrEmailNotVerified", err)
+	}
+	if _, ok := workspaces.members[2]; ok {
+		t.Fatal("an unverified account must not join the workspace")
+	}
+
+	membership, err := app.AcceptInvitation(ctx, 3, token)
+	if err != nil {
+		t.Fatalf("verified account: %v", err)
+	}
+	if membership.UserID != 3 || membership.Role != entity.RoleEditor {
+		t.Errorf("membership = %+v", membership)
+	}
+
+	if _, err := app.AcceptInvitation(ctx, 4, token); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("missing user: err = %v, want ErrUserNotFound", err)
+	}
+}
@@ -2,28 +2,57 @@ package bootstrap
 
 import (
 	"context"
-	"log"
+	"fmt"
+	"io"
+	"log/slog"
 	"time"
 
 	"go_short/conf"
 	// Identity Domain Imports
 	// (如果需要在 bootstrap 中引用)
 
+	analyticsservice "go_short/domain/analytics/service"
+	auditservice "go_short/domain/audit/service"
+	"go_short/domain/event"
 	identityservice "go_short/domain/identity/service"
+	planservice "go_short/domain/plan/service"
+	urlshortenerrepository "go_short/domain/urlshortener/repository"
 	urlshortenerservice "go_short/domain/urlshortener/service"
+	webhookentity "go_short/domain/webhook/entity"
+	webhookservice "go_short/domain/webhook/service"
+	workspaceservice "go_short/domain/workspace/service"
 
 	// Infrastructure Imports
 	"go_short/infra/database"
+	"go_short/infra/dns"
+	"go_short/infra/eventsink"
+	"go_short/infra/health"
+	"go_short/infra/logging"
+	"go_short/infra/mailer"
+	"go_short/infra/metrics"
 	gormpersistence "go_short/infra/persistence/gorm"
 	redispersistence "go_short/infra/persistence/redis"
+	"go_short/infra/ratelimit"
+	"go_short/infra/tracing"
+	"go_short/infra/webhook"
 
 	// API Imports
 	"go_short/internal/api"
 	"go_short/internal/api/handler"
 
 	// Application Imports
+	adminapp "go_short/internal/application/admin"
+	analyticsapp "go_short/internal/application/analytics"
+	healthapp "go_short/internal/application/health"
 	identityapp "go_short/internal/application/identity"
+	"go_short/internal/application/jobs"
+	outboxapp "go_short/internal/application/outbox"
+	planapp "go_short/internal/application/plan"
+	privacyapp "go_short/internal/application/privacy"
 	urlshortenerapp "go_short/internal/application/urlshortener"
+	webhookapp "go_short/internal/application/webhook"
+	workspaceapp "go_short/internal/application/workspace"
+	"go_short/migrations"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
@@ -32,106 +61,434 @@ import (
 
 // Dependencies 包含應用程式啟動所需的所有依賴項
 type Dependencies struct {
-	Config      *conf.Config
-	DB          *gorm.DB
-	RedisClient *redis.Client
-	GinEngine   *gin.Engine
-	URLApp      *urlshortenerapp.App // URL Shortener Application instance
-	IdentityApp *identityapp.App     // Identity Application instance
-	UserHandler *handler.UserHandler // User Handler instance
-	URLHandler  *handler.URLHandler  // URL Handler instance (保持現有)
+	Config           *conf.Config  // 啟動時的設定
+	Settings         *conf.Watcher // 目前的設定，可熱更新
+	Logger           *slog.Logger
+	DB               *gorm.DB
+	Migrator         *database.Migrator // Embedded schema migrations
+	RedisClient      *redis.Client
+	GinEngine        *gin.Engine
+	URLApp           *urlshortenerapp.App      // URL Shortener Application instance
+	IdentityApp      *identityapp.App          // Identity Application instance
+	UserHandler      *handler.UserHandler      // User Handler instance
+	URLHandler       *handler.URLHandler       // URL Handler instance (保持現有)
+	DomainHandler    *handler.DomainHandler    // Domain Handler instance
+	WorkspaceApp     *workspaceapp.App         // Workspace Application instance
+	WorkspaceHandler *handler.WorkspaceHandler // Workspace Handler instance
+	AdminApp         *adminapp.App             // Admin Application instance
+	AdminHandler     *handler.AdminHandler     // Admin Handler instance
+	APIKeyHandler    *handler.APIKeyHandler    // API Key Handler instance
+	OIDCHandler      *handler.OIDCHandler      // OIDC Handler instance
+	PrivacyHandler   *handler.PrivacyHandler   // Privacy Handler instance
+	PlanHandler      *handler.PlanHandler      // Plan Handler instance
+	WebhookApp       *webhookapp.App           // Webhook Application instance
+	WebhookHandler   *handler.WebhookHandler   // Webhook Handler instance
+	OutboxApp        *outboxapp.App            // Outbox relay instance
+	AnalyticsApp     *analyticsapp.App         // Click processing instance
+	Metrics          *metrics.Metrics          // Prometheus metrics (nil when disabled)
+	Heartbeats       *jobs.Heartbeats          // Background worker heartbeats
+	HealthApp        *healthapp.App            // Liveness / readiness checks
+
+	shutdownTracing func(context.Context) error // 送出剩餘的 span 並關閉 exporter
 }
 
-// InitDependencies 初始化應用程式的所有依賴項
-func InitDependencies() (*Dependencies, error) {
-	log.Println("Initializing dependencies...")
+// InitDependencies 依已載入並檢查過的設定初始化應用程式的所有依賴項
+// 可熱更新的設定 (日誌等級、限流、封鎖清單與預設演算法) 由各元件訂閱 settings
+// 日誌寫到 logOutput：伺服器使用標準輸出，命令列工具使用標準錯誤以免與命令的輸出混在一起
+func InitDependencies(settings *conf.Watcher, logOutput io.Writer) (deps *Dependencies, err error) {
+	config := settings.Current()
 
-	// 1. 初始化配置
-	config := conf.Conf()
-	log.Println("Configuration loaded.")
+	// 結構化日誌：設為 slog 的預設 logger，各層以 slog.InfoContext(ctx, ...) 等記錄，
+	// 標準函式庫 log 的輸出也會經過它
+	logLevel := new(slog.LevelVar)
+	logger, err := logging.New(logging.Options{
+		Level:    config.Log.Level,
+		Format:   config.Log.Format,
+		Output:   logOutput,
+		LevelVar: logLevel,
+	})
+	if err != nil {
+		slog.Error("Failed to initialize logger", "error", err)
+		return nil, err
+	}
+	slog.SetDefault(logger)
+	settings.Subscribe(func(config *conf.Config) {
+		// 設定已在載入時檢查過，這裡不會失敗
+		if level, err := logging.ParseLevel(config.Log.Level); err == nil {
+			logLevel.Set(level)
+		}
+	})
+	logger.Info("Configuration loaded", "env", config.Env, "config_file", config.File, "log_level", config.Log.Level, "log_format", config.Log.Format)
+
+	// 追蹤：HTTP 請求、URLService、資料庫查詢與 Redis 指令以同一個 trace 串連
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		ServiceName: config.Tracing.ServiceName,
+		Exporter:    config.Tracing.Exporter,
+		File:        config.Tracing.File,
+		SampleRatio: config.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("Failed to initialize tracing", "error", err)
+		return nil, err
+	}
+	slog.Info("Tracing initialized", "exporter", config.Tracing.Exporter)
+
+	// 之後的任何錯誤都關閉已建立的資料庫與 Redis 連線並送出剩餘的 span
+	partial := &Dependencies{shutdownTracing: shutdownTracing}
+	defer func() {
+		if err != nil {
+			partial.Close()
+		}
+	}()
 
 	// 2. 初始化資料庫連接
-	db, err := database.InitDB(config)
+	db, err := database.InitDB(config, logger)
 	if err != nil {
-		log.Printf("Failed to initialize database: %v", err)
+		slog.Error("Failed to initialize database", "error", err)
 		return nil, err
 	}
-	log.Println("Database connection initialized.")
+	partial.DB = db
+	if err := db.Use(tracing.GormPlugin()); err != nil {
+		slog.Error("Failed to install database tracing", "error", err)
+		return nil, err
+	}
+	slog.Info("Database connection initialized")
+
+	// 資料庫結構版本：啟用自動遷移時先在 advisory lock 下套用遷移，版本與執行檔不一致時拒絕啟動
+	migrator, err := database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		slog.Error("Failed to load migrations", "error", err)
+		return nil, err
+	}
+	if err := ensureSchema(migrator, config.Database); err != nil {
+		return nil, err
+	}
+
+	// 監控指標：資料庫與 Redis 的延遲由 GORM 插件與 go-redis hook 記錄
+	var appMetrics *metrics.Metrics
+	if config.Metrics.Enabled {
+		appMetrics = metrics.New()
+		if err := db.Use(appMetrics.GormPlugin()); err != nil {
+			slog.Error("Failed to install database metrics", "error", err)
+			return nil, err
+		}
+	}
 
 	// 3. 初始化 Redis 客戶端
 	redisClient := redis.NewClient(&redis.Options{
-		Addr:     config.RedisHost + ":" + config.RedisPort,
-		Password: config.RedisPassword,
-		DB:       config.RedisDB,
+		Addr:     config.Redis.Addr(),
+		Password: config.Redis.Password,
+		DB:       config.Redis.DB,
 	})
+	partial.RedisClient = redisClient
+	redisClient.AddHook(tracing.RedisHook())
+	if appMetrics != nil {
+		redisClient.AddHook(appMetrics.RedisHook())
+	}
 	pingCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if _, err := redisClient.Ping(pingCtx).Result(); err != nil {
-		log.Printf("Warning: Failed to connect to Redis: %v", err)
+		// 不中止啟動，Redis 恢復前 /readyz 返回 503
+		slog.Warn("Failed to connect to Redis", "error", err)
 	} else {
-		log.Println("Redis connection verified.")
+		slog.Info("Redis connection verified")
 	}
 
 	// --- 依賴注入 ---
-	log.Println("Setting up dependency injection...")
+	slog.Info("Setting up dependency injection...")
+
+	// 4. 初始化郵件寄送
+	mailSender, err := mailer.New(config)
+	if err != nil {
+		slog.Error("Failed to initialize mailer", "error", err)
+		return nil, err
+	}
+	slog.Info("Mailer initialized", "driver", config.Mailer.Driver)
+
+	// 使用者儲存庫由多個領域共用
+	userRepo := gormpersistence.NewGormUserRepository(db)
+
+	// 稽核記錄由各應用服務共用
+	auditRepo := gormpersistence.NewGormAuditRepository(db)
+	auditRecorder := auditservice.NewRecorder(auditRepo)
+
+	// 領域事件：狀態變更的事件與變更本身一起寫入 outbox，由 relay 發布給行程內訂閱者與設定的目的地
+	// 點擊事件不經過 outbox，直接交給事件匯流排
+	eventBus := event.NewBus()
+	eventOutbox := gormpersistence.NewGormOutbox(db)
+	eventSinks, err := newEventSinks(config, redisClient)
+	if err != nil {
+		slog.Error("Failed to initialize event sinks", "error", err)
+		return nil, err
+	}
+	outboxApplication := outboxapp.NewApp(event.NewRelay(gormpersistence.NewGormOutboxRepository(db), eventBus, eventSinks...))
+
+	// --- Analytics Dependencies ---
+	// 重定向只將點擊寫入 Redis Stream，由點擊處理任務批次寫入資料庫並累加訪問次數
+	clickService := analyticsservice.NewClickService(
+		gormpersistence.NewGormClickRepository(db),
+		redispersistence.NewRedisClickStream(redisClient, config.Clicks.Stream, config.Clicks.ConsumerGroup, config.Clicks.StreamMaxLen),
+	)
+	analyticsApplication := analyticsapp.NewApp(clickService, config.Clicks.ConsumerName)
+	analyticsHandler := handler.NewAnalyticsHandler(analyticsApplication)
+	eventBus.Subscribe(event.TypeLinkClicked, analyticsApplication.HandleClick)
+	if appMetrics != nil {
+		if err := appMetrics.Register(metrics.NewClickStreamCollector(clickService.Stats)); err != nil {
+			slog.Error("Failed to register click stream metrics", "error", err)
+			return nil, err
+		}
+	}
+	slog.Info("Analytics dependencies initialized")
+
+	// --- Plan Domain Dependencies ---
+	// 配額由建立連結與網域的用例檢查，API 限流依使用者方案計算
+	quotaService := planservice.NewQuotaService(gormpersistence.NewGormPlanRepository(db), gormpersistence.NewGormUsageRepository(db), config.Plans.UpgradeURL)
+	planApplication := planapp.NewApp(quotaService)
+	planHandler := handler.NewPlanHandler(planApplication)
+	slog.Info("Plan dependencies initialized")
+
+	// --- Workspace Domain Dependencies ---
+	workspaceRepo := gormpersistence.NewGormWorkspaceRepository(db)
+	invitationRepo := gormpersistence.NewGormInvitationRepository(db)
+	workspaceDomainService := workspaceservice.NewWorkspaceService(workspaceRepo, invitationRepo)
+	workspaceApplication := workspaceapp.NewApp(workspaceDomainService, userRepo)
+	workspaceHandler := handler.NewWorkspaceHandler(workspaceApplication)
+	slog.Info("Workspace dependencies initialized")
 
 	// --- URL Shortener Domain Dependencies ---
 	urlRepo := gormpersistence.NewGormURLRepository(db)
-	cacheRepo := redispersistence.NewRedisCacheRepository(redisClient)
-	urlDomainService := urlshortenerservice.NewURLService(urlRepo, cacheRepo, 24*time.Hour)
-	urlApp := urlshortenerapp.NewApp(urlDomainService)
-	urlHandler := handler.NewURLHandler(urlDomainService)
-	log.Println("URL Shortener dependencies initialized.")
+	domainRepo := gormpersistence.NewGormDomainRepository(db)
+	var cacheRepo urlshortenerrepository.CacheRepository = redispersistence.NewRedisCacheRepository(redisClient)
+	if appMetrics != nil {
+		cacheRepo = metrics.NewInstrumentedCache(cacheRepo, appMetrics)
+	}
+	urlDomainService := urlshortenerservice.NewURLService(urlRepo, domainRepo, cacheRepo, config.Shortener.CacheTTL, eventOutbox, eventBus)
+	domainService := urlshortenerservice.NewDomainService(domainRepo, cacheRepo, dns.NewTXTResolver())
+	urlApp := urlshortenerapp.NewApp(urlDomainService, domainService, workspaceDomainService, quotaService, auditRecorder)
+	settings.Subscribe(func(config *conf.Config) {
+		urlApp.SetBlockedDomains(config.Blocklist.Domains)
+	})
+	urlHandler := handler.NewURLHandler(urlApp)
+	domainHandler := handler.NewDomainHandler(urlApp)
+	slog.Info("URL Shortener dependencies initialized")
+
+	// --- Webhook Dependencies ---
+	// 連結事件由背景任務轉為投遞記錄並發送，失敗時依指數退避重試
+	backoffPolicy := webhookentity.DefaultBackoffPolicy()
+	backoffPolicy.MaxAttempts = config.Webhooks.MaxAttempts
+	webhookDomainService := webhookservice.NewWebhookService(
+		gormpersistence.NewGormWebhookRepository(db),
+		gormpersistence.NewGormDeliveryRepository(db),
+		webhook.NewHTTPSender(config.Webhooks.Timeout, config.Webhooks.AllowPrivateNetworks),
+		backoffPolicy,
+	)
+	webhookApplication := webhookapp.NewApp(webhookDomainService, workspaceDomainService, auditRecorder)
+	for _, eventType := range event.LinkLifecycleTypes {
+		eventBus.Subscribe(eventType, webhookApplication.HandleEvent)
+	}
+	eventBus.Subscribe(event.TypeLinkClicked, webhookApplication.QueueEvent)
+	webhookHandler := handler.NewWebhookHandler(webhookApplication)
+	slog.Info("Webhook dependencies initialized")
 
 	// --- Identity Domain Dependencies ---
-	userRepo := gormpersistence.NewGormUserRepository(db)
-	identityDomainService := identityservice.NewIdentityService(userRepo)
-	identityApplication := identityapp.NewApp(userRepo, identityDomainService)
+	// (刪除帳號時需要處理使用者的連結、網域與 webhook，因此在 URL Shortener 與 Webhook 之後初始化)
+	apiKeyRepo := gormpersistence.NewGormAPIKeyRepository(db)
+	refreshTokenRepo := gormpersistence.NewGormRefreshTokenRepository(db)
+	recoveryCodeRepo := gormpersistence.NewGormRecoveryCodeRepository(db)
+	tokenDenylist := redispersistence.NewRedisTokenDenylist(redisClient)
+	identityDomainService := identityservice.NewIdentityService(userRepo, eventOutbox)
+	lockoutPolicy := identityservice.DefaultLockoutPolicy()
+	lockoutPolicy.MaxUserFailures = config.Login.MaxFailures
+	lockoutPolicy.MaxIPFailures = config.Login.MaxIPFailures
+	lockoutPolicy.BaseLockout = config.Login.LockoutBase
+	loginGuard := identityservice.NewLoginGuard(redispersistence.NewRedisLoginAttemptStore(redisClient), lockoutPolicy)
+	identityApplication := identityapp.NewApp(userRepo, apiKeyRepo, refreshTokenRepo, recoveryCodeRepo, loginGuard, tokenDenylist, mailSender, urlApp, webhookApplication, workspaceDomainService, eventOutbox, auditRecorder, identityDomainService, identityConfig(config))
 	userHandler := handler.NewUserHandler(identityApplication)
-	log.Println("Identity dependencies initialized.")
+	apiKeyHandler := handler.NewAPIKeyHandler(identityApplication)
+
+	oidcProviders := make([]identityapp.OIDCProviderConfig, 0, len(config.OIDCProviders))
+	for _, p := range config.OIDCProviders {
+		oidcProviders = append(oidcProviders, identityapp.OIDCProviderConfig{
+			Name:          p.Name,
+			IssuerURL:     p.IssuerURL,
+			ClientID:      p.ClientID,
+			ClientSecret:  p.ClientSecret,
+			RedirectURL:   p.RedirectURL,
+			Scopes:        p.Scopes,
+			AutoProvision: p.AutoProvision,
+		})
+	}
+	externalIdentityRepo := gormpersistence.NewGormExternalIdentityRepository(db)
+	oidcApplication := identityapp.NewOIDCApp(
+		identityApplication,
+		externalIdentityRepo,
+		redispersistence.NewRedisOIDCStateStore(redisClient),
+		nil,
+		oidcProviders,
+	)
+	oidcHandler := handler.NewOIDCHandler(oidcApplication)
+	slog.Info("Identity dependencies initialized")
+
+	// --- Admin Dependencies ---
+	adminApplication := adminapp.NewApp(userRepo, identityDomainService, loginGuard, urlDomainService, quotaService, auditRepo, auditRecorder)
+	adminHandler := handler.NewAdminHandler(adminApplication)
+	slog.Info("Admin dependencies initialized")
+
+	// --- Privacy Dependencies ---
+	privacyApplication := privacyapp.NewApp(userRepo, apiKeyRepo, externalIdentityRepo, urlDomainService, domainService, workspaceDomainService, clickService, gormpersistence.NewGormErasureRepository(db), auditRecorder)
+	privacyHandler := handler.NewPrivacyHandler(privacyApplication)
+	slog.Info("Privacy dependencies initialized")
+
+	// --- Health Check Dependencies ---
+	// 背景任務以心跳回報進度，就緒檢查同時檢查資料庫、Redis、資料庫結構版本與心跳
+	heartbeats := jobs.NewHeartbeats()
+	healthApplication := healthapp.NewApp([]healthapp.Checker{
+		health.NewDatabaseCheck(db),
+		health.NewRedisCheck(redisClient),
+		health.NewSchemaCheck(migrator),
+	}, heartbeats, config.Health.CheckTimeout)
+	healthHandler := handler.NewHealthHandler(healthApplication)
+	slog.Info("Health check dependencies initialized")
 
 	// --- API Router Setup ---
-	ginEngine := gin.Default()
+	// 限流狀態存放在 Redis 供多個實例共享，Redis 無法使用時改為單機記憶體限流
+	limiter := ratelimit.NewFailoverLimiter(ratelimit.NewRedisLimiter(redisClient), ratelimit.NewMemoryLimiter())
+	// 以結構化的存取日誌取代 gin 預設的文字日誌
+	ginEngine := gin.New()
+	ginEngine.Use(gin.Recovery())
+	// 只信任設定中的代理所提供的 X-Forwarded-For，避免用戶端偽造來源 IP 繞過限流與登入鎖定
+	if err := ginEngine.SetTrustedProxies(config.Server.TrustedProxies); err != nil {
+		slog.Error("Failed to set trusted proxies", "error", err)
+		return nil, err
+	}
 	// 傳遞所有需要的 Handlers 給 Router
-	apiRouter := api.NewRouter(ginEngine, urlHandler, userHandler, config)
+	apiRouter := api.NewRouter(ginEngine, urlHandler, userHandler, domainHandler, workspaceHandler, adminHandler, apiKeyHandler, oidcHandler, privacyHandler, planHandler, webhookHandler, analyticsHandler, healthHandler, identityApplication, planApplication, limiter, appMetrics, logger, settings)
 	apiRouter.SetupRoutes()
-	log.Println("API Router initialized and routes set up.")
+	slog.Info("API Router initialized and routes set up")
 	// --- 依賴注入結束 ---
 
-	deps := &Dependencies{
-		Config:      config,
-		DB:          db,
-		RedisClient: redisClient,
-		GinEngine:   ginEngine,
-		URLApp:      urlApp,
-		IdentityApp: identityApplication,
-		UserHandler: userHandler,
-		URLHandler:  urlHandler,
+	deps = &Dependencies{
+		Config:           config,
+		Settings:         settings,
+		Logger:           logger,
+		DB:               db,
+		Migrator:         migrator,
+		RedisClient:      redisClient,
+		GinEngine:        ginEngine,
+		URLApp:           urlApp,
+		IdentityApp:      identityApplication,
+		UserHandler:      userHandler,
+		URLHandler:       urlHandler,
+		DomainHandler:    domainHandler,
+		WorkspaceApp:     workspaceApplication,
+		WorkspaceHandler: workspaceHandler,
+		AdminApp:         adminApplication,
+		AdminHandler:     adminHandler,
+		APIKeyHandler:    apiKeyHandler,
+		OIDCHandler:      oidcHandler,
+		PrivacyHandler:   privacyHandler,
+		PlanHandler:      planHandler,
+		WebhookApp:       webhookApplication,
+		WebhookHandler:   webhookHandler,
+		OutboxApp:        outboxApplication,
+		AnalyticsApp:     analyticsApplication,
+		Metrics:          appMetrics,
+		Heartbeats:       heartbeats,
+		HealthApp:        healthApplication,
+		shutdownTracing:  shutdownTracing,
 	}
 
-	log.Println("Dependencies initialized successfully.")
+	slog.Info("Dependencies initialized successfully")
 	return deps, nil
 }
 
+// ensureSchema 依設定套用尚未套用的遷移，並確認資料庫結構版本與執行檔一致
+func ensureSchema(migrator *database.Migrator, config conf.Database) error {
+	ctx, cancel := context.WithTimeout(context.Background(), config.MigrateTimeout)
+	defer cancel()
+
+	if config.AutoMigrate {
+		applied, err := migrator.Up(ctx, 0)
+		if err != nil {
+			slog.Error("Failed to apply migrations", "error", err)
+			return err
+		}
+		slog.Info("Database schema migrated", "applied", applied, "version", migrator.Latest())
+	}
+	if err := migrator.Check(ctx); err != nil {
+		slog.Error("Refusing to start on an unexpected database schema version, run the migrate subcommand or enable database.auto_migrate",
+			"error", err, "expected_version", migrator.Latest())
+		return fmt.Errorf("database schema: %w", err)
+	}
+	return nil
+}
+
+// identityConfig 從設定組出 Identity 應用服務的設定
+func identityConfig(config *conf.Config) identityapp.Config {
+	identity := identityapp.Config{
+		JWTSecret:                config.Auth.JWTSecret,
+		AccessTokenTTL:           config.Auth.AccessTokenTTL,
+		RefreshTokenTTL:          config.Auth.RefreshTokenTTL,
+		RequireEmailVerification: config.Auth.RequireEmailVerification,
+		PublicBaseURL:            config.Server.PublicBaseURL,
+	}
+	if config.Auth.AccountDeletionLinkPolicy == conf.LinkPolicyTransfer {
+		target := config.Auth.AccountDeletionTransferUserID
+		identity.DeletedUserLinksTo = &target
+	}
+	return identity
+}
+
+// newEventSinks 依 EVENT_SINKS 建立 outbox 事件的額外目的地
+func newEventSinks(config *conf.Config, redisClient *redis.Client) ([]event.Sink, error) {
+	var sinks []event.Sink
+	for _, name := range config.Events.Sinks {
+		switch name {
+		case "redis":
+			sinks = append(sinks, eventsink.NewRedisStreamSink(redisClient, config.Events.Stream, config.Events.StreamMaxLen))
+		default:
+			return nil, fmt.Errorf("unknown event sink %q", name)
+		}
+	}
+	return sinks, nil
+}
+
+// JobMonitor 返回交給背景任務的監控設定：清理結果交給監控指標、心跳供就緒檢查使用
+// (未啟用監控時不能直接傳入 nil 的 *metrics.Metrics，否則介面值不為 nil)
+func (d *Dependencies) JobMonitor() jobs.Monitor {
+	monitor := jobs.Monitor{Heartbeats: d.Heartbeats}
+	if d.Metrics != nil {
+		monitor.Cleanups = d.Metrics
+	}
+	return monitor
+}
+
 // Close gracefully closes the dependencies
 func (d *Dependencies) Close() {
-	log.Println("Closing resources...")
+	slog.Info("Closing resources...")
 	if d.RedisClient != nil {
 		if err := d.RedisClient.Close(); err != nil {
-			log.Printf("Error closing Redis connection: %v", err)
+			slog.Error("Error closing Redis connection", "error", err)
 		} else {
-			log.Println("Redis connection closed.")
+			slog.Info("Redis connection closed")
+		}
+	}
+	if d.shutdownTracing != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := d.shutdownTracing(ctx); err != nil {
+			slog.Error("Error flushing traces", "error", err)
 		}
 	}
 	if d.DB != nil {
 		sqlDB, err := d.DB.DB()
 		if err == nil {
 			if err := sqlDB.Close(); err != nil {
-				log.Printf("Error closing database connection: %v", err)
+				slog.Error("Error closing database connection", "error", err)
 			} else {
-				log.Println("Database connection closed.")
+				slog.Info("Database connection closed")
 			}
 		}
 	}
-	log.Println("Resources closed.")
+	slog.Info("Resources closed")
 }
@@ -0,0 +1,43 @@
+package bootstrap
+
+import (
+	"log/slog"
+	"os"
+
+	"go_short/conf"
+	"go_short/infra/database"
+	"go_short/infra/logging"
+	"go_short/migrations"
+)
+
+// InitMigrator 只初始化日誌與資料庫連線並返回遷移工具，供 migrate 子命令使用
+// (不檢查資料庫結構版本，因此可以在版本不一致時執行)，close 關閉資料庫連線
+func InitMigrator(config *conf.Config) (migrator *database.Migrator, close func(), err error) {
+	logger, err := logging.New(logging.Options{
+		Level:  config.Log.Level,
+		Format: config.Log.Format,
+		Output: os.Stderr,
+	})
+	if err != nil {
+		slog.Error("Failed to initialize logger", "error", err)
+		return nil, nil, err
+	}
+	slog.SetDefault(logger)
+
+	db, err := database.InitDB(config, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+	close = func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}
+	migrator, err = database.NewMigrator(db, migrations.FS)
+	if err != nil {
+		close()
+		slog.Error("Failed to load migrations", "error", err)
+		return nil, nil, err
+	}
+	return migrator, close, nil
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"go_short/conf"
+	urlentity "go_short/domain/urlshortener/entity"
+	adminapp "go_short/internal/application/admin"
+	urlshortenerapp "go_short/internal/application/urlshortener"
+	"go_short/internal/bootstrap"
+)
+
+const linksUsage = `usage: go_short [flags] links <command>
+
+commands:
+  import [-user USER] [-algorithm NAME] [file]
+                   create links from CSV (standard input when file is omitted or -)
+  export [-user USER] [-o file]
+                   write links as CSV (all links, or those of USER)
+`
+
+// exportHeader 是 links export 輸出的欄位，alias 與 url 等欄位可直接再以 links import 匯入
+var exportHeader = []string{"id", "short_url", "alias", "url", "domain_id", "workspace_id", "user_id", "expires_at", "visits", "disabled", "created_at"}
+
+// runLinks 執行 links 子命令
+func runLinks(settings *conf.Watcher, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, linksUsage)
+		return 2
+	}
+	switch args[0] {
+	case "import":
+		return runLinksImport(settings, args[1:])
+	case "export":
+		return runLinksExport(settings, args[1:])
+	default:
+		fmt.Fprint(os.Stderr, linksUsage)
+		return 2
+	}
+}
+
+// runLinksImport 逐列建立連結，規則與 API 相同 (封鎖清單、配額、自訂短碼與網域權限)；
+// 失敗的列輸出到標準錯誤後繼續處理，有任何失敗時結束碼為 1
+func runLinksImport(settings *conf.Watcher, args []string) int {
+	flags := newFlagSet("links import", "links import [-user USER] [-algorithm NAME] [file]")
+	owner := flags.String("user", "", "owner of the links (ID, username or email); anonymous links cannot use alias, domain or workspace_id")
+	algorithm := flags.String("algorithm", "", "shortening algorithm when the row has none (default: shortener.algorithm)")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	var input io.Reader = os.Stdin
+	if name := flags.Arg(0); name != "" && name != "-" {
+		file, err := os.Open(name)
+		if err != nil {
+			return fail("links import", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+
+	actorID, err := resolveOwner(ctx, deps, *owner)
+	if err != nil {
+		return fail("links import", err)
+	}
+
+	reader := csv.NewReader(input)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return fail("links import", fmt.Errorf("read header: %w", err))
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["url"]; !ok {
+		return fail("links import", errors.New(`the header must have a "url" column`))
+	}
+
+	// 成功的列以 CSV 輸出，欄位中的逗號、引號與換行會被正確跳脫
+	output := csv.NewWriter(os.Stdout)
+	imported, failed := 0, 0
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var mapping *urlentity.URLMapping
+		if err == nil {
+			mapping, err = importLink(ctx, deps, actorID, record, columns, *algorithm)
+		}
+		if err == nil {
+			output.Write(linkRecord(mapping)[:4])
+			output.Flush()
+			imported++
+			continue
+		}
+		if ctx.Err() != nil {
+			return fail("links import", ctx.Err())
+		}
+		fmt.Fprintf(os.Stderr, "links import: row %d: %v\n", row, err)
+		failed++
+	}
+
+	if err := output.Error(); err != nil {
+		return fail("links import", err)
+	}
+	fmt.Fprintf(os.Stderr, "imported %d link(s), %d failed\n", imported, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// importLink 以一列 CSV 建立連結
+func importLink(ctx context.Context, deps *bootstrap.Dependencies, actorID *uint, record []string, columns map[string]int, algorithm string) (*urlentity.URLMapping, error) {
+	input, err := parseLinkRecord(record, columns, algorithm)
+	if err != nil {
+		return nil, err
+	}
+	return deps.URLApp.CreateLink(ctx, actorID, input)
+}
+
+// parseLinkRecord 將一列 CSV 轉為建立連結的輸入，欄位 url 必填，
+// alias、domain、workspace_id、expires_at (RFC 3339) 與 algorithm 可省略
+func parseLinkRecord(record []string, columns map[string]int, algorithm string) (urlshortenerapp.CreateLinkInput, error) {
+	field := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	input := urlshortenerapp.CreateLinkInput{
+		URL:       field("url"),
+		Alias:     field("alias"),
+		Domain:    field("domain"),
+		Algorithm: field("algorithm"),
+	}
+	if input.URL == "" {
+		return input, errors.New("url is empty")
+	}
+	if input.Algorithm == "" {
+		input.Algorithm = algorithm
+	}
+	if value := field("workspace_id"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return input, fmt.Errorf("invalid workspace_id %q", value)
+		}
+		workspaceID := uint(id)
+		input.WorkspaceID = &workspaceID
+	}
+	if value := field("expires_at"); value != "" {
+		expiresAt, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return input, fmt.Errorf("invalid expires_at %q, expected RFC 3339", value)
+		}
+		expiresIn := time.Until(expiresAt)
+		if expiresIn <= 0 {
+			return input, fmt.Errorf("expires_at %s is in the past", value)
+		}
+		input.ExpiresIn = &expiresIn
+	}
+	return input, nil
+}
+
+// linkLister 分頁列出全系統的連結，由管理應用層實作
+type linkLister interface {
+	ListLinks(ctx context.Context, page, pageSize int) (*adminapp.LinkPage, error)
+}
+
+// listAllLinks 以每頁上限筆數逐頁讀取全系統的連結，直到讀完或某一頁為空
+func listAllLinks(ctx context.Context, lister linkLister) ([]*urlentity.URLMapping, error) {
+	var links []*urlentity.URLMapping
+	for page := 1; ; page++ {
+		result, err := lister.ListLinks(ctx, page, adminapp.MaxPageSize)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, result.Links...)
+		if len(result.Links) == 0 || int64(len(links)) >= result.Total {
+			return links, nil
+		}
+	}
+}
+
+// runLinksExport 將所有連結或指定使用者的個人連結輸出為 CSV
+func runLinksExport(settings *conf.Watcher, args []string) int {
+	flags := newFlagSet("links export", "links export [-user USER] [-o file]")
+	owner := flags.String("user", "", "export only the personal links of this user (ID, username or email)")
+	output := flags.String("o", "-", "output file, - for standard output")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+
+	var links []*urlentity.URLMapping
+	if *owner == "" {
+		links, err = listAllLinks(ctx, deps.AdminApp)
+	} else {
+		var actorID *uint
+		actorID, err = resolveOwner(ctx, deps, *owner)
+		if err == nil {
+			links, err = deps.URLApp.ListLinks(ctx, *actorID, nil)
+		}
+	}
+	if err != nil {
+		return fail("links export", err)
+	}
+
+	var out io.Writer = os.Stdout
+	if *output != "-" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return fail("links export", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	writer := csv.NewWriter(out)
+	writer.Write(exportHeader)
+	for _, link := range links {
+		writer.Write(linkRecord(link))
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return fail("links export", err)
+	}
+	fmt.Fprintf(os.Stderr, "exported %d link(s)\n", len(links))
+	return 0
+}
+
+// linkRecord 將連結轉為 exportHeader 順序的 CSV 欄位
+func linkRecord(link *urlentity.URLMapping) []string {
+	optionalID := func(id *uint) string {
+		if id == nil {
+			return ""
+		}
+		return strconv.FormatUint(uint64(*id), 10)
+	}
+	var shortURL, alias, expiresAt string
+	if link.ShortURL != nil {
+		shortURL = *link.ShortURL
+		if link.CustomAlias {
+			alias = shortURL
+		}
+	}
+	if link.ExpiresAt != nil {
+		expiresAt = link.ExpiresAt.UTC().Format(time.RFC3339)
+	}
+	return []string{
+		strconv.FormatUint(uint64(link.ID), 10),
+		shortURL,
+		alias,
+		link.OriginalURL,
+		optionalID(link.DomainID),
+		optionalID(link.WorkspaceID),
+		optionalID(link.UserID),
+		expiresAt,
+		strconv.Itoa(link.Visits),
+		strconv.FormatBool(link.DisabledAt != nil),
+		link.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// resolveOwner 查找連結的擁有者，ref 為空時返回 nil (匿名)
+func resolveOwner(ctx context.Context, deps *bootstrap.Dependencies, ref string) (*uint, error) {
+	if ref == "" {
+		return nil, nil
+	}
+	user, err := deps.AdminApp.FindUser(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("user %q: %w", ref, err)
+	}
+	return &user.ID, nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	urlentity "go_short/domain/urlshortener/entity"
+	adminapp "go_short/internal/application/admin"
+)
+
+func TestParseLinkRecord(t *testing.T) {
+	columns := map[string]int{"url": 0, "alias": 1, "domain": 2, "workspace_id": 3, "expires_at": 4, "algorithm": 5}
+	future := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	tests := []struct {
+		name          string
+		record        []string
+		wantErr       string
+		wantAlias     string
+		wantDomain    string
+		wantAlgorithm string
+		wantWorkspace uint
+		wantExpiry    bool
+	}{
+		{name: "url only", record: []string{" https://example.com "}, wantAlgorithm: "base62"},
+		{name: "every column", record: []string{"https://example.com", "launch", "go.example.com", "7", future, "md5"}, wantAlias: "launch", wantDomain: "go.example.com", wantWorkspace: 7, wantExpiry: true, wantAlgorithm: "md5"},
+		{name: "empty algorithm uses the default", record: []string{"https://example.com", "", "", "", "", ""}, wantAlgorithm: "base62"},
+		{name: "empty url", record: []string{"  ", "launch"}, wantErr: "url is empty"},
+		{name: "invalid workspace", record: []string{"https://example.com", "", "", "team"}, wantErr: `invalid workspace_id "team"`},
+		{name: "negative workspace", record: []string{"https://example.com", "", "", "-1"}, wantErr: `invalid workspace_id "-1"`},
+		{name: "invalid expiry", record: []string{"https://example.com", "", "", "", "2024-01-01"}, wantErr: "expected RFC 3339"},
+		{name: "expiry in the past", record: []string{"https://example.com", "", "", "", "2000-01-01T00:00:00Z"}, wantErr: "is in the past"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input, err := parseLinkRecord(tt.record, columns, "base62")
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("err = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if input.URL != "https://example.com" || input.Alias != tt.wantAlias || input.Domain != tt.wantDomain || input.Algorithm != tt.wantAlgorithm {
+				t.Errorf("input = %+v", input)
+			}
+			if (input.WorkspaceID != nil) != (tt.wantWorkspace != 0) || (input.WorkspaceID != nil && *input.WorkspaceID != tt.wantWorkspace) {
+				t.Errorf("workspace = %v, want %d", input.WorkspaceID, tt.wantWorkspace)
+			}
+			if (input.ExpiresIn != nil) != tt.wantExpiry {
+				t.Errorf("expires in = %v, want set %t", input.ExpiresIn, tt.wantExpiry)
+			}
+		})
+	}
+}
+
+// 匯出的 CSV 可直接再匯入，含逗號與引號的欄位也不會錯位
+func TestLinkRecordRoundTrip(t *testing.T) {
+	shortURL := "launch"
+	workspaceID := uint(3)
+	expiresAt := time.Now().Add(time.Hour).Truncate(time.Second)
+	link := &urlentity.URLMapping{
+		ShortURL:    &shortURL,
+		OriginalURL: `https://example.com/search?q=a,b&title="x"`,
+		WorkspaceID: &workspaceID,
+		ExpiresAt:   &expiresAt,
+		CustomAlias: true,
+		Visits:      12,
+	}
+	link.ID = 42
+
+	var buf bytes.Buffer
+	writer := csv.NewWriter(&buf)
+	writer.Write(exportHeader)
+	writer.Write(linkRecord(link))
+	writer.Flush()
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil || len(records) != 2 {
+		t.Fatalf("read %d records: %v", len(records), err)
+	}
+	record := records[1]
+	if len(record) != len(exportHeader) {
+		t.Fatalf("record has %d fields, want %d", len(record), len(exportHeader))
+	}
+	want := []string{"42", "launch", "launch", link.OriginalURL, "", "3", "", expiresAt.UTC().Format(time.RFC3339), "12", "false"}
+	if got := strings.Join(record[:len(want)], "|"); got != strings.Join(want, "|") {
+		t.Errorf("record = %s, want %s", got, strings.Join(want, "|"))
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[name] = i
+	}
+	input, err := parseLinkRecord(record, columns, "base62")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.URL != link.OriginalURL || input.Alias != "launch" || input.WorkspaceID == nil || *input.WorkspaceID != 3 || input.ExpiresIn == nil {
+		t.Errorf("re-imported input = %+v", input)
+	}
+}
+
+func TestLinkRecordWithoutAlias(t *testing.T) {
+	shortURL := "aZ3kQ9"
+	link := &urlentity.URLMapping{ShortURL: &shortURL, OriginalURL: "https://example.com"}
+	disabledAt := time.Now()
+	link.DisabledAt = &disabledAt
+
+	record := linkRecord(link)
+	if record[1] != "aZ3kQ9" || record[2] != "" {
+		t.Errorf("short_url %q, alias %q; a generated code must not be exported as an alias", record[1], record[2])
+	}
+	if record[9] != "true" {
+		t.Errorf("disabled = %q, want true", record[9])
+	}
+}
+
+// fakeLinkLister 依頁數切分固定數量的連結，記錄請求的頁數與每頁筆數
+type fakeLinkLister struct {
+	total    int
+	requests [][2]int
+}
+
+func (l *fakeLinkLister) ListLinks(ctx context.Context, page, pageSize int) (*adminapp.LinkPage, error) {
+	l.requests = append(l.requests, [2]int{page, pageSize})
+	result := &adminapp.LinkPage{Total: int64(l.total), Page: page, PageSize: pageSize}
+	for i := (page - 1) * pageSize; i < page*pageSize && i < l.total; i++ {
+		link := &urlentity.URLMapping{}
+		link.ID = uint(i + 1)
+		result.Links = append(result.Links, link)
+	}
+	return result, nil
+}
+
+func TestListAllLinks(t *testing.T) {
+	tests := []struct {
+		total     int
+		wantPages int
+	}{
+		{total: 0, wantPages: 1},
+		{total: 1, wantPages: 1},
+		{total: adminapp.MaxPageSize, wantPages: 1},
+		{total: adminapp.MaxPageSize + 1, wantPages: 2},
+		{total: 2*adminapp.MaxPageSize + 50, wantPages: 3},
+	}
+	for _, tt := range tests {
+		lister := &fakeLinkLister{total: tt.total}
+		links, err := listAllLinks(context.Background(), lister)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(links) != tt.total || len(lister.requests) != tt.wantPages {
+			t.Errorf("total %d: got %d links in %d pages, want %d pages", tt.total, len(links), len(lister.requests), tt.wantPages)
+		}
+		for i, link := range links {
+			if link.ID != uint(i+1) {
+				t.Fatalf("total %d: link %d has ID %d, pages were skipped or repeated", tt.total, i, link.ID)
+			}
+		}
+		for _, request := range lister.requests {
+			if request[1] != adminapp.MaxPageSize {
+				t.Errorf("page size %d, want %d", request[1], adminapp.MaxPageSize)
+			}
+		}
+	}
+}
@@ -1,64 +1,75 @@
 package main
 
 import (
-	"context"
-	"log"
-	"net/http" // 引入 net/http 以便使用 http.Server
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
 
-	"go_short/internal/bootstrap" // 引入新的 bootstrap 包
+	"go_short/conf"
 )
 
-func main() {
-	// 初始化依賴項
-	deps, err := bootstrap.InitDependencies()
-	if err != nil {
-		log.Fatalf("Failed to initialize dependencies: %v", err)
-	}
-	defer deps.Close()
+const usage = `usage: go_short [flags] [command]
 
-	// 創建一個可用於取消背景任務的 context
-	appCtx, cancelAppCtx := context.WithCancel(context.Background())
-	defer cancelAppCtx()
+commands:
+  serve                    run the HTTP server and background jobs (default)
+  migrate <command>        apply, revert or inspect database migrations
+  worker [-jobs list]      run background jobs without the HTTP server
+  admin create-user        create a user, e.g. the first administrator
+  admin activate-user      activate a user by ID, username or email
+  admin deactivate-user    deactivate a user by ID, username or email
+  links import [file]      create links from a CSV file
+  links export             write links as CSV
+  cleanup run-once         delete expired links once and exit
 
-	// 啟動定期清理過期 URL 的任務 (確保 URLApp 實例被正確傳遞)
-	deps.URLApp.StartCleanupTask(appCtx) // 使用 Bootstrap 返回的 URLApp 實例
+Run "go_short -h" for the configuration flags and "go_short <command> -h" for command flags.
+`
 
-	// --- 配置和啟動 HTTP 伺服器 ---
-	server := &http.Server{
-		Addr:    ":8080",        // 應從 deps.Config 讀取
-		Handler: deps.GinEngine, // 使用 bootstrap 返回的 gin Engine
-	}
-
-	go func() {
-		log.Printf("Starting server on %s", server.Addr)
-		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Failed to start server: %v", err)
-		}
-	}()
-	// --- HTTP 伺服器啟動結束 ---
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
 
-	// --- 優雅關閉 ---
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
-	<-quit
-	log.Println("Shutting down server...")
+// run 解析設定並執行子命令，返回行程的結束碼
+func run(arguments []string) int {
+	// 載入並檢查設定：預設值 < 設定檔 < 環境變數 < 命令列參數，設定有誤時直接結束
+	config, args, err := conf.Load(arguments)
+	if errors.Is(err, flag.ErrHelp) {
+		fmt.Fprint(os.Stderr, "\n"+usage)
+		return 0
+	}
+	if err != nil {
+		slog.Error("Failed to load configuration", "error", err)
+		return 2
+	}
 
-	// 給伺服器一點時間處理剩餘請求
-	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second) // 增加關閉超時
-	defer cancelShutdown()
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
 
-	// 觸發背景任務的取消
-	cancelAppCtx()
+	// 可熱更新的設定在設定檔變更或收到 SIGHUP 時重新載入
+	settings := conf.NewWatcher(config, arguments)
 
-	// 關閉 HTTP 伺服器
-	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Fatalf("Server forced to shutdown: %v", err)
+	switch command {
+	case "serve":
+		return runServe(settings)
+	case "migrate":
+		// 只需要資料庫連線，在檢查資料庫結構版本之前處理
+		return runMigrate(config, args)
+	case "worker":
+		return runWorker(settings, args)
+	case "click-worker":
+		// 舊的子命令名稱，等同 worker -jobs clicks
+		return runWorker(settings, []string{"-jobs", jobClicks})
+	case "admin":
+		return runAdmin(settings, args)
+	case "links":
+		return runLinks(settings, args)
+	case "cleanup":
+		return runCleanup(settings, args)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		return 2
 	}
-
-	log.Println("Server exiting")
-	// --- 優雅關閉結束 ---
 }
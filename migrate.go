@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"text/tabwriter"
+
+	"go_short/conf"
+	"go_short/infra/database"
+	"go_short/internal/bootstrap"
+)
+
+const migrateUsage = `usage: go_short [flags] migrate <command>
+
+commands:
+  up [N]        apply all pending migrations, or only the next N
+  down [N|all]  revert the last N migrations (default 1), or all of them
+  status        show the schema version and which migrations are applied
+  force V       record version V as applied and clear the dirty flag without running anything
+`
+
+// runMigrate 執行 migrate 子命令，返回行程的結束碼
+func runMigrate(config *conf.Config, args []string) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+	command, args := args[0], args[1:]
+
+	migrator, closeDB, err := bootstrap.InitMigrator(config)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	defer closeDB()
+
+	// Ctrl-C 時取消目前的遷移，交易回復後版本維持不變
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	switch {
+	case command == "up" && len(args) <= 1:
+		n, err := parseCount(args, 0)
+		if err == nil {
+			n, err = migrator.Up(ctx, n)
+			fmt.Printf("applied %d migration(s)\n", n)
+		}
+		return migrateResult(err)
+	case command == "down" && len(args) <= 1:
+		n, err := parseCount(args, 1)
+		if err == nil {
+			n, err = migrator.Down(ctx, n)
+			fmt.Printf("reverted %d migration(s)\n", n)
+		}
+		return migrateResult(err)
+	case command == "status" && len(args) == 0:
+		return migrateResult(printStatus(ctx, os.Stdout, migrator))
+	case command == "force" && len(args) == 1:
+		version, err := strconv.ParseUint(args[0], 10, 64)
+		if err != nil {
+			return migrateResult(fmt.Errorf("invalid version %q", args[0]))
+		}
+		return migrateResult(migrator.Force(ctx, uint(version)))
+	default:
+		fmt.Fprint(os.Stderr, migrateUsage)
+		return 2
+	}
+}
+
+// parseCount 解析 up / down 的數量參數，all 或 0 表示全部 (以 0 返回)
+func parseCount(args []string, def int) (int, error) {
+	if len(args) == 0 {
+		return def, nil
+	}
+	if args[0] == "all" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid count %q", args[0])
+	}
+	return n, nil
+}
+
+// printStatus 輸出資料庫目前的版本與每個遷移檔的狀態
+func printStatus(ctx context.Context, w io.Writer, migrator *database.Migrator) error {
+	statuses, version, dirty, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "version: %d (latest %d)", version, migrator.Latest())
+	if dirty {
+		fmt.Fprint(w, " dirty")
+	}
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tSTATUS")
+	for _, s := range statuses {
+		status := "pending"
+		if s.Applied {
+			status = "applied"
+		}
+		if dirty && s.Version == version {
+			status = "dirty"
+		}
+		fmt.Fprintf(tw, "%06d\t%s\t%s\n", s.Version, s.Name, status)
+	}
+	return tw.Flush()
+}
+
+// migrateResult 輸出錯誤並返回結束碼
+func migrateResult(err error) int {
+	if err == nil {
+		return 0
+	}
+	fmt.Fprintln(os.Stderr, "migrate:", err)
+	if errors.Is(err, database.ErrDirtySchema) {
+		fmt.Fprintln(os.Stderr, "fix the schema by hand, then run: go_short migrate force <version>")
+	}
+	return 1
+}
@@ -0,0 +1,17 @@
+-- 刪除 url_mappings 上的網域相關索引
+DROP INDEX IF EXISTS idx_url_mappings_domain_id;
+DROP INDEX IF EXISTS idx_url_mappings_domain_short_url;
+DROP INDEX IF EXISTS idx_url_mappings_default_short_url;
+
+-- 還原短碼的全域唯一限制 (自訂網域的重複短碼需先清除)
+DELETE FROM url_mappings WHERE domain_id IS NOT NULL;
+ALTER TABLE url_mappings
+DROP COLUMN IF EXISTS domain_id;
+ALTER TABLE url_mappings
+ADD CONSTRAINT url_mappings_short_url_key UNIQUE (short_url);
+
+-- 刪除 domains 表
+DROP INDEX IF EXISTS idx_domains_deleted_at;
+DROP INDEX IF EXISTS idx_domains_user_id;
+DROP INDEX IF EXISTS idx_domains_host;
+DROP TABLE IF EXISTS domains;
@@ -0,0 +1,28 @@
+-- 創建 domains 表 (自訂短網域)
+CREATE TABLE IF NOT EXISTS domains (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    host VARCHAR(255) NOT NULL,
+    user_id INTEGER,
+    fallback_url VARCHAR(2048) DEFAULT NULL,
+    verification_token VARCHAR(64) NOT NULL,
+    verified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_host ON domains(host) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_domains_user_id ON domains(user_id);
+CREATE INDEX IF NOT EXISTS idx_domains_deleted_at ON domains(deleted_at);
+
+-- url_mappings 加上 domain_id，短碼改為在各網域內唯一
+ALTER TABLE url_mappings
+ADD COLUMN domain_id INTEGER;
+
+ALTER TABLE url_mappings
+DROP CONSTRAINT IF EXISTS url_mappings_short_url_key;
+
+-- 預設網域 (domain_id IS NULL) 與自訂網域分別建立部分唯一索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_mappings_default_short_url ON url_mappings(short_url) WHERE domain_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_url_mappings_domain_short_url ON url_mappings(domain_id, short_url) WHERE domain_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_url_mappings_domain_id ON url_mappings(domain_id);
@@ -0,0 +1,13 @@
+-- 刪除連結與網域上的工作區欄位
+DROP INDEX IF EXISTS idx_domains_workspace_id;
+ALTER TABLE domains
+DROP COLUMN IF EXISTS workspace_id;
+
+DROP INDEX IF EXISTS idx_url_mappings_workspace_id;
+ALTER TABLE url_mappings
+DROP COLUMN IF EXISTS workspace_id;
+
+-- 刪除工作區相關表格
+DROP TABLE IF EXISTS workspace_invitations;
+DROP TABLE IF EXISTS workspace_members;
+DROP TABLE IF EXISTS workspaces;
@@ -0,0 +1,8 @@
+-- 刪除連結停用標記
+ALTER TABLE url_mappings
+DROP COLUMN IF EXISTS disabled_at;
+
+-- 刪除使用者角色
+DROP INDEX IF EXISTS idx_users_role;
+ALTER TABLE users
+DROP COLUMN IF EXISTS role;
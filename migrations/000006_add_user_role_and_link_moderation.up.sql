@@ -0,0 +1,9 @@
+-- 為 users 表添加角色欄位 (user, admin)
+ALTER TABLE users
+ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('user', 'admin'));
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
+
+-- 為 url_mappings 表添加管理員停用標記
+ALTER TABLE url_mappings
+ADD COLUMN disabled_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
@@ -0,0 +1,7 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_api_keys_deleted_at;
+DROP INDEX IF EXISTS idx_api_keys_user_id;
+DROP INDEX IF EXISTS idx_api_keys_key_hash;
+
+-- 刪除表格
+DROP TABLE IF EXISTS api_keys;
@@ -0,0 +1,8 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_refresh_tokens_deleted_at;
+DROP INDEX IF EXISTS idx_refresh_tokens_user_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+DROP INDEX IF EXISTS idx_refresh_tokens_token_hash;
+
+-- 刪除表格
+DROP TABLE IF EXISTS refresh_tokens;
@@ -0,0 +1,2 @@
+-- 移除電子郵件驗證時間欄位
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
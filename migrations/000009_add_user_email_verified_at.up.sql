@@ -0,0 +1,5 @@
+-- 新增電子郵件驗證時間欄位
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE DEFAULT NULL;
+
+-- 既有帳號視為已驗證，避免啟用驗證後無法登入
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
@@ -0,0 +1,10 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_recovery_codes_deleted_at;
+DROP INDEX IF EXISTS idx_recovery_codes_user_id;
+
+-- 刪除表格
+DROP TABLE IF EXISTS recovery_codes;
+
+-- 移除 TOTP 欄位
+ALTER TABLE users DROP COLUMN IF EXISTS totp_enabled_at;
+ALTER TABLE users DROP COLUMN IF EXISTS totp_secret;
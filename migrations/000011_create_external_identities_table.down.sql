@@ -0,0 +1,7 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_external_identities_deleted_at;
+DROP INDEX IF EXISTS idx_external_identities_user_id;
+DROP INDEX IF EXISTS idx_external_identities_provider_subject;
+
+-- 刪除表格
+DROP TABLE IF EXISTS external_identities;
@@ -0,0 +1,16 @@
+-- 創建 external_identities 表，記錄使用者在外部 OpenID Connect 身分提供者的帳號
+CREATE TABLE IF NOT EXISTS external_identities (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    deleted_at TIMESTAMP WITH TIME ZONE DEFAULT NULL,
+    user_id INTEGER NOT NULL,
+    provider VARCHAR(50) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255) DEFAULT NULL
+);
+
+-- 同一提供者的 subject 只能連結一個使用者
+CREATE UNIQUE INDEX IF NOT EXISTS idx_external_identities_provider_subject ON external_identities(provider, subject) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_external_identities_user_id ON external_identities(user_id);
+CREATE INDEX IF NOT EXISTS idx_external_identities_deleted_at ON external_identities(deleted_at);
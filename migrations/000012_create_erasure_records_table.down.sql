@@ -0,0 +1,10 @@
+-- 刪除觸發器與函式
+DROP TRIGGER IF EXISTS trg_erasure_records_append_only ON erasure_records;
+DROP FUNCTION IF EXISTS erasure_records_append_only();
+
+-- 刪除索引
+DROP INDEX IF EXISTS idx_erasure_records_subject_id;
+DROP INDEX IF EXISTS idx_erasure_records_hash;
+
+-- 刪除表格
+DROP TABLE IF EXISTS erasure_records;
@@ -0,0 +1,26 @@
+-- 創建 erasure_records 表，記錄個人資料清除的稽核軌跡
+-- 每筆記錄的 hash 包含前一筆的 hash，任何修改或刪除都會使雜湊鏈斷裂
+CREATE TABLE IF NOT EXISTS erasure_records (
+    id SERIAL PRIMARY KEY,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    subject_id INTEGER NOT NULL,
+    requested_by INTEGER NOT NULL,
+    reason VARCHAR(500) NOT NULL,
+    summary TEXT NOT NULL,
+    prev_hash VARCHAR(64) NOT NULL,
+    hash VARCHAR(64) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_erasure_records_hash ON erasure_records(hash);
+CREATE INDEX IF NOT EXISTS idx_erasure_records_subject_id ON erasure_records(subject_id);
+
+-- 稽核記錄只允許新增
+CREATE OR REPLACE FUNCTION erasure_records_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'erasure_records is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_erasure_records_append_only
+    BEFORE UPDATE OR DELETE ON erasure_records
+    FOR EACH ROW EXECUTE FUNCTION erasure_records_append_only();
@@ -0,0 +1,9 @@
+DROP INDEX IF EXISTS idx_url_mappings_user_created_at;
+
+ALTER TABLE url_mappings
+DROP COLUMN IF EXISTS custom_alias;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS plan;
+
+DROP TABLE IF EXISTS plans;
@@ -0,0 +1,28 @@
+-- 創建 plans 表 (方案與配額)，上限欄位為 NULL 表示不限制
+CREATE TABLE IF NOT EXISTS plans (
+    name VARCHAR(32) PRIMARY KEY,
+    display_name VARCHAR(100) NOT NULL,
+    max_links INTEGER DEFAULT NULL,
+    monthly_links INTEGER DEFAULT NULL,
+    max_custom_aliases INTEGER DEFAULT NULL,
+    max_custom_domains INTEGER DEFAULT NULL,
+    api_rate_limit INTEGER NOT NULL DEFAULT 0,
+    analytics_retention_days INTEGER DEFAULT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0
+);
+
+INSERT INTO plans (name, display_name, max_links, monthly_links, max_custom_aliases, max_custom_domains, api_rate_limit, analytics_retention_days, sort_order) VALUES
+    ('free', 'Free', 100, 50, 5, 0, 60, 30, 0),
+    ('pro', 'Pro', 5000, 1000, 500, 3, 600, 365, 1),
+    ('business', 'Business', NULL, NULL, NULL, 20, 3000, NULL, 2)
+ON CONFLICT (name) DO NOTHING;
+
+-- 使用者所屬的方案
+ALTER TABLE users
+ADD COLUMN plan VARCHAR(32) NOT NULL DEFAULT 'free' REFERENCES plans(name);
+
+-- 標記使用者自訂的短碼，計算自訂短碼配額時使用
+ALTER TABLE url_mappings
+ADD COLUMN custom_alias BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_url_mappings_user_created_at ON url_mappings(user_id, created_at);
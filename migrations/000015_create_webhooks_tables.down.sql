@@ -0,0 +1,11 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_webhook_deliveries_status;
+DROP INDEX IF EXISTS idx_webhook_deliveries_due;
+DROP INDEX IF EXISTS idx_webhook_deliveries_webhook_id;
+DROP INDEX IF EXISTS idx_webhooks_deleted_at;
+DROP INDEX IF EXISTS idx_webhooks_workspace_id;
+DROP INDEX IF EXISTS idx_webhooks_user_id;
+
+-- 刪除表格
+DROP TABLE IF EXISTS webhook_deliveries;
+DROP TABLE IF EXISTS webhooks;
@@ -0,0 +1,8 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_outbox_events_aggregate;
+DROP INDEX IF EXISTS idx_outbox_events_published_at;
+DROP INDEX IF EXISTS idx_outbox_events_due;
+DROP INDEX IF EXISTS idx_outbox_events_event_id;
+
+-- 刪除表格
+DROP TABLE IF EXISTS outbox_events;
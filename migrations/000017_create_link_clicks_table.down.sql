@@ -0,0 +1,6 @@
+-- 刪除索引
+DROP INDEX IF EXISTS idx_link_clicks_link_id_clicked_at;
+DROP INDEX IF EXISTS idx_link_clicks_stream_id;
+
+-- 刪除表格
+DROP TABLE IF EXISTS link_clicks;
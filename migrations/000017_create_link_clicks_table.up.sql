@@ -0,0 +1,13 @@
+-- 創建 link_clicks 表 (由點擊處理任務從 Redis Stream 批次寫入的點擊記錄)
+CREATE TABLE IF NOT EXISTS link_clicks (
+    id BIGSERIAL PRIMARY KEY,
+    stream_id VARCHAR(64) NOT NULL,
+    link_id INTEGER NOT NULL REFERENCES url_mappings(id) ON DELETE CASCADE,
+    clicked_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    referrer VARCHAR(1024) NOT NULL DEFAULT '',
+    user_agent VARCHAR(512) NOT NULL DEFAULT ''
+);
+
+-- stream_id 唯一，重新處理同一訊息時不會重複寫入
+CREATE UNIQUE INDEX IF NOT EXISTS idx_link_clicks_stream_id ON link_clicks(stream_id);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_link_id_clicked_at ON link_clicks(link_id, clicked_at);
@@ -0,0 +1,13 @@
+-- 還原主機的唯一限制 (同一主機只保留已驗證或最早的申請)
+DELETE FROM domains d
+USING domains other
+WHERE d.host = other.host
+  AND d.deleted_at IS NULL
+  AND other.deleted_at IS NULL
+  AND d.id <> other.id
+  AND (d.verified_at IS NULL AND other.verified_at IS NOT NULL
+       OR (d.verified_at IS NULL) = (other.verified_at IS NULL) AND d.id > other.id);
+
+DROP INDEX IF EXISTS idx_domains_verified_host;
+DROP INDEX IF EXISTS idx_domains_host;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_host ON domains(host) WHERE deleted_at IS NULL;
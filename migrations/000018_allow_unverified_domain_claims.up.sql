@@ -0,0 +1,5 @@
+-- 同一主機可有多筆未驗證的申請，唯一性只限制已驗證的網域，
+-- 避免有人先申請他人的主機卻不驗證，讓真正的擁有者無法註冊
+DROP INDEX IF EXISTS idx_domains_host;
+CREATE INDEX IF NOT EXISTS idx_domains_host ON domains(host);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_domains_verified_host ON domains(host) WHERE deleted_at IS NULL AND verified_at IS NOT NULL;
@@ -0,0 +1,9 @@
+// Package migrations 將資料庫遷移檔嵌入執行檔，由 migrate 子命令與啟動時的結構版本檢查使用
+package migrations
+
+import "embed"
+
+// FS 包含所有遷移檔，檔名格式為 <版本>_<名稱>.up.sql 與 <版本>_<名稱>.down.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -16,16 +16,18 @@ if [ -f .env ]; then
     source .env
 fi
 
-# 構建資料庫連接字串
+# 資料庫設定以環境變數傳入容器，未設定時使用預設值
 DB_HOST=${DB_HOST:-postgres}
 DB_PORT=${DB_PORT:-5432}
 DB_USER=${DB_USER:-postgres}
 DB_PASSWORD=${DB_PASSWORD:-postgres}
 DB_NAME=${DB_NAME:-go_short}
-DB_URL="postgres://${DB_USER}:${DB_PASSWORD}@${DB_HOST}:${DB_PORT}/${DB_NAME}?sslmode=disable"
 
-# 執行 golang-migrate 命令
+# 執行內嵌遷移檔的 migrate 子命令 (up [N]、down [N|all]、status、force V)
 echo "執行遷移命令: migrate $MIGRATE_CMD $MIGRATE_ARGS"
-docker exec -it go_short_dev migrate -path /app/migrations -database "$DB_URL" $MIGRATE_CMD $MIGRATE_ARGS
+docker exec -it \
+    -e DB_HOST="$DB_HOST" -e DB_PORT="$DB_PORT" -e DB_USER="$DB_USER" \
+    -e DB_PASSWORD="$DB_PASSWORD" -e DB_NAME="$DB_NAME" \
+    go_short_dev go run . migrate $MIGRATE_CMD $MIGRATE_ARGS
 
-echo "完成!" 
\ No newline at end of file
+echo "完成!"
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http" // 引入 net/http 以便使用 http.Server
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"go_short/conf"
+	"go_short/internal/bootstrap" // 引入新的 bootstrap 包
+)
+
+// runServe 啟動 HTTP 伺服器與背景任務，直到收到結束信號，返回行程的結束碼
+func runServe(settings *conf.Watcher) int {
+	config := settings.Current()
+
+	// 初始化依賴項
+	deps, err := bootstrap.InitDependencies(settings, os.Stdout)
+	if err != nil {
+		slog.Error("Failed to initialize dependencies", "error", err)
+		return 1
+	}
+	defer deps.Close()
+
+	// 創建一個可用於取消背景任務的 context
+	appCtx, cancelAppCtx := context.WithCancel(context.Background())
+	defer cancelAppCtx()
+
+	// 監看設定檔與 SIGHUP
+	go settings.Watch(appCtx)
+
+	// 啟動定期清理過期 URL 的任務 (確保 URLApp 實例被正確傳遞)
+	deps.URLApp.StartCleanupTask(appCtx, config.Shortener.CleanupInterval, deps.JobMonitor()) // 使用 Bootstrap 返回的 URLApp 實例
+
+	// 啟動 outbox relay 與 webhook 投遞任務
+	deps.OutboxApp.Start(appCtx, deps.JobMonitor())
+	deps.WebhookApp.Start(appCtx, deps.JobMonitor())
+
+	// 點擊處理任務可改由獨立的 worker 行程執行
+	if config.Clicks.WorkerInServer {
+		deps.AnalyticsApp.StartWorker(appCtx, deps.JobMonitor())
+	}
+
+	// --- 配置和啟動 HTTP 伺服器 ---
+	server := &http.Server{
+		Addr:    config.Server.Addr(),
+		Handler: deps.GinEngine, // 使用 bootstrap 返回的 gin Engine
+		// 伺服器內部的錯誤 (例如 TLS 交握失敗) 也以結構化日誌輸出
+		ErrorLog: slog.NewLogLogger(deps.Logger.Handler(), slog.LevelError),
+	}
+
+	serverErr := make(chan error, 1)
+	go func() {
+		deps.Logger.Info("Starting server", "addr", server.Addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			serverErr <- err
+		}
+	}()
+	// --- HTTP 伺服器啟動結束 ---
+
+	// --- 優雅關閉 ---
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	select {
+	case <-quit:
+	case err := <-serverErr:
+		deps.Logger.Error("Failed to start server", "error", err)
+		return 1
+	}
+
+	// 先讓 /readyz 返回 503，等負載平衡器停止導入流量後再關閉伺服器
+	deps.HealthApp.StartDraining()
+	deps.Logger.Info("Draining before shutdown", "drain", config.Server.ShutdownDrain)
+	time.Sleep(config.Server.ShutdownDrain)
+	deps.Logger.Info("Shutting down server...")
+
+	// 給伺服器一點時間處理剩餘請求
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), config.Server.ShutdownTimeout)
+	defer cancelShutdown()
+
+	// 觸發背景任務的取消
+	cancelAppCtx()
+
+	// 關閉 HTTP 伺服器
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		deps.Logger.Error("Server forced to shutdown", "error", err)
+		return 1
+	}
+
+	deps.Logger.Info("Server exiting")
+	// --- 優雅關閉結束 ---
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"go_short/conf"
+)
+
+// worker 可執行的背景任務
+const (
+	jobClicks   = "clicks"   // 點擊處理
+	jobCleanup  = "cleanup"  // 過期連結清理
+	jobOutbox   = "outbox"   // outbox relay
+	jobWebhooks = "webhooks" // webhook 投遞
+)
+
+// runWorker 只執行背景任務，不啟動 HTTP 伺服器，直到收到結束信號
+func runWorker(settings *conf.Watcher, args []string) int {
+	flags := newFlagSet("worker", "worker [-jobs list]")
+	jobList := flags.String("jobs", jobClicks, "comma-separated jobs to run: clicks, cleanup, outbox, webhooks")
+	if ok, code := parseFlags(flags, args); !ok {
+		return code
+	}
+
+	jobs := make(map[string]bool)
+	for _, job := range strings.Split(*jobList, ",") {
+		job = strings.TrimSpace(job)
+		switch job {
+		case jobClicks, jobCleanup, jobOutbox, jobWebhooks:
+			jobs[job] = true
+		default:
+			fmt.Fprintf(os.Stderr, "worker: unknown job %q\n", job)
+			return 2
+		}
+	}
+
+	deps, ctx, closeDeps, err := initCommand(settings)
+	if err != nil {
+		return 1
+	}
+	defer closeDeps()
+	go settings.Watch(ctx)
+
+	monitor := deps.JobMonitor()
+	if jobs[jobClicks] {
+		deps.AnalyticsApp.StartWorker(ctx, monitor)
+	}
+	if jobs[jobCleanup] {
+		deps.URLApp.StartCleanupTask(ctx, settings.Current().Shortener.CleanupInterval, monitor)
+	}
+	if jobs[jobOutbox] {
+		deps.OutboxApp.Start(ctx, monitor)
+	}
+	if jobs[jobWebhooks] {
+		deps.WebhookApp.Start(ctx, monitor)
+	}
+	deps.Logger.Info("Worker started", "jobs", *jobList)
+
+	<-ctx.Done()
+	deps.Logger.Info("Shutting down worker...")
+	return 0
+}